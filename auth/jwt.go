@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"go.opentelemetry.io/otel/attribute"
+	"payments/domain"
+	"payments/middleware"
+	"payments/telemetry"
+)
+
+// SignatureAlgorithms são os algoritmos aceitos; HS* fica de fora porque a
+// chave seria um segredo compartilhado, e não uma chave pública do JWKS
+var SignatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512, jose.EdDSA,
+}
+
+// Padrões do mapeamento dos claims
+const (
+	DefaultScopesClaim = "scope"
+	DefaultRolesClaim  = "roles"
+	DefaultLeeway      = time.Minute
+)
+
+// JWTAuthenticator valida os bearer tokens do provedor de identidade e mapeia
+// os claims para os escopos da API
+type JWTAuthenticator struct {
+	keys        *KeyCache
+	issuer      string
+	audience    string
+	scopesClaim string
+	rolesClaim  string
+	roleScopes  map[string][]string
+	leeway      time.Duration
+	now         func() time.Time
+}
+
+// JWTOption permite customizar o JWTAuthenticator
+type JWTOption func(*JWTAuthenticator)
+
+// WithIssuer exige o claim iss
+func WithIssuer(issuer string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience exige audience entre os valores do claim aud
+func WithAudience(audience string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.audience = audience
+	}
+}
+
+// WithScopesClaim define o claim com os escopos (string separada por espaços ou lista)
+func WithScopesClaim(claim string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.scopesClaim = claim
+	}
+}
+
+// WithRoleScopes concede os escopos de cada papel presente em claim. O claim
+// pode ser aninhado, como realm_access.roles.
+func WithRoleScopes(claim string, roleScopes map[string][]string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.rolesClaim = claim
+		a.roleScopes = roleScopes
+	}
+}
+
+// WithLeeway define a tolerância a diferenças de relógio em exp, nbf e iat
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// WithJWTClock substitui o relógio usado na validação de exp e nbf
+func WithJWTClock(now func() time.Time) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.now = now
+	}
+}
+
+func NewJWTAuthenticator(keys *KeyCache, opts ...JWTOption) *JWTAuthenticator {
+	a := &JWTAuthenticator{
+		keys:        keys,
+		scopesClaim: DefaultScopesClaim,
+		rolesClaim:  DefaultRolesClaim,
+		leeway:      DefaultLeeway,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authenticate valida assinatura, iss, aud, exp e nbf do token e devolve o
+// cliente com os escopos da API concedidos pelos claims. Escopos que a API não
+// conhece (ex.: openid) são ignorados.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (_ domain.Principal, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "JWTAuthenticator.Authenticate")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	parsed, err := jwt.ParseSigned(token, SignatureAlgorithms)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("malformed token: %v: %w", err, domain.ErrUnauthorized)
+	}
+	header := parsed.Headers[0]
+	key, err := a.keys.Key(ctx, header.KeyID)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	// Uma chave publicada para um algoritmo não vale para outro
+	if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+		return domain.Principal{}, fmt.Errorf("key %q does not sign %s: %w", header.KeyID, header.Algorithm, domain.ErrUnauthorized)
+	}
+
+	var (
+		registered jwt.Claims
+		claims     map[string]any
+	)
+	if err := parsed.Claims(key.Key, &registered, &claims); err != nil {
+		return domain.Principal{}, fmt.Errorf("invalid token signature: %v: %w", err, domain.ErrUnauthorized)
+	}
+	expected := jwt.Expected{Issuer: a.issuer, Time: a.now()}
+	if a.audience != "" {
+		expected.AnyAudience = jwt.Audience{a.audience}
+	}
+	if err := registered.ValidateWithLeeway(expected, a.leeway); err != nil {
+		return domain.Principal{}, fmt.Errorf("invalid token claims: %v: %w", err, domain.ErrUnauthorized)
+	}
+	// Sem exp, um token vazado valeria para sempre
+	if registered.Expiry == nil {
+		return domain.Principal{}, fmt.Errorf("token without exp: %w", domain.ErrUnauthorized)
+	}
+
+	span.SetAttributes(attribute.String("enduser.id", registered.Subject))
+	name, _ := claims["name"].(string)
+	return domain.Principal{Subject: "jwt:" + registered.Subject, Name: name, Scopes: a.scopes(claims)}, nil
+}
+
+// scopes reúne os escopos do claim de escopos e os concedidos pelos papéis
+func (a *JWTAuthenticator) scopes(claims map[string]any) []string {
+	scopes := []string{}
+	grant := func(scope string) {
+		if slices.Contains(domain.Scopes, scope) && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	for _, scope := range stringList(claimAt(claims, a.scopesClaim)) {
+		grant(scope)
+	}
+	for _, role := range stringList(claimAt(claims, a.rolesClaim)) {
+		for _, scope := range a.roleScopes[role] {
+			grant(scope)
+		}
+	}
+	return scopes
+}
+
+// claimAt percorre um caminho separado por pontos (ex.: realm_access.roles)
+func claimAt(claims map[string]any, path string) any {
+	var value any = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// stringList aceita um claim em string separada por espaços (como scope, RFC 8693) ou em lista
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// Authenticators escolhe o autenticador pelo formato da credencial: tokens de
+// stream começam com StreamTokenTag, JWTs têm três partes separadas por ponto e
+// as demais credenciais são chaves de API
+type Authenticators struct {
+	APIKeys middleware.Authenticator
+	// Tokens é opcional: sem ele, JWTs são recusados
+	Tokens *JWTAuthenticator
+	// Streams é opcional: sem ele, tokens de stream são recusados
+	Streams *StreamTokens
+}
+
+func (a Authenticators) Authenticate(ctx context.Context, credential string) (domain.Principal, error) {
+	if strings.HasPrefix(credential, StreamTokenTag) {
+		if a.Streams == nil {
+			return domain.Principal{}, fmt.Errorf("stream tokens are not accepted: %w", domain.ErrUnauthorized)
+		}
+		return a.Streams.Authenticate(ctx, credential)
+	}
+	if strings.Count(credential, ".") == 2 {
+		if a.Tokens == nil {
+			return domain.Principal{}, fmt.Errorf("bearer tokens are not accepted: %w", domain.ErrUnauthorized)
+		}
+		return a.Tokens.Authenticate(ctx, credential)
+	}
+	return a.APIKeys.Authenticate(ctx, credential)
+}
@@ -0,0 +1,241 @@
+// Package auth valida os JWTs emitidos pelo provedor de identidade (OIDC)
+// contra as chaves públicas publicadas no JWKS do provedor.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"payments/domain"
+)
+
+// KeySource obtém o JWKS com as chaves públicas de assinatura dos tokens
+type KeySource interface {
+	Keys(ctx context.Context) (jose.JSONWebKeySet, error)
+}
+
+// fileKeySource lê o JWKS de um arquivo local (testes e ambientes sem o provedor)
+type fileKeySource struct {
+	path string
+}
+
+// NewFileKeySource lê o JWKS do arquivo path a cada busca, acompanhando trocas do arquivo
+func NewFileKeySource(path string) KeySource {
+	return &fileKeySource{path: path}
+}
+
+func (s *fileKeySource) Keys(ctx context.Context) (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return keys, fmt.Errorf("error reading JWKS file: %v", err)
+	}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return keys, fmt.Errorf("invalid JWKS file %s: %v", s.path, err)
+	}
+	return keys, nil
+}
+
+// maxJWKSSize limita a resposta do provedor; um JWKS real tem poucos KB
+const maxJWKSSize = 1 << 20
+
+// httpKeySource busca o JWKS no jwks_uri do provedor
+type httpKeySource struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPKeySource busca o JWKS em url; sem client, usa um com timeout de 10s
+func NewHTTPKeySource(url string, client *http.Client) KeySource {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &httpKeySource{url: url, client: client}
+}
+
+func (s *httpKeySource) Keys(ctx context.Context) (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return keys, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return keys, fmt.Errorf("error fetching JWKS: %v: %w", err, domain.ErrUpstream)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return keys, fmt.Errorf("JWKS endpoint returned %d: %w", resp.StatusCode, domain.ErrUpstream)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&keys); err != nil {
+		return keys, fmt.Errorf("invalid JWKS response: %v: %w", err, domain.ErrUpstream)
+	}
+	return keys, nil
+}
+
+// Padrões do cache de chaves
+const (
+	DefaultKeyCacheTTL = time.Hour
+	// DefaultMinRefreshInterval limita as buscas disparadas por kids desconhecidos:
+	// tokens forjados com kids aleatórios não podem sobrecarregar o provedor
+	DefaultMinRefreshInterval = time.Minute
+)
+
+// KeyCache guarda o JWKS e o renova quando expira ou quando aparece um kid
+// desconhecido, o que acompanha a rotação das chaves no provedor
+type KeyCache struct {
+	source     KeySource
+	ttl        time.Duration
+	minRefresh time.Duration
+	now        func() time.Time
+	logger     *slog.Logger
+
+	mu          sync.Mutex
+	keys        jose.JSONWebKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing é fechado ao fim da busca em andamento; nil quando não há busca
+	refreshing chan struct{}
+	// fetchErr é o erro da última busca, devolvido a quem esperava por um kid novo
+	fetchErr error
+}
+
+// CacheOption permite customizar o KeyCache
+type CacheOption func(*KeyCache)
+
+// WithTTL define por quanto tempo o JWKS buscado é usado sem nova busca
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *KeyCache) {
+		c.ttl = ttl
+	}
+}
+
+// WithMinRefreshInterval define o intervalo mínimo entre duas buscas do JWKS
+func WithMinRefreshInterval(interval time.Duration) CacheOption {
+	return func(c *KeyCache) {
+		c.minRefresh = interval
+	}
+}
+
+// WithCacheClock substitui o relógio usado na expiração do cache
+func WithCacheClock(now func() time.Time) CacheOption {
+	return func(c *KeyCache) {
+		c.now = now
+	}
+}
+
+// WithCacheLogger define o logger das falhas de renovação
+func WithCacheLogger(logger *slog.Logger) CacheOption {
+	return func(c *KeyCache) {
+		c.logger = logger
+	}
+}
+
+func NewKeyCache(source KeySource, opts ...CacheOption) *KeyCache {
+	c := &KeyCache{
+		source:     source,
+		ttl:        DefaultKeyCacheTTL,
+		minRefresh: DefaultMinRefreshInterval,
+		now:        time.Now,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Key devolve a chave pública de assinatura kid. A busca do JWKS não bloqueia o
+// cache: enquanto ela corre, as chaves conhecidas continuam valendo e só quem
+// precisa de um kid novo espera por ela. Se o provedor estiver fora do ar, as
+// chaves já conhecidas valem até a próxima renovação bem-sucedida.
+func (c *KeyCache) Key(ctx context.Context, kid string) (jose.JSONWebKey, error) {
+	c.mu.Lock()
+	key, found := c.lookup(kid)
+	done := c.startRefresh(ctx, found)
+	c.mu.Unlock()
+	if found {
+		return key, nil
+	}
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return jose.JSONWebKey{}, ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, found = c.lookup(kid); found {
+		return key, nil
+	}
+	if done != nil && c.fetchErr != nil {
+		return jose.JSONWebKey{}, c.fetchErr
+	}
+	return jose.JSONWebKey{}, fmt.Errorf("unknown signing key %q: %w", kid, domain.ErrUnauthorized)
+}
+
+// startRefresh dispara a busca do JWKS quando o cache expirou ou o kid não foi
+// encontrado, respeitando minRefresh, e devolve o canal da busca em andamento
+// (nil se não houver). Exige o lock já adquirido.
+func (c *KeyCache) startRefresh(ctx context.Context, found bool) chan struct{} {
+	if c.refreshing != nil {
+		return c.refreshing
+	}
+	now := c.now()
+	expired := c.fetchedAt.IsZero() || now.Sub(c.fetchedAt) >= c.ttl
+	if !expired && found {
+		return nil
+	}
+	if !c.attemptedAt.IsZero() && now.Sub(c.attemptedAt) < c.minRefresh {
+		return nil
+	}
+	c.attemptedAt = now
+	c.refreshing = make(chan struct{})
+	// A busca sobrevive à requisição que a disparou: as demais podem estar esperando por ela
+	go c.refresh(context.WithoutCancel(ctx), now, c.refreshing)
+	return c.refreshing
+}
+
+// refresh busca o JWKS sem segurar o lock e guarda o resultado
+func (c *KeyCache) refresh(ctx context.Context, now time.Time, done chan struct{}) {
+	keys, err := c.source.Keys(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetchErr = err
+	if err != nil {
+		c.logger.WarnContext(ctx, "Erro ao renovar o JWKS; as chaves em cache continuam valendo", slog.Any("error", err))
+	} else {
+		c.keys, c.fetchedAt = keys, now
+	}
+	c.refreshing = nil
+	close(done)
+}
+
+// lookup procura uma chave pública de assinatura; tokens sem kid só são
+// aceitos quando o JWKS tem uma única chave. Exige o lock já adquirido.
+func (c *KeyCache) lookup(kid string) (jose.JSONWebKey, bool) {
+	candidates := c.keys.Keys
+	if kid != "" {
+		candidates = c.keys.Key(kid)
+	} else if len(candidates) != 1 {
+		return jose.JSONWebKey{}, false
+	}
+	for _, key := range candidates {
+		if key.IsPublic() && (key.Use == "" || key.Use == "sig") {
+			return key, true
+		}
+	}
+	return jose.JSONWebKey{}, false
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"payments/domain"
+)
+
+// StreamTokenTag inicia os tokens de stream: pst_<pagamento>_<expiração>_<assinatura>
+const StreamTokenTag = "pst_"
+
+// DefaultStreamTokenTTL é a validade padrão dos tokens de stream; o token só
+// precisa valer na abertura (e nas reconexões) do EventSource
+const DefaultStreamTokenTTL = 30 * time.Minute
+
+// StreamTokens emite e valida os tokens que o checkout usa para acompanhar um
+// único pagamento pelo stream SSE. Diferente de uma chave de API, o token pode
+// ir para o navegador: não dá acesso a nenhum outro pagamento nem a outra rota.
+type StreamTokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// StreamTokenOption permite customizar o StreamTokens
+type StreamTokenOption func(*StreamTokens)
+
+// WithStreamTokenTTL define a validade dos tokens emitidos
+func WithStreamTokenTTL(ttl time.Duration) StreamTokenOption {
+	return func(t *StreamTokens) {
+		t.ttl = ttl
+	}
+}
+
+// WithStreamTokenClock substitui o relógio usado na emissão e na validação
+func WithStreamTokenClock(now func() time.Time) StreamTokenOption {
+	return func(t *StreamTokens) {
+		t.now = now
+	}
+}
+
+// NewStreamTokens assina os tokens com HMAC-SHA256 usando secret; todas as
+// instâncias da API precisam do mesmo segredo
+func NewStreamTokens(secret []byte, opts ...StreamTokenOption) *StreamTokens {
+	t := &StreamTokens{secret: secret, ttl: DefaultStreamTokenTTL, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Issue emite o token de stream do pagamento id
+func (t *StreamTokens) Issue(id string) (string, time.Time, error) {
+	if id == "" || strings.Contains(id, "_") {
+		return "", time.Time{}, fmt.Errorf("invalid payment id %q for a stream token", id)
+	}
+	expiresAt := t.now().Add(t.ttl).Truncate(time.Second)
+	payload := id + "_" + strconv.FormatInt(expiresAt.Unix(), 10)
+	return StreamTokenTag + payload + "_" + t.sign(payload), expiresAt, nil
+}
+
+// Authenticate valida o token e devolve um cliente com acesso apenas aos
+// eventos do pagamento do token
+func (t *StreamTokens) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	// A assinatura é hexadecimal e fica depois do último "_"
+	rest, tagged := strings.CutPrefix(token, StreamTokenTag)
+	cut := strings.LastIndex(rest, "_")
+	if !tagged || cut < 0 {
+		return domain.Principal{}, fmt.Errorf("malformed stream token: %w", domain.ErrUnauthorized)
+	}
+	payload, signature := rest[:cut], rest[cut+1:]
+	id, expiry, ok := strings.Cut(payload, "_")
+	if !ok {
+		return domain.Principal{}, fmt.Errorf("malformed stream token: %w", domain.ErrUnauthorized)
+	}
+	if !hmac.Equal([]byte(signature), []byte(t.sign(payload))) {
+		return domain.Principal{}, fmt.Errorf("invalid stream token signature: %w", domain.ErrUnauthorized)
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !t.now().Before(time.Unix(unix, 0)) {
+		return domain.Principal{}, fmt.Errorf("expired stream token: %w", domain.ErrUnauthorized)
+	}
+	return domain.Principal{Subject: "stream:" + id, Scopes: []string{domain.PaymentEventsScope(id)}}, nil
+}
+
+func (t *StreamTokens) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/logging"
+	"payments/telemetry"
+)
+
+// DefaultOrderServiceURL é usada quando ORDER_SERVICE_URL não está definida
+const DefaultOrderServiceURL = "https://api-ms-order-6ec42f917adf.herokuapp.com"
+
+// Status do pedido no microserviço de pedidos
+const (
+	OrderStatusFinished  = "Finalizado"
+	OrderStatusCancelled = "Cancelado"
+)
+
+// OrderClient representa as chamadas de saída para o microserviço de pedidos
+type OrderClient interface {
+	// UpdateOrderStatus leva ao pedido o status do pagamento (do repositório ou
+	// do callback do gateway), traduzido por OrderStatus. Status intermediários
+	// não têm correspondente e não alteram o pedido.
+	UpdateOrderStatus(ctx context.Context, orderID string, status string) error
+}
+
+// OrderStatus traduz o status de um pagamento para o status do pedido: o
+// pagamento concluído finaliza o pedido e o que não se concretizou (recusado,
+// cancelado, vencido, estornado ou perdido em disputa) o cancela
+func OrderStatus(paymentStatus string) (string, bool) {
+	switch paymentStatus {
+	case domain.StatusCaptured:
+		return OrderStatusFinished, true
+	case domain.StatusCancelled, domain.StatusVoided, domain.StatusExpired, domain.StatusRefunded, domain.StatusChargedBack:
+		return OrderStatusCancelled, true
+	}
+	switch domain.CallbackEvent(paymentStatus) {
+	case domain.EventPaymentApproved:
+		return OrderStatusFinished, true
+	case domain.EventPaymentFailed:
+		return OrderStatusCancelled, true
+	}
+	return "", false
+}
+
+type orderClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOrderClient cria um cliente para o microserviço de pedidos a partir da URL base
+func NewOrderClient(baseURL string, httpClient *http.Client) OrderClient {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &orderClient{baseURL: baseURL, httpClient: httpClient}
+}
+
+// NewOrderClientFromEnv cria o cliente usando ORDER_SERVICE_URL (ou a URL padrão)
+func NewOrderClientFromEnv() OrderClient {
+	baseURL := os.Getenv("ORDER_SERVICE_URL")
+	if baseURL == "" {
+		baseURL = DefaultOrderServiceURL
+	}
+	return NewOrderClient(baseURL, nil)
+}
+
+func (c *orderClient) UpdateOrderStatus(ctx context.Context, orderID string, status string) error {
+	ctx, span := telemetry.Tracer().Start(ctx, "OrderClient.UpdateOrderStatus",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(telemetry.OrderIDAttr(orderID), attribute.String("payment.status", status)),
+	)
+	defer span.End()
+
+	orderStatus, ok := OrderStatus(status)
+	if !ok {
+		return nil
+	}
+	span.SetAttributes(attribute.String("order.status", orderStatus))
+	err := c.patchOrder(ctx, span, orderID, orderStatus)
+	telemetry.RecordError(span, err)
+	return err
+}
+
+func (c *orderClient) patchOrder(ctx context.Context, span trace.Span, orderID string, status string) error {
+	orderUpdate := map[string]interface{}{
+		"status": status,
+	}
+	jsonData, err := json.Marshal(orderUpdate)
+	if err != nil {
+		return fmt.Errorf("error marshalling order data: %v", err)
+	}
+
+	url := c.baseURL + "/orders/" + orderID
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating HTTP request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	propagateHeaders(ctx, req)
+
+	span.SetAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", url),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to update order status: received status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// propagateHeaders repassa o X-Request-ID e o traceparent da requisição de entrada
+func propagateHeaders(ctx context.Context, req *http.Request) {
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
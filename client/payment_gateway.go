@@ -0,0 +1,121 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// PaymentGateway representa as chamadas de saída para o provedor que processa os pagamentos
+type PaymentGateway interface {
+	CancelPayment(ctx context.Context, paymentID string) error
+	// CapturePayment captura amount de uma autorização
+	CapturePayment(ctx context.Context, paymentID string, amount float64) error
+	// VoidPayment libera uma autorização sem capturar
+	VoidPayment(ctx context.Context, paymentID string) error
+	// RefundPayment estorna amount, revertendo a parte de cada recebedor informada em splits
+	RefundPayment(ctx context.Context, paymentID string, amount float64, splits []domain.SplitReversal) error
+}
+
+type paymentGateway struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPaymentGateway cria um cliente para o gateway de pagamentos a partir da URL base
+func NewPaymentGateway(baseURL string, httpClient *http.Client) PaymentGateway {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &paymentGateway{baseURL: baseURL, httpClient: httpClient}
+}
+
+// NewPaymentGatewayFromEnv cria o cliente usando PAYMENT_GATEWAY_URL; devolve nil
+// quando nenhum gateway está configurado
+func NewPaymentGatewayFromEnv() PaymentGateway {
+	baseURL := os.Getenv("PAYMENT_GATEWAY_URL")
+	if baseURL == "" {
+		return nil
+	}
+	return NewPaymentGateway(baseURL, nil)
+}
+
+func (g *paymentGateway) CancelPayment(ctx context.Context, paymentID string) error {
+	return g.call(ctx, "CancelPayment", paymentID, "cancel", nil)
+}
+
+func (g *paymentGateway) CapturePayment(ctx context.Context, paymentID string, amount float64) error {
+	return g.call(ctx, "CapturePayment", paymentID, "capture", map[string]float64{"amount": amount})
+}
+
+func (g *paymentGateway) VoidPayment(ctx context.Context, paymentID string) error {
+	return g.call(ctx, "VoidPayment", paymentID, "void", nil)
+}
+
+// refundRequest é o corpo de POST /payments/{id}/refund no gateway
+type refundRequest struct {
+	Amount float64                `json:"amount"`
+	Splits []domain.SplitReversal `json:"splits,omitempty"`
+}
+
+func (g *paymentGateway) RefundPayment(ctx context.Context, paymentID string, amount float64, splits []domain.SplitReversal) error {
+	return g.call(ctx, "RefundPayment", paymentID, "refund", refundRequest{Amount: amount, Splits: splits})
+}
+
+// call faz POST {baseURL}/payments/{id}/{action} dentro de um span de cliente
+func (g *paymentGateway) call(ctx context.Context, operation string, paymentID string, action string, body interface{}) error {
+	url := g.baseURL + "/payments/" + paymentID + "/" + action
+	ctx, span := telemetry.Tracer().Start(ctx, "PaymentGateway."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			telemetry.PaymentIDAttr(paymentID),
+			attribute.String("http.request.method", http.MethodPost),
+			attribute.String("url.full", url),
+		),
+	)
+	defer span.End()
+
+	err := g.post(ctx, span, url, body)
+	telemetry.RecordError(span, err)
+	return err
+}
+
+func (g *paymentGateway) post(ctx context.Context, span trace.Span, url string, body interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshalling gateway request: %v", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reader)
+	if err != nil {
+		return fmt.Errorf("error creating HTTP request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	propagateHeaders(ctx, req)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("gateway rejected the request: received status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// RateProvider fornece as cotações de câmbio usadas nos pagamentos em moeda estrangeira
+type RateProvider interface {
+	Rate(ctx context.Context, from string, to string) (domain.FXRate, error)
+}
+
+// rateTable é o formato do arquivo de cotações: cada moeda de Rates vale Rates[moeda] unidades de Base
+type rateTable struct {
+	Source    string             `json:"source"`
+	Base      string             `json:"base"`
+	Timestamp time.Time          `json:"timestamp"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+type fileRateProvider struct {
+	path string
+}
+
+// NewFileRateProvider lê as cotações de um arquivo JSON local. O arquivo é
+// relido a cada consulta, para que uma atualização não exija reiniciar o serviço.
+func NewFileRateProvider(path string) RateProvider {
+	return &fileRateProvider{path: path}
+}
+
+// NewRateProviderFromEnv cria o provedor a partir de FX_RATES_FILE; devolve nil
+// quando nenhuma fonte de cotações está configurada
+func NewRateProviderFromEnv() RateProvider {
+	path := os.Getenv("FX_RATES_FILE")
+	if path == "" {
+		return nil
+	}
+	return NewFileRateProvider(path)
+}
+
+func (p *fileRateProvider) Rate(ctx context.Context, from string, to string) (_ domain.FXRate, err error) {
+	_, span := telemetry.Tracer().Start(ctx, "RateProvider.Rate",
+		trace.WithAttributes(attribute.String("fx.from", from), attribute.String("fx.to", to)),
+	)
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	table, err := p.load()
+	if err != nil {
+		return domain.FXRate{}, err
+	}
+	fromRate, err := table.rateOf(from)
+	if err != nil {
+		return domain.FXRate{}, err
+	}
+	toRate, err := table.rateOf(to)
+	if err != nil {
+		return domain.FXRate{}, err
+	}
+	return domain.FXRate{
+		From:      from,
+		To:        to,
+		Rate:      domain.RoundFXRate(fromRate / toRate),
+		Source:    table.Source,
+		Timestamp: table.Timestamp,
+	}, nil
+}
+
+func (p *fileRateProvider) load() (rateTable, error) {
+	var table rateTable
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return table, fmt.Errorf("error reading FX rates: %w", err)
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return table, fmt.Errorf("error decoding FX rates %s: %w", p.path, err)
+	}
+	if table.Base == "" {
+		return table, fmt.Errorf("FX rates %s: base currency is required", p.path)
+	}
+	if table.Source == "" {
+		table.Source = "file:" + p.path
+	}
+	// Sem timestamp no arquivo, vale a data da última modificação
+	if table.Timestamp.IsZero() {
+		if info, err := os.Stat(p.path); err == nil {
+			table.Timestamp = info.ModTime()
+		}
+	}
+	table.Timestamp = table.Timestamp.UTC()
+	return table, nil
+}
+
+// rateOf devolve quantas unidades da moeda base vale uma unidade de currency
+func (t rateTable) rateOf(currency string) (float64, error) {
+	if strings.EqualFold(currency, t.Base) {
+		return 1, nil
+	}
+	rate, ok := t.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no FX rate for %s in %s", currency, t.Source)
+	}
+	return rate, nil
+}
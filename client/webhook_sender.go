@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// Cabeçalhos enviados em toda entrega de webhook
+const (
+	WebhookIDHeader        = "X-Webhook-Id" // ID da entrega, estável entre as tentativas
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// DefaultWebhookTimeout limita cada tentativa; o lojista deve responder rápido e processar depois
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookSender envia uma entrega ao endpoint do lojista. Falhas de rede e
+// respostas não 2xx voltam no próprio WebhookAttempt, nunca como erro.
+type WebhookSender interface {
+	Send(ctx context.Context, url string, secret string, delivery domain.WebhookDelivery) domain.WebhookAttempt
+}
+
+type webhookSender struct {
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewWebhookSender cria o remetente HTTP; sem httpClient, usa DefaultWebhookTimeout
+// e só conecta a endereços públicos (ver publicOnlyTransport)
+func NewWebhookSender(httpClient *http.Client) WebhookSender {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultWebhookTimeout, Transport: publicOnlyTransport()}
+	}
+	return &webhookSender{httpClient: httpClient, now: time.Now}
+}
+
+// publicOnlyTransport confere o IP já resolvido de cada conexão, inclusive nos
+// redirecionamentos: um nome público que resolve para a rede interna (DNS
+// rebinding) não alcança os serviços internos. Não usa proxy, que esconderia o destino.
+func publicOnlyTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: DefaultWebhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !domain.IsPublicIP(ip) {
+				return fmt.Errorf("webhook destination %s is not a public address", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// SignWebhookPayload calcula a assinatura enviada em X-Webhook-Signature: o
+// HMAC-SHA256, em hexadecimal, de "<timestamp>.<corpo>" com o segredo da inscrição
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookSender) Send(ctx context.Context, url string, secret string, delivery domain.WebhookDelivery) domain.WebhookAttempt {
+	ctx, span := telemetry.Tracer().Start(ctx, "WebhookSender.Send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("webhook.delivery_id", delivery.ID.Hex()),
+			attribute.String("webhook.event", delivery.EventType),
+			attribute.String("url.full", url),
+		),
+	)
+	defer span.End()
+
+	started := s.now()
+	attempt := domain.WebhookAttempt{AttemptedAt: started}
+	statusCode, body, err := s.post(ctx, url, secret, delivery, started)
+	attempt.DurationMs = s.now().Sub(started).Milliseconds()
+	attempt.StatusCode = statusCode
+	attempt.ResponseBody = body
+	if err != nil {
+		attempt.Error = err.Error()
+	} else if !attempt.Succeeded() {
+		err = fmt.Errorf("webhook endpoint answered with status code %d", statusCode)
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	telemetry.RecordError(span, err)
+	return attempt
+}
+
+func (s *webhookSender) post(ctx context.Context, url string, secret string, delivery domain.WebhookDelivery, at time.Time) (int, string, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("error creating HTTP request: %v", err)
+	}
+	timestamp := at.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "payments-webhooks/1.0")
+	req.Header.Set(WebhookIDHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, payload))
+	propagateHeaders(ctx, req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("error sending HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, domain.MaxWebhookResponseLog))
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"payments/auth"
+	"payments/domain"
+	"payments/logging"
+)
+
+// NewJWTAuthenticator configura a validação dos JWTs do provedor de identidade
+// pelo JWKS em JWT_JWKS_URL (o jwks_uri do provedor) ou em JWT_JWKS_FILE. Sem
+// nenhum dos dois, devolve nil e só as chaves de API são aceitas.
+func NewJWTAuthenticator(logger *slog.Logger) (*auth.JWTAuthenticator, error) {
+	var source auth.KeySource
+	if url := os.Getenv("JWT_JWKS_URL"); url != "" {
+		source = auth.NewHTTPKeySource(url, nil)
+		logger.Info("Validando JWTs pelo JWKS do provedor", slog.String("url", logging.RedactURI(url)))
+	} else if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		source = auth.NewFileKeySource(path)
+		logger.Info("Validando JWTs pelo JWKS em arquivo", slog.String("path", path))
+	} else {
+		return nil, nil
+	}
+
+	ttl, err := durationEnv("JWT_JWKS_CACHE_TTL", auth.DefaultKeyCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	roleScopes, err := jwtRoleScopes()
+	if err != nil {
+		return nil, err
+	}
+	issuer, audience := os.Getenv("JWT_ISSUER"), os.Getenv("JWT_AUDIENCE")
+	if issuer == "" || audience == "" {
+		// Sem iss e aud, qualquer token do provedor, emitido para qualquer sistema, seria aceito
+		return nil, fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE are required to accept JWTs")
+	}
+	keys := auth.NewKeyCache(source, auth.WithTTL(ttl), auth.WithCacheLogger(logger))
+	return auth.NewJWTAuthenticator(keys,
+		auth.WithIssuer(issuer),
+		auth.WithAudience(audience),
+		auth.WithScopesClaim(envOr("JWT_SCOPES_CLAIM", auth.DefaultScopesClaim)),
+		auth.WithRoleScopes(envOr("JWT_ROLES_CLAIM", auth.DefaultRolesClaim), roleScopes),
+	), nil
+}
+
+// jwtRoleScopes lê JWT_ROLE_SCOPES: papéis separados por vírgula, cada um com
+// os escopos que concede separados por espaço (ex.:
+// "payments-reader=payments:read,finance=payments:read refunds:write")
+func jwtRoleScopes() (map[string][]string, error) {
+	roleScopes := make(map[string][]string)
+	for _, entry := range splitEnv("JWT_ROLE_SCOPES") {
+		role, scopes, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid JWT_ROLE_SCOPES entry %q: expected role=scope ...", entry)
+		}
+		if err := domain.ValidateScopes(strings.Fields(scopes)); err != nil {
+			return nil, fmt.Errorf("invalid JWT_ROLE_SCOPES for role %s: %v", role, err)
+		}
+		roleScopes[role] = strings.Fields(scopes)
+	}
+	return roleScopes, nil
+}
+
+// NewStreamTokens configura os tokens de stream dos pagamentos com o segredo em
+// STREAM_TOKEN_SECRET e a validade em STREAM_TOKEN_TTL. Sem segredo, usa um
+// aleatório: os tokens só valem nesta instância e até ela reiniciar.
+func NewStreamTokens(logger *slog.Logger) (*auth.StreamTokens, error) {
+	ttl, err := durationEnv("STREAM_TOKEN_TTL", auth.DefaultStreamTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("STREAM_TOKEN_TTL must be positive, got %s", ttl)
+	}
+	secret := []byte(os.Getenv("STREAM_TOKEN_SECRET"))
+	if len(secret) == 0 {
+		logger.Warn("STREAM_TOKEN_SECRET não configurado; os tokens de stream não valem em outras instâncias nem após reiniciar")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("error generating stream token secret: %v", err)
+		}
+	} else if len(secret) < 32 {
+		return nil, fmt.Errorf("STREAM_TOKEN_SECRET must have at least 32 bytes")
+	}
+	return auth.NewStreamTokens(secret, auth.WithStreamTokenTTL(ttl)), nil
+}
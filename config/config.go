@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"payments/domain"
+)
+
+// Backends de armazenamento aceitos em STORAGE_BACKEND
+const (
+	StorageMongo    = "mongo"
+	StorageMemory   = "memory"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+)
+
+// LoadEnv carrega o arquivo .env, quando existir, sem sobrescrever variáveis já definidas
+func LoadEnv() {
+	if err := godotenv.Load(); err != nil {
+		slog.Warn("Arquivo .env não carregado, usando apenas as variáveis de ambiente", slog.Any("error", err))
+	}
+}
+
+// StorageBackend retorna o backend configurado em STORAGE_BACKEND (padrão mongo)
+func StorageBackend() string {
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		return backend
+	}
+	return StorageMongo
+}
+
+// Valores padrão do job que libera autorizações não capturadas
+const (
+	DefaultAutoVoidWindow   = 7 * 24 * time.Hour
+	DefaultAutoVoidInterval = 15 * time.Minute
+)
+
+// AutoVoidSchedule lê AUTH_VOID_AFTER (idade máxima de uma autorização) e
+// AUTH_VOID_INTERVAL (frequência do job). AUTH_VOID_AFTER=0 desliga o job.
+func AutoVoidSchedule() (window time.Duration, interval time.Duration, err error) {
+	window, err = durationEnv("AUTH_VOID_AFTER", DefaultAutoVoidWindow)
+	if err != nil {
+		return 0, 0, err
+	}
+	interval, err = durationEnv("AUTH_VOID_INTERVAL", DefaultAutoVoidInterval)
+	if err != nil {
+		return 0, 0, err
+	}
+	if window > 0 && interval <= 0 {
+		return 0, 0, fmt.Errorf("AUTH_VOID_INTERVAL must be positive")
+	}
+	return window, interval, nil
+}
+
+// DefaultBoletoExpiryInterval é a frequência padrão do job de vencimento de boletos
+const DefaultBoletoExpiryInterval = time.Hour
+
+// BoletoExpiryInterval lê BOLETO_EXPIRY_INTERVAL; 0 desliga o job
+func BoletoExpiryInterval() (time.Duration, error) {
+	return durationEnv("BOLETO_EXPIRY_INTERVAL", DefaultBoletoExpiryInterval)
+}
+
+// DefaultWebhookDeliveryInterval é a frequência padrão do job de entrega de webhooks
+const DefaultWebhookDeliveryInterval = 5 * time.Second
+
+// WebhookDeliveryInterval lê WEBHOOK_DELIVERY_INTERVAL; 0 desliga o job
+func WebhookDeliveryInterval() (time.Duration, error) {
+	return durationEnv("WEBHOOK_DELIVERY_INTERVAL", DefaultWebhookDeliveryInterval)
+}
+
+// DefaultEventStreamHeartbeat é o intervalo padrão dos comentários que mantêm os streams SSE abertos
+const DefaultEventStreamHeartbeat = 15 * time.Second
+
+// EventStreamHeartbeat lê EVENT_STREAM_HEARTBEAT
+func EventStreamHeartbeat() (time.Duration, error) {
+	heartbeat, err := durationEnv("EVENT_STREAM_HEARTBEAT", DefaultEventStreamHeartbeat)
+	if err == nil && heartbeat <= 0 {
+		err = fmt.Errorf("EVENT_STREAM_HEARTBEAT must be positive, got %s", heartbeat)
+	}
+	return heartbeat, err
+}
+
+// APIAdminKey lê API_ADMIN_KEY: uma chave admin aceita sem estar no banco, para
+// emitir as primeiras chaves. Vazia (o padrão), só valem as chaves emitidas.
+func APIAdminKey() string {
+	return os.Getenv("API_ADMIN_KEY")
+}
+
+// GatewayCallbackSecret lê PAYMENT_GATEWAY_CALLBACK_SECRET, o segredo com que o
+// gateway assina os callbacks. Vazio, POST /payment/callback recusa tudo.
+func GatewayCallbackSecret() string {
+	return os.Getenv("PAYMENT_GATEWAY_CALLBACK_SECRET")
+}
+
+// CheckRemovedSettings recusa a inicialização com variáveis que deixaram de
+// valer e cuja ausência mudaria o comportamento sem aviso. WS_AUTH_TOKENS dava
+// acesso ao feed /ws/payments, que agora exige uma chave de API com payments:read.
+func CheckRemovedSettings() error {
+	if os.Getenv("WS_AUTH_TOKENS") != "" {
+		return fmt.Errorf("WS_AUTH_TOKENS is no longer supported: issue API keys with the %s scope at /admin/api-keys for the payment feed and unset it",
+			domain.ScopePaymentsRead)
+	}
+	return nil
+}
+
+// BoletoIssuer lê os dados do beneficiário (BOLETO_*), partindo de domain.DefaultBoletoIssuer
+func BoletoIssuer() (domain.BoletoIssuer, error) {
+	issuer := domain.DefaultBoletoIssuer
+	if bank := os.Getenv("BOLETO_BANK_CODE"); bank != "" {
+		issuer.BankCode = bank
+	}
+	if covenant := os.Getenv("BOLETO_COVENANT"); covenant != "" {
+		issuer.Covenant = covenant
+	}
+	var err error
+	if issuer.DueDays, err = intEnv("BOLETO_DUE_DAYS", issuer.DueDays); err != nil {
+		return issuer, err
+	}
+	if issuer.GraceDays, err = intEnv("BOLETO_GRACE_DAYS", issuer.GraceDays); err != nil {
+		return issuer, err
+	}
+	if issuer.FinePercent, err = floatEnv("BOLETO_FINE_PERCENT", issuer.FinePercent); err != nil {
+		return issuer, err
+	}
+	if issuer.InterestPercentPerMonth, err = floatEnv("BOLETO_INTEREST_PERCENT", issuer.InterestPercentPerMonth); err != nil {
+		return issuer, err
+	}
+	if issuer.DueDays < 0 || issuer.GraceDays < 0 {
+		return issuer, fmt.Errorf("BOLETO_DUE_DAYS and BOLETO_GRACE_DAYS must not be negative")
+	}
+	return issuer, nil
+}
+
+// InstallmentPolicies lê os limites de parcelamento no cartão (CREDIT_CARD_*),
+// partindo de domain.DefaultInstallmentPolicies
+func InstallmentPolicies() (domain.InstallmentPolicies, error) {
+	policies := domain.DefaultInstallmentPolicies()
+	card := policies[domain.CreditCard]
+	var err error
+	if card.MaxInstallments, err = intEnv("CREDIT_CARD_MAX_INSTALLMENTS", card.MaxInstallments); err != nil {
+		return nil, err
+	}
+	if card.MinInstallmentAmount, err = floatEnv("CREDIT_CARD_MIN_INSTALLMENT_AMOUNT", card.MinInstallmentAmount); err != nil {
+		return nil, err
+	}
+	if card.InterestFreeInstallments, err = intEnv("CREDIT_CARD_INTEREST_FREE_INSTALLMENTS", card.InterestFreeInstallments); err != nil {
+		return nil, err
+	}
+	if card.MonthlyInterestRate, err = floatEnv("CREDIT_CARD_MONTHLY_INTEREST_RATE", card.MonthlyInterestRate); err != nil {
+		return nil, err
+	}
+	if card.MaxInstallments < 1 || card.MinInstallmentAmount < 0 || card.MonthlyInterestRate < 0 {
+		return nil, fmt.Errorf("invalid installment policy for %s", domain.CreditCard)
+	}
+	policies[domain.CreditCard] = card
+	return policies, nil
+}
+
+// envOr lê a variável key, usando fallback quando ela está vazia
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// splitEnv lê uma lista separada por vírgulas da variável key, descartando itens vazios
+func splitEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// durationEnv lê uma duração no formato do time.ParseDuration (ex.: 168h, 15m)
+func durationEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return duration, nil
+}
+
+// intEnv lê um inteiro da variável key
+func intEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return n, nil
+}
+
+// floatEnv lê um número decimal da variável key
+func floatEnv(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return n, nil
+}
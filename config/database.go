@@ -3,35 +3,43 @@ package config
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"payments/logging"
 )
 
 // MongoDB é a instância do banco de dados MongoDB acessível globalmente
 var MongoDB *mongo.Database
 
+// InitDB conecta ao MongoDB Atlas; as variáveis devem ter sido carregadas com LoadEnv
 func InitDB() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Erro ao carregar o arquivo .env")
-	}
 	dbPassword := os.Getenv("DB_PASSWORD")
 	if dbPassword == "" {
-		log.Fatal("DB_PASSWORD não está definido")
+		fatal("DB_PASSWORD não está definido", nil)
 	}
 	uri := fmt.Sprintf("mongodb+srv://kaiquemotta:%s@payments.4shch.mongodb.net/?retryWrites=true&w=majority&appName=payments", dbPassword)
-	log.Printf("📝 URL de conexão com MongoDB: %s", uri)
+	slog.Info("URL de conexão com MongoDB", slog.String("uri", logging.RedactURI(uri)))
 	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
 	if err != nil {
-		log.Fatal("Erro ao conectar ao MongoDB:", err)
+		fatal("Erro ao conectar ao MongoDB", err)
 	}
 	err = client.Ping(context.Background(), nil)
 	if err != nil {
-		log.Fatal("Erro ao testar a conexão com o MongoDB:", err)
+		fatal("Erro ao testar a conexão com o MongoDB", err)
 	}
-	log.Println("✅ Conexão com MongoDB realizada com sucesso!")
+	slog.Info("Conexão com MongoDB realizada com sucesso")
 	MongoDB = client.Database("paymentDB")
 }
+
+// fatal registra o erro no logger padrão e encerra o processo
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, slog.Any("error", err))
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
+}
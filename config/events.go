@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/nats-io/nats.go"
+	"payments/events"
+	"payments/logging"
+)
+
+// Backends de publicação de eventos aceitos em EVENTS_BACKEND
+const (
+	EventsNone   = "none"
+	EventsMemory = "memory"
+	EventsFile   = "file"
+	EventsNATS   = "nats"
+	EventsKafka  = "kafka"
+)
+
+// Padrões dos destinos dos eventos
+const (
+	DefaultEventsFile    = "events.jsonl"
+	DefaultEventsSubject = "payments"
+	DefaultEventsTopic   = "payments.events"
+)
+
+// NewEventPublisher cria o publisher do backend escolhido em EVENTS_BACKEND.
+// Com "none" (o padrão), devolve nil e nenhum evento é publicado.
+func NewEventPublisher(logger *slog.Logger) (events.Publisher, error) {
+	switch backend := envOr("EVENTS_BACKEND", EventsNone); backend {
+	case EventsNone:
+		return nil, nil
+	case EventsMemory:
+		logger.Warn("Usando publicação de eventos em memória: nenhum consumidor externo os receberá")
+		return events.NewMemoryPublisher(), nil
+	case EventsFile:
+		path := envOr("EVENTS_FILE", DefaultEventsFile)
+		logger.Info("Publicando eventos em arquivo", slog.String("path", path))
+		return events.NewFilePublisher(path)
+	case EventsNATS:
+		conn, err := connectNATS(backend, logger)
+		if err != nil {
+			return nil, err
+		}
+		return events.NewNATSPublisher(conn, envOr("EVENTS_SUBJECT_PREFIX", DefaultEventsSubject)), nil
+	case EventsKafka:
+		brokers := splitEnv("KAFKA_BROKERS")
+		if len(brokers) == 0 {
+			return nil, fmt.Errorf("KAFKA_BROKERS is required for the %s events backend", backend)
+		}
+		return events.NewKafkaPublisher(brokers, envOr("KAFKA_TOPIC", DefaultEventsTopic), events.WithLogger(logger)), nil
+	default:
+		return nil, fmt.Errorf("unsupported EVENTS_BACKEND: %s", backend)
+	}
+}
+
+// Padrões da assinatura dos eventos de pedido
+const (
+	DefaultOrderCreatedSubject = "orders.order.created"
+	DefaultOrderCreatedTopic   = "order.created"
+	DefaultConsumerGroup       = "payments"
+)
+
+// NewOrderSubscriber cria o subscriber de order.created do backend escolhido em
+// ORDER_EVENTS_BACKEND (none, memory, nats ou kafka). Com "none" (o padrão),
+// devolve nil e nenhum pedido é consumido.
+func NewOrderSubscriber(logger *slog.Logger) (events.Subscriber, error) {
+	switch backend := envOr("ORDER_EVENTS_BACKEND", EventsNone); backend {
+	case EventsNone:
+		return nil, nil
+	case EventsMemory:
+		logger.Warn("Usando assinatura de pedidos em memória: nenhum pedido externo será recebido")
+		return events.NewMemorySubscriber(0, events.WithLogger(logger)), nil
+	case EventsNATS:
+		conn, err := connectNATS(backend, logger)
+		if err != nil {
+			return nil, err
+		}
+		subject := envOr("ORDER_CREATED_SUBJECT", DefaultOrderCreatedSubject)
+		return events.NewNATSSubscriber(conn, subject, envOr("CONSUMER_GROUP", DefaultConsumerGroup), events.WithLogger(logger)), nil
+	case EventsKafka:
+		brokers := splitEnv("KAFKA_BROKERS")
+		if len(brokers) == 0 {
+			return nil, fmt.Errorf("KAFKA_BROKERS is required for the %s order events backend", backend)
+		}
+		topic := envOr("ORDER_CREATED_TOPIC", DefaultOrderCreatedTopic)
+		return events.NewKafkaSubscriber(brokers, topic, envOr("CONSUMER_GROUP", DefaultConsumerGroup), events.WithLogger(logger)), nil
+	default:
+		return nil, fmt.Errorf("unsupported ORDER_EVENTS_BACKEND: %s", backend)
+	}
+}
+
+// connectNATS abre uma conexão com o servidor de NATS_URL
+func connectNATS(backend string, logger *slog.Logger) (*nats.Conn, error) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		return nil, fmt.Errorf("NATS_URL is required for the %s backend", backend)
+	}
+	conn, err := nats.Connect(url, nats.Name("payments"))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS: %v", err)
+	}
+	logger.Info("Conexão com NATS realizada com sucesso", slog.String("url", logging.RedactURI(url)))
+	return conn, nil
+}
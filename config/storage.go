@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"payments/logging"
+	"payments/repository"
+)
+
+// Repositories reúne os repositórios de um mesmo backend
+type Repositories struct {
+	Payments repository.PaymentRepository
+	FeeRules repository.FeeRuleRepository
+	Disputes repository.DisputeRepository
+	Webhooks repository.WebhookRepository
+	APIKeys  repository.APIKeyRepository
+}
+
+// NewRepositories cria os repositórios do backend escolhido em STORAGE_BACKEND
+func NewRepositories(logger *slog.Logger) (Repositories, error) {
+	switch backend := StorageBackend(); backend {
+	case StorageMongo:
+		InitDB()
+		if autoMigrate() {
+			if err := repository.MigrateMongo(context.Background(), MongoDB, repository.WithLogger(logger)); err != nil {
+				return Repositories{}, err
+			}
+		}
+		return Repositories{
+			Payments: repository.NewPaymentRepository(MongoDB, repository.WithLogger(logger)),
+			FeeRules: repository.NewFeeRuleRepository(MongoDB, repository.WithLogger(logger)),
+			Disputes: repository.NewDisputeRepository(MongoDB, repository.WithLogger(logger)),
+			Webhooks: repository.NewWebhookRepository(MongoDB, repository.WithLogger(logger)),
+			APIKeys:  repository.NewAPIKeyRepository(MongoDB, repository.WithLogger(logger)),
+		}, nil
+	case StorageMemory:
+		logger.Warn("Usando armazenamento em memória: os pagamentos serão perdidos ao reiniciar")
+		return Repositories{
+			Payments: repository.NewMemoryPaymentRepository(),
+			FeeRules: repository.NewMemoryFeeRuleRepository(),
+			Disputes: repository.NewMemoryDisputeRepository(),
+			Webhooks: repository.NewMemoryWebhookRepository(),
+			APIKeys:  repository.NewMemoryAPIKeyRepository(),
+		}, nil
+	case StoragePostgres:
+		dsn := os.Getenv("POSTGRES_DSN")
+		if dsn == "" {
+			return Repositories{}, fmt.Errorf("POSTGRES_DSN is required for the %s backend", backend)
+		}
+		db, err := repository.OpenPostgres(context.Background(), dsn, repository.WithLogger(logger))
+		if err != nil {
+			return Repositories{}, err
+		}
+		logger.Info("Conexão com PostgreSQL realizada com sucesso", slog.String("dsn", logging.RedactURI(dsn)))
+		return Repositories{
+			Payments: repository.NewPostgresPaymentRepository(db, repository.WithLogger(logger)),
+			FeeRules: repository.NewPostgresFeeRuleRepository(db, repository.WithLogger(logger)),
+			Disputes: repository.NewPostgresDisputeRepository(db, repository.WithLogger(logger)),
+			Webhooks: repository.NewPostgresWebhookRepository(db, repository.WithLogger(logger)),
+			APIKeys:  repository.NewPostgresAPIKeyRepository(db, repository.WithLogger(logger)),
+		}, nil
+	case StorageSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "payments.db"
+		}
+		db, err := repository.OpenSQLite(context.Background(), path, repository.WithLogger(logger))
+		if err != nil {
+			return Repositories{}, err
+		}
+		logger.Info("Banco SQLite aberto", slog.String("path", path))
+		return Repositories{
+			Payments: repository.NewSQLitePaymentRepository(db, repository.WithLogger(logger)),
+			FeeRules: repository.NewSQLiteFeeRuleRepository(db, repository.WithLogger(logger)),
+			Disputes: repository.NewSQLiteDisputeRepository(db, repository.WithLogger(logger)),
+			Webhooks: repository.NewSQLiteWebhookRepository(db, repository.WithLogger(logger)),
+			APIKeys:  repository.NewSQLiteAPIKeyRepository(db, repository.WithLogger(logger)),
+		}, nil
+	default:
+		return Repositories{}, fmt.Errorf("unsupported STORAGE_BACKEND: %s", backend)
+	}
+}
+
+// Migrate aplica as migrações pendentes do backend configurado, sem subir o servidor.
+// Os backends SQL migram ao abrir a conexão; o em memória não tem schema.
+func Migrate(logger *slog.Logger) error {
+	switch backend := StorageBackend(); backend {
+	case StorageMongo:
+		InitDB()
+		return repository.MigrateMongo(context.Background(), MongoDB, repository.WithLogger(logger))
+	case StorageMemory:
+		logger.Info("Armazenamento em memória não possui migrações")
+		return nil
+	default:
+		// Reaproveita a abertura dos backends SQL, que já aplica as migrações
+		_, err := NewRepositories(logger)
+		return err
+	}
+}
+
+// autoMigrate indica se as migrações do MongoDB rodam na subida (MONGO_AUTO_MIGRATE, padrão true)
+func autoMigrate() bool {
+	return os.Getenv("MONGO_AUTO_MIGRATE") != "false"
+}
@@ -0,0 +1,122 @@
+// Package consumers processa as mensagens que outros serviços publicam no broker
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/dto"
+	"payments/events"
+	"payments/telemetry"
+	"payments/usecase"
+)
+
+// OrderCreatedType é o type CloudEvents dos pedidos criados pelo serviço de pedidos
+const OrderCreatedType = "order.created"
+
+// OrderCreatedConsumer cria o pagamento de cada pedido recebido em order.created
+// e publica de volta as instruções de pagamento (payment.instructions)
+type OrderCreatedConsumer struct {
+	subscriber events.Subscriber
+	useCase    usecase.PaymentUseCase
+	publisher  events.Publisher
+	logger     *slog.Logger
+}
+
+// NewOrderCreatedConsumer cria o consumidor; as instruções são publicadas em publisher
+func NewOrderCreatedConsumer(subscriber events.Subscriber, useCase usecase.PaymentUseCase, publisher events.Publisher, logger *slog.Logger) *OrderCreatedConsumer {
+	return &OrderCreatedConsumer{
+		subscriber: subscriber,
+		useCase:    useCase,
+		publisher:  publisher,
+		logger:     logger,
+	}
+}
+
+// Run consome as mensagens até o contexto ser cancelado
+func (c *OrderCreatedConsumer) Run(ctx context.Context) error {
+	c.logger.InfoContext(ctx, "Consumidor de order.created iniciado")
+	return c.subscriber.Subscribe(ctx, c.Handle)
+}
+
+// Handle processa um order.created, cujo data é o corpo de POST /payments. É
+// idempotente por pedido: se o pedido já tem um pagamento, nada é criado e as
+// instruções desse pagamento são publicadas de novo, o que torna seguras as
+// novas tentativas do subscriber. Eventos de outro tipo ou inválidos falham
+// com events.ErrPermanent.
+func (c *OrderCreatedConsumer) Handle(ctx context.Context, event events.CloudEvent) (err error) {
+	ctx, span := telemetry.Tracer().Start(event.Context(ctx), "OrderCreatedConsumer.Handle",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("event.id", event.ID), attribute.String("event.type", event.Type)),
+	)
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Mensagens de outro tipo no mesmo tópico não são pedidos: nenhuma nova tentativa muda isso
+	if event.Type != OrderCreatedType {
+		return fmt.Errorf("unexpected event type %q in %s: %w", event.Type, event.ID, events.ErrPermanent)
+	}
+	req, err := dto.DecodeOrderCreated(event.Data)
+	if err != nil {
+		return fmt.Errorf("invalid order.created %s: %w: %w", event.ID, events.ErrPermanent, err)
+	}
+	span.SetAttributes(telemetry.OrderIDAttr(req.OrderID))
+
+	payment, created, err := c.ensurePayment(ctx, req)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(telemetry.PaymentIDAttr(payment.ID.Hex()), attribute.Bool("payment.created", created))
+
+	instructions, err := events.NewPaymentInstructionsEvent(ctx, events.DefaultSource, payment, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if err := c.publisher.Publish(ctx, instructions); err != nil {
+		return fmt.Errorf("error publishing payment instructions: %w", err)
+	}
+	c.logger.InfoContext(ctx, "Pedido processado",
+		slog.String("order_id", req.OrderID), slog.String("payment_id", payment.ID.Hex()), slog.Bool("created", created))
+	return nil
+}
+
+// ensurePayment devolve o pagamento do pedido, criando-o se o pedido ainda não
+// tem nenhum; created false indica uma reentrega da mensagem
+func (c *OrderCreatedConsumer) ensurePayment(ctx context.Context, req dto.CreatePaymentRequest) (_ domain.Payment, created bool, err error) {
+	if payment, found, err := c.existingPayment(ctx, req.OrderID); err != nil || found {
+		return payment, false, err
+	}
+	id, err := c.useCase.CreatePayment(ctx, req.ToPayment())
+	if errors.Is(err, domain.ErrConflict) {
+		// Outra instância (ou um POST /payments) criou o pagamento ao mesmo tempo
+		payment, found, findErr := c.existingPayment(ctx, req.OrderID)
+		if findErr != nil || !found {
+			return payment, false, errors.Join(err, findErr)
+		}
+		return payment, false, nil
+	}
+	if err != nil {
+		return domain.Payment{}, false, err
+	}
+	payment, err := c.useCase.GetPaymentByID(ctx, id)
+	return payment, true, err
+}
+
+// existingPayment devolve o pagamento ativo do pedido ou, sem ele, a tentativa mais recente
+func (c *OrderCreatedConsumer) existingPayment(ctx context.Context, orderID string) (domain.Payment, bool, error) {
+	order, err := c.useCase.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil || len(order.Payments) == 0 {
+		return domain.Payment{}, false, err
+	}
+	for _, payment := range order.Payments {
+		if payment.ID.Hex() == order.ActivePaymentID {
+			return payment, true, nil
+		}
+	}
+	return order.Payments[0], true, nil
+}
@@ -0,0 +1,87 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/dto"
+	"payments/telemetry"
+	"payments/usecase"
+)
+
+// APIKeyHandler expõe a administração das chaves de API em /admin/api-keys
+type APIKeyHandler struct {
+	useCase usecase.APIKeyUseCase
+}
+
+func NewAPIKeyHandler(useCase usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{useCase: useCase}
+}
+
+func startAPIKeySpan(c *fiber.Ctx, operation string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(c.UserContext(), "APIKeyHandler."+operation)
+}
+
+// ListAPIKeys retorna todas as chaves, inclusive as revogadas, sem o hash
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	ctx, span := startAPIKeySpan(c, "ListAPIKeys")
+	defer span.End()
+
+	keys, err := h.useCase.ListAPIKeys(ctx)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(keys)
+}
+
+// IssueAPIKey emite uma chave; a chave completa vem apenas nesta resposta
+func (h *APIKeyHandler) IssueAPIKey(c *fiber.Ctx) error {
+	ctx, span := startAPIKeySpan(c, "IssueAPIKey")
+	defer span.End()
+
+	req, err := dto.DecodeAPIKeyRequest(c.Body())
+	if err != nil {
+		return decodeError(err)
+	}
+	issued, err := h.useCase.IssueAPIKey(ctx, req.Name, req.Scopes)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.String("api_key.id", issued.ID.Hex()))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusCreated).JSON(issued)
+}
+
+// GetAPIKey retorna uma chave, sem o hash
+func (h *APIKeyHandler) GetAPIKey(c *fiber.Ctx) error {
+	ctx, span := startAPIKeySpan(c, "GetAPIKey")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("api_key.id", id))
+	key, err := h.useCase.GetAPIKey(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(key)
+}
+
+// RevokeAPIKey revoga a chave imediatamente; revogar de novo não muda a data
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	ctx, span := startAPIKeySpan(c, "RevokeAPIKey")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("api_key.id", id))
+	key, err := h.useCase.RevokeAPIKey(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(key)
+}
@@ -0,0 +1,124 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/dto"
+	"payments/telemetry"
+	"payments/usecase"
+)
+
+// DisputeHandler expõe a abertura, o acompanhamento e a resolução de disputas
+type DisputeHandler struct {
+	useCase usecase.DisputeUseCase
+}
+
+func NewDisputeHandler(useCase usecase.DisputeUseCase) *DisputeHandler {
+	return &DisputeHandler{useCase: useCase}
+}
+
+func startDisputeSpan(c *fiber.Ctx, operation string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(c.UserContext(), "DisputeHandler."+operation)
+}
+
+// decodeError devolve o erro de validação como está (422) e os demais como 400
+func decodeError(err error) error {
+	if errors.Is(err, domain.ErrValidation) {
+		return err
+	}
+	return invalidBody(err)
+}
+
+// OpenDispute registra a contestação de um pagamento
+func (h *DisputeHandler) OpenDispute(c *fiber.Ctx) error {
+	ctx, span := startDisputeSpan(c, "OpenDispute")
+	defer span.End()
+
+	paymentID := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(paymentID))
+	req, err := dto.DecodeOpenDisputeRequest(c.Body())
+	if err != nil {
+		return decodeError(err)
+	}
+	dispute, err := h.useCase.OpenDispute(ctx, paymentID, req.ToDispute())
+	if err != nil {
+		// Pagamento não liquidado ou já em disputa vira 409 no ErrorHandler
+		telemetry.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.String("dispute.id", dispute.ID.Hex()))
+	return c.Status(fiber.StatusCreated).JSON(dispute)
+}
+
+// ListPaymentDisputes retorna as disputas de um pagamento, da mais recente para a mais antiga
+func (h *DisputeHandler) ListPaymentDisputes(c *fiber.Ctx) error {
+	ctx, span := startDisputeSpan(c, "ListPaymentDisputes")
+	defer span.End()
+
+	paymentID := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(paymentID))
+	disputes, err := h.useCase.ListPaymentDisputes(ctx, paymentID)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(disputes)
+}
+
+// GetDispute retorna uma disputa específica
+func (h *DisputeHandler) GetDispute(c *fiber.Ctx) error {
+	ctx, span := startDisputeSpan(c, "GetDispute")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("dispute.id", id))
+	dispute, err := h.useCase.GetDispute(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(dispute)
+}
+
+// UpdateDispute altera o motivo e o prazo ou anexa evidências a uma disputa aberta
+func (h *DisputeHandler) UpdateDispute(c *fiber.Ctx) error {
+	ctx, span := startDisputeSpan(c, "UpdateDispute")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("dispute.id", id))
+	req, err := dto.DecodeUpdateDisputeRequest(c.Body())
+	if err != nil {
+		return decodeError(err)
+	}
+	dispute, err := h.useCase.UpdateDispute(ctx, id, req.ToUpdate())
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(dispute)
+}
+
+// ResolveDispute encerra a disputa como ganha ou perdida
+func (h *DisputeHandler) ResolveDispute(c *fiber.Ctx) error {
+	ctx, span := startDisputeSpan(c, "ResolveDispute")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("dispute.id", id))
+	req, err := dto.DecodeResolveDisputeRequest(c.Body())
+	if err != nil {
+		return decodeError(err)
+	}
+	dispute, err := h.useCase.ResolveDispute(ctx, id, req.Outcome)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(dispute)
+}
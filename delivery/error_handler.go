@@ -0,0 +1,98 @@
+package delivery
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"payments/domain"
+)
+
+// ProblemContentType é o media type das respostas de erro (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails é o envelope de erro retornado por todas as rotas
+type ProblemDetails struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Errors   []domain.ErrorResponse `json:"errors,omitempty"` // Detalhes por campo nos erros de validação
+}
+
+// upstreamDetail substitui, nas respostas 5xx, a mensagem do erro, que pode
+// trazer URLs e respostas de serviços internos; o erro completo fica no log e no span
+const upstreamDetail = "a dependency of the service failed; try again later"
+
+// problemMapping associa cada erro de domínio ao status e ao type do problema
+var problemMapping = []struct {
+	err    error
+	status int
+	typ    string
+}{
+	{domain.ErrValidation, fiber.StatusUnprocessableEntity, "/problems/validation"},
+	{domain.ErrInvalidID, fiber.StatusBadRequest, "/problems/invalid-id"},
+	{domain.ErrNotFound, fiber.StatusNotFound, "/problems/not-found"},
+	{domain.ErrConflict, fiber.StatusConflict, "/problems/conflict"},
+	{domain.ErrUpstream, fiber.StatusBadGateway, "/problems/upstream"},
+	{domain.ErrUnauthorized, fiber.StatusUnauthorized, "/problems/unauthorized"},
+	{domain.ErrForbidden, fiber.StatusForbidden, "/problems/forbidden"},
+}
+
+// NewProblem monta o ProblemDetails correspondente ao erro
+func NewProblem(err error) ProblemDetails {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return ProblemDetails{
+			Type:   "about:blank",
+			Title:  utils.StatusMessage(fiberErr.Code),
+			Status: fiberErr.Code,
+			Detail: fiberErr.Message,
+		}
+	}
+
+	for _, mapping := range problemMapping {
+		if !errors.Is(err, mapping.err) {
+			continue
+		}
+		problem := ProblemDetails{
+			Type:   mapping.typ,
+			Title:  utils.StatusMessage(mapping.status),
+			Status: mapping.status,
+			Detail: err.Error(),
+		}
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			problem.Detail = domain.ErrValidation.Error()
+			problem.Errors = validationErr.Errors
+		}
+		if mapping.status >= fiber.StatusInternalServerError {
+			problem.Detail = upstreamDetail
+		}
+		return problem
+	}
+
+	// Erros inesperados não expõem detalhes internos ao cliente
+	return ProblemDetails{
+		Type:   "about:blank",
+		Title:  utils.StatusMessage(fiber.StatusInternalServerError),
+		Status: fiber.StatusInternalServerError,
+	}
+}
+
+// ErrorHandler é o fiber.ErrorHandler do serviço: converte qualquer erro
+// retornado pelos handlers em uma resposta application/problem+json
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	problem := NewProblem(err)
+	// Só o caminho: a query pode trazer credenciais, como o access_token dos streams
+	problem.Instance = c.Path()
+
+	if problem.Status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "Erro ao processar requisição",
+			slog.String("path", c.Path()), slog.Int("status", problem.Status), slog.Any("error", err))
+	}
+
+	return c.Status(problem.Status).JSON(problem, ProblemContentType)
+}
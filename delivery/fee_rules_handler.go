@@ -0,0 +1,120 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/dto"
+	"payments/telemetry"
+	"payments/usecase"
+)
+
+// FeeRuleHandler expõe a administração das regras de tarifa em /admin/fee-rules
+type FeeRuleHandler struct {
+	useCase usecase.FeeRuleUseCase
+}
+
+func NewFeeRuleHandler(useCase usecase.FeeRuleUseCase) *FeeRuleHandler {
+	return &FeeRuleHandler{useCase: useCase}
+}
+
+func startFeeRuleSpan(c *fiber.Ctx, operation string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(c.UserContext(), "FeeRuleHandler."+operation)
+}
+
+func decodeFeeRule(c *fiber.Ctx) (*domain.FeeRule, error) {
+	req, err := dto.DecodeFeeRuleRequest(c.Body())
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return req.ToFeeRule(), nil
+}
+
+// ListFeeRules retorna todas as versões, ordenadas por tipo e vigência
+func (h *FeeRuleHandler) ListFeeRules(c *fiber.Ctx) error {
+	ctx, span := startFeeRuleSpan(c, "ListFeeRules")
+	defer span.End()
+
+	rules, err := h.useCase.ListFeeRules(ctx)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(rules)
+}
+
+// GetFeeRule retorna uma versão específica
+func (h *FeeRuleHandler) GetFeeRule(c *fiber.Ctx) error {
+	ctx, span := startFeeRuleSpan(c, "GetFeeRule")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("fee_rule.id", id))
+	rule, err := h.useCase.GetFeeRule(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(rule)
+}
+
+// CreateFeeRule cria uma nova versão; uma versão com o mesmo tipo e vigência vira 409
+func (h *FeeRuleHandler) CreateFeeRule(c *fiber.Ctx) error {
+	ctx, span := startFeeRuleSpan(c, "CreateFeeRule")
+	defer span.End()
+
+	rule, err := decodeFeeRule(c)
+	if err != nil {
+		return err
+	}
+	id, err := h.useCase.CreateFeeRule(ctx, rule)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.String("fee_rule.id", id))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id": id,
+	})
+}
+
+// UpdateFeeRule altera uma versão que ainda não entrou em vigor
+func (h *FeeRuleHandler) UpdateFeeRule(c *fiber.Ctx) error {
+	ctx, span := startFeeRuleSpan(c, "UpdateFeeRule")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("fee_rule.id", id))
+	rule, err := decodeFeeRule(c)
+	if err != nil {
+		return err
+	}
+	if err := h.useCase.UpdateFeeRule(ctx, id, rule); err != nil {
+		// Versão já em vigor vira 409 no ErrorHandler
+		telemetry.RecordError(span, err)
+		return err
+	}
+	updated, err := h.useCase.GetFeeRule(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// DeleteFeeRule remove uma versão que ainda não entrou em vigor
+func (h *FeeRuleHandler) DeleteFeeRule(c *fiber.Ctx) error {
+	ctx, span := startFeeRuleSpan(c, "DeleteFeeRule")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(attribute.String("fee_rule.id", id))
+	if err := h.useCase.DeleteFeeRule(ctx, id); err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
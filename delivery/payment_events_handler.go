@@ -0,0 +1,150 @@
+package delivery
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"payments/domain"
+	"payments/pubsub"
+	"payments/telemetry"
+	"payments/usecase"
+)
+
+const (
+	// DefaultHeartbeat é o intervalo padrão dos comentários enviados em streams ociosos
+	DefaultHeartbeat = 15 * time.Second
+	// reconnectDelay é o campo retry do SSE: quanto o navegador espera para reconectar
+	reconnectDelay = 3 * time.Second
+)
+
+// PaymentEventsHandler transmite as mudanças de status de um pagamento via Server-Sent Events
+type PaymentEventsHandler struct {
+	useCase   usecase.PaymentUseCase
+	broker    pubsub.Broker
+	heartbeat time.Duration
+	logger    *slog.Logger
+}
+
+// EventsOption permite customizar o PaymentEventsHandler
+type EventsOption func(*PaymentEventsHandler)
+
+// WithHeartbeat define o intervalo dos heartbeats que mantêm o stream aberto em proxies
+func WithHeartbeat(heartbeat time.Duration) EventsOption {
+	return func(h *PaymentEventsHandler) {
+		h.heartbeat = heartbeat
+	}
+}
+
+// WithEventsLogger define o logger estruturado usado pelo handler
+func WithEventsLogger(logger *slog.Logger) EventsOption {
+	return func(h *PaymentEventsHandler) {
+		h.logger = logger
+	}
+}
+
+func NewPaymentEventsHandler(useCase usecase.PaymentUseCase, broker pubsub.Broker, opts ...EventsOption) *PaymentEventsHandler {
+	h := &PaymentEventsHandler{useCase: useCase, broker: broker, heartbeat: DefaultHeartbeat, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// StreamPaymentEvents abre um stream text/event-stream com as mudanças de status
+// do pagamento. Sem Last-Event-ID, o stream começa com um payment.snapshot do
+// status atual; com ele, reenvia os eventos perdidos desde então. O stream
+// termina depois de um status terminal, e o cliente deve chamar close() no
+// EventSource; se ainda assim reconectar com o pagamento já finalizado e nada
+// novo a reenviar, recebe 204, que encerra as reconexões. Isso vale também
+// para um Last-Event-ID que o broker não reconhece mais (outra instância ou
+// reinício): o status final fica disponível em GET /payments/:id.
+func (h *PaymentEventsHandler) StreamPaymentEvents(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "StreamPaymentEvents")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	// A assinatura vem antes da leitura para nenhuma mudança cair entre as duas
+	sub := h.broker.Subscribe(pubsub.ForPayment(id), c.Get("Last-Event-ID"))
+	payment, err := h.useCase.GetPaymentByID(ctx, id)
+	if err != nil {
+		sub.Close()
+		telemetry.RecordError(span, err)
+		return err
+	}
+
+	var snapshot *domain.PaymentEvent
+	if !payment.IsActive() && c.Get("Last-Event-ID") != "" && (!sub.Resumed() || len(sub.Events()) == 0) {
+		// O cliente está reconectando a um pagamento finalizado: outro snapshot
+		// só o faria reconectar de novo
+		sub.Close()
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	if !sub.Resumed() {
+		event := domain.NewPaymentEvent(domain.EventPaymentSnapshot, payment, time.Now().UTC())
+		event.ID = sub.Cursor()
+		snapshot = &event
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Desliga o buffer do nginx
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.stream(w, id, sub, snapshot)
+	})
+	return nil
+}
+
+// stream escreve os eventos até um status terminal ou até a conexão cair, o que
+// só é percebido na escrita: os heartbeats limitam quanto tempo isso leva
+func (h *PaymentEventsHandler) stream(w *bufio.Writer, id string, sub pubsub.Subscription, snapshot *domain.PaymentEvent) {
+	defer sub.Close()
+
+	// Depois de um snapshot terminal não há o que esperar: sem a dica de retry
+	if snapshot == nil || !snapshot.Terminal() {
+		fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	}
+	if snapshot != nil {
+		if err := writeEvent(w, *snapshot); err != nil || snapshot.Terminal() {
+			return
+		}
+	} else if err := w.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Desligado por lentidão: o EventSource reconecta com o Last-Event-ID
+				h.logger.Warn("Stream de eventos desligado por lentidão", slog.String("payment_id", id))
+				return
+			}
+			if err := writeEvent(w, event); err != nil || event.Terminal() {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent escreve um evento no formato SSE e o envia imediatamente
+func writeEvent(w *bufio.Writer, event domain.PaymentEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return w.Flush()
+}
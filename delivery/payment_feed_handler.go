@@ -0,0 +1,205 @@
+package delivery
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"payments/domain"
+	"payments/dto"
+	"payments/pubsub"
+)
+
+const (
+	// DefaultPingInterval é o intervalo padrão dos pings que detectam conexões mortas
+	DefaultPingInterval = 30 * time.Second
+	// DefaultWriteTimeout é quanto uma escrita pode levar antes de a conexão ser descartada
+	DefaultWriteTimeout = 10 * time.Second
+	// maxFeedMessage limita o tamanho das mensagens enviadas pelo cliente
+	maxFeedMessage = 4096
+	// feedFilterKey guarda nos locals o filtro lido da query antes do upgrade
+	feedFilterKey = "payment_feed_filter"
+)
+
+// Tipos das mensagens enviadas pelo servidor em /ws/payments
+const (
+	FeedMessageEvent      = "event"
+	FeedMessageSubscribed = "subscribed"
+	FeedMessageError      = "error"
+)
+
+// FeedMessage é o envelope de toda mensagem enviada ao cliente do feed
+type FeedMessage struct {
+	Type    string                     `json:"type"`
+	Event   *domain.PaymentEvent       `json:"event,omitempty"`
+	Filter  *domain.PaymentEventFilter `json:"filter,omitempty"`
+	Message string                     `json:"message,omitempty"`
+	Errors  []domain.ErrorResponse     `json:"errors,omitempty"`
+}
+
+// PaymentFeedHandler transmite os eventos de todos os pagamentos via WebSocket,
+// para os painéis de operação
+type PaymentFeedHandler struct {
+	broker       pubsub.Broker
+	pingInterval time.Duration
+	writeTimeout time.Duration
+	logger       *slog.Logger
+}
+
+// FeedOption permite customizar o PaymentFeedHandler
+type FeedOption func(*PaymentFeedHandler)
+
+// WithPingInterval define o intervalo dos pings; sem pong até o próximo, a conexão é encerrada
+func WithPingInterval(interval time.Duration) FeedOption {
+	return func(h *PaymentFeedHandler) {
+		h.pingInterval = interval
+	}
+}
+
+// WithWriteTimeout define quanto uma escrita pode levar antes de o cliente ser desligado
+func WithWriteTimeout(timeout time.Duration) FeedOption {
+	return func(h *PaymentFeedHandler) {
+		h.writeTimeout = timeout
+	}
+}
+
+// WithFeedLogger define o logger estruturado usado pelo handler
+func WithFeedLogger(logger *slog.Logger) FeedOption {
+	return func(h *PaymentFeedHandler) {
+		h.logger = logger
+	}
+}
+
+func NewPaymentFeedHandler(broker pubsub.Broker, opts ...FeedOption) *PaymentFeedHandler {
+	h := &PaymentFeedHandler{
+		broker:       broker,
+		pingInterval: DefaultPingInterval,
+		writeTimeout: DefaultWriteTimeout,
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Upgrade valida a requisição antes do handshake. O filtro inicial vem da query
+// (status, payment_type e order_id_prefix); sem ele, a conexão recebe tudo.
+func (h *PaymentFeedHandler) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+	filter, err := dto.DecodePaymentFeedQuery(c.Query("status"), c.Query("payment_type"), c.Query("order_id_prefix"))
+	if err != nil {
+		return decodeError(err)
+	}
+	c.Locals(feedFilterKey, filter.ToFilter())
+	return c.Next()
+}
+
+// Stream atende a conexão já estabelecida
+func (h *PaymentFeedHandler) Stream() fiber.Handler {
+	return websocket.New(h.serve)
+}
+
+// serve entrega os eventos que atendem ao filtro atual. O cliente troca o filtro
+// com {"action": "subscribe", "filter": {...}}. Um cliente que não acompanha o
+// ritmo é desligado com o código 1013 e deve reconectar.
+func (h *PaymentFeedHandler) serve(conn *websocket.Conn) {
+	var filter atomic.Pointer[domain.PaymentEventFilter]
+	initial, _ := conn.Locals(feedFilterKey).(domain.PaymentEventFilter)
+	filter.Store(&initial)
+	sub := h.broker.Subscribe(func(event domain.PaymentEvent) bool {
+		return filter.Load().Matches(event)
+	}, "")
+	defer sub.Close()
+
+	// Só este laço escreve na conexão; o leitor repassa as respostas por replies
+	replies := make(chan FeedMessage, 1)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	go h.read(conn, &filter, replies, done, stop)
+	// A conexão é reciclada quando serve retorna: o leitor precisa terminar antes
+	defer func() {
+		close(stop)
+		_ = conn.Close()
+		<-done
+	}()
+
+	ping := time.NewTicker(h.pingInterval)
+	defer ping.Stop()
+	if h.write(conn, FeedMessage{Type: FeedMessageSubscribed, Filter: &initial}) != nil {
+		return
+	}
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case reply := <-replies:
+			err = h.write(conn, reply)
+		case event, ok := <-sub.Events():
+			if !ok {
+				h.logger.Warn("Cliente do feed de pagamentos desligado por lentidão", slog.String("ip", conn.IP()))
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer"),
+					time.Now().Add(h.writeTimeout))
+				return
+			}
+			err = h.write(conn, FeedMessage{Type: FeedMessageEvent, Event: &event})
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.writeTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// read processa as mensagens do cliente até a conexão cair ou o escritor parar
+func (h *PaymentFeedHandler) read(conn *websocket.Conn, filter *atomic.Pointer[domain.PaymentEventFilter],
+	replies chan<- FeedMessage, done chan<- struct{}, stop <-chan struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(maxFeedMessage)
+	deadline := h.pingInterval + h.writeTimeout
+	_ = conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		reply := FeedMessage{Type: FeedMessageError}
+		msg, err := dto.DecodePaymentFeedMessage(data)
+		var validationErr *domain.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			reply.Message = domain.ErrValidation.Error()
+			reply.Errors = validationErr.Errors
+		case err != nil:
+			reply.Message = "invalid message: " + err.Error()
+		default:
+			subscribed := msg.Filter.ToFilter()
+			filter.Store(&subscribed)
+			reply = FeedMessage{Type: FeedMessageSubscribed, Filter: &subscribed}
+		}
+		select {
+		case replies <- reply:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (h *PaymentFeedHandler) write(conn *websocket.Conn, msg FeedMessage) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(h.writeTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(msg)
+}
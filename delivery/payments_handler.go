@@ -1,106 +1,310 @@
 package delivery
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+	"payments/barcode"
 	"payments/domain"
+	"payments/dto"
+	"payments/telemetry"
 	"payments/usecase"
 )
 
-const ErrPaymentNotFound = "Payment not found"
-
 type PaymentHandler struct {
-	useCase usecase.PaymentUseCase
+	useCase      usecase.PaymentUseCase
+	streamTokens StreamTokenIssuer
+	logger       *slog.Logger
+}
+
+// StreamTokenIssuer emite o token que dá acesso apenas ao stream de eventos de
+// um pagamento, para o checkout acompanhá-lo do navegador
+type StreamTokenIssuer interface {
+	Issue(paymentID string) (token string, expiresAt time.Time, err error)
+}
+
+// Option permite customizar as dependências do PaymentHandler
+type Option func(*PaymentHandler)
+
+// WithLogger define o logger estruturado usado pelo handler
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *PaymentHandler) {
+		h.logger = logger
+	}
+}
+
+// WithStreamTokens inclui na resposta da criação o token do stream de eventos do pagamento
+func WithStreamTokens(issuer StreamTokenIssuer) Option {
+	return func(h *PaymentHandler) {
+		h.streamTokens = issuer
+	}
 }
 
-func NewPaymentHandler(useCase usecase.PaymentUseCase) *PaymentHandler {
-	return &PaymentHandler{useCase: useCase}
+func NewPaymentHandler(useCase usecase.PaymentUseCase, opts ...Option) *PaymentHandler {
+	h := &PaymentHandler{useCase: useCase, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// startSpan abre o span do handler como filho do span da requisição (ver middleware.Tracing)
+func startSpan(c *fiber.Ctx, operation string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(c.UserContext(), "PaymentHandler."+operation)
+}
+
+// Dimensões da imagem do código de barras, em pixels
+const (
+	boletoModuleWidth = 2
+	boletoBarHeight   = 100
+)
+
+// invalidBody padroniza o erro de corpo malformado
+func invalidBody(err error) error {
+	return fiber.NewError(fiber.StatusBadRequest, "Invalid request body: "+err.Error())
 }
 
 // GetAllPayments retorna todos os pagamentos
 func (h *PaymentHandler) GetAllPayments(c *fiber.Ctx) error {
-	payments, err := h.useCase.GetAllPayments()
+	ctx, span := startSpan(c, "GetAllPayments")
+	defer span.End()
+
+	payments, err := h.useCase.GetAllPayments(ctx)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		telemetry.RecordError(span, err)
+		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(payments)
 }
 
 // GetPaymentByID retorna um pagamento específico por ID
 func (h *PaymentHandler) GetPaymentByID(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "GetPaymentByID")
+	defer span.End()
+
 	id := c.Params("id")
-	payment, err := h.useCase.GetPaymentByID(id)
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	payment, err := h.useCase.GetPaymentByID(ctx, id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": ErrPaymentNotFound})
+		telemetry.RecordError(span, err)
+		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(payment)
 }
 
+// GetPaymentsByOrderID retorna as tentativas de pagamento de um pedido e o status efetivo
+func (h *PaymentHandler) GetPaymentsByOrderID(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "GetPaymentsByOrderID")
+	defer span.End()
+
+	orderID := c.Params("orderId")
+	span.SetAttributes(telemetry.OrderIDAttr(orderID))
+	payments, err := h.useCase.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(payments)
+}
+
 // CreatePayment cria um novo pagamento
 func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
-	var payment domain.Payment
-	// Tenta fazer o parsing do corpo da requisição
-	if err := c.BodyParser(&payment); err != nil {
+	ctx, span := startSpan(c, "CreatePayment")
+	defer span.End()
+
+	// Decodifica e valida o corpo; violações de campo viram 422 no ErrorHandler
+	req, err := dto.DecodeCreatePaymentRequest(c.Body())
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			return err
+		}
 		// Retorna erro 400 (Bad Request) se a requisição estiver malformada
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid input: " + err.Error())
+		return invalidBody(err)
 	}
 
 	// Chama o caso de uso para criar o pagamento e obter o UUID
-	uid, err := h.useCase.CreatePayment(&payment)
-	if err != nil {
-		// Verifica se o erro é de tipo inválido (InvalidPaymentTypeError)
-		var invalidErr *domain.InvalidPaymentTypeError
-		if errors.As(err, &invalidErr) {
-			// Retorna erro 422 (Unprocessable Entity) se o tipo de pagamento for inválido
-			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-				"error": invalidErr.Error(),
-			})
-		}
-		// Caso contrário, retorna erro 500 (Internal Server Error)
-		return c.Status(fiber.StatusInternalServerError).SendString("Internal server error: " + err.Error())
+	uid, err := h.useCase.CreatePayment(ctx, req.ToPayment())
+	if err != nil {
+		// Tipo de pagamento inválido vira 422 no ErrorHandler
+		telemetry.RecordError(span, err)
+		return err
 	}
 
+	span.SetAttributes(telemetry.PaymentIDAttr(uid))
 	// Retorna a resposta 201 (Created) com o UUID gerado
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+	response := fiber.Map{
 		"uuid": uid,
-	})
+	}
+	// O checkout abre /payments/:id/events?access_token=<events_token> sem precisar de uma chave de API
+	if h.streamTokens != nil {
+		token, expiresAt, err := h.streamTokens.Issue(uid)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Erro ao emitir o token de stream", slog.String("payment_id", uid), slog.Any("error", err))
+		} else {
+			response["events_token"] = token
+			response["events_token_expires_at"] = expiresAt
+		}
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
-// UpdatePayment atualiza um pagamento existente
+// UpdatePayment altera os campos mutáveis de um pagamento (ver dto.UpdatePaymentRequest)
+// e retorna o pagamento atualizado
 func (h *PaymentHandler) UpdatePayment(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "UpdatePayment")
+	defer span.End()
+
 	id := c.Params("id")
-	var payment domain.Payment
-	if err := c.BodyParser(&payment); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	req, err := dto.DecodeUpdatePaymentRequest(c.Body())
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			return err
+		}
+		return invalidBody(err)
 	}
-	if err := h.useCase.UpdatePayment(id, &payment); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": ErrPaymentNotFound})
+	payment, err := h.useCase.UpdatePayment(ctx, id, req.ToUpdate())
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
 	}
-	return c.Status(fiber.StatusNoContent).Send(nil)
+	return c.Status(fiber.StatusOK).JSON(payment)
 }
 
 func (h *PaymentHandler) DeletePayment(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "DeletePayment")
+	defer span.End()
+
 	id := c.Params("id")
-	if err := h.useCase.DeletePayment(id); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(map[string]string{"error": ErrPaymentNotFound})
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	if err := h.useCase.DeletePayment(ctx, id); err != nil {
+		telemetry.RecordError(span, err)
+		return err
 	}
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
+// CancelPayment cancela um pagamento pendente e retorna o pagamento atualizado
+func (h *PaymentHandler) CancelPayment(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "CancelPayment")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	payment, err := h.useCase.CancelPayment(ctx, id)
+	if err != nil {
+		// Pagamento já liquidado vira 409 no ErrorHandler
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(payment)
+}
+
+// CapturePayment captura, total ou parcialmente, um pagamento autorizado
+func (h *PaymentHandler) CapturePayment(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "CapturePayment")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	req, err := dto.DecodeCaptureRequest(c.Body())
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			return err
+		}
+		return invalidBody(err)
+	}
+	payment, err := h.useCase.CapturePayment(ctx, id, req.Amount)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(payment)
+}
+
+// VoidPayment libera a autorização de um pagamento sem capturá-lo
+func (h *PaymentHandler) VoidPayment(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "VoidPayment")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	payment, err := h.useCase.VoidPayment(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(payment)
+}
+
+// RefundPayment estorna, total ou parcialmente, um pagamento liquidado
+func (h *PaymentHandler) RefundPayment(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "RefundPayment")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	req, err := dto.DecodeRefundRequest(c.Body())
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			return err
+		}
+		return invalidBody(err)
+	}
+	payment, err := h.useCase.RefundPayment(ctx, id, req.Amount)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(payment)
+}
+
+// GetBoletoImage devolve o código de barras do boleto em PNG (intercalado 2 de 5)
+func (h *PaymentHandler) GetBoletoImage(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "GetBoletoImage")
+	defer span.End()
+
+	id := c.Params("id")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	payment, err := h.useCase.GetPaymentByID(ctx, id)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	if payment.Boleto == nil {
+		return fmt.Errorf("boleto of payment %s: %w", id, domain.ErrNotFound)
+	}
+	image, err := barcode.RenderPNG(payment.Boleto.Barcode, boletoModuleWidth, boletoBarHeight)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Status(fiber.StatusOK).Send(image)
+}
+
 func (ph *PaymentHandler) Callback(c *fiber.Ctx) error {
+	ctx, span := startSpan(c, "Callback")
+	defer span.End()
+
 	var callbackData domain.PaymentCallback
 
 	if err := c.BodyParser(&callbackData); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return invalidBody(err)
 	}
 
-	err := ph.useCase.ProcessPaymentCallback(&callbackData)
+	span.SetAttributes(telemetry.PaymentIDAttr(callbackData.PaymentID))
+	err := ph.useCase.ProcessPaymentCallback(ctx, &callbackData)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Error processing payment callback",
-		})
+		telemetry.RecordError(span, err)
+		ph.logger.ErrorContext(ctx, "Erro ao processar callback de pagamento",
+			slog.String("payment_id", callbackData.PaymentID), slog.Any("error", err))
+		return err
 	}
 
 	// Retornando um "OK" ao serviço de pagamento
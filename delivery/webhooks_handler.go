@@ -0,0 +1,153 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/dto"
+	"payments/telemetry"
+	"payments/usecase"
+)
+
+// WebhookHandler expõe as inscrições de webhook e o log das entregas em /webhooks
+type WebhookHandler struct {
+	useCase usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(useCase usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{useCase: useCase}
+}
+
+func startWebhookSpan(c *fiber.Ctx, operation string) (context.Context, trace.Span) {
+	ctx, span := telemetry.Tracer().Start(c.UserContext(), "WebhookHandler."+operation)
+	if id := c.Params("id"); id != "" {
+		span.SetAttributes(attribute.String("webhook.subscription_id", id))
+	}
+	return ctx, span
+}
+
+func decodeSubscription(c *fiber.Ctx) (*domain.WebhookSubscription, error) {
+	req, err := dto.DecodeWebhookSubscriptionRequest(c.Body())
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return req.ToSubscription(), nil
+}
+
+// ListWebhooks retorna as inscrições, sem os segredos
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "ListWebhooks")
+	defer span.End()
+
+	subscriptions, err := h.useCase.ListSubscriptions(ctx)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(subscriptions)
+}
+
+// CreateWebhook registra um endpoint; a resposta traz o segredo usado nas assinaturas
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "CreateWebhook")
+	defer span.End()
+
+	subscription, err := decodeSubscription(c)
+	if err != nil {
+		return err
+	}
+	created, err := h.useCase.CreateSubscription(ctx, subscription)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.String("webhook.subscription_id", created.ID.Hex()))
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// GetWebhook retorna uma inscrição, sem o segredo
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "GetWebhook")
+	defer span.End()
+
+	subscription, err := h.useCase.GetSubscription(ctx, c.Params("id"))
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(subscription)
+}
+
+// UpdateWebhook altera a URL, os eventos, a descrição ou a situação da inscrição
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "UpdateWebhook")
+	defer span.End()
+
+	subscription, err := decodeSubscription(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.useCase.UpdateSubscription(ctx, c.Params("id"), subscription)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// DeleteWebhook remove a inscrição; o log das entregas é mantido
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "DeleteWebhook")
+	defer span.End()
+
+	if err := h.useCase.DeleteSubscription(ctx, c.Params("id")); err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// ListDeliveries retorna as entregas mais recentes da inscrição com o log de cada tentativa
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "ListDeliveries")
+	defer span.End()
+
+	deliveries, err := h.useCase.ListDeliveries(ctx, c.Params("id"))
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(deliveries)
+}
+
+// SendTestEvent envia um evento webhook.test e retorna o resultado da tentativa.
+// Uma falha do endpoint do lojista não é erro da requisição: fica no status da entrega.
+func (h *WebhookHandler) SendTestEvent(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "SendTestEvent")
+	defer span.End()
+
+	delivery, err := h.useCase.SendTestEvent(ctx, c.Params("id"))
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(delivery)
+}
+
+// Redeliver reenvia uma entrega na hora, com o mesmo corpo da original
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	ctx, span := startWebhookSpan(c, "Redeliver")
+	defer span.End()
+
+	deliveryID := c.Params("deliveryId")
+	span.SetAttributes(attribute.String("webhook.delivery_id", deliveryID))
+	delivery, err := h.useCase.Redeliver(ctx, c.Params("id"), deliveryID)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(delivery)
+}
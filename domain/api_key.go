@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Escopos de acesso à API; admin inclui todos os demais
+const (
+	ScopePaymentsRead  = "payments:read"
+	ScopePaymentsWrite = "payments:write"
+	ScopeRefundsWrite  = "refunds:write"
+	ScopeAdmin         = "admin"
+)
+
+// Scopes lista os escopos aceitos em uma chave de API
+var Scopes = []string{ScopePaymentsRead, ScopePaymentsWrite, ScopeRefundsWrite, ScopeAdmin}
+
+// PaymentEventsScope é o escopo dos tokens de stream: dá acesso apenas aos
+// eventos do pagamento id e nunca é concedido a chaves de API
+func PaymentEventsScope(id string) string {
+	return "payments:events:" + id
+}
+
+// APIKeyTag inicia toda chave emitida, o que facilita encontrá-las em
+// varreduras de segredos vazados: pk_<identificador>_<segredo>
+const APIKeyTag = "pk_"
+
+// APIKey é uma chave de acesso de um cliente da API. Só o hash da chave é
+// guardado; a chave completa é exibida uma única vez, na emissão.
+type APIKey struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+	// Prefix é a parte pública da chave (pk_<identificador>): identifica a chave
+	// nas listagens e localiza o registro na autenticação
+	Prefix    string     `json:"prefix" bson:"prefix"`
+	Hash      string     `json:"-" bson:"hash"`
+	Scopes    []string   `json:"scopes" bson:"scopes"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// IssuedAPIKey é a resposta da emissão, a única que traz a chave completa
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Revoked indica se a chave foi revogada
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Principal é o cliente autenticado pela chave
+func (k APIKey) Principal() Principal {
+	return Principal{Subject: "api_key:" + k.ID.Hex(), Name: k.Name, Scopes: k.Scopes}
+}
+
+// HashAPIKey é o hash guardado da chave. As chaves são aleatórias e longas, por
+// isso um SHA-256 simples basta (não há dicionário a proteger).
+func HashAPIKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+// APIKeyPrefix extrai a parte pública de uma chave no formato pk_<identificador>_<segredo>
+func APIKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyTag)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return APIKeyTag + id, true
+}
+
+// ValidateScopes confere uma lista de escopos, reunindo todas as violações
+func ValidateScopes(scopes []string) error {
+	errs := NewValidationError()
+	if len(scopes) == 0 {
+		errs.Add("scopes", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			errs.Add("scopes", "unknown scope: "+scope)
+		}
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// Principal é o cliente autenticado de uma requisição
+type Principal struct {
+	// Subject identifica o cliente (ex.: api_key:<id>)
+	Subject string   `json:"subject"`
+	Name    string   `json:"name,omitempty"`
+	Scopes  []string `json:"scopes"`
+}
+
+// HasScope indica se o cliente pode usar o escopo; admin pode todos
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, ScopeAdmin) || slices.Contains(p.Scopes, scope)
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BoletoDetails é o boleto bancário (padrão FEBRABAN) emitido para pagamentos BOLETO
+type BoletoDetails struct {
+	// Barcode tem 44 dígitos: banco, moeda, DV geral, fator de vencimento, valor e campo livre
+	Barcode string `json:"barcode" bson:"barcode"`
+	// DigitableLine é a linha digitável de 47 dígitos, sem formatação
+	DigitableLine string    `json:"digitable_line" bson:"digitable_line"`
+	DueDate       time.Time `json:"due_date" bson:"due_date"`
+	// ExpiresAt é o último instante em que o boleto ainda pode ser pago (vencimento + carência)
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+	// FinePercent é a multa única aplicada após o vencimento
+	FinePercent float64 `json:"fine_percent" bson:"fine_percent"`
+	// InterestPercentPerMonth são os juros de mora, cobrados pro rata dia
+	InterestPercentPerMonth float64 `json:"interest_percent_per_month" bson:"interest_percent_per_month"`
+}
+
+// BoletoIssuer são os dados do beneficiário usados na emissão (carteira de
+// convênio de 7 dígitos, layout do Banco do Brasil)
+type BoletoIssuer struct {
+	BankCode                string // Código do banco com 3 dígitos, ex.: 001
+	Covenant                string // Convênio com 7 dígitos
+	DueDays                 int    // Dias até o vencimento quando o cliente não informa a data
+	GraceDays               int    // Dias após o vencimento em que o boleto ainda é aceito
+	FinePercent             float64
+	InterestPercentPerMonth float64
+}
+
+// DefaultBoletoIssuer são os parâmetros de emissão usados quando nada é configurado
+var DefaultBoletoIssuer = BoletoIssuer{
+	BankCode:                "001",
+	Covenant:                "0000000",
+	DueDays:                 3,
+	FinePercent:             2,
+	InterestPercentPerMonth: 1,
+}
+
+// boletoBaseDate é a data-base do fator de vencimento; o fator reinicia em 1000 ao passar de 9999
+var boletoBaseDate = time.Date(1997, time.October, 7, 0, 0, 0, 0, time.UTC)
+
+// maxBoletoCents é o maior valor que cabe nos 10 dígitos do código de barras
+const maxBoletoCents = 9999999999
+
+// NewBoleto gera o código de barras e a linha digitável do pagamento. O nosso
+// número é derivado do ID do pagamento, que precisa estar preenchido.
+func NewBoleto(issuer BoletoIssuer, payment Payment, dueDate time.Time) (BoletoDetails, error) {
+	if !isDigits(issuer.BankCode, 3) || !isDigits(issuer.Covenant, 7) {
+		return BoletoDetails{}, fmt.Errorf("invalid boleto issuer: bank code must have 3 digits and covenant 7 digits")
+	}
+	if payment.ID.IsZero() {
+		return BoletoDetails{}, fmt.Errorf("boleto requires the payment ID")
+	}
+	cents := int64(math.Round(payment.Amount * 100))
+	if cents <= 0 || cents > maxBoletoCents {
+		return BoletoDetails{}, NewValidationError(ErrorResponse{Field: "amount", Message: "is out of the range accepted for boletos"})
+	}
+	dueDate = truncateToDate(dueDate)
+
+	freeField := "000000" + issuer.Covenant + ourNumber(payment) + "17" // Carteira 17
+	withoutDV := issuer.BankCode + "9" + dueDateFactor(dueDate) + fmt.Sprintf("%010d", cents) + freeField
+	barcode := withoutDV[:4] + strconv.Itoa(mod11(withoutDV)) + withoutDV[4:]
+
+	return BoletoDetails{
+		Barcode:                 barcode,
+		DigitableLine:           DigitableLine(barcode),
+		DueDate:                 dueDate,
+		ExpiresAt:               dueDate.AddDate(0, 0, issuer.GraceDays+1).Add(-time.Nanosecond),
+		FinePercent:             issuer.FinePercent,
+		InterestPercentPerMonth: issuer.InterestPercentPerMonth,
+	}, nil
+}
+
+// DigitableLine monta a linha digitável a partir do código de barras de 44 dígitos
+func DigitableLine(barcode string) string {
+	freeField := barcode[19:44]
+	field1 := barcode[0:4] + freeField[0:5]
+	field2 := freeField[5:15]
+	field3 := freeField[15:25]
+	return field1 + strconv.Itoa(mod10(field1)) +
+		field2 + strconv.Itoa(mod10(field2)) +
+		field3 + strconv.Itoa(mod10(field3)) +
+		barcode[4:5] + // DV geral
+		barcode[5:19] // Fator de vencimento e valor
+}
+
+// FormatDigitableLine formata a linha como impressa no boleto:
+// AAAAA.AAAAA BBBBB.BBBBBB CCCCC.CCCCCC D EEEEEEEEEEEEEE
+func FormatDigitableLine(line string) string {
+	if len(line) != 47 {
+		return line
+	}
+	return line[0:5] + "." + line[5:10] + " " +
+		line[10:15] + "." + line[15:21] + " " +
+		line[21:26] + "." + line[26:32] + " " +
+		line[32:33] + " " + line[33:47]
+}
+
+// ValidBoletoBarcode confere o tamanho e o DV geral (mod 11) de um código de barras
+func ValidBoletoBarcode(barcode string) bool {
+	if !isDigits(barcode, 44) {
+		return false
+	}
+	return strconv.Itoa(mod11(barcode[:4]+barcode[5:])) == barcode[4:5]
+}
+
+// IsExpired indica se o prazo de pagamento (vencimento + carência) já passou
+func (b BoletoDetails) IsExpired(now time.Time) bool {
+	return now.After(b.ExpiresAt)
+}
+
+// AmountDue calcula o valor a pagar em now: o valor original até o vencimento e,
+// depois dele, acrescido da multa e dos juros de mora por dia de atraso
+func (b BoletoDetails) AmountDue(amount float64, now time.Time) float64 {
+	lateDays := int(truncateToDate(now).Sub(b.DueDate).Hours() / 24)
+	if lateDays <= 0 {
+		return amount
+	}
+	fine := amount * b.FinePercent / 100
+	interest := amount * b.InterestPercentPerMonth / 100 / 30 * float64(lateDays)
+	return math.Round((amount+fine+interest)*100) / 100
+}
+
+// dueDateFactor conta os dias desde a data-base; após 9999 o fator volta para 1000
+func dueDateFactor(dueDate time.Time) string {
+	days := int(dueDate.Sub(boletoBaseDate).Hours() / 24)
+	if days > 9999 {
+		days = (days-10000)%9000 + 1000
+	}
+	return fmt.Sprintf("%04d", days)
+}
+
+// ourNumber deriva os 10 dígitos do nosso número dos bytes finais do ObjectID
+// (valor aleatório do processo e contador), distintos entre pagamentos
+func ourNumber(payment Payment) string {
+	id := payment.ID
+	var n uint64
+	for _, b := range id[4:] {
+		n = n<<8 | uint64(b)
+	}
+	return fmt.Sprintf("%010d", n%10000000000)
+}
+
+// mod10 calcula o DV dos campos da linha digitável (pesos 2 e 1 da direita para a esquerda)
+func mod10(digits string) int {
+	sum := 0
+	weight := 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		product := int(digits[i]-'0') * weight
+		sum += product/10 + product%10
+		weight = 3 - weight
+	}
+	return (10 - sum%10) % 10
+}
+
+// mod11 calcula o DV geral do código de barras (pesos 2 a 9); 0, 10 e 11 viram 1
+func mod11(digits string) int {
+	sum := 0
+	weight := 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	dv := 11 - sum%11
+	if dv == 0 || dv == 10 || dv == 11 {
+		return 1
+	}
+	return dv
+}
+
+func isDigits(value string, length int) bool {
+	return len(value) == length && strings.Trim(value, "0123456789") == ""
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bandeiras de cartão aceitas
+const (
+	BrandVisa       = "visa"
+	BrandMastercard = "mastercard"
+	BrandAmex       = "amex"
+	BrandElo        = "elo"
+	BrandHipercard  = "hipercard"
+)
+
+// Card guarda apenas a referência ao cartão tokenizado no gateway. O número
+// completo (PAN) e o CVV nunca chegam ao serviço nem são persistidos.
+type Card struct {
+	Token string `json:"token" bson:"token"`
+	Brand string `json:"brand" bson:"brand"`
+	Last4 string `json:"last4" bson:"last4"`
+}
+
+// eloPrefixes são os BINs da Elo, que se sobrepõem às faixas de Visa e Mastercard
+var eloPrefixes = []string{
+	"401178", "401179", "431274", "438935", "451416", "457393", "457631", "457632",
+	"504175", "506699", "5067", "509", "627780", "636297", "636368", "650", "6516", "6550",
+}
+
+// LuhnValid confere o dígito verificador (mod 10) de um número de cartão
+func LuhnValid(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// DetectBrand identifica a bandeira pelo BIN (primeiros dígitos); vazio se desconhecida
+func DetectBrand(number string) string {
+	for _, prefix := range eloPrefixes {
+		if strings.HasPrefix(number, prefix) {
+			return BrandElo
+		}
+	}
+	switch {
+	case strings.HasPrefix(number, "606282") || strings.HasPrefix(number, "3841"):
+		return BrandHipercard
+	case strings.HasPrefix(number, "34") || strings.HasPrefix(number, "37"):
+		return BrandAmex
+	case strings.HasPrefix(number, "4"):
+		return BrandVisa
+	case binInRange(number, 51, 55, 2) || binInRange(number, 2221, 2720, 4):
+		return BrandMastercard
+	default:
+		return ""
+	}
+}
+
+// binInRange verifica se os primeiros digits dígitos estão entre low e high
+func binInRange(number string, low, high, digits int) bool {
+	if len(number) < digits {
+		return false
+	}
+	bin := 0
+	for _, c := range number[:digits] {
+		if c < '0' || c > '9' {
+			return false
+		}
+		bin = bin*10 + int(c-'0')
+	}
+	return bin >= low && bin <= high
+}
+
+// ValidatePAN faz as checagens do momento da tokenização: só dígitos,
+// 13 a 19 posições, dígito verificador e bandeira conhecida
+func ValidatePAN(number string) error {
+	if len(number) < 13 || len(number) > 19 {
+		return fmt.Errorf("%w: card number must have between 13 and 19 digits", ErrValidation)
+	}
+	if !LuhnValid(number) {
+		return fmt.Errorf("%w: card number failed the Luhn check", ErrValidation)
+	}
+	if DetectBrand(number) == "" {
+		return fmt.Errorf("%w: card brand not supported", ErrValidation)
+	}
+	return nil
+}
+
+// LooksLikePAN indica se o valor parece um número de cartão em claro
+func LooksLikePAN(value string) bool {
+	return len(value) >= 13 && len(value) <= 19 && LuhnValid(value)
+}
+
+// ValidateDetails confere os dados que dependem do tipo de pagamento:
+// cartão, boleto, divisão entre recebedores e captura manual. O parcelamento depende da política
+// configurada e é conferido por ValidateInstallments.
+func (p Payment) ValidateDetails() error {
+	errs := NewValidationError()
+	if p.PaymentType == CreditCard {
+		switch {
+		case p.Card == nil || p.Card.Token == "":
+			errs.Add("card.token", "is required for CREDIT_CARD payments")
+		case LooksLikePAN(p.Card.Token):
+			errs.Add("card.token", "must be a gateway token, not a card number")
+		}
+	} else if p.Card != nil {
+		errs.Add("card", "is only accepted for CREDIT_CARD payments")
+	}
+	if p.Boleto != nil && p.PaymentType != Boleto {
+		errs.Add("due_date", "is only accepted for BOLETO payments")
+	}
+	p.validateCurrency(errs)
+	p.validateSplits(errs)
+	if p.CaptureMethod == CaptureManual && !p.PaymentType.SupportsManualCapture() {
+		errs.Add("capture", fmt.Sprintf("%s payments are captured immediately", p.PaymentType))
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
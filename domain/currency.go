@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SettlementCurrency é a moeda em que os pagamentos são liquidados; pagamentos
+// em outra moeda guardam a cotação usada na conversão (ver ApplyFXRate)
+const SettlementCurrency = "BRL"
+
+// Moedas (ISO 4217) aceitas nos pagamentos
+const (
+	BRL = "BRL"
+	USD = "USD"
+	EUR = "EUR"
+)
+
+// paymentTypeCurrencies lista as moedas aceitas por tipo de pagamento; PIX, QR
+// Code e boleto são instrumentos nacionais e só operam em real
+var paymentTypeCurrencies = map[PaymentType][]string{
+	Pix:        {BRL},
+	QRCode:     {BRL},
+	Boleto:     {BRL},
+	CreditCard: {BRL, USD, EUR},
+}
+
+// fxRateDecimals é a precisão com que as cotações são guardadas
+const fxRateDecimals = 1e6
+
+// FXRate é a cotação usada para converter From em To: 1 From = Rate To
+type FXRate struct {
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	Rate      float64   `json:"rate" bson:"rate"`
+	Source    string    `json:"source" bson:"source"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
+// Convert converte amount pela cotação, arredondando ao centavo
+func (r FXRate) Convert(amount float64) float64 {
+	return math.Round(amount*r.Rate*100) / 100
+}
+
+// RoundFXRate limita a cotação a seis casas decimais
+func RoundFXRate(rate float64) float64 {
+	return math.Round(rate*fxRateDecimals) / fxRateDecimals
+}
+
+// SupportedCurrencies devolve as moedas aceitas por algum tipo de pagamento, em ordem alfabética
+func SupportedCurrencies() []string {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, accepted := range paymentTypeCurrencies {
+		for _, currency := range accepted {
+			if !seen[currency] {
+				seen[currency] = true
+				currencies = append(currencies, currency)
+			}
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// AcceptsCurrency indica se o tipo de pagamento opera na moeda informada
+func (p PaymentType) AcceptsCurrency(currency string) bool {
+	return slices.Contains(paymentTypeCurrencies[p], currency)
+}
+
+// CurrencyCode devolve a moeda do pagamento; pagamentos anteriores ao suporte
+// a várias moedas não têm o campo e são em real
+func (p Payment) CurrencyCode() string {
+	if p.Currency == "" {
+		return SettlementCurrency
+	}
+	return p.Currency
+}
+
+// IsCrossCurrency indica se o pagamento precisa de conversão para a moeda de liquidação
+func (p Payment) IsCrossCurrency() bool {
+	return p.CurrencyCode() != SettlementCurrency
+}
+
+// validateCurrency acumula em errs as violações da moeda do pagamento
+func (p Payment) validateCurrency(errs *ValidationError) {
+	currency := p.CurrencyCode()
+	supported := SupportedCurrencies()
+	if !slices.Contains(supported, currency) {
+		errs.Add("currency", "must be one of: "+strings.Join(supported, ", "))
+		return
+	}
+	if p.PaymentType.IsValid() == nil && !p.PaymentType.AcceptsCurrency(currency) {
+		errs.Add("currency", fmt.Sprintf("is not accepted for %s payments", p.PaymentType))
+	}
+}
+
+// ApplyFXRate registra a cotação e o valor liquidado na moeda de liquidação,
+// para que conversões posteriores (captura, estorno) usem a mesma taxa
+func (p *Payment) ApplyFXRate(rate FXRate) error {
+	if rate.From != p.CurrencyCode() || rate.To != SettlementCurrency || rate.Rate <= 0 {
+		return fmt.Errorf("invalid FX rate %s->%s (%v) for a %s payment", rate.From, rate.To, rate.Rate, p.CurrencyCode())
+	}
+	p.FXRate = &rate
+	p.SettlementAmount = rate.Convert(p.Amount)
+	return nil
+}
+
+// SettlementValue converte um valor na moeda do pagamento pela cotação registrada
+func (p Payment) SettlementValue(amount float64) float64 {
+	if p.FXRate == nil {
+		return amount
+	}
+	return p.FXRate.Convert(amount)
+}
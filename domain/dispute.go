@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Situações de uma disputa (chargeback) aberta pelo portador do cartão
+const (
+	DisputeOpen = "open"
+	DisputeWon  = "won"  // A contestação foi revertida a favor do lojista
+	DisputeLost = "lost" // O valor volta ao cliente e o pagamento vira StatusChargedBack
+)
+
+// DefaultDisputeEvidenceWindow é o prazo para enviar evidências quando a
+// bandeira não informa um
+const DefaultDisputeEvidenceWindow = 7 * 24 * time.Hour
+
+// MaxDisputeEvidence limita os anexos de uma disputa
+const MaxDisputeEvidence = 20
+
+// Dispute registra a contestação de um pagamento junto à bandeira ou ao banco emissor
+type Dispute struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PaymentID  string             `json:"payment_id" bson:"payment_id"`
+	ReasonCode string             `json:"reason_code" bson:"reason_code"` // Código do motivo na bandeira, ex.: 4837
+	Amount     float64            `json:"amount" bson:"amount"`
+	Status     string             `json:"status" bson:"status"`
+	// EvidenceDueBy é o prazo para anexar evidências; depois dele a disputa só pode ser resolvida
+	EvidenceDueBy time.Time            `json:"evidence_due_by" bson:"evidence_due_by"`
+	Evidence      []EvidenceAttachment `json:"evidence" bson:"evidence"`
+	CreatedAt     time.Time            `json:"created_at" bson:"created_at"`
+	ResolvedAt    *time.Time           `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+}
+
+// EvidenceAttachment descreve um arquivo de evidência; o conteúdo fica no
+// armazenamento externo apontado por URL
+type EvidenceAttachment struct {
+	FileName    string    `json:"file_name" bson:"file_name"`
+	ContentType string    `json:"content_type" bson:"content_type"`
+	Size        int64     `json:"size" bson:"size"`
+	URL         string    `json:"url" bson:"url"`
+	UploadedAt  time.Time `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+// DisputeUpdate são as alterações aceitas em uma disputa aberta; campos
+// vazios são mantidos e Evidence é acrescentada aos anexos existentes
+type DisputeUpdate struct {
+	ReasonCode    string
+	EvidenceDueBy *time.Time
+	Evidence      []EvidenceAttachment
+}
+
+// Disputable indica se o pagamento foi liquidado e ainda tem saldo a contestar
+func (p Payment) Disputable() bool {
+	return p.Refundable()
+}
+
+// NewDispute abre uma disputa sobre o pagamento. Amount zero contesta todo o
+// saldo ainda não estornado; EvidenceDueBy zero usa DefaultDisputeEvidenceWindow.
+func NewDispute(payment Payment, input Dispute, now time.Time) (Dispute, error) {
+	if !payment.Disputable() {
+		return Dispute{}, fmt.Errorf("%w: payment in status %q cannot be disputed", ErrConflict, payment.Status)
+	}
+	balance := fromCents(toCents(payment.SettledAmount()) - toCents(payment.RefundedAmount))
+	errs := NewValidationError()
+	if input.ReasonCode == "" {
+		errs.Add("reason_code", "is required")
+	}
+	amount := input.Amount
+	switch {
+	case amount < 0:
+		errs.Add("amount", "must not be negative")
+	case amount == 0:
+		amount = balance
+	case toCents(amount) > toCents(balance):
+		errs.Add("amount", fmt.Sprintf("must be at most %.2f", balance))
+	}
+	dueBy := input.EvidenceDueBy
+	if dueBy.IsZero() {
+		dueBy = now.Add(DefaultDisputeEvidenceWindow)
+	} else if !dueBy.After(now) {
+		errs.Add("evidence_due_by", "must be in the future")
+	}
+	validateEvidence(input.Evidence, 0, errs)
+	if errs.HasErrors() {
+		return Dispute{}, errs
+	}
+	return Dispute{
+		PaymentID:     payment.ID.Hex(),
+		ReasonCode:    input.ReasonCode,
+		Amount:        amount,
+		Status:        DisputeOpen,
+		EvidenceDueBy: dueBy.UTC(),
+		Evidence:      stampEvidence(input.Evidence, now),
+		CreatedAt:     now.UTC(),
+	}, nil
+}
+
+// Apply altera uma disputa aberta. Evidências só são aceitas até EvidenceDueBy.
+func (d *Dispute) Apply(update DisputeUpdate, now time.Time) error {
+	if d.Status != DisputeOpen {
+		return fmt.Errorf("%w: dispute is already %s", ErrConflict, d.Status)
+	}
+	if len(update.Evidence) > 0 && now.After(d.EvidenceDueBy) {
+		return fmt.Errorf("%w: the evidence deadline of the dispute has passed", ErrConflict)
+	}
+	errs := NewValidationError()
+	if update.EvidenceDueBy != nil && !update.EvidenceDueBy.After(now) {
+		errs.Add("evidence_due_by", "must be in the future")
+	}
+	validateEvidence(update.Evidence, len(d.Evidence), errs)
+	if errs.HasErrors() {
+		return errs
+	}
+	if update.ReasonCode != "" {
+		d.ReasonCode = update.ReasonCode
+	}
+	if update.EvidenceDueBy != nil {
+		d.EvidenceDueBy = update.EvidenceDueBy.UTC()
+	}
+	d.Evidence = append(d.Evidence, stampEvidence(update.Evidence, now)...)
+	return nil
+}
+
+// Resolve encerra a disputa com o resultado informado pela bandeira
+func (d *Dispute) Resolve(outcome string, now time.Time) error {
+	if d.Status != DisputeOpen {
+		return fmt.Errorf("%w: dispute is already %s", ErrConflict, d.Status)
+	}
+	if outcome != DisputeWon && outcome != DisputeLost {
+		return NewValidationError(ErrorResponse{Field: "outcome", Message: "must be one of: won, lost"})
+	}
+	resolvedAt := now.UTC()
+	d.Status = outcome
+	d.ResolvedAt = &resolvedAt
+	return nil
+}
+
+// ChargeBack registra a perda da disputa no pagamento
+func (p *Payment) ChargeBack() {
+	p.Status = StatusChargedBack
+}
+
+func validateEvidence(evidence []EvidenceAttachment, existing int, errs *ValidationError) {
+	if existing+len(evidence) > MaxDisputeEvidence {
+		errs.Add("evidence", fmt.Sprintf("must have at most %d attachments per dispute", MaxDisputeEvidence))
+	}
+	for i, attachment := range evidence {
+		if attachment.FileName == "" {
+			errs.Add(fmt.Sprintf("evidence[%d].file_name", i), "is required")
+		}
+		if attachment.URL == "" {
+			errs.Add(fmt.Sprintf("evidence[%d].url", i), "is required")
+		}
+	}
+}
+
+// stampEvidence marca a data de envio dos anexos que não a trazem
+func stampEvidence(evidence []EvidenceAttachment, now time.Time) []EvidenceAttachment {
+	stamped := make([]EvidenceAttachment, 0, len(evidence))
+	for _, attachment := range evidence {
+		if attachment.UploadedAt.IsZero() {
+			attachment.UploadedAt = now.UTC()
+		}
+		stamped = append(stamped, attachment)
+	}
+	return stamped
+}
@@ -1,11 +1,66 @@
 package domain
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Erros sentinela do domínio; as camadas externas os embrulham com %w para
+// que o handler HTTP consiga mapeá-los para o status correto
+var (
+	ErrNotFound   = errors.New("resource not found")
+	ErrInvalidID  = errors.New("invalid id")
+	ErrValidation = errors.New("validation failed")
+	ErrConflict   = errors.New("conflict")
+	ErrUpstream   = errors.New("upstream service error")
+	// ErrUnauthorized indica credenciais ausentes, inválidas ou revogadas
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden indica um cliente autenticado sem o escopo exigido
+	ErrForbidden = errors.New("forbidden")
+)
 
 type ErrorResponse struct {
 	Message string `json:"message"`
 	Field   string `json:"field,omitempty"` // Campo opcional para indicar qual campo tem erro
 }
+
+// ValidationError reúne todas as violações encontradas em uma entrada
+type ValidationError struct {
+	Errors []ErrorResponse
+}
+
+// NewValidationError cria um ValidationError com as violações informadas
+func NewValidationError(errs ...ErrorResponse) *ValidationError {
+	return &ValidationError{Errors: errs}
+}
+
+// Add registra uma nova violação para o campo
+func (e *ValidationError) Add(field, message string) {
+	e.Errors = append(e.Errors, ErrorResponse{Field: field, Message: message})
+}
+
+// HasErrors indica se alguma violação foi registrada
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Errors) > 0
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		if fieldErr.Field != "" {
+			messages = append(messages, fieldErr.Field+": "+fieldErr.Message)
+		} else {
+			messages = append(messages, fieldErr.Message)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
 type InvalidPaymentTypeError struct {
 	Type string
 }
@@ -13,3 +68,8 @@ type InvalidPaymentTypeError struct {
 func (e *InvalidPaymentTypeError) Error() string {
 	return fmt.Sprintf("invalid payment type: %s", e.Type)
 }
+
+// Is faz o InvalidPaymentTypeError ser tratado como um erro de validação
+func (e *InvalidPaymentTypeError) Is(target error) bool {
+	return target == ErrValidation
+}
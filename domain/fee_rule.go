@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeeRule é a tarifa do PSP para um tipo de pagamento a partir de EffectiveFrom.
+// Uma nova versão da regra é uma nova FeeRule com EffectiveFrom posterior; as
+// versões já em vigor não mudam, para que o histórico de tarifas seja preservado.
+type FeeRule struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PaymentType PaymentType        `json:"payment_type" bson:"payment_type"`
+	FixedAmount float64            `json:"fixed_amount" bson:"fixed_amount"`
+	Percentage  float64            `json:"percentage" bson:"percentage"` // Percentual sobre o valor bruto
+	// MinFee e MaxFee limitam a tarifa calculada; zero significa sem limite
+	MinFee        float64   `json:"min_fee,omitempty" bson:"min_fee,omitempty"`
+	MaxFee        float64   `json:"max_fee,omitempty" bson:"max_fee,omitempty"`
+	EffectiveFrom time.Time `json:"effective_from" bson:"effective_from"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at,omitempty"`
+}
+
+// Validate confere os campos da regra, reunindo todas as violações
+func (r FeeRule) Validate() error {
+	errs := NewValidationError()
+	if err := r.PaymentType.IsValid(); err != nil {
+		errs.Add("payment_type", err.Error())
+	}
+	if r.FixedAmount < 0 {
+		errs.Add("fixed_amount", "must not be negative")
+	}
+	if r.Percentage < 0 || r.Percentage > 100 {
+		errs.Add("percentage", "must be between 0 and 100")
+	}
+	if r.MinFee < 0 {
+		errs.Add("min_fee", "must not be negative")
+	}
+	if r.MaxFee < 0 || r.MaxFee > 0 && r.MaxFee < r.MinFee {
+		errs.Add("max_fee", "must not be negative nor lower than min_fee")
+	}
+	if r.EffectiveFrom.IsZero() {
+		errs.Add("effective_from", "is required")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// InEffect indica se a regra já vale em at
+func (r FeeRule) InEffect(at time.Time) bool {
+	return !r.EffectiveFrom.After(at)
+}
+
+// Calculate devolve a tarifa sobre gross, arredondada ao centavo e limitada por
+// MinFee/MaxFee. A tarifa nunca passa do próprio valor bruto.
+func (r FeeRule) Calculate(gross float64) float64 {
+	fee := r.FixedAmount + gross*r.Percentage/100
+	if r.MinFee > 0 && fee < r.MinFee {
+		fee = r.MinFee
+	}
+	if r.MaxFee > 0 && fee > r.MaxFee {
+		fee = r.MaxFee
+	}
+	fee = math.Min(fee, gross)
+	return fromCents(toCents(fee))
+}
+
+// EffectiveFeeRule escolhe, entre as versões do tipo de pagamento, a mais recente em vigor em at
+func EffectiveFeeRule(rules []FeeRule, paymentType PaymentType, at time.Time) (FeeRule, bool) {
+	candidates := make([]FeeRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.PaymentType == paymentType && rule.InEffect(at) {
+			candidates = append(candidates, rule)
+		}
+	}
+	if len(candidates) == 0 {
+		return FeeRule{}, false
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].EffectiveFrom.After(candidates[j].EffectiveFrom) })
+	return candidates[0], true
+}
+
+// ApplyFee registra o valor bruto, a tarifa e o líquido do pagamento segundo a
+// regra. Os valores ficam na moeda de liquidação, a mesma da tarifa fixa e dos
+// limites da regra, convertidos pela cotação registrada no pagamento.
+func (p *Payment) ApplyFee(rule FeeRule) {
+	gross := p.SettlementValue(p.SettledAmount())
+	p.GrossAmount = gross
+	p.FeeAmount = rule.Calculate(gross)
+	p.NetAmount = fromCents(toCents(gross) - toCents(p.FeeAmount))
+	p.FeeRuleID = rule.ID.Hex()
+}
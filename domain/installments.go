@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limites padrão de parcelamento no cartão: até MaxInstallments parcelas de no mínimo MinInstallmentAmount
+const (
+	MaxInstallments      = 12
+	MinInstallmentAmount = 5.0
+)
+
+// InstallmentPolicy são os limites de parcelamento de um tipo de pagamento
+type InstallmentPolicy struct {
+	MaxInstallments      int
+	MinInstallmentAmount float64
+	// InterestFreeInstallments é o maior número de parcelas sem juros; acima
+	// dele as parcelas são calculadas pela tabela Price com MonthlyInterestRate
+	InterestFreeInstallments int
+	MonthlyInterestRate      float64 // Percentual ao mês
+}
+
+// InstallmentPolicies associa cada tipo de pagamento à sua política. Tipos
+// ausentes só aceitam pagamento à vista.
+type InstallmentPolicies map[PaymentType]InstallmentPolicy
+
+// DefaultInstallmentPolicies parcela apenas no cartão, em até 12 vezes sem juros
+func DefaultInstallmentPolicies() InstallmentPolicies {
+	return InstallmentPolicies{
+		CreditCard: {
+			MaxInstallments:          MaxInstallments,
+			MinInstallmentAmount:     MinInstallmentAmount,
+			InterestFreeInstallments: MaxInstallments,
+		},
+	}
+}
+
+// MaxInstallmentsFor devolve o número máximo de parcelas permitido para o valor
+func (p InstallmentPolicy) MaxInstallmentsFor(amount float64) int {
+	n := p.MaxInstallments
+	if p.MinInstallmentAmount > 0 {
+		n = int(math.Floor(amount / p.MinInstallmentAmount))
+	}
+	if n > p.MaxInstallments {
+		return p.MaxInstallments
+	}
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// MaxInstallmentsFor aplica a política padrão do cartão
+func MaxInstallmentsFor(amount float64) int {
+	return DefaultInstallmentPolicies()[CreditCard].MaxInstallmentsFor(amount)
+}
+
+// Installment é uma parcela do plano
+type Installment struct {
+	Number  int       `json:"number" bson:"number"`
+	Amount  float64   `json:"amount" bson:"amount"`
+	DueDate time.Time `json:"due_date" bson:"due_date"`
+}
+
+// InstallmentPlan é o parcelamento calculado na criação do pagamento
+type InstallmentPlan struct {
+	Count               int     `json:"count" bson:"count"`
+	InterestFree        bool    `json:"interest_free" bson:"interest_free"`
+	MonthlyInterestRate float64 `json:"monthly_interest_rate,omitempty" bson:"monthly_interest_rate,omitempty"`
+	// TotalAmount é a soma das parcelas, incluindo os juros quando houver
+	TotalAmount float64       `json:"total_amount" bson:"total_amount"`
+	Schedule    []Installment `json:"schedule" bson:"schedule"`
+}
+
+// NewInstallmentPlan divide amount em count parcelas mensais, a primeira um mês
+// após start. Os centavos que sobram da divisão vão para as primeiras parcelas.
+func NewInstallmentPlan(amount float64, count int, policy InstallmentPolicy, start time.Time) InstallmentPlan {
+	plan := InstallmentPlan{Count: count, InterestFree: count <= policy.InterestFreeInstallments || policy.MonthlyInterestRate <= 0}
+
+	totalCents := int64(math.Round(amount * 100))
+	if !plan.InterestFree {
+		plan.MonthlyInterestRate = policy.MonthlyInterestRate
+		rate := policy.MonthlyInterestRate / 100
+		// Tabela Price: parcela fixa que amortiza o valor com juros compostos
+		payment := amount * rate / (1 - math.Pow(1+rate, -float64(count)))
+		totalCents = int64(math.Round(payment * float64(count) * 100))
+	}
+
+	base, remainder := totalCents/int64(count), totalCents%int64(count)
+	plan.Schedule = make([]Installment, count)
+	for i := range plan.Schedule {
+		cents := base
+		if int64(i) < remainder {
+			cents++
+		}
+		plan.Schedule[i] = Installment{
+			Number:  i + 1,
+			Amount:  float64(cents) / 100,
+			DueDate: start.AddDate(0, i+1, 0),
+		}
+	}
+	plan.TotalAmount = float64(totalCents) / 100
+	return plan
+}
+
+// ValidateInstallments confere o número de parcelas contra a política do tipo de pagamento
+func (p Payment) ValidateInstallments(policies InstallmentPolicies) error {
+	if p.Installments <= 1 {
+		return nil
+	}
+	policy, ok := policies[p.PaymentType]
+	if !ok {
+		return NewValidationError(ErrorResponse{Field: "installments", Message: fmt.Sprintf("is not accepted for %s payments", p.PaymentType)})
+	}
+	if limit := policy.MaxInstallmentsFor(p.Amount); p.Installments > limit {
+		return NewValidationError(ErrorResponse{Field: "installments", Message: fmt.Sprintf("must be at most %d for this amount", limit)})
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package domain
+
+// OrderPayments agrupa as tentativas de pagamento de um pedido, da mais recente
+// para a mais antiga, com o status efetivo do pedido do ponto de vista de pagamentos
+type OrderPayments struct {
+	OrderID string `json:"order_id"`
+	// Status é o do pagamento ativo, se houver, senão o da tentativa mais recente
+	Status          string    `json:"status"`
+	ActivePaymentID string    `json:"active_payment_id,omitempty"`
+	Attempts        int       `json:"attempts"`
+	Payments        []Payment `json:"payments"`
+}
+
+// NewOrderPayments resume as tentativas de um pedido; payments deve estar
+// ordenado da mais recente para a mais antiga, como devolve o repositório
+func NewOrderPayments(orderID string, payments []Payment) OrderPayments {
+	summary := OrderPayments{OrderID: orderID, Attempts: len(payments), Payments: payments}
+	if summary.Payments == nil {
+		summary.Payments = []Payment{}
+	}
+	for _, payment := range payments {
+		if payment.IsActive() {
+			summary.Status = payment.Status
+			summary.ActivePaymentID = payment.ID.Hex()
+			return summary
+		}
+	}
+	if len(payments) > 0 {
+		summary.Status = payments[0].Status
+	}
+	return summary
+}
@@ -1,12 +1,62 @@
 package domain
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Payment struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	OrderId     string             `json:"order_id" bson:"order_id"`
-	Amount      float64            `json:"amount" bson:"amount"`
-	Method      string             `json:"method" bson:"method"`
-	Status      string             `json:"status" bson:"status"`
-	PaymentType PaymentType        `json:"payment_type" bson:"payment_type"`
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderId string             `json:"order_id" bson:"order_id"`
+	Amount  float64            `json:"amount" bson:"amount"`
+	// Currency é o código ISO 4217 do valor; vazio equivale a SettlementCurrency
+	Currency    string      `json:"currency,omitempty" bson:"currency,omitempty"`
+	Method      string      `json:"method" bson:"method"`
+	Status      string      `json:"status" bson:"status"`
+	PaymentType PaymentType `json:"payment_type" bson:"payment_type"`
+	// Card só é preenchido em pagamentos CREDIT_CARD e nunca contém o PAN
+	Card         *Card `json:"card,omitempty" bson:"card,omitempty"`
+	Installments int   `json:"installments,omitempty" bson:"installments,omitempty"`
+	// InstallmentPlan traz o cronograma das parcelas quando Installments > 1
+	InstallmentPlan *InstallmentPlan `json:"installment_plan,omitempty" bson:"installment_plan,omitempty"`
+	// Boleto é gerado na criação de pagamentos BOLETO
+	Boleto *BoletoDetails `json:"boleto,omitempty" bson:"boleto,omitempty"`
+	// Splits divide o pagamento entre recebedores; as partes somam Amount
+	Splits []Split `json:"splits,omitempty" bson:"splits,omitempty"`
+	// FXRate e SettlementAmount registram a conversão de pagamentos em moeda estrangeira
+	FXRate           *FXRate `json:"fx_rate,omitempty" bson:"fx_rate,omitempty"`
+	SettlementAmount float64 `json:"settlement_amount,omitempty" bson:"settlement_amount,omitempty"`
+	// CaptureMethod CaptureManual cria o pagamento apenas autorizado (ver Capture)
+	CaptureMethod  string  `json:"capture_method,omitempty" bson:"capture_method,omitempty"`
+	CapturedAmount float64 `json:"captured_amount,omitempty" bson:"captured_amount,omitempty"`
+	RefundedAmount float64 `json:"refunded_amount,omitempty" bson:"refunded_amount,omitempty"`
+	// GrossAmount, FeeAmount e NetAmount são registrados na aprovação, na moeda de liquidação (ver ApplyFee)
+	GrossAmount float64 `json:"gross_amount,omitempty" bson:"gross_amount,omitempty"`
+	FeeAmount   float64 `json:"fee_amount,omitempty" bson:"fee_amount,omitempty"`
+	NetAmount   float64 `json:"net_amount,omitempty" bson:"net_amount,omitempty"`
+	FeeRuleID   string  `json:"fee_rule_id,omitempty" bson:"fee_rule_id,omitempty"`
+	// RefundRequired marca pagamentos aprovados pelo gateway depois de cancelados
+	RefundRequired bool `json:"refund_required" bson:"refund_required"`
+	// CreatedAt é definido pelo repositório na criação e nunca sobrescrito por updates
+	CreatedAt time.Time `json:"created_at" bson:"created_at,omitempty"`
+}
+
+// PaymentUpdate são as alterações aceitas em um pagamento existente; os
+// demais campos só mudam pelas operações do pagamento
+type PaymentUpdate struct {
+	Method string
+}
+
+// LogValue limita os campos do pagamento que aparecem nos logs estruturados
+func (p Payment) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", p.ID.Hex()),
+		slog.String("order_id", p.OrderId),
+		slog.Float64("amount", p.Amount),
+		slog.String("currency", p.CurrencyCode()),
+		slog.String("status", p.Status),
+		slog.String("payment_type", string(p.PaymentType)),
+	)
 }
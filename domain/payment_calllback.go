@@ -1,5 +1,7 @@
 package domain
 
+import "strings"
+
 type PaymentCallback struct {
 	PaymentID string  `json:"id"`
 	Status    string  `json:"status"` // Pode ser 'success', 'failed', etc.
@@ -7,3 +9,16 @@ type PaymentCallback struct {
 	Message   string  `json:"message"`
 	OrderId   string  `json:"order_id"`
 }
+
+// approvalStatuses são os status com que o gateway confirma a aprovação
+var approvalStatuses = map[string]bool{
+	"success":   true,
+	"approved":  true,
+	"completed": true,
+	"aprovado":  true,
+}
+
+// IsApproval indica se o callback confirma a aprovação do pagamento
+func (c PaymentCallback) IsApproval() bool {
+	return approvalStatuses[strings.ToLower(c.Status)]
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "fmt"
+
+// CaptureManual separa a autorização da captura; vazio significa captura imediata
+const CaptureManual = "manual"
+
+// Capture captura amount da autorização; zero captura o valor total.
+// O restante de uma captura parcial é liberado.
+func (p *Payment) Capture(amount float64) error {
+	if p.Status != StatusAuthorized {
+		return fmt.Errorf("payment %s is %q and cannot be captured: %w", p.ID.Hex(), p.Status, ErrConflict)
+	}
+	if amount == 0 {
+		amount = p.Amount
+	}
+	if amount > p.Amount {
+		return NewValidationError(ErrorResponse{Field: "amount", Message: "must not exceed the authorized amount"})
+	}
+	p.CapturedAmount = amount
+	p.Status = StatusCaptured
+	return nil
+}
+
+// Void libera a autorização sem capturar
+func (p *Payment) Void() error {
+	if p.Status != StatusAuthorized {
+		return fmt.Errorf("payment %s is %q and cannot be voided: %w", p.ID.Hex(), p.Status, ErrConflict)
+	}
+	p.Status = StatusVoided
+	return nil
+}
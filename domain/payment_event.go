@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// EventPaymentSnapshot abre um stream com o status atual do pagamento. Não é
+// uma mudança de status e não pode ser assinado por webhook.
+const EventPaymentSnapshot = "payment.snapshot"
+
+// PaymentEvent é uma mudança no ciclo de vida de um pagamento entregue aos
+// assinantes em tempo real. O ID é atribuído por quem publica o evento.
+type PaymentEvent struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	PaymentID   string      `json:"payment_id"`
+	OrderID     string      `json:"order_id,omitempty"`
+	Status      string      `json:"status"`
+	PaymentType PaymentType `json:"payment_type"`
+	Amount      float64     `json:"amount"`
+	Currency    string      `json:"currency"`
+	OccurredAt  time.Time   `json:"occurred_at"`
+}
+
+// NewPaymentEvent descreve o estado do pagamento depois da mudança eventType
+func NewPaymentEvent(eventType string, payment Payment, at time.Time) PaymentEvent {
+	return PaymentEvent{
+		Type:        eventType,
+		PaymentID:   payment.ID.Hex(),
+		OrderID:     payment.OrderId,
+		Status:      payment.Status,
+		PaymentType: payment.PaymentType,
+		Amount:      payment.Amount,
+		Currency:    payment.CurrencyCode(),
+		OccurredAt:  at,
+	}
+}
+
+// Terminal indica que o pagamento saiu do andamento (ver Payment.IsActive): o
+// checkout não tem mais o que esperar
+func (e PaymentEvent) Terminal() bool {
+	return !Payment{Status: e.Status}.IsActive()
+}
+
+// PaymentEventFilter seleciona eventos por status, tipo de pagamento e prefixo
+// do pedido. Um critério vazio aceita qualquer valor.
+type PaymentEventFilter struct {
+	Statuses      []string      `json:"status,omitempty"`
+	PaymentTypes  []PaymentType `json:"payment_type,omitempty"`
+	OrderIDPrefix string        `json:"order_id_prefix,omitempty"`
+}
+
+// Matches indica se o evento atende a todos os critérios do filtro
+func (f PaymentEventFilter) Matches(event PaymentEvent) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, event.Status) {
+		return false
+	}
+	if len(f.PaymentTypes) > 0 && !slices.Contains(f.PaymentTypes, event.PaymentType) {
+		return false
+	}
+	return strings.HasPrefix(event.OrderID, f.OrderIDPrefix)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Refundable indica se o pagamento foi liquidado e ainda tem saldo a estornar
+func (p Payment) Refundable() bool {
+	settled := p.Status == StatusCaptured || approvalStatuses[strings.ToLower(p.Status)]
+	return settled && p.RefundedAmount < p.SettledAmount()
+}
+
+// SettledAmount é o valor efetivamente cobrado: o capturado ou, na captura imediata, o total
+func (p Payment) SettledAmount() float64 {
+	if p.CapturedAmount > 0 {
+		return p.CapturedAmount
+	}
+	return p.Amount
+}
+
+// Refund estorna amount (zero estorna todo o saldo) e devolve o valor estornado
+// e a parte revertida de cada recebedor quando o pagamento é dividido. O estorno
+// integral encerra o pagamento como StatusRefunded.
+func (p *Payment) Refund(amount float64) (float64, []SplitReversal, error) {
+	if !p.Refundable() {
+		return 0, nil, fmt.Errorf("payment %s is %q and cannot be refunded: %w", p.ID.Hex(), p.Status, ErrConflict)
+	}
+	balance := toCents(p.SettledAmount()) - toCents(p.RefundedAmount)
+	cents := toCents(amount)
+	if amount == 0 {
+		cents = balance
+	}
+	if cents < 0 {
+		return 0, nil, NewValidationError(ErrorResponse{Field: "amount", Message: "must be greater than 0"})
+	}
+	if cents > balance {
+		return 0, nil, NewValidationError(ErrorResponse{Field: "amount", Message: "must not exceed the refundable balance"})
+	}
+
+	reversals := refundSplits(p.Splits, cents)
+	p.RefundedAmount = fromCents(toCents(p.RefundedAmount) + cents)
+	if cents == balance {
+		p.Status = StatusRefunded
+	}
+	return fromCents(cents), reversals, nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// StatusProcessing é o status atribuído a todo pagamento recém-criado
+	StatusProcessing = "Em processamento"
+	// StatusCancelled é o status de um pagamento cancelado antes da liquidação
+	StatusCancelled = "Cancelado"
+	// StatusAuthorized indica valor reservado aguardando captura (CaptureManual)
+	StatusAuthorized = "Autorizado"
+	// StatusCaptured indica autorização capturada, total ou parcialmente
+	StatusCaptured = "Capturado"
+	// StatusVoided indica autorização liberada sem captura
+	StatusVoided = "Anulado"
+	// StatusExpired indica boleto não pago dentro do prazo
+	StatusExpired = "Vencido"
+	// StatusRefunded indica pagamento estornado integralmente
+	StatusRefunded = "Estornado"
+	// StatusChargedBack indica disputa perdida: o valor contestado voltou ao cliente
+	StatusChargedBack = "Chargeback"
+)
+
+// InitialStatus é o status com que o repositório grava um pagamento novo
+func (p Payment) InitialStatus() string {
+	if p.CaptureMethod == CaptureManual {
+		return StatusAuthorized
+	}
+	return StatusProcessing
+}
+
+// IsCancellable indica se o pagamento ainda pode ser cancelado; pagamentos
+// liquidados (aprovados, recusados etc.) não podem
+func (p Payment) IsCancellable() bool {
+	return p.IsActive()
+}
+
+// IsActive indica se o pagamento ainda está em andamento. Um pedido só pode ter
+// um pagamento ativo por vez.
+func (p Payment) IsActive() bool {
+	return p.Status == StatusProcessing || p.Status == StatusAuthorized
+}
+
+// IsClosed indica se o pagamento já foi encerrado: cancelado, anulado, vencido,
+// aprovado, capturado, estornado ou perdido em disputa. Callbacks tardios do
+// gateway não alteram mais o seu status.
+func (p Payment) IsClosed() bool {
+	switch p.Status {
+	case StatusCancelled, StatusVoided, StatusExpired:
+		return true
+	}
+	return p.WasCharged()
+}
+
+// WasCharged indica se o valor chegou a ser cobrado do cliente, ainda que
+// depois tenha sido estornado ou contestado
+func (p Payment) WasCharged() bool {
+	switch p.Status {
+	case StatusCaptured, StatusRefunded, StatusChargedBack:
+		return true
+	}
+	return approvalStatuses[strings.ToLower(p.Status)]
+}
+
+// Expire encerra um pagamento ainda em aberto cujo prazo acabou
+func (p *Payment) Expire() error {
+	if !p.IsActive() {
+		return fmt.Errorf("payment %s is %q and cannot expire: %w", p.ID.Hex(), p.Status, ErrConflict)
+	}
+	p.Status = StatusExpired
+	return nil
+}
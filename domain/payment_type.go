@@ -3,16 +3,24 @@ package domain
 type PaymentType string
 
 const (
-	Pix    PaymentType = "PIX"
-	QRCode PaymentType = "QR_CODE"
+	Pix        PaymentType = "PIX"
+	QRCode     PaymentType = "QR_CODE"
+	CreditCard PaymentType = "CREDIT_CARD"
+	Boleto     PaymentType = "BOLETO"
 )
 
 func (p PaymentType) IsValid() error {
 	switch p {
-	case Pix, QRCode:
+	case Pix, QRCode, CreditCard, Boleto:
 		return nil
 	default:
 		return &InvalidPaymentTypeError{Type: string(p)} // Retorna um erro customizado
 	}
 
 }
+
+// SupportsManualCapture indica se o tipo permite autorizar e capturar depois;
+// PIX e QR Code são liquidados na hora
+func (p PaymentType) SupportsManualCapture() bool {
+	return p == CreditCard
+}
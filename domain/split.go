@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Formas de definir a parte de cada recebedor
+const (
+	SplitFixed = "fixed" // Value em reais
+	// SplitPercentage tem Value em percentual, com até duas casas, do que resta
+	// do total depois das partes fixas; os percentuais somam sempre 100
+	SplitPercentage = "percentage"
+)
+
+// MaxSplits limita o número de recebedores de um pagamento
+const MaxSplits = 20
+
+// Split é a parte de um recebedor (vendedor ou plataforma) em um pagamento
+type Split struct {
+	RecipientID string  `json:"recipient_id" bson:"recipient_id"`
+	Type        string  `json:"type" bson:"type"`
+	Value       float64 `json:"value" bson:"value"`
+	// Amount é a parte calculada em reais, com o arredondamento já distribuído
+	Amount float64 `json:"amount" bson:"amount"`
+	// ChargeProcessingFee indica que o recebedor paga as taxas do processamento
+	ChargeProcessingFee bool `json:"charge_processing_fee" bson:"charge_processing_fee"`
+	// Liable indica que o recebedor absorve os chargebacks
+	Liable         bool    `json:"liable" bson:"liable"`
+	RefundedAmount float64 `json:"refunded_amount,omitempty" bson:"refunded_amount,omitempty"`
+}
+
+// SplitReversal é a parte de um estorno devolvida por um recebedor
+type SplitReversal struct {
+	RecipientID string  `json:"recipient_id"`
+	Amount      float64 `json:"amount"`
+}
+
+// ResolveSplits calcula a parte de cada recebedor. As partes fixas saem primeiro
+// e os percentuais dividem o restante: são arredondados para baixo e os centavos
+// que sobram vão para as maiores frações (empate: ordem da lista), de modo que
+// as partes somem exatamente o total.
+func (p *Payment) ResolveSplits() error {
+	if len(p.Splits) == 0 {
+		return nil
+	}
+	cents, errs := allocateSplits(toCents(p.Amount), p.Splits)
+	if errs != nil {
+		return errs
+	}
+	for i := range p.Splits {
+		p.Splits[i].Amount = fromCents(cents[i])
+	}
+	return nil
+}
+
+// validateSplits registra em errs as violações das regras de divisão
+func (p Payment) validateSplits(errs *ValidationError) {
+	if len(p.Splits) == 0 {
+		return
+	}
+	if _, splitErrs := allocateSplits(toCents(p.Amount), p.Splits); splitErrs != nil {
+		errs.Errors = append(errs.Errors, splitErrs.Errors...)
+	}
+}
+
+// allocateSplits devolve a parte em centavos de cada split, na ordem recebida,
+// ou as violações encontradas
+func allocateSplits(totalCents int64, splits []Split) ([]int64, *ValidationError) {
+	errs := NewValidationError()
+	if len(splits) > MaxSplits {
+		errs.Add("splits", fmt.Sprintf("must have at most %d recipients", MaxSplits))
+		return nil, errs
+	}
+
+	seen := make(map[string]bool, len(splits))
+	var payer, liable, percentages bool
+	// Percentuais com duas casas decimais são somados em pontos-base (100% = 10000)
+	var fixedCents, totalBasisPoints int64
+	basisPoints := make([]int64, len(splits))
+	cents := make([]int64, len(splits))
+	for i, split := range splits {
+		field := fmt.Sprintf("splits[%d]", i)
+		switch {
+		case split.RecipientID == "":
+			errs.Add(field+".recipient_id", "is required")
+		case seen[split.RecipientID]:
+			errs.Add(field+".recipient_id", "must not repeat a recipient")
+		}
+		seen[split.RecipientID] = true
+		payer = payer || split.ChargeProcessingFee
+		liable = liable || split.Liable
+
+		if split.Value <= 0 || !hasAtMostTwoDecimals(split.Value) {
+			errs.Add(field+".value", "must be greater than 0 with at most 2 decimal places")
+			continue
+		}
+		switch split.Type {
+		case SplitFixed:
+			cents[i] = toCents(split.Value)
+			fixedCents += cents[i]
+		case SplitPercentage:
+			percentages = true
+			if split.Value > 100 {
+				errs.Add(field+".value", "must be at most 100")
+				continue
+			}
+			basisPoints[i] = toCents(split.Value)
+			totalBasisPoints += basisPoints[i]
+		default:
+			errs.Add(field+".type", "must be one of: fixed, percentage")
+		}
+	}
+	if !payer {
+		errs.Add("splits", "must have a recipient that pays the processing fees")
+	}
+	if !liable {
+		errs.Add("splits", "must have a recipient liable for chargebacks")
+	}
+	if errs.HasErrors() {
+		return nil, errs
+	}
+	// Comparar os percentuais com o restante, e não com o total, deixa qualquer
+	// combinação ser exata: 50.00 fixos de 100.01 deixam 50.01 para os 100%
+	remainingCents := totalCents - fixedCents
+	switch {
+	case !percentages && remainingCents != 0:
+		errs.Add("splits", "must add up exactly to the payment amount")
+	case percentages && remainingCents <= 0:
+		errs.Add("splits", "fixed splits must leave part of the payment amount to the percentage splits")
+	case percentages && totalBasisPoints != 10000:
+		errs.Add("splits", "percentage splits must add up to 100% of the amount left after the fixed splits")
+	}
+	if errs.HasErrors() {
+		return nil, errs
+	}
+
+	// Percentuais: parte inteira primeiro, depois um centavo por maior fração
+	type fraction struct {
+		index     int
+		remainder int64
+	}
+	var fractions []fraction
+	allocated := int64(0)
+	for i := range splits {
+		if basisPoints[i] > 0 {
+			share := remainingCents * basisPoints[i]
+			cents[i] = share / 10000
+			fractions = append(fractions, fraction{index: i, remainder: share % 10000})
+		}
+		allocated += cents[i]
+	}
+	sort.SliceStable(fractions, func(a, b int) bool { return fractions[a].remainder > fractions[b].remainder })
+	for k := int64(0); k < totalCents-allocated; k++ {
+		cents[fractions[k].index]++
+	}
+	return cents, nil
+}
+
+// refundSplits reparte o estorno entre os recebedores na proporção do que cada
+// um ainda tem a receber, com a mesma regra de arredondamento das partes
+func refundSplits(splits []Split, amountCents int64) []SplitReversal {
+	remaining := make([]int64, len(splits))
+	var totalRemaining int64
+	for i, split := range splits {
+		remaining[i] = toCents(split.Amount) - toCents(split.RefundedAmount)
+		totalRemaining += remaining[i]
+	}
+	if totalRemaining <= 0 {
+		return nil
+	}
+
+	shares := make([]int64, len(splits))
+	remainders := make([]int64, len(splits))
+	order := make([]int, len(splits))
+	allocated := int64(0)
+	for i := range splits {
+		exact := amountCents * remaining[i]
+		shares[i] = exact / totalRemaining
+		remainders[i] = exact % totalRemaining
+		order[i] = i
+		allocated += shares[i]
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for k := int64(0); k < amountCents-allocated; k++ {
+		shares[order[k]]++
+	}
+
+	reversals := make([]SplitReversal, 0, len(splits))
+	for i := range splits {
+		splits[i].RefundedAmount = fromCents(toCents(splits[i].RefundedAmount) + shares[i])
+		reversals = append(reversals, SplitReversal{RecipientID: splits[i].RecipientID, Amount: fromCents(shares[i])})
+	}
+	return reversals
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+func hasAtMostTwoDecimals(value float64) bool {
+	cents := value * 100
+	return math.Abs(cents-math.Round(cents)) < 1e-6
+}
@@ -0,0 +1,289 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Eventos do ciclo de vida dos pagamentos enviados aos webhooks dos lojistas
+const (
+	EventPaymentCreated     = "payment.created"
+	EventPaymentApproved    = "payment.approved"
+	EventPaymentFailed      = "payment.failed"
+	EventPaymentUpdated     = "payment.updated" // Callback com status intermediário, ex.: pending
+	EventPaymentCaptured    = "payment.captured"
+	EventPaymentCancelled   = "payment.cancelled"
+	EventPaymentVoided      = "payment.voided"
+	EventPaymentExpired     = "payment.expired"
+	EventPaymentRefunded    = "payment.refunded"
+	EventPaymentChargedBack = "payment.charged_back"
+	// EventWebhookTest é enviado apenas por POST /webhooks/:id/test e não pode ser assinado
+	EventWebhookTest = "webhook.test"
+	// EventAll assina todos os eventos de pagamento
+	EventAll = "*"
+)
+
+var webhookEventTypes = []string{
+	EventPaymentApproved, EventPaymentCancelled, EventPaymentCaptured, EventPaymentChargedBack, EventPaymentCreated,
+	EventPaymentExpired, EventPaymentFailed, EventPaymentRefunded, EventPaymentUpdated, EventPaymentVoided,
+}
+
+// failureStatuses são os status com que o gateway informa a recusa do pagamento
+var failureStatuses = map[string]bool{
+	"failed":   true,
+	"rejected": true,
+	"declined": true,
+	"recusado": true,
+}
+
+// WebhookEventTypes lista, em ordem alfabética, os eventos que podem ser assinados
+func WebhookEventTypes() []string {
+	return slices.Clone(webhookEventTypes)
+}
+
+// CallbackEvent é o evento correspondente ao status recebido no callback do gateway
+func CallbackEvent(status string) string {
+	switch {
+	case approvalStatuses[strings.ToLower(status)]:
+		return EventPaymentApproved
+	case failureStatuses[strings.ToLower(status)]:
+		return EventPaymentFailed
+	default:
+		return EventPaymentUpdated
+	}
+}
+
+// Situações de uma entrega de webhook
+const (
+	DeliveryPending   = "pending" // Aguardando a primeira tentativa ou uma nova tentativa
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed" // Tentativas esgotadas; só a reentrega manual tenta de novo
+)
+
+// WebhookSubscription é um endpoint do lojista inscrito em eventos de pagamento
+type WebhookSubscription struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	URL         string             `json:"url" bson:"url"`
+	Events      []string           `json:"events" bson:"events"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Active      bool               `json:"active" bson:"active"`
+	// Secret assina as entregas com HMAC-SHA256; só é exibido na criação
+	Secret    string    `json:"secret,omitempty" bson:"secret"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate confere a URL e os eventos da inscrição, reunindo todas as violações
+func (s WebhookSubscription) Validate() error {
+	errs := NewValidationError()
+	if target, err := url.Parse(s.URL); err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		errs.Add("url", "must be an absolute http or https URL")
+	} else if !isPublicHost(target.Hostname()) {
+		errs.Add("url", "must point to a public host")
+	}
+	if len(s.Events) == 0 {
+		errs.Add("events", "is required")
+	}
+	for i, event := range s.Events {
+		if event != EventAll && !slices.Contains(webhookEventTypes, event) {
+			errs.Add(fmt.Sprintf("events[%d]", i), "must be one of: "+strings.Join(append([]string{EventAll}, webhookEventTypes...), ", "))
+		}
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// isPublicHost recusa localhost e IPs literais fora da internet. Nomes que
+// resolvem para endereços internos são barrados no envio (ver IsPublicIP).
+func isPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// cgnat é a faixa compartilhada dos provedores (RFC 6598), não roteável na internet
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP indica se ip pode receber webhooks: loopback, redes privadas,
+// link-local (como o metadata da nuvem, 169.254.169.254), multicast e
+// endereços não especificados ficam de fora para evitar SSRF
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnat.Contains(ip))
+}
+
+// Matches indica se a inscrição ativa deve receber eventType
+func (s WebhookSubscription) Matches(eventType string) bool {
+	if !s.Active {
+		return false
+	}
+	return slices.Contains(s.Events, EventAll) || slices.Contains(s.Events, eventType)
+}
+
+// Redacted devolve a inscrição sem o segredo, para as respostas de consulta
+func (s WebhookSubscription) Redacted() WebhookSubscription {
+	s.Secret = ""
+	return s
+}
+
+// WebhookDataVersion é a versão do formato de WebhookPaymentData; uma mudança
+// incompatível cria uma nova versão, enviada em WebhookEvent.Version
+const WebhookDataVersion = "v1"
+
+// WebhookEvent é o corpo JSON enviado ao endpoint do lojista
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookPaymentData é o corpo (versão v1) dos eventos de pagamento enviados
+// aos lojistas. É um contrato público: traz apenas o que o lojista pode ver
+// (nada do cartão, das tarifas, dos recebedores ou da conversão de moeda) e
+// campos só podem ser acrescentados.
+type WebhookPaymentData struct {
+	ID             string      `json:"id"`
+	OrderID        string      `json:"order_id"`
+	Amount         float64     `json:"amount"`
+	Currency       string      `json:"currency"`
+	Status         string      `json:"status"`
+	PaymentType    PaymentType `json:"payment_type"`
+	Method         string      `json:"method,omitempty"`
+	Installments   int         `json:"installments,omitempty"`
+	CapturedAmount float64     `json:"captured_amount,omitempty"`
+	RefundedAmount float64     `json:"refunded_amount,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// NewWebhookPaymentData extrai do pagamento os campos públicos do webhook
+func NewWebhookPaymentData(p Payment) WebhookPaymentData {
+	return WebhookPaymentData{
+		ID:             p.ID.Hex(),
+		OrderID:        p.OrderId,
+		Amount:         p.Amount,
+		Currency:       p.CurrencyCode(),
+		Status:         p.Status,
+		PaymentType:    p.PaymentType,
+		Method:         p.Method,
+		Installments:   p.Installments,
+		CapturedAmount: p.CapturedAmount,
+		RefundedAmount: p.RefundedAmount,
+		CreatedAt:      p.CreatedAt,
+	}
+}
+
+// WebhookDelivery registra o envio de um evento a uma inscrição e todas as suas tentativas
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SubscriptionID string             `json:"subscription_id" bson:"subscription_id"`
+	EventID        string             `json:"event_id" bson:"event_id"`
+	EventType      string             `json:"event_type" bson:"event_type"`
+	// Payload é o corpo enviado, preservado para que a reentrega seja idêntica
+	Payload  string           `json:"payload" bson:"payload"`
+	Status   string           `json:"status" bson:"status"`
+	Attempts []WebhookAttempt `json:"attempts" bson:"attempts"`
+	// NextAttemptAt é quando o job tentará de novo; vazio fora de DeliveryPending
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// WebhookAttempt é o log de uma tentativa de entrega
+type WebhookAttempt struct {
+	Number      int       `json:"number" bson:"number"`
+	AttemptedAt time.Time `json:"attempted_at" bson:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	// ResponseBody guarda o início da resposta, limitado a MaxWebhookResponseLog bytes
+	ResponseBody string `json:"response_body,omitempty" bson:"response_body,omitempty"`
+	Error        string `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs   int64  `json:"duration_ms" bson:"duration_ms"`
+}
+
+// MaxWebhookResponseLog limita o trecho da resposta guardado em cada tentativa
+const MaxWebhookResponseLog = 1024
+
+// Succeeded indica se o endpoint aceitou a entrega com um status 2xx
+func (a WebhookAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// WebhookRetryPolicy define as novas tentativas, com espera exponencial entre elas
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultWebhookRetryPolicy tenta por cerca de um dia antes de desistir
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{MaxAttempts: 10, BaseDelay: 30 * time.Second, MaxDelay: 6 * time.Hour}
+
+// Backoff é a espera depois da tentativa attempt (a partir de 1): BaseDelay dobrado a cada falha, até MaxDelay
+func (p WebhookRetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// NewWebhookDelivery cria a entrega pendente de um evento já serializado em payload
+func NewWebhookDelivery(subscriptionID string, event WebhookEvent, payload []byte, now time.Time) WebhookDelivery {
+	return WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         DeliveryPending,
+		Attempts:       []WebhookAttempt{},
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// NextAttemptNumber é o número da próxima tentativa
+func (d WebhookDelivery) NextAttemptNumber() int {
+	return len(d.Attempts) + 1
+}
+
+// Record registra a tentativa e decide o próximo passo: entregue, nova tentativa
+// após o backoff ou falha definitiva quando as tentativas se esgotam
+func (d *WebhookDelivery) Record(attempt WebhookAttempt, policy WebhookRetryPolicy) {
+	attempt.Number = d.NextAttemptNumber()
+	d.Attempts = append(d.Attempts, attempt)
+	d.UpdatedAt = attempt.AttemptedAt
+	d.NextAttemptAt = nil
+	switch {
+	case attempt.Succeeded():
+		d.Status = DeliverySucceeded
+	case len(d.Attempts) >= policy.MaxAttempts:
+		d.Status = DeliveryFailed
+	default:
+		d.Status = DeliveryPending
+		next := attempt.AttemptedAt.Add(policy.Backoff(len(d.Attempts)))
+		d.NextAttemptAt = &next
+	}
+}
+
+// Abandon encerra sem envio a entrega cuja inscrição foi removida ou desativada
+func (d *WebhookDelivery) Abandon(reason string, now time.Time) {
+	d.Attempts = append(d.Attempts, WebhookAttempt{Number: d.NextAttemptNumber(), AttemptedAt: now, Error: reason})
+	d.Status = DeliveryFailed
+	d.NextAttemptAt = nil
+	d.UpdatedAt = now
+}
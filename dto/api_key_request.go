@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"errors"
+
+	"payments/domain"
+)
+
+// apiKeyServerFields são preenchidos pelo servidor nas chaves de API
+var apiKeyServerFields = []string{"id", "_id", "prefix", "hash", "key", "created_at", "revoked_at"}
+
+// APIKeyRequest é o corpo aceito por POST /admin/api-keys
+type APIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required"`
+}
+
+// DecodeAPIKeyRequest lê e valida o corpo da emissão de uma chave, reunindo as
+// violações das tags e os escopos desconhecidos
+func DecodeAPIKeyRequest(body []byte) (APIKeyRequest, error) {
+	var req APIKeyRequest
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, apiKeyServerFields, errs); err != nil {
+		return req, err
+	}
+	validateStruct(req, errs)
+	var scopeErrs *domain.ValidationError
+	if len(req.Scopes) > 0 && errors.As(domain.ValidateScopes(req.Scopes), &scopeErrs) {
+		mergeErrors(errs, scopeErrs)
+	}
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
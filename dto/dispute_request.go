@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"time"
+
+	"payments/domain"
+)
+
+// disputeServerFields são controlados pelo fluxo da disputa e não pelo cliente
+var disputeServerFields = []string{"id", "_id", "payment_id", "status", "created_at", "resolved_at"}
+
+// EvidenceRequest descreve um anexo já enviado ao armazenamento de arquivos
+type EvidenceRequest struct {
+	FileName    string `json:"file_name" validate:"required,max=255"`
+	ContentType string `json:"content_type" validate:"required,max=100"`
+	Size        int64  `json:"size" validate:"gt=0"`
+	URL         string `json:"url" validate:"required,url,max=2048"`
+}
+
+// OpenDisputeRequest é o corpo de POST /payments/:id/disputes
+type OpenDisputeRequest struct {
+	ReasonCode string `json:"reason_code" validate:"required,max=32"`
+	// Amount omitido contesta todo o saldo do pagamento
+	Amount        float64           `json:"amount" validate:"omitempty,gt=0,money"`
+	EvidenceDueBy time.Time         `json:"evidence_due_by"`
+	Evidence      []EvidenceRequest `json:"evidence" validate:"omitempty,max=20,dive"`
+}
+
+// UpdateDisputeRequest é o corpo de PATCH /disputes/:id; evidence é acrescentada aos anexos
+type UpdateDisputeRequest struct {
+	ReasonCode    string            `json:"reason_code" validate:"omitempty,max=32"`
+	EvidenceDueBy *time.Time        `json:"evidence_due_by"`
+	Evidence      []EvidenceRequest `json:"evidence" validate:"omitempty,max=20,dive"`
+}
+
+// ResolveDisputeRequest é o corpo de POST /disputes/:id/resolve
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome" validate:"required,oneof=won lost"`
+}
+
+// DecodeOpenDisputeRequest lê e valida o corpo da abertura de disputa
+func DecodeOpenDisputeRequest(body []byte) (OpenDisputeRequest, error) {
+	return decodeDisputeBody[OpenDisputeRequest](body)
+}
+
+// DecodeUpdateDisputeRequest lê e valida o corpo da atualização de disputa
+func DecodeUpdateDisputeRequest(body []byte) (UpdateDisputeRequest, error) {
+	return decodeDisputeBody[UpdateDisputeRequest](body)
+}
+
+// DecodeResolveDisputeRequest lê e valida o corpo da resolução de disputa
+func DecodeResolveDisputeRequest(body []byte) (ResolveDisputeRequest, error) {
+	return decodeDisputeBody[ResolveDisputeRequest](body)
+}
+
+func decodeDisputeBody[T any](body []byte) (T, error) {
+	var req T
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, disputeServerFields, errs); err != nil {
+		return req, err
+	}
+	validateStruct(req, errs)
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
+
+// ToDispute converte a requisição nos dados de abertura da disputa
+func (r OpenDisputeRequest) ToDispute() domain.Dispute {
+	return domain.Dispute{
+		ReasonCode:    r.ReasonCode,
+		Amount:        r.Amount,
+		EvidenceDueBy: r.EvidenceDueBy,
+		Evidence:      toEvidence(r.Evidence),
+	}
+}
+
+// ToUpdate converte a requisição nas alterações da disputa
+func (r UpdateDisputeRequest) ToUpdate() domain.DisputeUpdate {
+	return domain.DisputeUpdate{
+		ReasonCode:    r.ReasonCode,
+		EvidenceDueBy: r.EvidenceDueBy,
+		Evidence:      toEvidence(r.Evidence),
+	}
+}
+
+func toEvidence(evidence []EvidenceRequest) []domain.EvidenceAttachment {
+	var attachments []domain.EvidenceAttachment
+	for _, attachment := range evidence {
+		attachments = append(attachments, domain.EvidenceAttachment{
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			URL:         attachment.URL,
+		})
+	}
+	return attachments
+}
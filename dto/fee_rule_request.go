@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"payments/domain"
+)
+
+// feeRuleServerFields são preenchidos pelo servidor nas regras de tarifa
+var feeRuleServerFields = []string{"id", "_id", "created_at"}
+
+// FeeRuleRequest é o corpo aceito por POST e PUT /admin/fee-rules
+type FeeRuleRequest struct {
+	PaymentType domain.PaymentType `json:"payment_type" validate:"required,payment_type"`
+	FixedAmount float64            `json:"fixed_amount" validate:"gte=0,money"`
+	Percentage  float64            `json:"percentage" validate:"gte=0,lte=100"`
+	// MinFee e MaxFee são opcionais; zero significa sem limite
+	MinFee float64 `json:"min_fee" validate:"gte=0,money"`
+	MaxFee float64 `json:"max_fee" validate:"gte=0,money"`
+	// EffectiveFrom é o instante, em RFC 3339, a partir do qual a versão vale
+	EffectiveFrom time.Time `json:"effective_from" validate:"required"`
+}
+
+// DecodeFeeRuleRequest lê e valida o corpo de uma regra de tarifa, reunindo as
+// violações das tags e as regras de domínio (ex.: max_fee menor que min_fee)
+func DecodeFeeRuleRequest(body []byte) (FeeRuleRequest, error) {
+	var req FeeRuleRequest
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, feeRuleServerFields, errs); err != nil {
+		return req, err
+	}
+	validateStruct(req, errs)
+	var ruleErrs *domain.ValidationError
+	if errors.As(req.ToFeeRule().Validate(), &ruleErrs) {
+		mergeErrors(errs, ruleErrs)
+	}
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
+
+// ToFeeRule converte a requisição para a entidade de domínio
+func (r FeeRuleRequest) ToFeeRule() *domain.FeeRule {
+	return &domain.FeeRule{
+		PaymentType:   r.PaymentType,
+		FixedAmount:   r.FixedAmount,
+		Percentage:    r.Percentage,
+		MinFee:        r.MinFee,
+		MaxFee:        r.MaxFee,
+		EffectiveFrom: r.EffectiveFrom,
+	}
+}
@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"strings"
+
+	"payments/domain"
+)
+
+// FeedActionSubscribe substitui o filtro da conexão pelo enviado na mensagem
+const FeedActionSubscribe = "subscribe"
+
+// PaymentFeedFilter é o filtro aceito na query e nas mensagens de /ws/payments
+type PaymentFeedFilter struct {
+	Status        []string `json:"status" validate:"omitempty,max=20,dive,required,max=32"`
+	PaymentType   []string `json:"payment_type" validate:"omitempty,max=10,dive,payment_type"`
+	OrderIDPrefix string   `json:"order_id_prefix" validate:"max=64"`
+}
+
+// PaymentFeedMessage é uma mensagem enviada pelo cliente de /ws/payments
+type PaymentFeedMessage struct {
+	Action string            `json:"action" validate:"required,oneof=subscribe"`
+	Filter PaymentFeedFilter `json:"filter"`
+}
+
+// DecodePaymentFeedMessage lê e valida uma mensagem do cliente do feed
+func DecodePaymentFeedMessage(body []byte) (PaymentFeedMessage, error) {
+	var msg PaymentFeedMessage
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &msg, nil, errs); err != nil {
+		return msg, err
+	}
+	validateStruct(msg, errs)
+	if errs.HasErrors() {
+		return msg, errs
+	}
+	return msg, nil
+}
+
+// DecodePaymentFeedQuery monta o filtro inicial a partir da query; status e
+// payment_type aceitam vários valores separados por vírgula
+func DecodePaymentFeedQuery(status, paymentType, orderIDPrefix string) (PaymentFeedFilter, error) {
+	filter := PaymentFeedFilter{
+		Status:        splitList(status),
+		PaymentType:   splitList(paymentType),
+		OrderIDPrefix: orderIDPrefix,
+	}
+	errs := domain.NewValidationError()
+	validateStruct(filter, errs)
+	if errs.HasErrors() {
+		return filter, errs
+	}
+	return filter, nil
+}
+
+// ToFilter converte o filtro para o critério de domínio
+func (f PaymentFeedFilter) ToFilter() domain.PaymentEventFilter {
+	filter := domain.PaymentEventFilter{Statuses: f.Status, OrderIDPrefix: f.OrderIDPrefix}
+	for _, paymentType := range f.PaymentType {
+		filter.PaymentTypes = append(filter.PaymentTypes, domain.PaymentType(paymentType))
+	}
+	return filter
+}
+
+// splitList separa uma lista por vírgulas, descartando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
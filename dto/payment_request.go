@@ -0,0 +1,230 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"payments/domain"
+)
+
+// Métodos (canais de origem) aceitos na criação de pagamentos
+const (
+	MethodOnline  = "online"
+	MethodInStore = "in_store"
+	MethodApp     = "app"
+)
+
+// serverControlledFields não podem ser enviados pelo cliente: são calculados na
+// criação ou pelas operações do pagamento (captura, estorno, tarifas, câmbio)
+var serverControlledFields = []string{
+	"id", "_id", "status", "created_at",
+	"gross_amount", "fee_amount", "net_amount", "fee_rule_id",
+	"capture_method", "captured_amount", "refunded_amount", "refund_required",
+	"boleto", "fx_rate", "settlement_amount", "installment_plan",
+}
+
+// rawCardFields são dados de cartão em claro, que só o gateway pode receber
+var rawCardFields = []string{"number", "pan", "card_number", "cvv", "cvc", "security_code"}
+
+// CreatePaymentRequest é o corpo aceito por POST /payments
+type CreatePaymentRequest struct {
+	OrderID string  `json:"order_id" validate:"required,max=64"`
+	Amount  float64 `json:"amount" validate:"gt=0,money"`
+	// Currency é o código ISO 4217 do valor; sem ele o pagamento é em BRL
+	Currency    string             `json:"currency" validate:"omitempty,len=3"`
+	Method      string             `json:"method" validate:"required,oneof=online in_store app"`
+	PaymentType domain.PaymentType `json:"payment_type" validate:"required,payment_type"`
+	Card        *CardRequest       `json:"card"`
+	// Installments tem o máximo definido pela política de parcelamento do tipo de pagamento
+	Installments int `json:"installments" validate:"omitempty,gte=1"`
+	// Capture false apenas autoriza o valor, que é capturado depois em /payments/:id/capture
+	Capture *bool          `json:"capture"`
+	Splits  []SplitRequest `json:"splits" validate:"omitempty,max=20,dive"`
+	// DueDate é o vencimento do boleto (AAAA-MM-DD); sem ele vale o prazo padrão do emissor
+	DueDate string `json:"due_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// dueDateLayout é o formato de due_date
+const dueDateLayout = "2006-01-02"
+
+// DecodeCreatePaymentRequest lê e valida o corpo da requisição.
+// Todas as violações são retornadas juntas em um *domain.ValidationError;
+// qualquer outro erro indica um JSON malformado.
+func DecodeCreatePaymentRequest(body []byte) (CreatePaymentRequest, error) {
+	return decodeCreatePayment(body, CreatePaymentRequest{})
+}
+
+// DecodeOrderCreated lê o data de um evento order.created: o mesmo corpo de
+// POST /payments, com method online quando o pedido não informa o canal
+func DecodeOrderCreated(data []byte) (CreatePaymentRequest, error) {
+	return decodeCreatePayment(data, CreatePaymentRequest{Method: MethodOnline})
+}
+
+// decodeCreatePayment preenche req, que traz os valores padrão, a partir de body
+func decodeCreatePayment(body []byte, req CreatePaymentRequest) (CreatePaymentRequest, error) {
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, serverControlledFields, errs); err != nil {
+		return req, err
+	}
+	rejectRawCardData(body, errs)
+	validateStruct(req, errs)
+	rejectPastDueDate(req.DueDate, errs)
+	// Regras de domínio que dependem da combinação de campos (cartão, boleto, captura)
+	var detailErrs *domain.ValidationError
+	if errors.As(req.ToPayment().ValidateDetails(), &detailErrs) {
+		mergeErrors(errs, detailErrs)
+	}
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
+
+// CardRequest identifica um cartão já tokenizado no gateway
+type CardRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+	Brand string `json:"brand" validate:"required,oneof=visa mastercard amex elo hipercard"`
+	Last4 string `json:"last4" validate:"required,len=4,numeric"`
+}
+
+// SplitRequest é a parte de um recebedor; as partes devem somar o amount do pagamento
+type SplitRequest struct {
+	RecipientID         string  `json:"recipient_id" validate:"required,max=64"`
+	Type                string  `json:"type" validate:"required,oneof=fixed percentage"`
+	Value               float64 `json:"value" validate:"gt=0,money"`
+	ChargeProcessingFee bool    `json:"charge_processing_fee"`
+	Liable              bool    `json:"liable"`
+}
+
+// rejectRawCardData recusa PAN e CVV tanto na raiz quanto dentro de card
+func rejectRawCardData(body []byte, errs *domain.ValidationError) {
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(body, &raw) != nil {
+		return
+	}
+	var card map[string]json.RawMessage
+	_ = json.Unmarshal(raw["card"], &card)
+	for _, field := range rawCardFields {
+		if _, ok := raw[field]; ok {
+			errs.Add(field, "must not be sent; tokenize the card on the gateway")
+		}
+		if _, ok := card[field]; ok {
+			errs.Add("card."+field, "must not be sent; tokenize the card on the gateway")
+		}
+	}
+}
+
+// rejectPastDueDate recusa vencimentos anteriores à data atual
+func rejectPastDueDate(dueDate string, errs *domain.ValidationError) {
+	date, err := time.Parse(dueDateLayout, dueDate)
+	if err != nil {
+		return
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if date.Before(today) {
+		errs.Add("due_date", "must not be in the past")
+	}
+}
+
+// ToPayment converte a requisição na entidade de domínio
+func (r CreatePaymentRequest) ToPayment() *domain.Payment {
+	payment := &domain.Payment{
+		OrderId:      r.OrderID,
+		Amount:       r.Amount,
+		Currency:     r.Currency,
+		Method:       r.Method,
+		PaymentType:  r.PaymentType,
+		Installments: r.Installments,
+	}
+	if r.Card != nil {
+		payment.Card = &domain.Card{Token: r.Card.Token, Brand: r.Card.Brand, Last4: r.Card.Last4}
+	}
+	if r.Capture != nil && !*r.Capture {
+		payment.CaptureMethod = domain.CaptureManual
+	}
+	for _, split := range r.Splits {
+		payment.Splits = append(payment.Splits, domain.Split{
+			RecipientID:         split.RecipientID,
+			Type:                split.Type,
+			Value:               split.Value,
+			ChargeProcessingFee: split.ChargeProcessingFee,
+			Liable:              split.Liable,
+		})
+	}
+	// O restante do boleto é gerado na criação; aqui vai só o vencimento pedido
+	if dueDate, err := time.Parse(dueDateLayout, r.DueDate); err == nil {
+		payment.Boleto = &domain.BoletoDetails{DueDate: dueDate}
+	}
+	return payment
+}
+
+// UpdatePaymentRequest é o corpo de PUT /payments/:id. Só o canal de origem
+// pode ser corrigido: valores, status, cartão, tarifas e os demais campos mudam
+// apenas pelas operações do pagamento (captura, estorno, callback...).
+type UpdatePaymentRequest struct {
+	Method string `json:"method" validate:"required,oneof=online in_store app"`
+}
+
+// DecodeUpdatePaymentRequest lê e valida o corpo da atualização, recusando
+// os campos controlados pelo servidor e os que não podem ser alterados
+func DecodeUpdatePaymentRequest(body []byte) (UpdatePaymentRequest, error) {
+	var req UpdatePaymentRequest
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, serverControlledFields, errs); err != nil {
+		return req, err
+	}
+	unknown := domain.NewValidationError()
+	rejectUnknownFields(body, &req, unknown)
+	mergeErrors(errs, unknown)
+	validateStruct(req, errs)
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
+
+// ToUpdate converte a requisição nas alterações do pagamento
+func (r UpdatePaymentRequest) ToUpdate() domain.PaymentUpdate {
+	return domain.PaymentUpdate{Method: r.Method}
+}
+
+// CaptureRequest é o corpo opcional de POST /payments/:id/capture; sem amount
+// o valor autorizado é capturado integralmente
+type CaptureRequest struct {
+	Amount float64 `json:"amount" validate:"omitempty,gt=0,money"`
+}
+
+// RefundRequest é o corpo opcional de POST /payments/:id/refund; sem amount
+// todo o saldo é estornado
+type RefundRequest struct {
+	Amount float64 `json:"amount" validate:"omitempty,gt=0,money"`
+}
+
+// DecodeCaptureRequest lê e valida o corpo da captura; um corpo vazio é aceito
+func DecodeCaptureRequest(body []byte) (CaptureRequest, error) {
+	return decodeOptionalBody[CaptureRequest](body)
+}
+
+// DecodeRefundRequest lê e valida o corpo do estorno; um corpo vazio é aceito
+func DecodeRefundRequest(body []byte) (RefundRequest, error) {
+	return decodeOptionalBody[RefundRequest](body)
+}
+
+// decodeOptionalBody decodifica e valida um corpo que pode ser omitido
+func decodeOptionalBody[T any](body []byte) (T, error) {
+	var req T
+	if len(bytes.TrimSpace(body)) == 0 {
+		return req, nil
+	}
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, nil, errs); err != nil {
+		return req, err
+	}
+	validateStruct(req, errs)
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
@@ -0,0 +1,165 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"payments/domain"
+)
+
+// validate é compartilhado por todos os DTOs; as regras ficam nas tags `validate`
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Usa o nome do campo no JSON nas mensagens de erro
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// payment_type: deve ser um domain.PaymentType conhecido
+	_ = v.RegisterValidation("payment_type", func(fl validator.FieldLevel) bool {
+		return domain.PaymentType(fl.Field().String()).IsValid() == nil
+	})
+	// money: valores monetários com no máximo duas casas decimais
+	_ = v.RegisterValidation("money", func(fl validator.FieldLevel) bool {
+		cents := fl.Field().Float() * 100
+		return math.Abs(cents-math.Round(cents)) < 1e-6
+	})
+	return v
+}
+
+// validateStruct aplica as tags `validate` e acumula as violações em errs,
+// ignorando campos que já foram rejeitados na decodificação
+func validateStruct(s interface{}, errs *domain.ValidationError) {
+	err := validate.Struct(s)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return
+	}
+	reported := make(map[string]bool, len(errs.Errors))
+	for _, fieldErr := range errs.Errors {
+		reported[fieldErr.Field] = true
+	}
+	for _, fieldErr := range fieldErrs {
+		if field := fieldPath(fieldErr); !reported[field] {
+			errs.Add(field, message(fieldErr))
+		}
+	}
+}
+
+// mergeErrors acrescenta a errs as violações de other em campos ainda não reportados
+func mergeErrors(errs *domain.ValidationError, other *domain.ValidationError) {
+	reported := make(map[string]bool, len(errs.Errors))
+	for _, fieldErr := range errs.Errors {
+		reported[fieldErr.Field] = true
+	}
+	for _, fieldErr := range other.Errors {
+		if !reported[fieldErr.Field] {
+			errs.Add(fieldErr.Field, fieldErr.Message)
+		}
+	}
+}
+
+// fieldPath remove o nome da struct raiz do namespace (ex.: CreatePaymentRequest.order_id)
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func message(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "gte":
+		return "must be greater than or equal to " + fieldErr.Param()
+	case "lte":
+		return "must be less than or equal to " + fieldErr.Param()
+	case "len":
+		return "must be exactly " + fieldErr.Param() + " characters long"
+	case "numeric":
+		return "must contain only digits"
+	case "max":
+		if fieldErr.Kind() == reflect.Slice {
+			return "must have at most " + fieldErr.Param() + " items"
+		}
+		return "must be at most " + fieldErr.Param() + " characters long"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "payment_type":
+		return fmt.Sprintf("invalid payment type: %v", fieldErr.Value())
+	case "money":
+		return "must have at most 2 decimal places"
+	case "url":
+		return "must be a valid URL"
+	case "datetime":
+		return "must be a date in the format " + fieldErr.Param()
+	default:
+		return "is invalid"
+	}
+}
+
+// decodeObject lê um objeto JSON, rejeitando os campos controlados pelo servidor.
+// Erros de tipo por campo também são acumulados em errs.
+func decodeObject(body []byte, target interface{}, forbidden []string, errs *domain.ValidationError) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return err
+	}
+	for _, field := range forbidden {
+		if _, ok := raw[field]; ok {
+			errs.Add(field, "is controlled by the server and must not be sent")
+		}
+	}
+	for field, value := range raw {
+		fieldValue, ok := lookupField(target, field)
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, fieldValue); err != nil {
+			errs.Add(field, "has an invalid type")
+		}
+	}
+	return nil
+}
+
+// rejectUnknownFields recusa os campos de body que target não aceita, em vez
+// de ignorá-los em silêncio
+func rejectUnknownFields(body []byte, target interface{}, errs *domain.ValidationError) {
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(body, &raw) != nil {
+		return
+	}
+	for field := range raw {
+		if _, ok := lookupField(target, field); !ok {
+			errs.Add(field, "cannot be updated")
+		}
+	}
+}
+
+// lookupField devolve um ponteiro para o campo de target com a tag json informada
+func lookupField(target interface{}, jsonName string) (interface{}, bool) {
+	value := reflect.ValueOf(target).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.SplitN(value.Type().Field(i).Tag.Get("json"), ",", 2)[0]
+		if name == jsonName {
+			return value.Field(i).Addr().Interface(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"errors"
+
+	"payments/domain"
+)
+
+// webhookServerFields são preenchidos pelo servidor nas inscrições de webhook
+var webhookServerFields = []string{"id", "_id", "secret", "created_at", "updated_at"}
+
+// WebhookSubscriptionRequest é o corpo aceito por POST e PUT /webhooks
+type WebhookSubscriptionRequest struct {
+	URL string `json:"url" validate:"required,max=2048"`
+	// Events lista os eventos assinados; "*" assina todos
+	Events      []string `json:"events" validate:"required,max=20"`
+	Description string   `json:"description" validate:"max=255"`
+	// Active omitido cria a inscrição ativa
+	Active *bool `json:"active"`
+}
+
+// DecodeWebhookSubscriptionRequest lê e valida o corpo de uma inscrição,
+// reunindo as violações das tags e as regras de domínio (URL e eventos)
+func DecodeWebhookSubscriptionRequest(body []byte) (WebhookSubscriptionRequest, error) {
+	var req WebhookSubscriptionRequest
+	errs := domain.NewValidationError()
+	if err := decodeObject(body, &req, webhookServerFields, errs); err != nil {
+		return req, err
+	}
+	validateStruct(req, errs)
+	var subscriptionErrs *domain.ValidationError
+	if errors.As(req.ToSubscription().Validate(), &subscriptionErrs) {
+		mergeErrors(errs, subscriptionErrs)
+	}
+	if errs.HasErrors() {
+		return req, errs
+	}
+	return req, nil
+}
+
+// ToSubscription converte a requisição para a entidade de domínio
+func (r WebhookSubscriptionRequest) ToSubscription() *domain.WebhookSubscription {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return &domain.WebhookSubscription{
+		URL:         r.URL,
+		Events:      r.Events,
+		Description: r.Description,
+		Active:      active,
+	}
+}
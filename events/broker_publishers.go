@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaBatchTimeout limita quanto uma mensagem espera para formar um lote
+const kafkaBatchTimeout = 50 * time.Millisecond
+
+// natsPublisher publica cada evento no subject <prefixo>.<type sem o prefixo
+// payments.>, ex.: payments.payment.approved.v1. O Nats-Msg-Id permite que o
+// JetStream descarte as duplicatas.
+type natsPublisher struct {
+	conn          *nats.Conn
+	subjectPrefix string
+}
+
+// NewNATSPublisher publica pela conexão conn; Close drena e fecha a conexão
+func NewNATSPublisher(conn *nats.Conn, subjectPrefix string) Publisher {
+	return &natsPublisher{conn: conn, subjectPrefix: subjectPrefix}
+}
+
+func (p *natsPublisher) Publish(_ context.Context, event CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(p.subjectPrefix + "." + strings.TrimPrefix(event.Type, typePrefix))
+	msg.Header.Set("Content-Type", ContentType)
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	msg.Data = data
+	// O cliente só enfileira a mensagem; a escrita na rede é assíncrona
+	return p.conn.PublishMsg(msg)
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+// kafkaPublisher publica todos os eventos em um tópico, com a PartitionKey como
+// chave: os eventos de um pagamento ficam na mesma partição, em ordem
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher publica no tópico topic de qualquer broker compatível com o
+// protocolo Kafka. A escrita é assíncrona: as falhas de entrega só são registradas.
+func NewKafkaPublisher(brokers []string, topic string, opts ...Option) Publisher {
+	o := newOptions(opts)
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: kafkaBatchTimeout,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				o.logger.Error("Erro ao publicar eventos no Kafka",
+					slog.String("topic", topic), slog.Int("messages", len(messages)), slog.Any("error", err))
+			}
+		},
+	}}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, event CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(event.PartitionKey),
+		Value:   data,
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte(ContentType)}},
+	})
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
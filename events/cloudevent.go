@@ -0,0 +1,150 @@
+// Package events publica os eventos do ciclo de vida dos pagamentos em um
+// broker de mensagens, no formato CloudEvents 1.0 (modo estruturado, JSON).
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"payments/domain"
+)
+
+const (
+	// SpecVersion é a versão da especificação CloudEvents seguida pelas mensagens
+	SpecVersion = "1.0"
+	// ContentType identifica uma mensagem CloudEvents em modo estruturado
+	ContentType = "application/cloudevents+json"
+	// DefaultSource identifica este serviço como origem dos eventos
+	DefaultSource = "/payments"
+	// PaymentDataVersion é a versão do formato de PaymentData; uma mudança
+	// incompatível cria um novo sufixo no type (ex.: payment.approved.v2)
+	PaymentDataVersion = "v1"
+	// typePrefix agrupa os tipos dos eventos deste serviço
+	typePrefix = "payments."
+)
+
+// CloudEvent é o envelope de toda mensagem publicada. PartitionKey (extensão
+// partitioning) mantém a ordem dos eventos de um mesmo pagamento; traceparent e
+// tracestate (extensão distributed tracing) ligam o consumo ao trace de origem.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	PartitionKey    string          `json:"partitionkey,omitempty"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	TraceState      string          `json:"tracestate,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// PaymentData é o corpo (versão v1) dos eventos de pagamento. É um contrato
+// com os consumidores: campos só podem ser acrescentados.
+type PaymentData struct {
+	ID             string             `json:"id"`
+	OrderID        string             `json:"order_id"`
+	Amount         float64            `json:"amount"`
+	Currency       string             `json:"currency"`
+	Status         string             `json:"status"`
+	PaymentType    domain.PaymentType `json:"payment_type"`
+	Method         string             `json:"method,omitempty"`
+	CapturedAmount float64            `json:"captured_amount,omitempty"`
+	RefundedAmount float64            `json:"refunded_amount,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// EventPaymentInstructions leva ao pedido as instruções de pagamento (boleto,
+// parcelas etc.) do pagamento criado a partir de um order.created
+const EventPaymentInstructions = "payment.instructions"
+
+// PaymentInstructions é o corpo (versão v1) do evento payment.instructions
+type PaymentInstructions struct {
+	PaymentID        string                  `json:"payment_id"`
+	OrderID          string                  `json:"order_id"`
+	Status           string                  `json:"status"`
+	Amount           float64                 `json:"amount"`
+	Currency         string                  `json:"currency"`
+	PaymentType      domain.PaymentType      `json:"payment_type"`
+	SettlementAmount float64                 `json:"settlement_amount,omitempty"`
+	InstallmentPlan  *domain.InstallmentPlan `json:"installment_plan,omitempty"`
+	Boleto           *domain.BoletoDetails   `json:"boleto,omitempty"`
+	// BoletoImagePath é o caminho, nesta API, da imagem do código de barras
+	BoletoImagePath string `json:"boleto_image_path,omitempty"`
+}
+
+// PaymentType é o type CloudEvents do evento de pagamento eventType (ex.:
+// payment.approved vira payments.payment.approved.v1)
+func PaymentType(eventType string) string {
+	return typePrefix + eventType + "." + PaymentDataVersion
+}
+
+// NewPaymentEvent monta o CloudEvent da mudança eventType no pagamento,
+// propagando o trace de ctx
+func NewPaymentEvent(ctx context.Context, source string, eventType string, payment domain.Payment, at time.Time) (CloudEvent, error) {
+	return newEvent(ctx, source, PaymentType(eventType), payment.ID.Hex(), PaymentData{
+		ID:             payment.ID.Hex(),
+		OrderID:        payment.OrderId,
+		Amount:         payment.Amount,
+		Currency:       payment.CurrencyCode(),
+		Status:         payment.Status,
+		PaymentType:    payment.PaymentType,
+		Method:         payment.Method,
+		CapturedAmount: payment.CapturedAmount,
+		RefundedAmount: payment.RefundedAmount,
+		CreatedAt:      payment.CreatedAt,
+	}, at)
+}
+
+// NewPaymentInstructionsEvent monta o payment.instructions do pagamento criado
+// para um pedido, com o que o comprador precisa para pagar
+func NewPaymentInstructionsEvent(ctx context.Context, source string, payment domain.Payment, at time.Time) (CloudEvent, error) {
+	instructions := PaymentInstructions{
+		PaymentID:        payment.ID.Hex(),
+		OrderID:          payment.OrderId,
+		Status:           payment.Status,
+		Amount:           payment.Amount,
+		Currency:         payment.CurrencyCode(),
+		PaymentType:      payment.PaymentType,
+		SettlementAmount: payment.SettlementAmount,
+		InstallmentPlan:  payment.InstallmentPlan,
+		Boleto:           payment.Boleto,
+	}
+	if payment.Boleto != nil {
+		instructions.BoletoImagePath = "/payments/" + payment.ID.Hex() + "/boleto.png"
+	}
+	return newEvent(ctx, source, PaymentType(EventPaymentInstructions), payment.ID.Hex(), instructions, at)
+}
+
+func newEvent(ctx context.Context, source string, eventType string, subject string, payload any, at time.Time) (CloudEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return CloudEvent{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            at,
+		DataContentType: "application/json",
+		PartitionKey:    subject,
+		TraceParent:     carrier.Get("traceparent"),
+		TraceState:      carrier.Get("tracestate"),
+		Data:            data,
+	}, nil
+}
+
+// Context devolve ctx com o trace propagado na mensagem, para continuar o trace de quem publicou
+func (e CloudEvent) Context(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{"traceparent": e.TraceParent, "tracestate": e.TraceState}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
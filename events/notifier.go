@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// PaymentNotifier publica cada mudança no ciclo de vida dos pagamentos como
+// CloudEvent. Implementa usecase.PaymentNotifier: falhas são apenas registradas.
+type PaymentNotifier struct {
+	publisher Publisher
+	source    string
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+func NewPaymentNotifier(publisher Publisher, opts ...Option) *PaymentNotifier {
+	o := newOptions(opts)
+	return &PaymentNotifier{publisher: publisher, source: o.source, now: o.now, logger: o.logger}
+}
+
+func (n *PaymentNotifier) NotifyPayment(ctx context.Context, eventType string, payment domain.Payment) {
+	// O span de produtor é o pai do trace propagado na mensagem
+	ctx, span := telemetry.Tracer().Start(ctx, "EventPublisher.Publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(telemetry.PaymentIDAttr(payment.ID.Hex()), attribute.String("event.type", eventType)),
+	)
+	defer span.End()
+
+	event, err := NewPaymentEvent(ctx, n.source, eventType, payment, n.now().UTC())
+	if err == nil {
+		span.SetAttributes(attribute.String("event.id", event.ID))
+		err = n.publisher.Publish(ctx, event)
+	}
+	if err != nil {
+		telemetry.RecordError(span, err)
+		n.logger.ErrorContext(ctx, "Erro ao publicar evento de pagamento",
+			slog.String("event_type", eventType), slog.Any("payment", payment), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"log/slog"
+	"time"
+)
+
+// options reúne as dependências opcionais compartilhadas pelos publishers, subscribers e pelo notificador
+type options struct {
+	logger *slog.Logger
+	source string
+	now    func() time.Time
+	// retryBase e retryMax limitam o backoff das novas tentativas dos subscribers
+	retryBase time.Duration
+	retryMax  time.Duration
+}
+
+// Backoff padrão das novas tentativas de mensagens com falha transitória
+const (
+	DefaultRetryBaseDelay = time.Second
+	DefaultRetryMaxDelay  = time.Minute
+)
+
+// Option permite customizar os publishers, os subscribers e o notificador
+type Option func(*options)
+
+// WithLogger define o logger estruturado usado para as falhas de publicação
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSource substitui o source dos CloudEvents (padrão DefaultSource)
+func WithSource(source string) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+// WithClock substitui o relógio usado na data dos eventos (usado em testes)
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithRetryBackoff define o atraso da primeira nova tentativa de uma mensagem
+// com falha transitória, dobrado a cada tentativa até max
+func WithRetryBackoff(base time.Duration, max time.Duration) Option {
+	return func(o *options) {
+		o.retryBase = base
+		o.retryMax = max
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{logger: slog.Default(), source: DefaultSource, now: time.Now,
+		retryBase: DefaultRetryBaseDelay, retryMax: DefaultRetryMaxDelay}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Publisher entrega CloudEvents a um broker de mensagens
+type Publisher interface {
+	Publish(ctx context.Context, event CloudEvent) error
+	// Close envia o que estiver pendente e libera a conexão
+	Close() error
+}
+
+// MemoryPublisher guarda os eventos publicados em memória (testes e desenvolvimento)
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []CloudEvent
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event CloudEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events devolve os eventos publicados, em ordem
+func (p *MemoryPublisher) Events() []CloudEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.events)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
+
+// filePublisher acrescenta os eventos a um arquivo JSON Lines: um substituto
+// local do broker que pode ser inspecionado ou reprocessado
+type filePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePublisher abre (ou cria) o arquivo path para acrescentar os eventos
+func NewFilePublisher(path string) (Publisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening events file: %v", err)
+	}
+	return &filePublisher{file: file}, nil
+}
+
+func (p *filePublisher) Publish(_ context.Context, event CloudEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Uma única escrita por evento: com O_APPEND, as linhas não se intercalam
+	_, err = p.file.Write(append(line, '\n'))
+	return err
+}
+
+func (p *filePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.file.Close()
+}
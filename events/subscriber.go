@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+	"payments/domain"
+)
+
+// Handler processa uma mensagem recebida
+type Handler func(ctx context.Context, event CloudEvent) error
+
+// ErrPermanent marca as falhas que nenhuma nova tentativa resolve, como uma
+// mensagem malformada ou de outro tipo; o handler deve embrulhá-lo
+var ErrPermanent = errors.New("permanent message failure")
+
+// IsPermanent indica se a falha do handler é permanente: ErrPermanent ou uma
+// violação de validação (domain.ErrValidation)
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrPermanent) || errors.Is(err, domain.ErrValidation)
+}
+
+// Subscriber entrega ao handler as mensagens de um tópico até ctx ser
+// cancelado. Falhas transitórias do handler são repetidas com backoff
+// exponencial (ver WithRetryBackoff) até o sucesso; falhas permanentes e
+// mensagens que não são CloudEvents são registradas e descartadas.
+type Subscriber interface {
+	Subscribe(ctx context.Context, handler Handler) error
+	Close() error
+}
+
+// MemorySubscriber recebe as mensagens por Deliver (testes e desenvolvimento)
+type MemorySubscriber struct {
+	messages chan CloudEvent
+	options  options
+}
+
+// NewMemorySubscriber cria um subscriber que aceita até buffer mensagens ainda não consumidas
+func NewMemorySubscriber(buffer int, opts ...Option) *MemorySubscriber {
+	return &MemorySubscriber{messages: make(chan CloudEvent, buffer), options: newOptions(opts)}
+}
+
+// Deliver enfileira uma mensagem, esperando espaço no buffer até ctx ser cancelado
+func (s *MemorySubscriber) Deliver(ctx context.Context, event CloudEvent) error {
+	select {
+	case s.messages <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *MemorySubscriber) Subscribe(ctx context.Context, handler Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-s.messages:
+			if err := handle(ctx, handler, event, s.options); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *MemorySubscriber) Close() error {
+	return nil
+}
+
+// natsSubscriber assina o subject em um queue group: cada mensagem vai para uma
+// única instância do serviço. O NATS core não reentrega mensagens: as novas
+// tentativas são feitas aqui, e uma mensagem ainda em tentativa quando o
+// serviço encerra se perde.
+type natsSubscriber struct {
+	conn       *nats.Conn
+	subject    string
+	queueGroup string
+	options    options
+}
+
+// NewNATSSubscriber assina subject pela conexão conn; Close drena e fecha a conexão
+func NewNATSSubscriber(conn *nats.Conn, subject string, queueGroup string, opts ...Option) Subscriber {
+	return &natsSubscriber{conn: conn, subject: subject, queueGroup: queueGroup, options: newOptions(opts)}
+}
+
+func (s *natsSubscriber) Subscribe(ctx context.Context, handler Handler) error {
+	// O handler roda na goroutine do cliente, uma mensagem por vez e em ordem
+	sub, err := s.conn.QueueSubscribe(s.subject, s.queueGroup, func(msg *nats.Msg) {
+		if err := handleRaw(ctx, handler, msg.Data, s.options); err != nil {
+			s.options.logger.WarnContext(ctx, "Mensagem não processada antes do encerramento", slog.String("subject", msg.Subject))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error subscribing to %s: %v", s.subject, err)
+	}
+	<-ctx.Done()
+	return sub.Drain()
+}
+
+func (s *natsSubscriber) Close() error {
+	return s.conn.Drain()
+}
+
+// kafkaSubscriber lê o tópico em um consumer group. O offset só é confirmado
+// depois do sucesso do handler ou de uma falha permanente: a mensagem
+// interrompida pelo encerramento volta na próxima leitura do grupo.
+type kafkaSubscriber struct {
+	reader  *kafka.Reader
+	options options
+}
+
+// NewKafkaSubscriber lê topic de qualquer broker compatível com o protocolo Kafka
+func NewKafkaSubscriber(brokers []string, topic string, groupID string, opts ...Option) Subscriber {
+	return &kafkaSubscriber{
+		reader:  kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, Topic: topic, GroupID: groupID}),
+		options: newOptions(opts),
+	}
+}
+
+func (s *kafkaSubscriber) Subscribe(ctx context.Context, handler Handler) error {
+	for {
+		msg, err := s.reader.FetchMessage(ctx)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading from Kafka: %v", err)
+		}
+		if err := handleRaw(ctx, handler, msg.Value, s.options); err != nil {
+			return nil
+		}
+		if err := s.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("error committing Kafka offset: %v", err)
+		}
+	}
+}
+
+func (s *kafkaSubscriber) Close() error {
+	return s.reader.Close()
+}
+
+// handleRaw decodifica uma mensagem em modo estruturado e a entrega ao handler
+func handleRaw(ctx context.Context, handler Handler, data []byte, o options) error {
+	var event CloudEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		o.logger.ErrorContext(ctx, "Mensagem descartada: não é um CloudEvent", slog.Any("error", err))
+		return nil
+	}
+	return handle(ctx, handler, event, o)
+}
+
+// handle entrega a mensagem ao handler, repetindo as falhas transitórias com
+// backoff exponencial. Devolve nil quando a mensagem pode ser confirmada
+// (sucesso ou falha permanente) e o erro de ctx quando o encerramento
+// interrompeu as tentativas.
+func handle(ctx context.Context, handler Handler, event CloudEvent, o options) error {
+	delay := o.retryBase
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, event)
+		if err == nil {
+			return nil
+		}
+		attrs := []any{slog.String("event_id", event.ID), slog.String("event_type", event.Type), slog.Any("error", err)}
+		if IsPermanent(err) {
+			o.logger.ErrorContext(ctx, "Mensagem descartada: falha permanente", attrs...)
+			return nil
+		}
+		o.logger.WarnContext(ctx, "Erro ao processar mensagem; nova tentativa",
+			append(attrs, slog.Int("attempt", attempt), slog.Duration("retry_in", delay))...)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, o.retryMax)
+	}
+}
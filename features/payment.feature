@@ -4,4 +4,9 @@ Feature: Criar Pagamento
     Given que tenho um pagamento válido
     When eu tentar criar o pagamento
     Then o pagamento deve ser criado com sucesso
+    And o pagamento deve estar com status "Em processamento"
 
+  Scenario: Rejeitar pagamento com tipo inválido
+    Given que tenho um pagamento com tipo "CHEQUE"
+    When eu tentar criar o pagamento
+    Then a criação deve falhar por validação
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"payments/usecase"
+)
+
+// AutoVoidJob libera periodicamente as autorizações não capturadas dentro da janela
+type AutoVoidJob struct {
+	useCase  usecase.PaymentUseCase
+	window   time.Duration
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewAutoVoidJob cria o job; autorizações mais antigas que window são liberadas a cada interval
+func NewAutoVoidJob(useCase usecase.PaymentUseCase, window time.Duration, interval time.Duration, logger *slog.Logger) *AutoVoidJob {
+	return &AutoVoidJob{
+		useCase:  useCase,
+		window:   window,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run executa o job até o contexto ser cancelado
+func (j *AutoVoidJob) Run(ctx context.Context) {
+	j.logger.InfoContext(ctx, "Job de liberação de autorizações iniciado",
+		slog.Duration("window", j.window), slog.Duration("interval", j.interval))
+	runEvery(ctx, j.interval, func() { j.RunOnce(ctx) })
+}
+
+// RunOnce libera as autorizações vencidas e devolve quantas foram liberadas
+func (j *AutoVoidJob) RunOnce(ctx context.Context) int {
+	cutoff := time.Now().Add(-j.window)
+	voided, err := j.useCase.VoidExpiredAuthorizations(ctx, cutoff)
+	if err != nil {
+		j.logger.ErrorContext(ctx, "Erro ao liberar autorizações vencidas", slog.Any("error", err))
+	}
+	if voided > 0 {
+		j.logger.InfoContext(ctx, "Autorizações vencidas liberadas", slog.Int("count", voided), slog.Time("cutoff", cutoff))
+	}
+	return voided
+}
+
+// runEvery executa fn imediatamente e depois a cada interval, até o contexto ser cancelado
+func runEvery(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"payments/usecase"
+)
+
+// BoletoExpiryJob marca como vencidos os boletos não pagos após o prazo
+type BoletoExpiryJob struct {
+	useCase  usecase.PaymentUseCase
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewBoletoExpiryJob cria o job, que procura boletos vencidos a cada interval
+func NewBoletoExpiryJob(useCase usecase.PaymentUseCase, interval time.Duration, logger *slog.Logger) *BoletoExpiryJob {
+	return &BoletoExpiryJob{
+		useCase:  useCase,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run executa o job até o contexto ser cancelado
+func (j *BoletoExpiryJob) Run(ctx context.Context) {
+	j.logger.InfoContext(ctx, "Job de vencimento de boletos iniciado", slog.Duration("interval", j.interval))
+	runEvery(ctx, j.interval, func() { j.RunOnce(ctx) })
+}
+
+// RunOnce encerra os boletos vencidos e devolve quantos foram encerrados
+func (j *BoletoExpiryJob) RunOnce(ctx context.Context) int {
+	expired, err := j.useCase.ExpireOverdueBoletos(ctx, time.Now())
+	if err != nil {
+		j.logger.ErrorContext(ctx, "Erro ao encerrar boletos vencidos", slog.Any("error", err))
+	}
+	if expired > 0 {
+		j.logger.InfoContext(ctx, "Boletos vencidos encerrados", slog.Int("count", expired))
+	}
+	return expired
+}
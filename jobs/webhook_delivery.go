@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"payments/usecase"
+)
+
+// WebhookDeliveryJob envia as entregas de webhook pendentes e as novas tentativas vencidas
+type WebhookDeliveryJob struct {
+	useCase  usecase.WebhookUseCase
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewWebhookDeliveryJob cria o job, que procura entregas vencidas a cada interval
+func NewWebhookDeliveryJob(useCase usecase.WebhookUseCase, interval time.Duration, logger *slog.Logger) *WebhookDeliveryJob {
+	return &WebhookDeliveryJob{
+		useCase:  useCase,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run executa o job até o contexto ser cancelado
+func (j *WebhookDeliveryJob) Run(ctx context.Context) {
+	j.logger.InfoContext(ctx, "Job de entrega de webhooks iniciado", slog.Duration("interval", j.interval))
+	runEvery(ctx, j.interval, func() { j.RunOnce(ctx) })
+}
+
+// RunOnce tenta as entregas vencidas e devolve quantas foram tentadas
+func (j *WebhookDeliveryJob) RunOnce(ctx context.Context) int {
+	attempted, err := j.useCase.DeliverDue(ctx, time.Now())
+	if err != nil {
+		j.logger.ErrorContext(ctx, "Erro ao entregar webhooks", slog.Any("error", err))
+	}
+	if attempted > 0 {
+		j.logger.DebugContext(ctx, "Entregas de webhook tentadas", slog.Int("count", attempted))
+	}
+	return attempted
+}
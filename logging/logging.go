@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedactedValue substitui o valor de atributos sensíveis
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeys são os atributos que nunca devem aparecer em claro nos logs
+var sensitiveKeys = map[string]struct{}{
+	"password":      {},
+	"db_password":   {},
+	"secret":        {},
+	"token":         {},
+	"authorization": {},
+	"api_key":       {},
+	"card_number":   {},
+	"cvv":           {},
+}
+
+type requestIDKey struct{}
+
+// WithRequestID guarda o ID de correlação da requisição no contexto
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext retorna o ID de correlação da requisição, se houver
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// New cria um logger JSON que adiciona request_id/trace_id a partir do contexto
+// e mascara os atributos sensíveis
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// NewFromEnv cria o logger do serviço no stdout com o nível definido em LOG_LEVEL
+// (debug, info, warn, error; padrão info)
+func NewFromEnv() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+	return New(os.Stdout, level)
+}
+
+// RedactURI remove a senha de uma URI de conexão antes de registrá-la
+func RedactURI(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return RedactedValue
+	}
+	return u.Redacted()
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if _, ok := sensitiveKeys[strings.ToLower(attr.Key)]; ok {
+		return slog.String(attr.Key, RedactedValue)
+	}
+	return attr
+}
+
+// contextHandler enriquece cada registro com os dados de correlação do contexto
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
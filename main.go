@@ -1,37 +1,179 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
+	"payments/auth"
 	"payments/config"
+	"payments/consumers"
+	"payments/delivery"
 	_ "payments/docs"
-	"payments/repository"
+	"payments/domain"
+	"payments/events"
+	"payments/jobs"
+	"payments/logging"
+	"payments/middleware"
+	"payments/pubsub"
 	"payments/routes"
+	"payments/telemetry"
 	"payments/usecase"
 )
 
 func main() {
-	config.InitDB()
-	repo := repository.NewPaymentRepository(config.MongoDB)
-	useCase := usecase.NewPaymentUseCase(repo)
+	config.LoadEnv()
 
-	app := fiber.New()
+	logger := logging.NewFromEnv()
+	slog.SetDefault(logger)
+
+	shutdownTracer, err := telemetry.InitTracer(context.Background())
+	if err != nil {
+		logger.Error("Erro ao configurar o OpenTelemetry", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer shutdownTracer(context.Background())
+
+	// "payments migrate" aplica as migrações do banco e encerra
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := config.Migrate(logger); err != nil {
+			logger.Error("Erro ao aplicar as migrações", slog.Any("error", err))
+			os.Exit(1)
+		}
+		logger.Info("Migrações aplicadas")
+		return
+	}
+
+	if err := config.CheckRemovedSettings(); err != nil {
+		logger.Error("Configuração não suportada", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	repos, err := config.NewRepositories(logger)
+	if err != nil {
+		logger.Error("Erro ao configurar o armazenamento", slog.Any("error", err))
+		os.Exit(1)
+	}
+	issuer, err := config.BoletoIssuer()
+	if err != nil {
+		logger.Error("Erro ao configurar o emissor de boletos", slog.Any("error", err))
+		os.Exit(1)
+	}
+	installments, err := config.InstallmentPolicies()
+	if err != nil {
+		logger.Error("Erro ao configurar o parcelamento", slog.Any("error", err))
+		os.Exit(1)
+	}
+	heartbeat, err := config.EventStreamHeartbeat()
+	if err != nil {
+		logger.Error("Erro ao configurar o stream de eventos", slog.Any("error", err))
+		os.Exit(1)
+	}
+	publisher, err := config.NewEventPublisher(logger)
+	if err != nil {
+		logger.Error("Erro ao configurar a publicação de eventos", slog.Any("error", err))
+		os.Exit(1)
+	}
+	broker := pubsub.NewMemoryBroker()
+	webhooks := usecase.NewWebhookUseCase(repos.Webhooks, usecase.WithWebhookLogger(logger))
+	notifiers := usecase.Notifiers{webhooks, broker}
+	if publisher != nil {
+		defer publisher.Close()
+		notifiers = append(notifiers, events.NewPaymentNotifier(publisher, events.WithLogger(logger)))
+	}
+	useCase := usecase.NewPaymentUseCase(repos.Payments, usecase.WithLogger(logger), usecase.WithBoletoIssuer(issuer),
+		usecase.WithInstallmentPolicies(installments), usecase.WithFeeRules(repos.FeeRules), usecase.WithNotifier(notifiers))
+
+	window, interval, err := config.AutoVoidSchedule()
+	if err != nil {
+		logger.Error("Erro ao configurar a liberação de autorizações", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if window > 0 {
+		go jobs.NewAutoVoidJob(useCase, window, interval, logger).Run(context.Background())
+	}
+	boletoInterval, err := config.BoletoExpiryInterval()
+	if err != nil {
+		logger.Error("Erro ao configurar o vencimento de boletos", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if boletoInterval > 0 {
+		go jobs.NewBoletoExpiryJob(useCase, boletoInterval, logger).Run(context.Background())
+	}
+	webhookInterval, err := config.WebhookDeliveryInterval()
+	if err != nil {
+		logger.Error("Erro ao configurar a entrega de webhooks", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if webhookInterval > 0 {
+		go jobs.NewWebhookDeliveryJob(webhooks, webhookInterval, logger).Run(context.Background())
+	}
+	subscriber, err := config.NewOrderSubscriber(logger)
+	if err != nil {
+		logger.Error("Erro ao configurar o consumo de pedidos", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if subscriber != nil {
+		// As instruções de pagamento voltam ao pedido pelo publisher de eventos
+		if publisher == nil {
+			logger.Error("ORDER_EVENTS_BACKEND exige um EVENTS_BACKEND para publicar as instruções de pagamento")
+			os.Exit(1)
+		}
+		defer subscriber.Close()
+		go func() {
+			if err := consumers.NewOrderCreatedConsumer(subscriber, useCase, publisher, logger).Run(context.Background()); err != nil {
+				logger.Error("Erro no consumo de pedidos", slog.Any("error", err))
+			}
+		}()
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+	adminKey := config.APIAdminKey()
+	if adminKey != "" {
+		// A chave de bootstrap vale como admin sem poder ser revogada: deve sair do ambiente assim que as chaves forem emitidas
+		logger.Warn("API_ADMIN_KEY configurada: a chave tem acesso admin irrestrito até ser removida do ambiente")
+	}
+	apiKeys := usecase.NewAPIKeyUseCase(repos.APIKeys, usecase.WithAPIKeyLogger(logger), usecase.WithAdminKey(adminKey))
+	tokens, err := config.NewJWTAuthenticator(logger)
+	if err != nil {
+		logger.Error("Erro ao configurar a validação de JWTs", slog.Any("error", err))
+		os.Exit(1)
+	}
+	streamTokens, err := config.NewStreamTokens(logger)
+	if err != nil {
+		logger.Error("Erro ao configurar os tokens de stream", slog.Any("error", err))
+		os.Exit(1)
+	}
+	authenticator := auth.Authenticators{APIKeys: apiKeys, Tokens: tokens, Streams: streamTokens}
+	app.Use(middleware.RequestID(), middleware.Tracing(), middleware.RequestLogger(logger), middleware.Authenticate(authenticator))
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	log.Println("Registrando rotas de pagamento...")
-	routes.RegisterPaymentRoutes(app, useCase)
+	logger.Info("Registrando rotas de pagamento...")
+	callbackSecret := config.GatewayCallbackSecret()
+	if callbackSecret == "" {
+		logger.Warn("PAYMENT_GATEWAY_CALLBACK_SECRET vazio: os callbacks do gateway serão recusados")
+	}
+	routes.RegisterPaymentRoutes(app, useCase, middleware.GatewaySignature(callbackSecret),
+		delivery.WithLogger(logger), delivery.WithStreamTokens(streamTokens))
+	routes.RegisterPaymentEventRoutes(app, useCase, broker, delivery.WithHeartbeat(heartbeat), delivery.WithEventsLogger(logger))
+	routes.RegisterPaymentFeedRoutes(app, broker, middleware.RequireScope(domain.ScopePaymentsRead), delivery.WithFeedLogger(logger))
+	routes.RegisterFeeRuleRoutes(app, usecase.NewFeeRuleUseCase(repos.FeeRules, usecase.WithFeeRuleLogger(logger)))
+	routes.RegisterDisputeRoutes(app, usecase.NewDisputeUseCase(repos.Disputes, repos.Payments,
+		usecase.WithDisputeLogger(logger), usecase.WithDisputeNotifier(notifiers)))
+	routes.RegisterWebhookRoutes(app, webhooks)
+	routes.RegisterAPIKeyRoutes(app, apiKeys)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	log.Printf("📌 Servidor iniciando na porta: %s", port)
+	logger.Info("Servidor iniciando", slog.String("port", port))
 
 	if err := app.Listen(":" + port); err != nil {
-		log.Fatal("Erro ao iniciar o servidor:", err)
+		logger.Error("Erro ao iniciar o servidor", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 }
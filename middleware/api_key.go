@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"payments/domain"
+)
+
+// APIKeyHeader é a alternativa ao header "Authorization: Bearer <chave>"
+const APIKeyHeader = "X-API-Key"
+
+// AccessTokenQuery leva a credencial na URL para clientes que não conseguem
+// enviar headers, como o WebSocket e o EventSource dos navegadores
+const AccessTokenQuery = "access_token"
+
+// principalLocal guarda em c.Locals o cliente autenticado da requisição
+const principalLocal = "principal"
+
+// Authenticator valida uma credencial e devolve o cliente dono dela; falhas de
+// autenticação devem embrulhar domain.ErrUnauthorized
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (domain.Principal, error)
+}
+
+// Authenticate identifica o cliente pela credencial em X-API-Key ou em
+// "Authorization: Bearer". Credenciais inválidas são recusadas com 401;
+// requisições sem credencial seguem anônimas e cada rota decide, com
+// RequireScope, se as aceita.
+func Authenticate(authenticator Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		credential := c.Get(APIKeyHeader)
+		if credential == "" {
+			credential = bearerToken(c)
+		}
+		// O WebSocket e o EventSource dos navegadores não enviam headers: só
+		// no handshake e nos streams SSE a credencial pode vir na URL
+		if credential == "" && (websocket.IsWebSocketUpgrade(c) || isEventStream(c)) {
+			credential = c.Query(AccessTokenQuery)
+		}
+		if credential == "" {
+			return c.Next()
+		}
+		principal, err := authenticator.Authenticate(c.UserContext(), credential)
+		if err != nil {
+			return challenge(c, err)
+		}
+		c.Locals(principalLocal, principal)
+		return c.Next()
+	}
+}
+
+// RequireScope só deixa passar clientes autenticados com o escopo (ou admin)
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			return challenge(c, fmt.Errorf("missing credentials: %w", domain.ErrUnauthorized))
+		}
+		if !principal.HasScope(scope) {
+			return fmt.Errorf("scope %s is required: %w", scope, domain.ErrForbidden)
+		}
+		return c.Next()
+	}
+}
+
+// RequireResourceScope deixa passar quem tem scope ou o escopo restrito ao
+// recurso da requisição, como o token de stream de um único pagamento
+func RequireResourceScope(scope string, resourceScope func(c *fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			return challenge(c, fmt.Errorf("missing credentials: %w", domain.ErrUnauthorized))
+		}
+		if !principal.HasScope(scope) && !principal.HasScope(resourceScope(c)) {
+			return fmt.Errorf("scope %s is required: %w", scope, domain.ErrForbidden)
+		}
+		return c.Next()
+	}
+}
+
+// PrincipalFrom devolve o cliente autenticado pela requisição, se houver
+func PrincipalFrom(c *fiber.Ctx) (domain.Principal, bool) {
+	principal, ok := c.Locals(principalLocal).(domain.Principal)
+	return principal, ok
+}
+
+// isEventStream indica uma requisição de stream SSE, como as do EventSource
+func isEventStream(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet && strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream")
+}
+
+// bearerToken extrai o token do header Authorization, se houver
+func bearerToken(c *fiber.Ctx) string {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// challenge indica ao cliente, nas falhas de autenticação, como se autenticar
+func challenge(c *fiber.Ctx, err error) error {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="payments"`)
+	}
+	return err
+}
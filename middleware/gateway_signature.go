@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"payments/domain"
+)
+
+// Headers com que o gateway assina os callbacks, no mesmo formato dos webhooks enviados aos lojistas
+const (
+	GatewaySignatureHeader = "X-Gateway-Signature"
+	GatewayTimestampHeader = "X-Gateway-Timestamp"
+)
+
+// DefaultGatewaySignatureTolerance limita a idade de um callback assinado, para
+// que um callback capturado não possa ser repetido depois
+const DefaultGatewaySignatureTolerance = 5 * time.Minute
+
+// SignGatewayCallback calcula a assinatura esperada do callback: HMAC-SHA256
+// de "<timestamp>.<corpo>" com o segredo compartilhado com o gateway
+func SignGatewayCallback(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GatewaySignature só deixa passar callbacks assinados pelo gateway com secret,
+// comparando as assinaturas em tempo constante. Sem segredo configurado, todo
+// callback é recusado: a rota não tem outra autenticação.
+func GatewaySignature(secret string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if secret == "" {
+			return fmt.Errorf("gateway callbacks are disabled: no callback secret configured: %w", domain.ErrUnauthorized)
+		}
+		timestamp, err := strconv.ParseInt(c.Get(GatewayTimestampHeader), 10, 64)
+		if err != nil {
+			return fmt.Errorf("missing or invalid %s header: %w", GatewayTimestampHeader, domain.ErrUnauthorized)
+		}
+		if age := time.Since(time.Unix(timestamp, 0)); age > DefaultGatewaySignatureTolerance || age < -DefaultGatewaySignatureTolerance {
+			return fmt.Errorf("gateway callback timestamp outside the tolerance: %w", domain.ErrUnauthorized)
+		}
+		expected := SignGatewayCallback(secret, timestamp, c.Body())
+		if !hmac.Equal([]byte(expected), []byte(c.Get(GatewaySignatureHeader))) {
+			return fmt.Errorf("invalid gateway callback signature: %w", domain.ErrUnauthorized)
+		}
+		return c.Next()
+	}
+}
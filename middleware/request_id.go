@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"payments/logging"
+)
+
+// RequestIDHeader é o header usado para correlacionar as requisições entre serviços
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID lê o X-Request-ID recebido (ou gera um novo), devolve no response
+// e o guarda no contexto para os logs e para as chamadas de saída
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Set(RequestIDHeader, requestID)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), requestID))
+		return c.Next()
+	}
+}
+
+// RequestLogger registra uma linha por requisição com método, rota, status e duração
+func RequestLogger(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		}
+		level := slog.LevelInfo
+		if err != nil || status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(c.UserContext(), level, "Requisição processada",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return err
+	}
+}
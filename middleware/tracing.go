@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"payments/telemetry"
+)
+
+// Tracing abre um span de servidor por requisição, continuando o trace recebido
+// no header traceparent, e disponibiliza o contexto via c.UserContext()
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		carrier := propagation.HeaderCarrier(c.GetReqHeaders())
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		ctx, span := telemetry.Tracer().Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// A rota só é conhecida depois do roteamento
+		span.SetName(c.Method() + " " + c.Route().Path)
+		span.SetAttributes(attribute.String("http.route", c.Route().Path))
+
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if err != nil {
+			telemetry.RecordError(span, err)
+		} else if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+		return err
+	}
+}
@@ -0,0 +1,224 @@
+// Package pubsub distribui os eventos de pagamento aos assinantes em tempo real
+// (streams SSE). A implementação em memória atende uma única instância; um
+// broker externo pode substituí-la implementando Broker.
+package pubsub
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"payments/domain"
+)
+
+const (
+	// DefaultHistorySize é quantos eventos recentes ficam retidos para retomadas
+	DefaultHistorySize = 1024
+	// DefaultBufferSize é quantos eventos um assinante pode acumular sem ler
+	DefaultBufferSize = 64
+)
+
+// Filter seleciona os eventos entregues a uma assinatura; nil aceita todos
+type Filter func(domain.PaymentEvent) bool
+
+// ForPayment aceita apenas os eventos do pagamento id
+func ForPayment(id string) Filter {
+	return func(event domain.PaymentEvent) bool {
+		return event.PaymentID == id
+	}
+}
+
+// Broker publica os eventos de pagamento e os entrega aos assinantes.
+// NotifyPayment permite usá-lo como usecase.PaymentNotifier.
+type Broker interface {
+	NotifyPayment(ctx context.Context, eventType string, payment domain.Payment)
+	// Publish atribui o ID ao evento e o entrega aos assinantes interessados
+	Publish(event domain.PaymentEvent) domain.PaymentEvent
+	// Subscribe começa a entregar os eventos aceitos por filter. Com
+	// lastEventID, os eventos retidos publicados depois dele vêm primeiro.
+	Subscribe(filter Filter, lastEventID string) Subscription
+}
+
+// Subscription entrega os eventos de uma assinatura até Close
+type Subscription interface {
+	// Events é fechado no Close ou quando o assinante é desligado por lentidão
+	Events() <-chan domain.PaymentEvent
+	// Cursor é o ID do último evento publicado antes da assinatura
+	Cursor() string
+	// Resumed indica que os eventos posteriores ao lastEventID foram reenviados;
+	// false quando não havia lastEventID ou ele já saiu do histórico
+	Resumed() bool
+	// Dropped indica que o assinante não acompanhou o ritmo e foi desligado
+	Dropped() bool
+	Close()
+}
+
+type entry struct {
+	seq   uint64
+	event domain.PaymentEvent
+}
+
+type memoryBroker struct {
+	mu          sync.Mutex
+	seq         uint64
+	history     []entry
+	subscribers map[*subscription]struct{}
+	historySize int
+	bufferSize  int
+	now         func() time.Time
+}
+
+// Option permite customizar o broker em memória
+type Option func(*memoryBroker)
+
+// WithHistorySize define quantos eventos recentes ficam disponíveis para retomadas
+func WithHistorySize(size int) Option {
+	return func(b *memoryBroker) {
+		b.historySize = size
+	}
+}
+
+// WithBufferSize define quantos eventos um assinante pode acumular antes de ser desligado
+func WithBufferSize(size int) Option {
+	return func(b *memoryBroker) {
+		b.bufferSize = size
+	}
+}
+
+// WithClock substitui o relógio usado na data dos eventos (usado em testes)
+func WithClock(now func() time.Time) Option {
+	return func(b *memoryBroker) {
+		b.now = now
+	}
+}
+
+// NewMemoryBroker cria um broker em processo. Os IDs dos eventos são uma
+// sequência que recomeça junto com o processo.
+func NewMemoryBroker(opts ...Option) Broker {
+	b := &memoryBroker{
+		subscribers: make(map[*subscription]struct{}),
+		historySize: DefaultHistorySize,
+		bufferSize:  DefaultBufferSize,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *memoryBroker) NotifyPayment(_ context.Context, eventType string, payment domain.Payment) {
+	b.Publish(domain.NewPaymentEvent(eventType, payment, b.now().UTC()))
+}
+
+func (b *memoryBroker) Publish(event domain.PaymentEvent) domain.PaymentEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event.ID = strconv.FormatUint(b.seq, 10)
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			b.history = append(b.history[:0], b.history[1:]...)
+		}
+		b.history = append(b.history, entry{seq: b.seq, event: event})
+	}
+
+	for sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Assinante lento: desligá-lo evita reter memória e atrasar os demais;
+			// quem usa Last-Event-ID retoma a partir do histórico
+			sub.dropped = true
+			b.remove(sub)
+		}
+	}
+	return event
+}
+
+func (b *memoryBroker) Subscribe(filter Filter, lastEventID string) Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay, resumed := b.replay(filter, lastEventID)
+	sub := &subscription{
+		broker:  b,
+		filter:  filter,
+		events:  make(chan domain.PaymentEvent, b.bufferSize+len(replay)),
+		cursor:  strconv.FormatUint(b.seq, 10),
+		resumed: resumed,
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// replay devolve os eventos retidos posteriores a lastEventID. Um ID maior que
+// a sequência atual vem de antes de um reinício e não pode ser retomado.
+func (b *memoryBroker) replay(filter Filter, lastEventID string) ([]domain.PaymentEvent, bool) {
+	if lastEventID == "" {
+		return nil, false
+	}
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || last > b.seq {
+		return nil, false
+	}
+	if last < b.seq && (len(b.history) == 0 || b.history[0].seq > last+1) {
+		return nil, false
+	}
+	var events []domain.PaymentEvent
+	for _, e := range b.history {
+		if e.seq > last && (filter == nil || filter(e.event)) {
+			events = append(events, e.event)
+		}
+	}
+	return events, true
+}
+
+// remove desliga o assinante; deve ser chamado com o lock
+func (b *memoryBroker) remove(sub *subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+type subscription struct {
+	broker  *memoryBroker
+	filter  Filter
+	events  chan domain.PaymentEvent
+	cursor  string
+	resumed bool
+	dropped bool
+}
+
+func (s *subscription) Events() <-chan domain.PaymentEvent {
+	return s.events
+}
+
+func (s *subscription) Cursor() string {
+	return s.cursor
+}
+
+func (s *subscription) Resumed() bool {
+	return s.resumed
+}
+
+func (s *subscription) Dropped() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.dropped
+}
+
+func (s *subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+const apiKeysCollection = "api_keys"
+
+// APIKeyRepository guarda as chaves de API. O prefixo é único e localiza a
+// chave na autenticação; chaves revogadas são mantidas para auditoria.
+type APIKeyRepository interface {
+	// List devolve as chaves da mais antiga para a mais recente
+	List(ctx context.Context) ([]domain.APIKey, error)
+	GetByID(ctx context.Context, id string) (domain.APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (domain.APIKey, error)
+	Create(ctx context.Context, key *domain.APIKey) (string, error)
+	// Revoke marca a chave como revogada em at; revogar de novo mantém a data original
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db     *mongo.Database
+	logger *slog.Logger
+}
+
+// NewAPIKeyRepository cria o APIKeyRepository sobre o MongoDB
+func NewAPIKeyRepository(db *mongo.Database, opts ...Option) APIKeyRepository {
+	o := newOptions(opts)
+	return &apiKeyRepository{db: db, logger: o.logger}
+}
+
+func startAPIKeySpan(ctx context.Context, system string, operation string) (context.Context, trace.Span) {
+	return startCollectionSpan(ctx, "APIKeyRepository", apiKeysCollection, system, operation)
+}
+
+// normalizeAPIKey aplica a precisão de milissegundos do BSON às datas, como creationTime
+func normalizeAPIKey(key *domain.APIKey) {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = creationTime()
+	}
+	key.CreatedAt = key.CreatedAt.UTC().Truncate(time.Millisecond)
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) (keys []domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "mongodb", "List")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	cursor, err := r.db.Collection(apiKeysCollection).Find(ctx, bson.M{},
+		mongooptions.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	keys = []domain.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id string) (key domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "mongodb", "GetByID")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return key, err
+	}
+	err = r.db.Collection(apiKeysCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return key, fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	return key, err
+}
+
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (key domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "mongodb", "FindByPrefix")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Coberto pelo índice único de prefix (ver MongoMigrations)
+	err = r.db.Collection(apiKeysCollection).FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return key, fmt.Errorf("api key %s: %w", prefix, domain.ErrNotFound)
+	}
+	return key, err
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) (_ string, err error) {
+	ctx, span := startAPIKeySpan(ctx, "mongodb", "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeAPIKey(key)
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	_, err = r.db.Collection(apiKeysCollection).InsertOne(ctx, key)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", fmt.Errorf("api key %s: %w", key.Prefix, domain.ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Chave de API inserida", slog.String("db", "mongodb"), slog.String("api_key_id", key.ID.Hex()))
+	return key.ID.Hex(), nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string, at time.Time) (err error) {
+	ctx, span := startAPIKeySpan(ctx, "mongodb", "Revoke")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	// $ifNull preserva a data de uma revogação anterior
+	result, err := r.db.Collection(apiKeysCollection).UpdateOne(ctx, bson.M{"_id": objectID}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"revoked_at": bson.M{"$ifNull": bson.A{"$revoked_at", at.UTC().Truncate(time.Millisecond)}}}}},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+const disputesCollection = "disputes"
+
+// DisputeRepository guarda as disputas dos pagamentos. Um pagamento tem no
+// máximo uma disputa aberta por vez.
+type DisputeRepository interface {
+	GetByID(ctx context.Context, id string) (domain.Dispute, error)
+	// FindByPaymentID devolve as disputas do pagamento, da mais recente para a mais antiga
+	FindByPaymentID(ctx context.Context, paymentID string) ([]domain.Dispute, error)
+	Create(ctx context.Context, dispute *domain.Dispute) (string, error)
+	Update(ctx context.Context, id string, dispute *domain.Dispute) error
+}
+
+type disputeRepository struct {
+	db     *mongo.Database
+	logger *slog.Logger
+}
+
+// NewDisputeRepository cria o DisputeRepository sobre o MongoDB
+func NewDisputeRepository(db *mongo.Database, opts ...Option) DisputeRepository {
+	o := newOptions(opts)
+	return &disputeRepository{db: db, logger: o.logger}
+}
+
+func startDisputeSpan(ctx context.Context, system string, operation string) (context.Context, trace.Span) {
+	return startCollectionSpan(ctx, "DisputeRepository", disputesCollection, system, operation)
+}
+
+// normalizeDispute aplica a precisão de milissegundos do BSON às datas, como creationTime
+func normalizeDispute(dispute *domain.Dispute) {
+	if dispute.CreatedAt.IsZero() {
+		dispute.CreatedAt = creationTime()
+	}
+	dispute.CreatedAt = dispute.CreatedAt.UTC().Truncate(time.Millisecond)
+	dispute.EvidenceDueBy = dispute.EvidenceDueBy.UTC().Truncate(time.Millisecond)
+	if dispute.ResolvedAt != nil {
+		resolvedAt := dispute.ResolvedAt.UTC().Truncate(time.Millisecond)
+		dispute.ResolvedAt = &resolvedAt
+	}
+	if dispute.Evidence == nil {
+		dispute.Evidence = []domain.EvidenceAttachment{}
+	}
+	for i := range dispute.Evidence {
+		dispute.Evidence[i].UploadedAt = dispute.Evidence[i].UploadedAt.UTC().Truncate(time.Millisecond)
+	}
+}
+
+// disputeConflict descreve a violação do índice de disputa aberta por pagamento
+func disputeConflict(dispute *domain.Dispute) error {
+	return fmt.Errorf("payment %s already has an open dispute: %w", dispute.PaymentID, domain.ErrConflict)
+}
+
+func (r *disputeRepository) GetByID(ctx context.Context, id string) (dispute domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "mongodb", "GetByID")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return dispute, err
+	}
+	err = r.db.Collection(disputesCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&dispute)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return dispute, fmt.Errorf("dispute %s: %w", id, domain.ErrNotFound)
+	}
+	return dispute, err
+}
+
+func (r *disputeRepository) FindByPaymentID(ctx context.Context, paymentID string) (disputes []domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "mongodb", "FindByPaymentID")
+	span.SetAttributes(telemetry.PaymentIDAttr(paymentID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	cursor, err := r.db.Collection(disputesCollection).Find(ctx, bson.M{"payment_id": paymentID},
+		mongooptions.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	disputes = []domain.Dispute{}
+	if err := cursor.All(ctx, &disputes); err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *domain.Dispute) (_ string, err error) {
+	ctx, span := startDisputeSpan(ctx, "mongodb", "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeDispute(dispute)
+	if dispute.ID.IsZero() {
+		dispute.ID = primitive.NewObjectID()
+	}
+	// Coberto pelo índice único parcial de disputa aberta (ver MongoMigrations)
+	_, err = r.db.Collection(disputesCollection).InsertOne(ctx, dispute)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", disputeConflict(dispute)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Disputa inserida", slog.String("db", "mongodb"), slog.String("dispute_id", dispute.ID.Hex()))
+	return dispute.ID.Hex(), nil
+}
+
+func (r *disputeRepository) Update(ctx context.Context, id string, dispute *domain.Dispute) (err error) {
+	ctx, span := startDisputeSpan(ctx, "mongodb", "Update")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	normalizeDispute(dispute)
+	// payment_id e created_at não mudam depois da abertura
+	update := bson.M{
+		"reason_code":     dispute.ReasonCode,
+		"amount":          dispute.Amount,
+		"status":          dispute.Status,
+		"evidence_due_by": dispute.EvidenceDueBy,
+		"evidence":        dispute.Evidence,
+		"resolved_at":     dispute.ResolvedAt,
+	}
+	result, err := r.db.Collection(disputesCollection).UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": update})
+	if mongo.IsDuplicateKeyError(err) {
+		return disputeConflict(dispute)
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("dispute %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+const feeRulesCollection = "fee_rules"
+
+// FeeRuleRepository guarda as versões das regras de tarifa. Duas versões do
+// mesmo tipo de pagamento não podem ter o mesmo effective_from.
+type FeeRuleRepository interface {
+	// List devolve as regras ordenadas por tipo de pagamento e vigência
+	List(ctx context.Context) ([]domain.FeeRule, error)
+	GetByID(ctx context.Context, id string) (domain.FeeRule, error)
+	// FindEffective devolve a versão em vigor em at, ou ErrNotFound se não houver
+	FindEffective(ctx context.Context, paymentType domain.PaymentType, at time.Time) (domain.FeeRule, error)
+	Create(ctx context.Context, rule *domain.FeeRule) (string, error)
+	Update(ctx context.Context, id string, rule *domain.FeeRule) error
+	Delete(ctx context.Context, id string) error
+}
+
+type feeRuleRepository struct {
+	db     *mongo.Database
+	logger *slog.Logger
+}
+
+// NewFeeRuleRepository cria o FeeRuleRepository sobre o MongoDB
+func NewFeeRuleRepository(db *mongo.Database, opts ...Option) FeeRuleRepository {
+	o := newOptions(opts)
+	return &feeRuleRepository{db: db, logger: o.logger}
+}
+
+func startFeeRuleSpan(ctx context.Context, system string, operation string) (context.Context, trace.Span) {
+	return startCollectionSpan(ctx, "FeeRuleRepository", feeRulesCollection, system, operation)
+}
+
+// normalizeFeeRule aplica a precisão de milissegundos do BSON à vigência, como creationTime
+func normalizeFeeRule(rule *domain.FeeRule) {
+	rule.EffectiveFrom = rule.EffectiveFrom.UTC().Truncate(time.Millisecond)
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = creationTime()
+	}
+}
+
+// feeRuleConflict descreve a violação do índice único de vigência
+func feeRuleConflict(rule *domain.FeeRule) error {
+	return fmt.Errorf("a %s fee rule already starts at %s: %w",
+		rule.PaymentType, rule.EffectiveFrom.Format(time.RFC3339), domain.ErrConflict)
+}
+
+func (r *feeRuleRepository) List(ctx context.Context) (rules []domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "mongodb", "List")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	cursor, err := r.db.Collection(feeRulesCollection).Find(ctx, bson.M{},
+		mongooptions.Find().SetSort(bson.D{{Key: "payment_type", Value: 1}, {Key: "effective_from", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	rules = []domain.FeeRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *feeRuleRepository) GetByID(ctx context.Context, id string) (rule domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "mongodb", "GetByID")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return rule, err
+	}
+	err = r.db.Collection(feeRulesCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return rule, fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	return rule, err
+}
+
+func (r *feeRuleRepository) FindEffective(ctx context.Context, paymentType domain.PaymentType, at time.Time) (rule domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "mongodb", "FindEffective")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Coberto pelo índice único payment_type+effective_from (ver MongoMigrations)
+	err = r.db.Collection(feeRulesCollection).FindOne(ctx,
+		bson.M{"payment_type": paymentType, "effective_from": bson.M{"$lte": at}},
+		mongooptions.FindOne().SetSort(bson.D{{Key: "effective_from", Value: -1}}),
+	).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return rule, fmt.Errorf("no %s fee rule in effect: %w", paymentType, domain.ErrNotFound)
+	}
+	return rule, err
+}
+
+func (r *feeRuleRepository) Create(ctx context.Context, rule *domain.FeeRule) (_ string, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "mongodb", "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeFeeRule(rule)
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	_, err = r.db.Collection(feeRulesCollection).InsertOne(ctx, rule)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", feeRuleConflict(rule)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Regra de tarifa inserida", slog.String("db", "mongodb"), slog.String("fee_rule_id", rule.ID.Hex()))
+	return rule.ID.Hex(), nil
+}
+
+func (r *feeRuleRepository) Update(ctx context.Context, id string, rule *domain.FeeRule) (err error) {
+	ctx, span := startFeeRuleSpan(ctx, "mongodb", "Update")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	normalizeFeeRule(rule)
+	// Campos explícitos: com omitempty, zerar min_fee/max_fee manteria o valor antigo.
+	// created_at fica de fora, preservando a data de criação.
+	update := bson.M{
+		"payment_type":   rule.PaymentType,
+		"fixed_amount":   rule.FixedAmount,
+		"percentage":     rule.Percentage,
+		"min_fee":        rule.MinFee,
+		"max_fee":        rule.MaxFee,
+		"effective_from": rule.EffectiveFrom,
+	}
+	result, err := r.db.Collection(feeRulesCollection).UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": update})
+	if mongo.IsDuplicateKeyError(err) {
+		return feeRuleConflict(rule)
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *feeRuleRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startFeeRuleSpan(ctx, "mongodb", "Delete")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Collection(feeRulesCollection).DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+)
+
+// memoryAPIKeyRepository guarda as chaves de API em memória, com a mesma
+// semântica dos demais backends
+type memoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[primitive.ObjectID]domain.APIKey
+}
+
+// NewMemoryAPIKeyRepository cria um APIKeyRepository em memória, seguro para uso concorrente
+func NewMemoryAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepository{keys: make(map[primitive.ObjectID]domain.APIKey)}
+}
+
+// cloneAPIKey evita que quem recebe a chave altere o slice de escopos guardado
+func cloneAPIKey(key domain.APIKey) domain.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return key
+}
+
+func (r *memoryAPIKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]domain.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, cloneAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID.Hex() < keys[j].ID.Hex()
+	})
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) GetByID(ctx context.Context, id string) (domain.APIKey, error) {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[objectID]
+	if !ok {
+		return domain.APIKey{}, fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	return cloneAPIKey(key), nil
+}
+
+func (r *memoryAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return domain.APIKey{}, fmt.Errorf("api key %s: %w", prefix, domain.ErrNotFound)
+}
+
+func (r *memoryAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	normalizeAPIKey(key)
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.keys[key.ID]; exists {
+		return "", fmt.Errorf("api key %s: %w", key.ID.Hex(), domain.ErrConflict)
+	}
+	// Replica o índice único de prefix
+	for _, existing := range r.keys {
+		if existing.Prefix == key.Prefix {
+			return "", fmt.Errorf("api key %s: %w", key.Prefix, domain.ErrConflict)
+		}
+	}
+	r.keys[key.ID] = cloneAPIKey(*key)
+	return key.ID.Hex(), nil
+}
+
+func (r *memoryAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[objectID]
+	if !ok {
+		return fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	if key.RevokedAt == nil {
+		revokedAt := at.UTC().Truncate(time.Millisecond)
+		key.RevokedAt = &revokedAt
+		r.keys[objectID] = key
+	}
+	return nil
+}
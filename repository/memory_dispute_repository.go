@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+)
+
+// memoryDisputeRepository guarda as disputas em memória, com a mesma semântica
+// dos demais backends
+type memoryDisputeRepository struct {
+	mu       sync.RWMutex
+	disputes map[primitive.ObjectID]domain.Dispute
+}
+
+// NewMemoryDisputeRepository cria um DisputeRepository em memória, seguro para uso concorrente
+func NewMemoryDisputeRepository() DisputeRepository {
+	return &memoryDisputeRepository{disputes: make(map[primitive.ObjectID]domain.Dispute)}
+}
+
+// cloneDispute copia os anexos, para que o chamador não altere o estado guardado
+func cloneDispute(dispute domain.Dispute) domain.Dispute {
+	dispute.Evidence = append([]domain.EvidenceAttachment{}, dispute.Evidence...)
+	return dispute
+}
+
+func (r *memoryDisputeRepository) GetByID(ctx context.Context, id string) (domain.Dispute, error) {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dispute, ok := r.disputes[objectID]
+	if !ok {
+		return domain.Dispute{}, fmt.Errorf("dispute %s: %w", id, domain.ErrNotFound)
+	}
+	return cloneDispute(dispute), nil
+}
+
+func (r *memoryDisputeRepository) FindByPaymentID(ctx context.Context, paymentID string) ([]domain.Dispute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	disputes := []domain.Dispute{}
+	for _, dispute := range r.disputes {
+		if dispute.PaymentID == paymentID {
+			disputes = append(disputes, cloneDispute(dispute))
+		}
+	}
+	sort.Slice(disputes, func(i, j int) bool {
+		if !disputes[i].CreatedAt.Equal(disputes[j].CreatedAt) {
+			return disputes[i].CreatedAt.After(disputes[j].CreatedAt)
+		}
+		return disputes[i].ID.Hex() > disputes[j].ID.Hex()
+	})
+	return disputes, nil
+}
+
+// hasOpenDispute replica o índice único parcial de disputa aberta. Exige o lock já adquirido.
+func (r *memoryDisputeRepository) hasOpenDispute(dispute domain.Dispute) bool {
+	if dispute.Status != domain.DisputeOpen {
+		return false
+	}
+	for id, existing := range r.disputes {
+		if id != dispute.ID && existing.PaymentID == dispute.PaymentID && existing.Status == domain.DisputeOpen {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryDisputeRepository) Create(ctx context.Context, dispute *domain.Dispute) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	normalizeDispute(dispute)
+	if dispute.ID.IsZero() {
+		dispute.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.disputes[dispute.ID]; exists {
+		return "", fmt.Errorf("dispute %s: %w", dispute.ID.Hex(), domain.ErrConflict)
+	}
+	if r.hasOpenDispute(*dispute) {
+		return "", disputeConflict(dispute)
+	}
+	r.disputes[dispute.ID] = cloneDispute(*dispute)
+	return dispute.ID.Hex(), nil
+}
+
+func (r *memoryDisputeRepository) Update(ctx context.Context, id string, dispute *domain.Dispute) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.disputes[objectID]
+	if !ok {
+		return fmt.Errorf("dispute %s: %w", id, domain.ErrNotFound)
+	}
+	normalizeDispute(dispute)
+	updated := cloneDispute(*dispute)
+	updated.ID = objectID
+	updated.PaymentID = existing.PaymentID
+	updated.CreatedAt = existing.CreatedAt
+	if r.hasOpenDispute(updated) {
+		return disputeConflict(&updated)
+	}
+	r.disputes[objectID] = updated
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+)
+
+// memoryFeeRuleRepository guarda as regras de tarifa em memória, com a mesma
+// semântica dos demais backends
+type memoryFeeRuleRepository struct {
+	mu    sync.RWMutex
+	rules map[primitive.ObjectID]domain.FeeRule
+}
+
+// NewMemoryFeeRuleRepository cria um FeeRuleRepository em memória, seguro para uso concorrente
+func NewMemoryFeeRuleRepository() FeeRuleRepository {
+	return &memoryFeeRuleRepository{rules: make(map[primitive.ObjectID]domain.FeeRule)}
+}
+
+func (r *memoryFeeRuleRepository) List(ctx context.Context) ([]domain.FeeRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]domain.FeeRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].PaymentType != rules[j].PaymentType {
+			return rules[i].PaymentType < rules[j].PaymentType
+		}
+		return rules[i].EffectiveFrom.Before(rules[j].EffectiveFrom)
+	})
+	return rules, nil
+}
+
+func (r *memoryFeeRuleRepository) GetByID(ctx context.Context, id string) (domain.FeeRule, error) {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return domain.FeeRule{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.rules[objectID]
+	if !ok {
+		return domain.FeeRule{}, fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	return rule, nil
+}
+
+func (r *memoryFeeRuleRepository) FindEffective(ctx context.Context, paymentType domain.PaymentType, at time.Time) (domain.FeeRule, error) {
+	rules, _ := r.List(ctx)
+	rule, ok := domain.EffectiveFeeRule(rules, paymentType, at)
+	if !ok {
+		return rule, fmt.Errorf("no %s fee rule in effect: %w", paymentType, domain.ErrNotFound)
+	}
+	return rule, nil
+}
+
+// hasVersionAt replica o índice único de vigência. Exige o lock já adquirido.
+func (r *memoryFeeRuleRepository) hasVersionAt(rule domain.FeeRule) bool {
+	for id, existing := range r.rules {
+		if id != rule.ID && existing.PaymentType == rule.PaymentType && existing.EffectiveFrom.Equal(rule.EffectiveFrom) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryFeeRuleRepository) Create(ctx context.Context, rule *domain.FeeRule) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	normalizeFeeRule(rule)
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.rules[rule.ID]; exists {
+		return "", fmt.Errorf("fee rule %s: %w", rule.ID.Hex(), domain.ErrConflict)
+	}
+	if r.hasVersionAt(*rule) {
+		return "", feeRuleConflict(rule)
+	}
+	r.rules[rule.ID] = *rule
+	return rule.ID.Hex(), nil
+}
+
+func (r *memoryFeeRuleRepository) Update(ctx context.Context, id string, rule *domain.FeeRule) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.rules[objectID]
+	if !ok {
+		return fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	normalizeFeeRule(rule)
+	updated := *rule
+	updated.ID = objectID
+	updated.CreatedAt = existing.CreatedAt
+	if r.hasVersionAt(updated) {
+		return feeRuleConflict(rule)
+	}
+	r.rules[objectID] = updated
+	return nil
+}
+
+func (r *memoryFeeRuleRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[objectID]; !ok {
+		return fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	delete(r.rules, objectID)
+	return nil
+}
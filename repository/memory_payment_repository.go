@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+)
+
+// memoryPaymentRepository guarda os pagamentos em memória com a mesma semântica
+// do repositório MongoDB (IDs ObjectID, erros de domínio e status inicial).
+// Útil para rodar o serviço localmente e nos testes BDD.
+type memoryPaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[primitive.ObjectID]domain.Payment
+	order    []primitive.ObjectID // Mantém a ordem de inserção, como a ordem natural do MongoDB
+}
+
+// NewMemoryPaymentRepository cria um PaymentRepository em memória, seguro para uso concorrente
+func NewMemoryPaymentRepository() PaymentRepository {
+	return &memoryPaymentRepository{
+		payments: make(map[primitive.ObjectID]domain.Payment),
+	}
+}
+
+func (r *memoryPaymentRepository) GetAll(ctx context.Context) ([]domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []domain.Payment
+	for _, id := range r.order {
+		payments = append(payments, r.payments[id])
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentRepository) GetByID(ctx context.Context, id string) (domain.Payment, error) {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.payments[objectID]
+	if !ok {
+		return domain.Payment{}, fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	return payment, nil
+}
+
+func (r *memoryPaymentRepository) FindByOrderID(ctx context.Context, orderID string) ([]domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payments := []domain.Payment{}
+	for _, id := range r.order {
+		if payment := r.payments[id]; payment.OrderId == orderID {
+			payments = append(payments, payment)
+		}
+	}
+	// Mesma ordenação dos demais backends: created_at e, no empate, o ID
+	sort.SliceStable(payments, func(i, j int) bool {
+		if !payments[i].CreatedAt.Equal(payments[j].CreatedAt) {
+			return payments[i].CreatedAt.After(payments[j].CreatedAt)
+		}
+		return payments[i].ID.Hex() > payments[j].ID.Hex()
+	})
+	return payments, nil
+}
+
+func (r *memoryPaymentRepository) FindByStatus(ctx context.Context, status string, createdBefore time.Time) ([]domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payments := []domain.Payment{}
+	for _, id := range r.order {
+		if payment := r.payments[id]; payment.Status == status && payment.CreatedAt.Before(createdBefore) {
+			payments = append(payments, payment)
+		}
+	}
+	sort.SliceStable(payments, func(i, j int) bool {
+		if !payments[i].CreatedAt.Equal(payments[j].CreatedAt) {
+			return payments[i].CreatedAt.Before(payments[j].CreatedAt)
+		}
+		return payments[i].ID.Hex() < payments[j].ID.Hex()
+	})
+	return payments, nil
+}
+
+// hasOtherActivePayment replica o índice único parcial dos bancos: ignora
+// pedidos vazios e o próprio pagamento. Exige o lock já adquirido.
+func (r *memoryPaymentRepository) hasOtherActivePayment(payment domain.Payment) bool {
+	if payment.OrderId == "" || !payment.IsActive() {
+		return false
+	}
+	for id, existing := range r.payments {
+		if id != payment.ID && existing.OrderId == payment.OrderId && existing.IsActive() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryPaymentRepository) Create(ctx context.Context, payment *domain.Payment) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment.Status = payment.InitialStatus()
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = creationTime()
+	}
+	// Assim como o driver do MongoDB, respeita um ID já preenchido
+	if payment.ID.IsZero() {
+		payment.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.payments[payment.ID]; exists {
+		return "", fmt.Errorf("payment %s: %w", payment.ID.Hex(), domain.ErrConflict)
+	}
+	if r.hasOtherActivePayment(*payment) {
+		return "", fmt.Errorf("order %s already has an active payment: %w", payment.OrderId, domain.ErrConflict)
+	}
+	r.payments[payment.ID] = *payment
+	r.order = append(r.order, payment.ID)
+	return payment.ID.Hex(), nil
+}
+
+func (r *memoryPaymentRepository) Update(ctx context.Context, id string, payment *domain.Payment) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.payments[objectID]
+	if !ok {
+		return fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	// Como nos outros backends, o pagamento substitui o salvo, preservando o _id e o created_at
+	updated := *payment
+	updated.ID = objectID
+	updated.CreatedAt = existing.CreatedAt
+	if r.hasOtherActivePayment(updated) {
+		return fmt.Errorf("order %s already has an active payment: %w", updated.OrderId, domain.ErrConflict)
+	}
+	r.payments[objectID] = updated
+	return nil
+}
+
+func (r *memoryPaymentRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.payments[objectID]; !ok {
+		return fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	delete(r.payments, objectID)
+	for i, existing := range r.order {
+		if existing == objectID {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+)
+
+// memoryWebhookRepository guarda inscrições e entregas em memória, com a mesma
+// semântica dos demais backends
+type memoryWebhookRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[primitive.ObjectID]domain.WebhookSubscription
+	deliveries    map[primitive.ObjectID]domain.WebhookDelivery
+}
+
+// NewMemoryWebhookRepository cria um WebhookRepository em memória, seguro para uso concorrente
+func NewMemoryWebhookRepository() WebhookRepository {
+	return &memoryWebhookRepository{
+		subscriptions: make(map[primitive.ObjectID]domain.WebhookSubscription),
+		deliveries:    make(map[primitive.ObjectID]domain.WebhookDelivery),
+	}
+}
+
+// cloneSubscription e cloneDelivery copiam as fatias, para que o chamador não altere o estado guardado
+func cloneSubscription(subscription domain.WebhookSubscription) domain.WebhookSubscription {
+	subscription.Events = append([]string{}, subscription.Events...)
+	return subscription
+}
+
+func cloneDelivery(delivery domain.WebhookDelivery) domain.WebhookDelivery {
+	delivery.Attempts = append([]domain.WebhookAttempt{}, delivery.Attempts...)
+	return delivery
+}
+
+func (r *memoryWebhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions := make([]domain.WebhookSubscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, cloneSubscription(subscription))
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if !subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+		}
+		return subscriptions[i].ID.Hex() < subscriptions[j].ID.Hex()
+	})
+	return subscriptions, nil
+}
+
+func (r *memoryWebhookRepository) GetSubscription(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, ok := r.subscriptions[objectID]
+	if !ok {
+		return domain.WebhookSubscription{}, fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	return cloneSubscription(subscription), nil
+}
+
+func (r *memoryWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	normalizeSubscription(subscription)
+	if subscription.ID.IsZero() {
+		subscription.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.subscriptions[subscription.ID]; exists {
+		return "", fmt.Errorf("webhook subscription %s: %w", subscription.ID.Hex(), domain.ErrConflict)
+	}
+	r.subscriptions[subscription.ID] = cloneSubscription(*subscription)
+	return subscription.ID.Hex(), nil
+}
+
+func (r *memoryWebhookRepository) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.subscriptions[objectID]
+	if !ok {
+		return fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	normalizeSubscription(subscription)
+	existing.URL = subscription.URL
+	existing.Events = subscription.Events
+	existing.Description = subscription.Description
+	existing.Active = subscription.Active
+	existing.UpdatedAt = subscription.UpdatedAt
+	r.subscriptions[objectID] = cloneSubscription(existing)
+	return nil
+}
+
+func (r *memoryWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[objectID]; !ok {
+		return fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	delete(r.subscriptions, objectID)
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetDelivery(ctx context.Context, id string) (domain.WebhookDelivery, error) {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	delivery, ok := r.deliveries[objectID]
+	if !ok {
+		return domain.WebhookDelivery{}, fmt.Errorf("webhook delivery %s: %w", id, domain.ErrNotFound)
+	}
+	return cloneDelivery(delivery), nil
+}
+
+func (r *memoryWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID.Hex() > deliveries[j].ID.Hex()
+	})
+	return deliveries[:min(limit, len(deliveries))], nil
+}
+
+func (r *memoryWebhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == domain.DeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].NextAttemptAt.Equal(*deliveries[j].NextAttemptAt) {
+			return deliveries[i].NextAttemptAt.Before(*deliveries[j].NextAttemptAt)
+		}
+		return deliveries[i].ID.Hex() < deliveries[j].ID.Hex()
+	})
+	return deliveries[:min(limit, len(deliveries))], nil
+}
+
+func (r *memoryWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	normalizeDelivery(delivery)
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.deliveries[delivery.ID]; exists {
+		return "", fmt.Errorf("webhook delivery %s: %w", delivery.ID.Hex(), domain.ErrConflict)
+	}
+	r.deliveries[delivery.ID] = cloneDelivery(*delivery)
+	return delivery.ID.Hex(), nil
+}
+
+func (r *memoryWebhookRepository) UpdateDelivery(ctx context.Context, id string, delivery *domain.WebhookDelivery) error {
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.deliveries[objectID]
+	if !ok {
+		return fmt.Errorf("webhook delivery %s: %w", id, domain.ErrNotFound)
+	}
+	normalizeDelivery(delivery)
+	existing.Status = delivery.Status
+	existing.Attempts = delivery.Attempts
+	existing.NextAttemptAt = delivery.NextAttemptAt
+	existing.UpdatedAt = delivery.UpdatedAt
+	r.deliveries[objectID] = cloneDelivery(existing)
+	return nil
+}
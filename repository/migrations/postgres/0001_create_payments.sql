@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id           CHAR(24) PRIMARY KEY,
+    order_id     TEXT NOT NULL DEFAULT '',
+    amount       NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    method       TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,
+    payment_type TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
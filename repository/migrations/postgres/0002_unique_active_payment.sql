@@ -0,0 +1,3 @@
+-- Um pedido só pode ter um pagamento ativo por vez (ver domain.Payment.IsActive)
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_active_order_id ON payments (order_id)
+    WHERE status = 'Em processamento' AND order_id <> '';
@@ -0,0 +1 @@
+ALTER TABLE payments ADD COLUMN refund_required BOOLEAN NOT NULL DEFAULT FALSE;
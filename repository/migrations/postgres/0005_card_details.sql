@@ -0,0 +1,5 @@
+-- Apenas o token do gateway e dados de exibição; o PAN nunca é persistido
+ALTER TABLE payments ADD COLUMN card_token TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD COLUMN card_brand TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD COLUMN card_last4 TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD COLUMN installments INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Cronograma de parcelas calculado na criação, em JSON
+ALTER TABLE payments ADD COLUMN installment_plan JSONB;
@@ -0,0 +1,3 @@
+-- Divisão entre recebedores (JSON) e total já estornado
+ALTER TABLE payments ADD COLUMN splits JSONB;
+ALTER TABLE payments ADD COLUMN refunded_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,19 @@
+-- Versões das tarifas do PSP por tipo de pagamento
+CREATE TABLE IF NOT EXISTS fee_rules (
+    id             CHAR(24) PRIMARY KEY,
+    payment_type   TEXT NOT NULL,
+    fixed_amount   NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    percentage     NUMERIC(7, 4) NOT NULL DEFAULT 0,
+    min_fee        NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    max_fee        NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_fee_rules_payment_type_effective_from ON fee_rules (payment_type, effective_from);
+
+-- Valores registrados na aprovação do pagamento
+ALTER TABLE payments ADD COLUMN gross_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN fee_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN fee_rule_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Moeda do pagamento e cotação usada na conversão para a moeda de liquidação
+ALTER TABLE payments ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE payments ADD COLUMN fx_rate JSONB;
+ALTER TABLE payments ADD COLUMN settlement_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- Disputas (chargebacks) dos pagamentos; anexos de evidência em JSON
+CREATE TABLE IF NOT EXISTS disputes (
+    id              CHAR(24) PRIMARY KEY,
+    payment_id      CHAR(24) NOT NULL,
+    reason_code     TEXT NOT NULL,
+    amount          NUMERIC(15, 2) NOT NULL,
+    status          TEXT NOT NULL,
+    evidence_due_by TIMESTAMPTZ NOT NULL,
+    evidence        JSONB NOT NULL DEFAULT '[]',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_disputes_payment_id ON disputes (payment_id, created_at);
+
+-- No máximo uma disputa aberta por pagamento
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_open_dispute_per_payment ON disputes (payment_id) WHERE status = 'open';
@@ -0,0 +1,28 @@
+-- Inscrições de webhook dos lojistas; eventos assinados em JSON
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          CHAR(24) PRIMARY KEY,
+    url         TEXT NOT NULL,
+    events      JSONB NOT NULL DEFAULT '[]',
+    description TEXT NOT NULL DEFAULT '',
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    secret      TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Log das entregas; cada tentativa fica em attempts
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              CHAR(24) PRIMARY KEY,
+    subscription_id CHAR(24) NOT NULL,
+    event_id        TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    attempts        JSONB NOT NULL DEFAULT '[]',
+    next_attempt_at TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
@@ -0,0 +1,12 @@
+-- Chaves de API dos clientes; só o hash SHA-256 da chave é guardado
+CREATE TABLE IF NOT EXISTS api_keys (
+    id         CHAR(24) PRIMARY KEY,
+    name       TEXT NOT NULL,
+    prefix     TEXT NOT NULL,
+    hash       TEXT NOT NULL,
+    scopes     JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id           TEXT PRIMARY KEY,
+    order_id     TEXT NOT NULL DEFAULT '',
+    amount       NUMERIC NOT NULL DEFAULT 0,
+    method       TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,
+    payment_type TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
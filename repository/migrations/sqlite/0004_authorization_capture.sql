@@ -0,0 +1,7 @@
+ALTER TABLE payments ADD COLUMN capture_method TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD COLUMN captured_amount NUMERIC NOT NULL DEFAULT 0;
+
+-- Pagamentos autorizados também ocupam a vaga de pagamento ativo do pedido
+DROP INDEX IF EXISTS uniq_active_order_id;
+CREATE UNIQUE INDEX uniq_active_order_id ON payments (order_id)
+    WHERE status IN ('Em processamento', 'Autorizado') AND order_id <> '';
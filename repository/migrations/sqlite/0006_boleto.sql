@@ -0,0 +1,2 @@
+-- Código de barras, linha digitável, vencimento e encargos do boleto, em JSON
+ALTER TABLE payments ADD COLUMN boleto TEXT;
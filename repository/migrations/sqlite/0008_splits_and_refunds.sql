@@ -0,0 +1,3 @@
+-- Divisão entre recebedores (JSON) e total já estornado
+ALTER TABLE payments ADD COLUMN splits TEXT;
+ALTER TABLE payments ADD COLUMN refunded_amount NUMERIC NOT NULL DEFAULT 0;
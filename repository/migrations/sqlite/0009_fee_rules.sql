@@ -0,0 +1,19 @@
+-- Versões das tarifas do PSP por tipo de pagamento
+CREATE TABLE IF NOT EXISTS fee_rules (
+    id             TEXT PRIMARY KEY,
+    payment_type   TEXT NOT NULL,
+    fixed_amount   NUMERIC NOT NULL DEFAULT 0,
+    percentage     NUMERIC NOT NULL DEFAULT 0,
+    min_fee        NUMERIC NOT NULL DEFAULT 0,
+    max_fee        NUMERIC NOT NULL DEFAULT 0,
+    effective_from TIMESTAMP NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_fee_rules_payment_type_effective_from ON fee_rules (payment_type, effective_from);
+
+-- Valores registrados na aprovação do pagamento
+ALTER TABLE payments ADD COLUMN gross_amount NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN fee_amount NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN net_amount NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN fee_rule_id TEXT NOT NULL DEFAULT '';
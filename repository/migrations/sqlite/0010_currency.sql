@@ -0,0 +1,4 @@
+-- Moeda do pagamento e cotação usada na conversão para a moeda de liquidação
+ALTER TABLE payments ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+ALTER TABLE payments ADD COLUMN fx_rate TEXT;
+ALTER TABLE payments ADD COLUMN settlement_amount NUMERIC NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- Disputas (chargebacks) dos pagamentos; anexos de evidência em JSON
+CREATE TABLE IF NOT EXISTS disputes (
+    id              TEXT PRIMARY KEY,
+    payment_id      TEXT NOT NULL,
+    reason_code     TEXT NOT NULL,
+    amount          NUMERIC NOT NULL,
+    status          TEXT NOT NULL,
+    evidence_due_by TIMESTAMP NOT NULL,
+    evidence        TEXT NOT NULL DEFAULT '[]',
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_disputes_payment_id ON disputes (payment_id, created_at);
+
+-- No máximo uma disputa aberta por pagamento
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_open_dispute_per_payment ON disputes (payment_id) WHERE status = 'open';
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+	"payments/domain"
+)
+
+const (
+	// mongoMigrationsCollection registra as versões já aplicadas, uma por documento
+	mongoMigrationsCollection = "schema_migrations"
+	// orderPaymentsIndex cobre FindByOrderID: filtra por order_id e já ordena as tentativas
+	orderPaymentsIndex = "order_id_1_created_at_-1"
+)
+
+// MongoMigration é uma etapa versionada do schema do MongoDB. O Up deve ser
+// idempotente: duas instâncias subindo juntas podem executá-lo ao mesmo tempo.
+type MongoMigration struct {
+	Version     string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+type appliedMigration struct {
+	Version     string    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// MongoMigrations lista as migrações do MongoDB em ordem de aplicação
+func MongoMigrations() []MongoMigration {
+	return []MongoMigration{
+		{
+			Version:     "0001_payments_indexes",
+			Description: "indexes on order_id and status+created_at",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(paymentsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: mongooptions.Index().SetName("order_id_1")},
+					{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}, Options: mongooptions.Index().SetName("status_1_created_at_-1")},
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0003_backfill_payments_created_at",
+			Description: "backfill created_at from the ObjectID timestamp",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(paymentsCollection).UpdateMany(ctx,
+					bson.M{"created_at": bson.M{"$exists": false}},
+					mongo.Pipeline{{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}}},
+				)
+				return err
+			},
+		},
+		{
+			Version:     "0004_payments_validator",
+			Description: "JSON schema validator on payments",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return applyValidator(ctx, db, paymentsCollection, paymentsSchema)
+			},
+		},
+		{
+			Version:     "0005_unique_active_payment_per_order",
+			Description: "at most one active payment per order",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(paymentsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "order_id", Value: 1}},
+					Options: mongooptions.Index().SetName(activePaymentIndex).SetUnique(true).
+						SetPartialFilterExpression(bson.M{
+							"status":   domain.StatusProcessing,
+							"order_id": bson.M{"$gt": ""},
+						}),
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0006_active_payment_includes_authorized",
+			Description: "authorized payments also count as the active payment of the order",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				indexes := db.Collection(paymentsCollection).Indexes()
+				var cmdErr mongo.CommandError
+				if _, err := indexes.DropOne(ctx, activePaymentIndex); err != nil &&
+					!(errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound") {
+					return err
+				}
+				// $in em partialFilterExpression exige MongoDB 6.0+, a versão mínima suportada
+				// (ver MigrateMongo). O índice só contém pagamentos ativos: não serve
+				// FindByOrderID, que lista todas as tentativas do pedido.
+				_, err := indexes.CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "order_id", Value: 1}},
+					Options: mongooptions.Index().SetName(activePaymentIndex).SetUnique(true).
+						SetPartialFilterExpression(bson.M{
+							"status":   bson.M{"$in": bson.A{domain.StatusProcessing, domain.StatusAuthorized}},
+							"order_id": bson.M{"$gt": ""},
+						}),
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0007_fee_rules_index",
+			Description: "one fee rule version per payment type and effective date",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(feeRulesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "payment_type", Value: 1}, {Key: "effective_from", Value: -1}},
+					Options: mongooptions.Index().SetName("uniq_payment_type_effective_from").SetUnique(true),
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0008_backfill_payments_currency",
+			Description: "payments created before multi-currency support are in BRL",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(paymentsCollection).UpdateMany(ctx,
+					bson.M{"currency": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"currency": domain.SettlementCurrency}},
+				)
+				return err
+			},
+		},
+		{
+			Version:     "0009_disputes_indexes",
+			Description: "disputes by payment and at most one open dispute per payment",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(disputesCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "payment_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: mongooptions.Index().SetName("payment_id_1_created_at_-1")},
+					{
+						Keys: bson.D{{Key: "payment_id", Value: 1}},
+						Options: mongooptions.Index().SetName("uniq_open_dispute_per_payment").SetUnique(true).
+							SetPartialFilterExpression(bson.M{"status": domain.DisputeOpen}),
+					},
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0010_webhook_deliveries_indexes",
+			Description: "webhook deliveries by subscription and due retries",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(webhookDeliveriesCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: mongooptions.Index().SetName("subscription_id_1_created_at_-1")},
+					{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}, Options: mongooptions.Index().SetName("status_1_next_attempt_at_1")},
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0011_api_keys_prefix_index",
+			Description: "unique api key prefix used to look keys up",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(apiKeysCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "prefix", Value: 1}},
+					Options: mongooptions.Index().SetName("uniq_prefix").SetUnique(true),
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0012_drop_idempotency_keys",
+			Description: "drop the idempotency_keys collection, which no request used",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				// Drop ignora coleções inexistentes
+				return db.Collection("idempotency_keys").Drop(ctx)
+			},
+		},
+		{
+			Version:     "0013_payments_order_id_created_at_index",
+			Description: "replace order_id_1 with an index that also sorts the attempts of the order",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				indexes := db.Collection(paymentsCollection).Indexes()
+				if _, err := indexes.CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: mongooptions.Index().SetName(orderPaymentsIndex),
+				}); err != nil {
+					return err
+				}
+				var cmdErr mongo.CommandError
+				if _, err := indexes.DropOne(ctx, "order_id_1"); err != nil &&
+					!(errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound") {
+					return err
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// paymentsSchema espelha os campos de domain.Payment gravados pelo repositório
+var paymentsSchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"status", "created_at"},
+	"properties": bson.M{
+		"order_id":     bson.M{"bsonType": "string"},
+		"amount":       bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}},
+		"method":       bson.M{"bsonType": "string"},
+		"status":       bson.M{"bsonType": "string"},
+		"payment_type": bson.M{"bsonType": "string"},
+		"created_at":   bson.M{"bsonType": "date"},
+	},
+}
+
+// applyValidator aplica o $jsonSchema com validationLevel moderate, para que
+// documentos antigos fora do schema não travem updates de outros campos
+func applyValidator(ctx context.Context, db *mongo.Database, collection string, schema bson.M) error {
+	err := db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceNotFound" {
+		// O collMod exige a coleção; em um banco novo ela é criada já com o validador
+		return db.CreateCollection(ctx, collection, mongooptions.CreateCollection().
+			SetValidator(bson.M{"$jsonSchema": schema}).
+			SetValidationLevel("moderate"))
+	}
+	return err
+}
+
+// MigrateMongo aplica, em ordem, as migrações ainda não registradas em
+// schema_migrations. As migrações exigem MongoDB 6.0 ou mais novo.
+func MigrateMongo(ctx context.Context, db *mongo.Database, opts ...Option) error {
+	o := newOptions(opts)
+	registry := db.Collection(mongoMigrationsCollection)
+
+	for _, migration := range MongoMigrations() {
+		err := registry.FindOne(ctx, bson.M{"_id": migration.Version}).Err()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error checking migration %s: %v", migration.Version, err)
+		}
+
+		if err := migration.Up(ctx, db); err != nil {
+			return fmt.Errorf("error applying migration %s: %v", migration.Version, err)
+		}
+		_, err = registry.InsertOne(ctx, appliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UTC(),
+		})
+		// Outra instância registrou a mesma versão primeiro
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("error recording migration %s: %v", migration.Version, err)
+		}
+		o.logger.InfoContext(ctx, "Migração aplicada", slog.String("db", "mongodb"), slog.String("version", migration.Version))
+	}
+	return nil
+}
+
+// AppliedMongoMigrations devolve as versões já registradas, em ordem
+func AppliedMongoMigrations(ctx context.Context, db *mongo.Database) ([]string, error) {
+	cursor, err := db.Collection(mongoMigrationsCollection).Find(ctx, bson.M{}, mongooptions.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var applied []appliedMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(applied))
+	for _, migration := range applied {
+		versions = append(versions, migration.Version)
+	}
+	return versions, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "log/slog"
+
+// options reúne as dependências opcionais compartilhadas pelos backends
+type options struct {
+	logger *slog.Logger
+}
+
+// Option permite customizar as dependências do repositório
+type Option func(*options)
+
+// WithLogger define o logger estruturado usado pelo repositório
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
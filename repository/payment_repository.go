@@ -2,38 +2,118 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"payments/domain"
+	"payments/telemetry"
 )
 
+const paymentsCollection = "payments"
+
+// activePaymentIndex é o índice único parcial que impede dois pagamentos ativos
+// para o mesmo pedido; o nome identifica a violação (ver conflictError)
+const activePaymentIndex = "uniq_active_order_id"
+
 type PaymentRepository interface {
-	GetAll() ([]domain.Payment, error)
-	GetByID(id string) (domain.Payment, error)
-	Create(payment *domain.Payment) (string, error) // Atualizando para incluir a assinatura correta
-	Update(id string, payment *domain.Payment) error
-	Delete(id string) error
+	GetAll(ctx context.Context) ([]domain.Payment, error)
+	GetByID(ctx context.Context, id string) (domain.Payment, error)
+	// FindByOrderID devolve as tentativas de pagamento do pedido, da mais recente para a mais antiga
+	FindByOrderID(ctx context.Context, orderID string) ([]domain.Payment, error)
+	// FindByStatus devolve os pagamentos no status criados antes de createdBefore, dos mais antigos para os mais novos
+	FindByStatus(ctx context.Context, status string, createdBefore time.Time) ([]domain.Payment, error)
+	Create(ctx context.Context, payment *domain.Payment) (string, error) // Atualizando para incluir a assinatura correta
+	Update(ctx context.Context, id string, payment *domain.Payment) error
+	Delete(ctx context.Context, id string) error
 }
 
 type paymentRepository struct {
-	db *mongo.Database
+	db     *mongo.Database
+	logger *slog.Logger
+}
+
+func NewPaymentRepository(db *mongo.Database, opts ...Option) PaymentRepository {
+	o := newOptions(opts)
+	return &paymentRepository{db: db, logger: o.logger}
+}
+
+// toObjectID converte o ID recebido na API para ObjectID
+func toObjectID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, fmt.Errorf("%w: invalid ObjectID format: %v", domain.ErrInvalidID, err)
+	}
+	return objectID, nil
+}
+
+// conflictError distingue a violação do pagamento ativo por pedido
+// (activePaymentIndex) da colisão de ID
+func conflictError(payment *domain.Payment, activePayment bool) error {
+	if activePayment {
+		return fmt.Errorf("order %s already has an active payment: %w", payment.OrderId, domain.ErrConflict)
+	}
+	return fmt.Errorf("payment %s: %w", payment.ID.Hex(), domain.ErrConflict)
+}
+
+// isActivePaymentViolation indica se a chave duplicada do MongoDB é a de
+// activePaymentIndex; o servidor informa o índice violado só na mensagem
+// ("E11000 duplicate key error collection: ... index: <nome> dup key: ...")
+func isActivePaymentViolation(err error) bool {
+	var writeErr mongo.WriteException
+	if !errors.As(err, &writeErr) {
+		return false
+	}
+	for _, e := range writeErr.WriteErrors {
+		if strings.Contains(e.Message, "index: "+activePaymentIndex+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// creationTime devolve o instante de criação na precisão de milissegundos do BSON,
+// para que todos os backends devolvam o mesmo valor que gravaram
+func creationTime() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// startSpan abre um span de cliente para a operação no banco de dados
+func startSpan(ctx context.Context, system string, operation string) (context.Context, trace.Span) {
+	return startCollectionSpan(ctx, "PaymentRepository", paymentsCollection, system, operation)
 }
 
-func NewPaymentRepository(db *mongo.Database) PaymentRepository {
-	return &paymentRepository{db}
+// startCollectionSpan abre o span de cliente de uma operação do repositório sobre a coleção
+func startCollectionSpan(ctx context.Context, repository, collection, system, operation string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, repository+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", system),
+			attribute.String("db.collection.name", collection),
+			attribute.String("db.operation.name", operation),
+		),
+	)
 }
 
-func (r *paymentRepository) GetAll() ([]domain.Payment, error) {
-	var payments []domain.Payment
-	cursor, err := r.db.Collection("payments").Find(context.Background(), bson.M{})
+func (r *paymentRepository) GetAll(ctx context.Context) (payments []domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "mongodb", "GetAll")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	cursor, err := r.db.Collection(paymentsCollection).Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		var payment domain.Payment
 		if err := cursor.Decode(&payment); err != nil {
 			return nil, err
@@ -43,21 +123,72 @@ func (r *paymentRepository) GetAll() ([]domain.Payment, error) {
 	return payments, nil
 }
 
-func (r *paymentRepository) GetByID(id string) (domain.Payment, error) {
-	var payment domain.Payment
+func (r *paymentRepository) GetByID(ctx context.Context, id string) (payment domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "mongodb", "GetByID")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
 
 	// Convertendo a string para ObjectID
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := toObjectID(id)
 	if err != nil {
-		return payment, fmt.Errorf("invalid ObjectID format: %v", err)
+		return payment, err
+	}
+	err = r.db.Collection(paymentsCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&payment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return payment, fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
 	}
-	err = r.db.Collection("payments").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&payment)
 	return payment, err
 }
 
-func (r *paymentRepository) Create(payment *domain.Payment) (string, error) {
-	payment.Status = "Em processamento"
-	result, err := r.db.Collection("payments").InsertOne(context.Background(), payment)
+func (r *paymentRepository) FindByOrderID(ctx context.Context, orderID string) (payments []domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "mongodb", "FindByOrderID")
+	span.SetAttributes(telemetry.OrderIDAttr(orderID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Coberto pelo índice order_id_1_created_at_-1 (ver MongoMigrations)
+	cursor, err := r.db.Collection(paymentsCollection).Find(ctx, bson.M{"order_id": orderID},
+		mongooptions.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	payments = []domain.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *paymentRepository) FindByStatus(ctx context.Context, status string, createdBefore time.Time) (payments []domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "mongodb", "FindByStatus")
+	span.SetAttributes(attribute.String("payment.status", status))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Coberto pelo índice status_1_created_at_-1 (ver MongoMigrations)
+	cursor, err := r.db.Collection(paymentsCollection).Find(ctx,
+		bson.M{"status": status, "created_at": bson.M{"$lt": createdBefore}},
+		mongooptions.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	payments = []domain.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment) (_ string, err error) {
+	ctx, span := startSpan(ctx, "mongodb", "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment.Status = payment.InitialStatus()
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = creationTime()
+	}
+	result, err := r.db.Collection(paymentsCollection).InsertOne(ctx, payment)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", conflictError(payment, isActivePaymentViolation(err))
+	}
 	if err != nil {
 		return "", err
 	}
@@ -65,30 +196,67 @@ func (r *paymentRepository) Create(payment *domain.Payment) (string, error) {
 	if !ok {
 		return "", fmt.Errorf("failed to convert InsertedID to ObjectID")
 	}
+	span.SetAttributes(telemetry.PaymentIDAttr(id.Hex()))
+	r.logger.DebugContext(ctx, "Pagamento inserido no MongoDB", slog.String("payment_id", id.Hex()))
 	return id.Hex(), nil // Retorna o UUID gerado
 }
 
-func (r *paymentRepository) Update(id string, payment *domain.Payment) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (r *paymentRepository) Update(ctx context.Context, id string, payment *domain.Payment) (err error) {
+	ctx, span := startSpan(ctx, "mongodb", "Update")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
 	if err != nil {
-		return fmt.Errorf("invalid ObjectID format: %v", err)
+		return err
 	}
-	_, err = r.db.Collection("payments").UpdateOne(
-		context.Background(),
-		bson.M{"_id": objectID}, // Usando ObjectID em vez de string
-		bson.M{"$set": payment},
-	)
-	return err
+	// Substitui o documento inteiro, como os outros backends: um $set da struct
+	// nunca apagaria os campos omitempty que ficaram vazios (splits, boleto...).
+	// O _id e o created_at vêm do documento salvo, na mesma operação; $literal
+	// impede que valores iniciados por "$" sejam lidos como expressões.
+	update := *payment
+	update.ID = primitive.NilObjectID
+	update.CreatedAt = time.Time{}
+	result, err := r.db.Collection(paymentsCollection).UpdateOne(ctx, bson.M{"_id": objectID}, mongo.Pipeline{
+		{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+			bson.M{"$literal": update},
+			bson.M{"_id": "$_id", "created_at": "$created_at"},
+		}}}},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return conflictError(payment, isActivePaymentViolation(err))
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	r.logger.DebugContext(ctx, "Pagamento atualizado no MongoDB",
+		slog.String("payment_id", id), slog.Int64("modified", result.ModifiedCount))
+	return nil
 }
 
-func (r *paymentRepository) Delete(id string) error {
+func (r *paymentRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "mongodb", "Delete")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
 	// Convertendo a string para ObjectID
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := toObjectID(id)
 	if err != nil {
-		return fmt.Errorf("invalid ObjectID format: %v", err)
+		return err
 	}
 
 	// Deletando o documento com o ObjectID convertido
-	_, err = r.db.Collection("payments").DeleteOne(context.Background(), bson.M{"_id": objectID})
-	return err
+	result, err := r.db.Collection(paymentsCollection).DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	r.logger.DebugContext(ctx, "Pagamento removido do MongoDB",
+		slog.String("payment_id", id), slog.Int64("deleted", result.DeletedCount))
+	return nil
 }
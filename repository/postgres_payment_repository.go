@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // Registra o driver "pgx" no database/sql
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+var postgresDialect = sqlDialect{
+	system:         "postgresql",
+	numberedParams: true,
+	isUniqueViolation: func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	},
+	isActivePaymentViolation: func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.ConstraintName == activePaymentIndex
+	},
+}
+
+// OpenPostgres conecta ao PostgreSQL e aplica as migrações pendentes
+func OpenPostgres(ctx context.Context, dsn string, opts ...Option) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to PostgreSQL: %v", err)
+	}
+	o := newOptions(opts)
+	if err := applySQLMigrations(ctx, db, postgresDialect, postgresMigrations, "migrations/postgres", o.logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewPostgresPaymentRepository cria o PaymentRepository sobre um banco PostgreSQL já migrado
+func NewPostgresPaymentRepository(db *sql.DB, opts ...Option) PaymentRepository {
+	o := newOptions(opts)
+	return &sqlPaymentRepository{db: db, dialect: postgresDialect, logger: o.logger}
+}
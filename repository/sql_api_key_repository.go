@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// sqlAPIKeyRepository implementa o APIKeyRepository sobre database/sql
+type sqlAPIKeyRepository struct {
+	db      *sql.DB
+	dialect sqlDialect
+	logger  *slog.Logger
+}
+
+const apiKeyColumns = "id, name, prefix, hash, scopes, created_at, revoked_at"
+
+// NewPostgresAPIKeyRepository cria o APIKeyRepository sobre um banco PostgreSQL já migrado
+func NewPostgresAPIKeyRepository(db *sql.DB, opts ...Option) APIKeyRepository {
+	o := newOptions(opts)
+	return &sqlAPIKeyRepository{db: db, dialect: postgresDialect, logger: o.logger}
+}
+
+// NewSQLiteAPIKeyRepository cria o APIKeyRepository sobre um banco SQLite já migrado
+func NewSQLiteAPIKeyRepository(db *sql.DB, opts ...Option) APIKeyRepository {
+	o := newOptions(opts)
+	return &sqlAPIKeyRepository{db: db, dialect: sqliteDialect, logger: o.logger}
+}
+
+func (r *sqlAPIKeyRepository) List(ctx context.Context) (keys []domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, r.dialect.system, "List")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys = []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *sqlAPIKeyRepository) GetByID(ctx context.Context, id string) (key domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, r.dialect.system, "GetByID")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return key, err
+	}
+	key, err = scanAPIKey(r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	return key, err
+}
+
+func (r *sqlAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (key domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, r.dialect.system, "FindByPrefix")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	key, err = scanAPIKey(r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = ?"), prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, fmt.Errorf("api key %s: %w", prefix, domain.ErrNotFound)
+	}
+	return key, err
+}
+
+func (r *sqlAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) (_ string, err error) {
+	ctx, span := startAPIKeySpan(ctx, r.dialect.system, "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeAPIKey(key)
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind("INSERT INTO api_keys ("+apiKeyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		key.ID.Hex(), key.Name, key.Prefix, key.Hash, string(scopes), key.CreatedAt, nullTime(key.RevokedAt))
+	if r.dialect.isUniqueViolation(err) {
+		return "", fmt.Errorf("api key %s: %w", key.Prefix, domain.ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Chave de API inserida", slog.String("db", r.dialect.system), slog.String("api_key_id", key.ID.Hex()))
+	return key.ID.Hex(), nil
+}
+
+func (r *sqlAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) (err error) {
+	ctx, span := startAPIKeySpan(ctx, r.dialect.system, "Revoke")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?"),
+		at.UTC().Truncate(time.Millisecond), id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result, "api key", id)
+}
+
+func scanAPIKey(row rowScanner) (domain.APIKey, error) {
+	var (
+		key       domain.APIKey
+		id        string
+		scopes    []byte
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&id, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+		return key, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return key, fmt.Errorf("corrupted api key id %q: %v", id, err)
+	}
+	key.ID = objectID
+	key.CreatedAt = key.CreatedAt.UTC()
+	if revokedAt.Valid {
+		t := revokedAt.Time.UTC()
+		key.RevokedAt = &t
+	}
+	key.Scopes = []string{}
+	if len(scopes) > 0 {
+		if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+			return key, fmt.Errorf("corrupted scopes of api key %q: %v", id, err)
+		}
+	}
+	return key, nil
+}
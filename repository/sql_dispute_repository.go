@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// sqlDisputeRepository implementa o DisputeRepository sobre database/sql
+type sqlDisputeRepository struct {
+	db      *sql.DB
+	dialect sqlDialect
+	logger  *slog.Logger
+}
+
+const disputeColumns = "id, payment_id, reason_code, amount, status, evidence_due_by, evidence, created_at, resolved_at"
+
+// NewPostgresDisputeRepository cria o DisputeRepository sobre um banco PostgreSQL já migrado
+func NewPostgresDisputeRepository(db *sql.DB, opts ...Option) DisputeRepository {
+	o := newOptions(opts)
+	return &sqlDisputeRepository{db: db, dialect: postgresDialect, logger: o.logger}
+}
+
+// NewSQLiteDisputeRepository cria o DisputeRepository sobre um banco SQLite já migrado
+func NewSQLiteDisputeRepository(db *sql.DB, opts ...Option) DisputeRepository {
+	o := newOptions(opts)
+	return &sqlDisputeRepository{db: db, dialect: sqliteDialect, logger: o.logger}
+}
+
+func (r *sqlDisputeRepository) GetByID(ctx context.Context, id string) (dispute domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, r.dialect.system, "GetByID")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return dispute, err
+	}
+	dispute, err = scanDispute(r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+disputeColumns+" FROM disputes WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return dispute, fmt.Errorf("dispute %s: %w", id, domain.ErrNotFound)
+	}
+	return dispute, err
+}
+
+func (r *sqlDisputeRepository) FindByPaymentID(ctx context.Context, paymentID string) (disputes []domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, r.dialect.system, "FindByPaymentID")
+	span.SetAttributes(telemetry.PaymentIDAttr(paymentID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(
+		"SELECT "+disputeColumns+" FROM disputes WHERE payment_id = ? ORDER BY created_at DESC, id DESC"), paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disputes = []domain.Dispute{}
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, dispute)
+	}
+	return disputes, rows.Err()
+}
+
+func (r *sqlDisputeRepository) Create(ctx context.Context, dispute *domain.Dispute) (_ string, err error) {
+	ctx, span := startDisputeSpan(ctx, r.dialect.system, "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeDispute(dispute)
+	if dispute.ID.IsZero() {
+		dispute.ID = primitive.NewObjectID()
+	}
+	evidence, err := json.Marshal(dispute.Evidence)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind("INSERT INTO disputes ("+disputeColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		dispute.ID.Hex(), dispute.PaymentID, dispute.ReasonCode, dispute.Amount, dispute.Status,
+		dispute.EvidenceDueBy, string(evidence), dispute.CreatedAt, nullTime(dispute.ResolvedAt))
+	if r.dialect.isUniqueViolation(err) {
+		return "", disputeConflict(dispute)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Disputa inserida", slog.String("db", r.dialect.system), slog.String("dispute_id", dispute.ID.Hex()))
+	return dispute.ID.Hex(), nil
+}
+
+func (r *sqlDisputeRepository) Update(ctx context.Context, id string, dispute *domain.Dispute) (err error) {
+	ctx, span := startDisputeSpan(ctx, r.dialect.system, "Update")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	normalizeDispute(dispute)
+	evidence, err := json.Marshal(dispute.Evidence)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		"UPDATE disputes SET reason_code = ?, amount = ?, status = ?, evidence_due_by = ?, evidence = ?, resolved_at = ? WHERE id = ?"),
+		dispute.ReasonCode, dispute.Amount, dispute.Status, dispute.EvidenceDueBy, string(evidence), nullTime(dispute.ResolvedAt), id)
+	if r.dialect.isUniqueViolation(err) {
+		return disputeConflict(dispute)
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("dispute %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// nullTime grava NULL para datas opcionais ainda não preenchidas
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func scanDispute(row rowScanner) (domain.Dispute, error) {
+	var (
+		dispute    domain.Dispute
+		id         string
+		evidence   []byte
+		resolvedAt sql.NullTime
+	)
+	if err := row.Scan(&id, &dispute.PaymentID, &dispute.ReasonCode, &dispute.Amount, &dispute.Status,
+		&dispute.EvidenceDueBy, &evidence, &dispute.CreatedAt, &resolvedAt); err != nil {
+		return dispute, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return dispute, fmt.Errorf("corrupted dispute id %q: %v", id, err)
+	}
+	dispute.ID = objectID
+	dispute.EvidenceDueBy = dispute.EvidenceDueBy.UTC()
+	dispute.CreatedAt = dispute.CreatedAt.UTC()
+	if resolvedAt.Valid {
+		t := resolvedAt.Time.UTC()
+		dispute.ResolvedAt = &t
+	}
+	dispute.Evidence = []domain.EvidenceAttachment{}
+	if len(evidence) > 0 {
+		if err := json.Unmarshal(evidence, &dispute.Evidence); err != nil {
+			return dispute, fmt.Errorf("corrupted evidence of dispute %q: %v", id, err)
+		}
+	}
+	return dispute, nil
+}
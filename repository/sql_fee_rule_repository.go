@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// sqlFeeRuleRepository implementa o FeeRuleRepository sobre database/sql
+type sqlFeeRuleRepository struct {
+	db      *sql.DB
+	dialect sqlDialect
+	logger  *slog.Logger
+}
+
+const feeRuleColumns = "id, payment_type, fixed_amount, percentage, min_fee, max_fee, effective_from, created_at"
+
+// NewPostgresFeeRuleRepository cria o FeeRuleRepository sobre um banco PostgreSQL já migrado
+func NewPostgresFeeRuleRepository(db *sql.DB, opts ...Option) FeeRuleRepository {
+	o := newOptions(opts)
+	return &sqlFeeRuleRepository{db: db, dialect: postgresDialect, logger: o.logger}
+}
+
+// NewSQLiteFeeRuleRepository cria o FeeRuleRepository sobre um banco SQLite já migrado
+func NewSQLiteFeeRuleRepository(db *sql.DB, opts ...Option) FeeRuleRepository {
+	o := newOptions(opts)
+	return &sqlFeeRuleRepository{db: db, dialect: sqliteDialect, logger: o.logger}
+}
+
+func (r *sqlFeeRuleRepository) List(ctx context.Context) (rules []domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, r.dialect.system, "List")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+feeRuleColumns+" FROM fee_rules ORDER BY payment_type, effective_from")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules = []domain.FeeRule{}
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *sqlFeeRuleRepository) GetByID(ctx context.Context, id string) (rule domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, r.dialect.system, "GetByID")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return rule, err
+	}
+	rule, err = scanFeeRule(r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+feeRuleColumns+" FROM fee_rules WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return rule, fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	return rule, err
+}
+
+func (r *sqlFeeRuleRepository) FindEffective(ctx context.Context, paymentType domain.PaymentType, at time.Time) (rule domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, r.dialect.system, "FindEffective")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	rule, err = scanFeeRule(r.db.QueryRowContext(ctx, r.dialect.rebind(
+		"SELECT "+feeRuleColumns+" FROM fee_rules WHERE payment_type = ? AND effective_from <= ? ORDER BY effective_from DESC LIMIT 1"),
+		string(paymentType), at.UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return rule, fmt.Errorf("no %s fee rule in effect: %w", paymentType, domain.ErrNotFound)
+	}
+	return rule, err
+}
+
+func (r *sqlFeeRuleRepository) Create(ctx context.Context, rule *domain.FeeRule) (_ string, err error) {
+	ctx, span := startFeeRuleSpan(ctx, r.dialect.system, "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeFeeRule(rule)
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind("INSERT INTO fee_rules ("+feeRuleColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		rule.ID.Hex(), string(rule.PaymentType), rule.FixedAmount, rule.Percentage, rule.MinFee, rule.MaxFee, rule.EffectiveFrom, rule.CreatedAt)
+	if r.dialect.isUniqueViolation(err) {
+		return "", feeRuleConflict(rule)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Regra de tarifa inserida", slog.String("db", r.dialect.system), slog.String("fee_rule_id", rule.ID.Hex()))
+	return rule.ID.Hex(), nil
+}
+
+func (r *sqlFeeRuleRepository) Update(ctx context.Context, id string, rule *domain.FeeRule) (err error) {
+	ctx, span := startFeeRuleSpan(ctx, r.dialect.system, "Update")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	normalizeFeeRule(rule)
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		"UPDATE fee_rules SET payment_type = ?, fixed_amount = ?, percentage = ?, min_fee = ?, max_fee = ?, effective_from = ? WHERE id = ?"),
+		string(rule.PaymentType), rule.FixedAmount, rule.Percentage, rule.MinFee, rule.MaxFee, rule.EffectiveFrom, id)
+	if r.dialect.isUniqueViolation(err) {
+		return feeRuleConflict(rule)
+	}
+	if err != nil {
+		return err
+	}
+	return expectFeeRuleAffected(result, id)
+}
+
+func (r *sqlFeeRuleRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startFeeRuleSpan(ctx, r.dialect.system, "Delete")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM fee_rules WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	return expectFeeRuleAffected(result, id)
+}
+
+// expectFeeRuleAffected converte "nenhuma linha afetada" em ErrNotFound
+func expectFeeRuleAffected(result sql.Result, id string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("fee rule %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func scanFeeRule(row rowScanner) (domain.FeeRule, error) {
+	var (
+		rule        domain.FeeRule
+		id          string
+		paymentType string
+	)
+	if err := row.Scan(&id, &paymentType, &rule.FixedAmount, &rule.Percentage, &rule.MinFee, &rule.MaxFee,
+		&rule.EffectiveFrom, &rule.CreatedAt); err != nil {
+		return rule, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return rule, fmt.Errorf("corrupted fee rule id %q: %v", id, err)
+	}
+	rule.ID = objectID
+	rule.PaymentType = domain.PaymentType(paymentType)
+	rule.EffectiveFrom = rule.EffectiveFrom.UTC()
+	rule.CreatedAt = rule.CreatedAt.UTC()
+	return rule, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// applySQLMigrations executa, em ordem de nome, os arquivos .sql de dir ainda não
+// registrados na tabela schema_migrations. Cada arquivo roda em uma transação.
+func applySQLMigrations(ctx context.Context, db *sql.DB, dialect sqlDialect, migrations fs.FS, dir string, logger *slog.Logger) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %v", err)
+	}
+
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return fmt.Errorf("error reading migrations: %v", err)
+	}
+	var versions []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Strings(versions)
+
+	for _, version := range versions {
+		var applied int
+		err := db.QueryRowContext(ctx, dialect.rebind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("error checking migration %s: %v", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := fs.ReadFile(migrations, dir+"/"+version)
+		if err != nil {
+			return fmt.Errorf("error reading migration %s: %v", version, err)
+		}
+		if err := applySQLMigration(ctx, db, dialect, version, string(script)); err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "Migração aplicada", slog.String("db", dialect.system), slog.String("version", version))
+	}
+	return nil
+}
+
+func applySQLMigration(ctx context.Context, db *sql.DB, dialect sqlDialect, version string, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("error applying migration %s: %v", version, err)
+	}
+	if _, err := tx.ExecContext(ctx, dialect.rebind("INSERT INTO schema_migrations (version) VALUES (?)"), version); err != nil {
+		return fmt.Errorf("error recording migration %s: %v", version, err)
+	}
+	return tx.Commit()
+}
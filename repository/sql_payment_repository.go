@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// sqlDialect isola as diferenças entre os bancos SQL suportados
+type sqlDialect struct {
+	system string // Valor do atributo db.system nos spans
+	// numberedParams indica placeholders $1, $2... em vez de ?
+	numberedParams bool
+	// isUniqueViolation identifica violações de chave única do driver
+	isUniqueViolation func(err error) bool
+	// isActivePaymentViolation identifica, entre elas, a de activePaymentIndex
+	isActivePaymentViolation func(err error) bool
+}
+
+// rebind converte os placeholders ? para o formato do dialeto
+func (d sqlDialect) rebind(query string) string {
+	if !d.numberedParams {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlPaymentRepository implementa o PaymentRepository sobre database/sql,
+// com a mesma semântica do repositório MongoDB
+type sqlPaymentRepository struct {
+	db      *sql.DB
+	dialect sqlDialect
+	logger  *slog.Logger
+}
+
+const paymentColumns = "id, order_id, amount, method, status, payment_type, capture_method, captured_amount, refund_required, " +
+	"card_token, card_brand, card_last4, installments, installment_plan, boleto, splits, refunded_amount, gross_amount, fee_amount, net_amount, fee_rule_id, " +
+	"currency, fx_rate, settlement_amount, created_at"
+
+func (r *sqlPaymentRepository) GetAll(ctx context.Context) (payments []domain.Payment, err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "GetAll")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+paymentColumns+" FROM payments ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
+func (r *sqlPaymentRepository) GetByID(ctx context.Context, id string) (payment domain.Payment, err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "GetByID")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return payment, err
+	}
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+paymentColumns+" FROM payments WHERE id = ?"), id)
+	payment, err = scanPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return payment, fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	return payment, err
+}
+
+func (r *sqlPaymentRepository) FindByOrderID(ctx context.Context, orderID string) (payments []domain.Payment, err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "FindByOrderID")
+	span.SetAttributes(telemetry.OrderIDAttr(orderID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return r.query(ctx, "SELECT "+paymentColumns+" FROM payments WHERE order_id = ? ORDER BY created_at DESC, id DESC", orderID)
+}
+
+func (r *sqlPaymentRepository) FindByStatus(ctx context.Context, status string, createdBefore time.Time) (payments []domain.Payment, err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "FindByStatus")
+	span.SetAttributes(attribute.String("payment.status", status))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return r.query(ctx, "SELECT "+paymentColumns+" FROM payments WHERE status = ? AND created_at < ? ORDER BY created_at, id",
+		status, createdBefore.UTC())
+}
+
+func (r *sqlPaymentRepository) Create(ctx context.Context, payment *domain.Payment) (_ string, err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "Create")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment.Status = payment.InitialStatus()
+	// Gera o ID no mesmo formato do MongoDB, respeitando um ID já preenchido
+	if payment.ID.IsZero() {
+		payment.ID = primitive.NewObjectID()
+	}
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = creationTime()
+	}
+	card := cardColumns(payment)
+	plan, err := jsonColumn(payment.InstallmentPlan)
+	if err != nil {
+		return "", err
+	}
+	boleto, err := jsonColumn(payment.Boleto)
+	if err != nil {
+		return "", err
+	}
+	splits, err := jsonColumn(splitsOrNil(payment))
+	if err != nil {
+		return "", err
+	}
+	fxRate, err := jsonColumn(payment.FXRate)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		"INSERT INTO payments ("+paymentColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		payment.ID.Hex(), payment.OrderId, payment.Amount, payment.Method, payment.Status, string(payment.PaymentType),
+		payment.CaptureMethod, payment.CapturedAmount, payment.RefundRequired,
+		card.Token, card.Brand, card.Last4, payment.Installments, plan, boleto, splits, payment.RefundedAmount,
+		payment.GrossAmount, payment.FeeAmount, payment.NetAmount, payment.FeeRuleID,
+		payment.CurrencyCode(), fxRate, payment.SettlementAmount, payment.CreatedAt,
+	)
+	if r.dialect.isUniqueViolation(err) {
+		return "", conflictError(payment, r.dialect.isActivePaymentViolation(err))
+	}
+	if err != nil {
+		return "", err
+	}
+	span.SetAttributes(telemetry.PaymentIDAttr(payment.ID.Hex()))
+	r.logger.DebugContext(ctx, "Pagamento inserido", slog.String("db", r.dialect.system), slog.String("payment_id", payment.ID.Hex()))
+	return payment.ID.Hex(), nil
+}
+
+func (r *sqlPaymentRepository) Update(ctx context.Context, id string, payment *domain.Payment) (err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "Update")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	card := cardColumns(payment)
+	plan, err := jsonColumn(payment.InstallmentPlan)
+	if err != nil {
+		return err
+	}
+	boleto, err := jsonColumn(payment.Boleto)
+	if err != nil {
+		return err
+	}
+	splits, err := jsonColumn(splitsOrNil(payment))
+	if err != nil {
+		return err
+	}
+	fxRate, err := jsonColumn(payment.FXRate)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		"UPDATE payments SET order_id = ?, amount = ?, method = ?, status = ?, payment_type = ?, capture_method = ?, captured_amount = ?, "+
+			"refund_required = ?, card_token = ?, card_brand = ?, card_last4 = ?, installments = ?, installment_plan = ?, boleto = ?, splits = ?, refunded_amount = ?, "+
+			"gross_amount = ?, fee_amount = ?, net_amount = ?, fee_rule_id = ?, currency = ?, fx_rate = ?, settlement_amount = ? WHERE id = ?"),
+		payment.OrderId, payment.Amount, payment.Method, payment.Status, string(payment.PaymentType),
+		payment.CaptureMethod, payment.CapturedAmount, payment.RefundRequired,
+		card.Token, card.Brand, card.Last4, payment.Installments, plan, boleto, splits, payment.RefundedAmount,
+		payment.GrossAmount, payment.FeeAmount, payment.NetAmount, payment.FeeRuleID,
+		payment.CurrencyCode(), fxRate, payment.SettlementAmount, id,
+	)
+	if r.dialect.isUniqueViolation(err) {
+		return conflictError(payment, r.dialect.isActivePaymentViolation(err))
+	}
+	if err != nil {
+		return err
+	}
+	return r.expectAffected(ctx, result, id, "Pagamento atualizado")
+}
+
+func (r *sqlPaymentRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, r.dialect.system, "Delete")
+	span.SetAttributes(telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM payments WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	return r.expectAffected(ctx, result, id, "Pagamento removido")
+}
+
+// query executa um SELECT de pagamentos, devolvendo uma lista vazia (não nil) sem resultados
+func (r *sqlPaymentRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.Payment, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []domain.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
+// expectAffected converte "nenhuma linha afetada" em ErrNotFound
+func (r *sqlPaymentRepository) expectAffected(ctx context.Context, result sql.Result, id string, msg string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+	r.logger.DebugContext(ctx, msg, slog.String("db", r.dialect.system), slog.String("payment_id", id))
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPayment(row rowScanner) (domain.Payment, error) {
+	var (
+		payment     domain.Payment
+		id          string
+		paymentType string
+		card        domain.Card
+		plan        []byte
+		boleto      []byte
+		splits      []byte
+		fxRate      []byte
+	)
+	if err := row.Scan(&id, &payment.OrderId, &payment.Amount, &payment.Method, &payment.Status, &paymentType,
+		&payment.CaptureMethod, &payment.CapturedAmount, &payment.RefundRequired,
+		&card.Token, &card.Brand, &card.Last4, &payment.Installments, &plan, &boleto, &splits, &payment.RefundedAmount,
+		&payment.GrossAmount, &payment.FeeAmount, &payment.NetAmount, &payment.FeeRuleID,
+		&payment.Currency, &fxRate, &payment.SettlementAmount, &payment.CreatedAt); err != nil {
+		return payment, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return payment, fmt.Errorf("corrupted payment id %q: %v", id, err)
+	}
+	payment.ID = objectID
+	payment.PaymentType = domain.PaymentType(paymentType)
+	payment.CreatedAt = payment.CreatedAt.UTC()
+	if card.Token != "" {
+		payment.Card = &card
+	}
+	if len(plan) > 0 {
+		payment.InstallmentPlan = &domain.InstallmentPlan{}
+		if err := json.Unmarshal(plan, payment.InstallmentPlan); err != nil {
+			return payment, fmt.Errorf("corrupted installment plan of payment %q: %v", id, err)
+		}
+	}
+	if len(splits) > 0 {
+		if err := json.Unmarshal(splits, &payment.Splits); err != nil {
+			return payment, fmt.Errorf("corrupted splits of payment %q: %v", id, err)
+		}
+	}
+	if len(fxRate) > 0 {
+		payment.FXRate = &domain.FXRate{}
+		if err := json.Unmarshal(fxRate, payment.FXRate); err != nil {
+			return payment, fmt.Errorf("corrupted FX rate of payment %q: %v", id, err)
+		}
+	}
+	if len(boleto) > 0 {
+		payment.Boleto = &domain.BoletoDetails{}
+		if err := json.Unmarshal(boleto, payment.Boleto); err != nil {
+			return payment, fmt.Errorf("corrupted boleto of payment %q: %v", id, err)
+		}
+	}
+	return payment, nil
+}
+
+// cardColumns achata o cartão opcional nas colunas card_*
+func cardColumns(payment *domain.Payment) domain.Card {
+	if payment.Card == nil {
+		return domain.Card{}
+	}
+	return *payment.Card
+}
+
+// jsonColumn serializa um campo opcional para uma coluna JSON (NULL quando ausente)
+func jsonColumn[T any](value *T) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// splitsOrNil grava NULL em vez de uma lista vazia quando o pagamento não é dividido
+func splitsOrNil(payment *domain.Payment) *[]domain.Split {
+	if len(payment.Splits) == 0 {
+		return nil
+	}
+	return &payment.Splits
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+// sqlWebhookRepository implementa o WebhookRepository sobre database/sql
+type sqlWebhookRepository struct {
+	db      *sql.DB
+	dialect sqlDialect
+	logger  *slog.Logger
+}
+
+const (
+	subscriptionColumns = "id, url, events, description, active, secret, created_at, updated_at"
+	deliveryColumns     = "id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at"
+)
+
+// NewPostgresWebhookRepository cria o WebhookRepository sobre um banco PostgreSQL já migrado
+func NewPostgresWebhookRepository(db *sql.DB, opts ...Option) WebhookRepository {
+	o := newOptions(opts)
+	return &sqlWebhookRepository{db: db, dialect: postgresDialect, logger: o.logger}
+}
+
+// NewSQLiteWebhookRepository cria o WebhookRepository sobre um banco SQLite já migrado
+func NewSQLiteWebhookRepository(db *sql.DB, opts ...Option) WebhookRepository {
+	o := newOptions(opts)
+	return &sqlWebhookRepository{db: db, dialect: sqliteDialect, logger: o.logger}
+}
+
+func (r *sqlWebhookRepository) ListSubscriptions(ctx context.Context) (subscriptions []domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, r.dialect.system, "ListSubscriptions")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions = []domain.WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *sqlWebhookRepository) GetSubscription(ctx context.Context, id string) (subscription domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, r.dialect.system, "GetSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return subscription, err
+	}
+	subscription, err = scanSubscription(r.db.QueryRowContext(ctx,
+		r.dialect.rebind("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return subscription, fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	return subscription, err
+}
+
+func (r *sqlWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (_ string, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, r.dialect.system, "CreateSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeSubscription(subscription)
+	if subscription.ID.IsZero() {
+		subscription.ID = primitive.NewObjectID()
+	}
+	events, err := json.Marshal(subscription.Events)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind("INSERT INTO webhook_subscriptions ("+subscriptionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		subscription.ID.Hex(), subscription.URL, string(events), subscription.Description, subscription.Active,
+		subscription.Secret, subscription.CreatedAt, subscription.UpdatedAt)
+	if r.dialect.isUniqueViolation(err) {
+		return "", fmt.Errorf("webhook subscription %s: %w", subscription.ID.Hex(), domain.ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Inscrição de webhook inserida", slog.String("db", r.dialect.system), slog.String("subscription_id", subscription.ID.Hex()))
+	return subscription.ID.Hex(), nil
+}
+
+func (r *sqlWebhookRepository) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) (err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, r.dialect.system, "UpdateSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	normalizeSubscription(subscription)
+	events, err := json.Marshal(subscription.Events)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		"UPDATE webhook_subscriptions SET url = ?, events = ?, description = ?, active = ?, updated_at = ? WHERE id = ?"),
+		subscription.URL, string(events), subscription.Description, subscription.Active, subscription.UpdatedAt, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result, "webhook subscription", id)
+}
+
+func (r *sqlWebhookRepository) DeleteSubscription(ctx context.Context, id string) (err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, r.dialect.system, "DeleteSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM webhook_subscriptions WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result, "webhook subscription", id)
+}
+
+func (r *sqlWebhookRepository) GetDelivery(ctx context.Context, id string) (delivery domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, r.dialect.system, "GetDelivery")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return delivery, err
+	}
+	delivery, err = scanDelivery(r.db.QueryRowContext(ctx,
+		r.dialect.rebind("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivery, fmt.Errorf("webhook delivery %s: %w", id, domain.ErrNotFound)
+	}
+	return delivery, err
+}
+
+func (r *sqlWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, r.dialect.system, "ListDeliveries")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return r.queryDeliveries(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE subscription_id = ? ORDER BY created_at DESC, id DESC LIMIT ?",
+		subscriptionID, limit)
+}
+
+func (r *sqlWebhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, r.dialect.system, "FindDueDeliveries")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return r.queryDeliveries(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?",
+		domain.DeliveryPending, now.UTC(), limit)
+}
+
+func (r *sqlWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *sqlWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (_ string, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, r.dialect.system, "CreateDelivery")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeDelivery(delivery)
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	attempts, err := json.Marshal(delivery.Attempts)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind("INSERT INTO webhook_deliveries ("+deliveryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		delivery.ID.Hex(), delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status,
+		string(attempts), nullTime(delivery.NextAttemptAt), delivery.CreatedAt, delivery.UpdatedAt)
+	if r.dialect.isUniqueViolation(err) {
+		return "", fmt.Errorf("webhook delivery %s: %w", delivery.ID.Hex(), domain.ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+	return delivery.ID.Hex(), nil
+}
+
+func (r *sqlWebhookRepository) UpdateDelivery(ctx context.Context, id string, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, r.dialect.system, "UpdateDelivery")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if _, err := toObjectID(id); err != nil {
+		return err
+	}
+	normalizeDelivery(delivery)
+	attempts, err := json.Marshal(delivery.Attempts)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		"UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?"),
+		delivery.Status, string(attempts), nullTime(delivery.NextAttemptAt), delivery.UpdatedAt, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result, "webhook delivery", id)
+}
+
+// expectAffected traduz uma alteração que não encontrou a linha em ErrNotFound
+func expectAffected(result sql.Result, entity string, id string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s %s: %w", entity, id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func scanSubscription(row rowScanner) (domain.WebhookSubscription, error) {
+	var (
+		subscription domain.WebhookSubscription
+		id           string
+		events       []byte
+	)
+	if err := row.Scan(&id, &subscription.URL, &events, &subscription.Description, &subscription.Active,
+		&subscription.Secret, &subscription.CreatedAt, &subscription.UpdatedAt); err != nil {
+		return subscription, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return subscription, fmt.Errorf("corrupted webhook subscription id %q: %v", id, err)
+	}
+	subscription.ID = objectID
+	subscription.CreatedAt = subscription.CreatedAt.UTC()
+	subscription.UpdatedAt = subscription.UpdatedAt.UTC()
+	subscription.Events = []string{}
+	if len(events) > 0 {
+		if err := json.Unmarshal(events, &subscription.Events); err != nil {
+			return subscription, fmt.Errorf("corrupted events of webhook subscription %q: %v", id, err)
+		}
+	}
+	return subscription, nil
+}
+
+func scanDelivery(row rowScanner) (domain.WebhookDelivery, error) {
+	var (
+		delivery    domain.WebhookDelivery
+		id          string
+		attempts    []byte
+		nextAttempt sql.NullTime
+	)
+	if err := row.Scan(&id, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Payload, &delivery.Status,
+		&attempts, &nextAttempt, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+		return delivery, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return delivery, fmt.Errorf("corrupted webhook delivery id %q: %v", id, err)
+	}
+	delivery.ID = objectID
+	delivery.CreatedAt = delivery.CreatedAt.UTC()
+	delivery.UpdatedAt = delivery.UpdatedAt.UTC()
+	if nextAttempt.Valid {
+		t := nextAttempt.Time.UTC()
+		delivery.NextAttemptAt = &t
+	}
+	delivery.Attempts = []domain.WebhookAttempt{}
+	if len(attempts) > 0 {
+		if err := json.Unmarshal(attempts, &delivery.Attempts); err != nil {
+			return delivery, fmt.Errorf("corrupted attempts of webhook delivery %q: %v", id, err)
+		}
+	}
+	return delivery, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+var sqliteDialect = sqlDialect{
+	system: "sqlite",
+	isUniqueViolation: func(err error) bool {
+		var sqliteErr *sqlite.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		code := sqliteErr.Code()
+		return code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || code == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	},
+	// O SQLite não informa o nome do índice, mas separa a chave primária dos
+	// índices únicos: em payments, o único além dela é activePaymentIndex
+	isActivePaymentViolation: func(err error) bool {
+		var sqliteErr *sqlite.Error
+		return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	},
+}
+
+// OpenSQLite abre (ou cria) o arquivo SQLite em WAL mode e aplica as migrações pendentes.
+// O path ":memory:" cria um banco volátil, útil nos testes.
+func OpenSQLite(ctx context.Context, path string, opts ...Option) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	if path == ":memory:" {
+		// Cada conexão teria o seu próprio banco em memória
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error opening SQLite database: %v", err)
+	}
+	o := newOptions(opts)
+	if err := applySQLMigrations(ctx, db, sqliteDialect, sqliteMigrations, "migrations/sqlite", o.logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteDSN liga o WAL, o timeout de lock e as foreign keys em toda conexão
+func sqliteDSN(path string) string {
+	pragmas := "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	if strings.Contains(path, "?") {
+		return path + "&" + pragmas
+	}
+	return path + "?" + pragmas
+}
+
+// NewSQLitePaymentRepository cria o PaymentRepository sobre um banco SQLite já migrado
+func NewSQLitePaymentRepository(db *sql.DB, opts ...Option) PaymentRepository {
+	o := newOptions(opts)
+	return &sqlPaymentRepository{db: db, dialect: sqliteDialect, logger: o.logger}
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/telemetry"
+)
+
+const (
+	webhookSubscriptionsCollection = "webhook_subscriptions"
+	webhookDeliveriesCollection    = "webhook_deliveries"
+)
+
+// WebhookRepository guarda as inscrições de webhook e o log das entregas
+type WebhookRepository interface {
+	// ListSubscriptions devolve as inscrições da mais antiga para a mais recente
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (domain.WebhookSubscription, error)
+	CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (string, error)
+	// UpdateSubscription altera URL, eventos, descrição e situação; o segredo não muda
+	UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+
+	GetDelivery(ctx context.Context, id string) (domain.WebhookDelivery, error)
+	// ListDeliveries devolve até limit entregas da inscrição, da mais recente para a mais antiga
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error)
+	// FindDueDeliveries devolve até limit entregas pendentes com a próxima tentativa até now, das mais atrasadas primeiro
+	FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (string, error)
+	// UpdateDelivery grava a situação, as tentativas e o agendamento da entrega
+	UpdateDelivery(ctx context.Context, id string, delivery *domain.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db     *mongo.Database
+	logger *slog.Logger
+}
+
+// NewWebhookRepository cria o WebhookRepository sobre o MongoDB
+func NewWebhookRepository(db *mongo.Database, opts ...Option) WebhookRepository {
+	o := newOptions(opts)
+	return &webhookRepository{db: db, logger: o.logger}
+}
+
+func startWebhookSpan(ctx context.Context, collection string, system string, operation string) (context.Context, trace.Span) {
+	return startCollectionSpan(ctx, "WebhookRepository", collection, system, operation)
+}
+
+// normalizeSubscription aplica a precisão de milissegundos do BSON às datas, como creationTime
+func normalizeSubscription(subscription *domain.WebhookSubscription) {
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = creationTime()
+	}
+	if subscription.UpdatedAt.IsZero() {
+		subscription.UpdatedAt = subscription.CreatedAt
+	}
+	subscription.CreatedAt = subscription.CreatedAt.UTC().Truncate(time.Millisecond)
+	subscription.UpdatedAt = subscription.UpdatedAt.UTC().Truncate(time.Millisecond)
+	if subscription.Events == nil {
+		subscription.Events = []string{}
+	}
+}
+
+// normalizeDelivery aplica a precisão de milissegundos do BSON às datas, como creationTime
+func normalizeDelivery(delivery *domain.WebhookDelivery) {
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = creationTime()
+	}
+	if delivery.UpdatedAt.IsZero() {
+		delivery.UpdatedAt = delivery.CreatedAt
+	}
+	delivery.CreatedAt = delivery.CreatedAt.UTC().Truncate(time.Millisecond)
+	delivery.UpdatedAt = delivery.UpdatedAt.UTC().Truncate(time.Millisecond)
+	if delivery.NextAttemptAt != nil {
+		next := delivery.NextAttemptAt.UTC().Truncate(time.Millisecond)
+		delivery.NextAttemptAt = &next
+	}
+	if delivery.Attempts == nil {
+		delivery.Attempts = []domain.WebhookAttempt{}
+	}
+	for i := range delivery.Attempts {
+		delivery.Attempts[i].AttemptedAt = delivery.Attempts[i].AttemptedAt.UTC().Truncate(time.Millisecond)
+	}
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) (subscriptions []domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, "mongodb", "ListSubscriptions")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	cursor, err := r.db.Collection(webhookSubscriptionsCollection).Find(ctx, bson.M{},
+		mongooptions.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	subscriptions = []domain.WebhookSubscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id string) (subscription domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, "mongodb", "GetSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return subscription, err
+	}
+	err = r.db.Collection(webhookSubscriptionsCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&subscription)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return subscription, fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	return subscription, err
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (_ string, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, "mongodb", "CreateSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeSubscription(subscription)
+	if subscription.ID.IsZero() {
+		subscription.ID = primitive.NewObjectID()
+	}
+	_, err = r.db.Collection(webhookSubscriptionsCollection).InsertOne(ctx, subscription)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", fmt.Errorf("webhook subscription %s: %w", subscription.ID.Hex(), domain.ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.logger.DebugContext(ctx, "Inscrição de webhook inserida", slog.String("db", "mongodb"), slog.String("subscription_id", subscription.ID.Hex()))
+	return subscription.ID.Hex(), nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) (err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, "mongodb", "UpdateSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	normalizeSubscription(subscription)
+	update := bson.M{
+		"url":         subscription.URL,
+		"events":      subscription.Events,
+		"description": subscription.Description,
+		"active":      subscription.Active,
+		"updated_at":  subscription.UpdatedAt,
+	}
+	result, err := r.db.Collection(webhookSubscriptionsCollection).UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id string) (err error) {
+	ctx, span := startWebhookSpan(ctx, webhookSubscriptionsCollection, "mongodb", "DeleteSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	// O log das entregas é mantido; as pendentes são abandonadas pelo job
+	result, err := r.db.Collection(webhookSubscriptionsCollection).DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("webhook subscription %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id string) (delivery domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, "mongodb", "GetDelivery")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return delivery, err
+	}
+	err = r.db.Collection(webhookDeliveriesCollection).FindOne(ctx, bson.M{"_id": objectID}).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return delivery, fmt.Errorf("webhook delivery %s: %w", id, domain.ErrNotFound)
+	}
+	return delivery, err
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) (deliveries []domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, "mongodb", "ListDeliveries")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	cursor, err := r.db.Collection(webhookDeliveriesCollection).Find(ctx, bson.M{"subscription_id": subscriptionID},
+		mongooptions.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	deliveries = []domain.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) (deliveries []domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, "mongodb", "FindDueDeliveries")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Coberto pelo índice status+next_attempt_at (ver MongoMigrations)
+	cursor, err := r.db.Collection(webhookDeliveriesCollection).Find(ctx,
+		bson.M{"status": domain.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+		mongooptions.Find().SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	deliveries = []domain.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (_ string, err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, "mongodb", "CreateDelivery")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	normalizeDelivery(delivery)
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	_, err = r.db.Collection(webhookDeliveriesCollection).InsertOne(ctx, delivery)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", fmt.Errorf("webhook delivery %s: %w", delivery.ID.Hex(), domain.ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+	return delivery.ID.Hex(), nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, id string, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := startWebhookSpan(ctx, webhookDeliveriesCollection, "mongodb", "UpdateDelivery")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	objectID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
+	normalizeDelivery(delivery)
+	// O evento e o corpo não mudam: a reentrega envia exatamente o mesmo payload
+	update := bson.M{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"updated_at":      delivery.UpdatedAt,
+	}
+	result, err := r.db.Collection(webhookDeliveriesCollection).UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("webhook delivery %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -4,16 +4,103 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"payments/delivery"
+	"payments/domain"
+	"payments/middleware"
+	"payments/pubsub"
 	"payments/usecase"
 )
 
-func RegisterPaymentRoutes(app *fiber.App, useCase usecase.PaymentUseCase) {
-	handler := delivery.NewPaymentHandler(useCase)
+// As rotas exigem os escopos da chave de API autenticada por middleware.Authenticate
+var (
+	canRead    = middleware.RequireScope(domain.ScopePaymentsRead)
+	canWrite   = middleware.RequireScope(domain.ScopePaymentsWrite)
+	canRefund  = middleware.RequireScope(domain.ScopeRefundsWrite)
+	adminsOnly = middleware.RequireScope(domain.ScopeAdmin)
+	// canStream também aceita o token de stream do próprio pagamento, que o checkout recebe na criação
+	canStream = middleware.RequireResourceScope(domain.ScopePaymentsRead, func(c *fiber.Ctx) string {
+		return domain.PaymentEventsScope(c.Params("id"))
+	})
+)
+
+// RegisterPaymentRoutes expõe os pagamentos; gateway autentica o callback do
+// gateway de pagamento, que não tem chave de API (ver middleware.GatewaySignature)
+func RegisterPaymentRoutes(app *fiber.App, useCase usecase.PaymentUseCase, gateway fiber.Handler, opts ...delivery.Option) {
+	handler := delivery.NewPaymentHandler(useCase, opts...)
+
+	app.Get("/payments", canRead, handler.GetAllPayments)
+	app.Get("/payments/:id", canRead, handler.GetPaymentByID)
+	app.Post("/payments", canWrite, handler.CreatePayment)
+	app.Put("/payments/:id", canWrite, handler.UpdatePayment)
+	app.Delete("/payments/:id", canWrite, handler.DeletePayment)
+	app.Post("/payments/:id/cancel", canWrite, handler.CancelPayment)
+	app.Post("/payments/:id/capture", canWrite, handler.CapturePayment)
+	app.Post("/payments/:id/void", canWrite, handler.VoidPayment)
+	app.Post("/payments/:id/refund", canRefund, handler.RefundPayment)
+	app.Get("/payments/:id/boleto.png", canRead, handler.GetBoletoImage)
+	app.Post("/payment/callback", gateway, handler.Callback)
+	app.Get("/orders/:orderId/payments", canRead, handler.GetPaymentsByOrderID)
+}
+
+// RegisterPaymentEventRoutes expõe o stream SSE das mudanças de status dos pagamentos
+func RegisterPaymentEventRoutes(app *fiber.App, useCase usecase.PaymentUseCase, broker pubsub.Broker, opts ...delivery.EventsOption) {
+	handler := delivery.NewPaymentEventsHandler(useCase, broker, opts...)
+
+	app.Get("/payments/:id/events", canStream, handler.StreamPaymentEvents)
+}
+
+// RegisterPaymentFeedRoutes expõe o feed WebSocket de todos os pagamentos; auth
+// autentica o cliente antes do handshake
+func RegisterPaymentFeedRoutes(app *fiber.App, broker pubsub.Broker, auth fiber.Handler, opts ...delivery.FeedOption) {
+	handler := delivery.NewPaymentFeedHandler(broker, opts...)
+
+	app.Get("/ws/payments", auth, handler.Upgrade, handler.Stream())
+}
+
+// RegisterFeeRuleRoutes expõe a administração das regras de tarifa
+func RegisterFeeRuleRoutes(app *fiber.App, useCase usecase.FeeRuleUseCase) {
+	handler := delivery.NewFeeRuleHandler(useCase)
+
+	admin := app.Group("/admin/fee-rules", adminsOnly)
+	admin.Get("/", handler.ListFeeRules)
+	admin.Post("/", handler.CreateFeeRule)
+	admin.Get("/:id", handler.GetFeeRule)
+	admin.Put("/:id", handler.UpdateFeeRule)
+	admin.Delete("/:id", handler.DeleteFeeRule)
+}
+
+// RegisterDisputeRoutes expõe as disputas (chargebacks) dos pagamentos
+func RegisterDisputeRoutes(app *fiber.App, useCase usecase.DisputeUseCase) {
+	handler := delivery.NewDisputeHandler(useCase)
+
+	app.Post("/payments/:id/disputes", canWrite, handler.OpenDispute)
+	app.Get("/payments/:id/disputes", canRead, handler.ListPaymentDisputes)
+	app.Get("/disputes/:id", canRead, handler.GetDispute)
+	app.Patch("/disputes/:id", canWrite, handler.UpdateDispute)
+	app.Post("/disputes/:id/resolve", canWrite, handler.ResolveDispute)
+}
+
+// RegisterWebhookRoutes expõe as inscrições de webhook dos lojistas e o log das entregas
+func RegisterWebhookRoutes(app *fiber.App, useCase usecase.WebhookUseCase) {
+	handler := delivery.NewWebhookHandler(useCase)
+
+	webhooks := app.Group("/webhooks", adminsOnly)
+	webhooks.Get("/", handler.ListWebhooks)
+	webhooks.Post("/", handler.CreateWebhook)
+	webhooks.Get("/:id", handler.GetWebhook)
+	webhooks.Put("/:id", handler.UpdateWebhook)
+	webhooks.Delete("/:id", handler.DeleteWebhook)
+	webhooks.Get("/:id/deliveries", handler.ListDeliveries)
+	webhooks.Post("/:id/test", handler.SendTestEvent)
+	webhooks.Post("/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+}
+
+// RegisterAPIKeyRoutes expõe a emissão, a consulta e a revogação das chaves de API
+func RegisterAPIKeyRoutes(app *fiber.App, useCase usecase.APIKeyUseCase) {
+	handler := delivery.NewAPIKeyHandler(useCase)
 
-	app.Get("/payments", handler.GetAllPayments)
-	app.Get("/payments/:id", handler.GetPaymentByID)
-	app.Post("/payments", handler.CreatePayment)
-	app.Put("/payments/:id", handler.UpdatePayment)
-	app.Delete("/payments/:id", handler.DeletePayment)
-	app.Post("/payment/callback", handler.Callback)
+	admin := app.Group("/admin/api-keys", adminsOnly)
+	admin.Get("/", handler.ListAPIKeys)
+	admin.Post("/", handler.IssueAPIKey)
+	admin.Get("/:id", handler.GetAPIKey)
+	admin.Post("/:id/revoke", handler.RevokeAPIKey)
 }
@@ -0,0 +1,96 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName identifica o instrumentation scope dos spans criados pelo serviço
+const TracerName = "payments"
+
+const defaultServiceName = "payments"
+
+// Tracer retorna o tracer do serviço a partir do TracerProvider global
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// InitTracer configura o TracerProvider global e o propagador W3C (traceparent/baggage).
+// O exporter é escolhido pela variável OTEL_TRACES_EXPORTER:
+//   - "otlp": envia via OTLP/HTTP (endpoint em OTEL_EXPORTER_OTLP_ENDPOINT)
+//   - "stdout" ou "console": imprime os spans no stdout, útil para rodar localmente
+//   - "none" ou vazio: nenhum span é exportado
+//
+// A função retornada deve ser chamada no encerramento para descarregar os spans pendentes.
+func InitTracer(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, os.Getenv("OTEL_TRACES_EXPORTER"))
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return nil, nil
+	case "stdout", "console":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER: %s", name)
+	}
+}
+
+// RecordError marca o span como erro quando err não é nil
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// PaymentIDAttr padroniza o atributo com o ID do pagamento nos spans
+func PaymentIDAttr(id string) attribute.KeyValue {
+	return attribute.String("payment.id", id)
+}
+
+// OrderIDAttr padroniza o atributo com o ID do pedido nos spans
+func OrderIDAttr(id string) attribute.KeyValue {
+	return attribute.String("order.id", id)
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auth2 "payments/auth"
+	"payments/domain"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/realms/payments"
+	testAudience = "payments-api"
+)
+
+var testNow = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+// writeJWKS grava o JWKS com as chaves em um arquivo temporário
+func writeJWKS(t *testing.T, keys ...jose.JSONWebKey) string {
+	t.Helper()
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// issuer assina tokens como o provedor de identidade
+type issuer struct {
+	t      *testing.T
+	key    *rsa.PrivateKey
+	kid    string
+	public jose.JSONWebKey
+}
+
+func newIssuer(t *testing.T, kid string) issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	public := jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"}
+	return issuer{t: t, key: key, kid: kid, public: public}
+}
+
+func (i issuer) sign(claims map[string]any) string {
+	i.t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: i.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader(jose.HeaderKey("kid"), i.kid))
+	require.NoError(i.t, err)
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(i.t, err)
+	return token
+}
+
+// claims são os claims de um token válido, sobrescritos por extra
+func claims(extra map[string]any) map[string]any {
+	base := map[string]any{
+		"iss":  testIssuer,
+		"aud":  []string{testAudience, "account"},
+		"sub":  "svc-checkout",
+		"name": "checkout",
+		"iat":  testNow.Add(-time.Minute).Unix(),
+		"exp":  testNow.Add(5 * time.Minute).Unix(),
+	}
+	for name, value := range extra {
+		if value == nil {
+			delete(base, name)
+			continue
+		}
+		base[name] = value
+	}
+	return base
+}
+
+func newAuthenticator(t *testing.T, signer issuer, opts ...auth2.JWTOption) *auth2.JWTAuthenticator {
+	t.Helper()
+	keys := auth2.NewKeyCache(auth2.NewFileKeySource(writeJWKS(t, signer.public)))
+	opts = append([]auth2.JWTOption{
+		auth2.WithIssuer(testIssuer),
+		auth2.WithAudience(testAudience),
+		auth2.WithJWTClock(func() time.Time { return testNow }),
+	}, opts...)
+	return auth2.NewJWTAuthenticator(keys, opts...)
+}
+
+func TestJWTAuthenticatorMapsClaimsToScopes(t *testing.T) {
+	signer := newIssuer(t, "k1")
+	authenticator := newAuthenticator(t, signer,
+		auth2.WithRoleScopes("realm_access.roles", map[string][]string{
+			"finance": {domain.ScopePaymentsRead, domain.ScopeRefundsWrite},
+		}))
+
+	token := signer.sign(claims(map[string]any{
+		"scope":        "openid payments:read payments:write",
+		"realm_access": map[string]any{"roles": []string{"finance", "offline_access"}},
+	}))
+	principal, err := authenticator.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "jwt:svc-checkout", principal.Subject)
+	assert.Equal(t, "checkout", principal.Name)
+	assert.ElementsMatch(t, []string{domain.ScopePaymentsRead, domain.ScopePaymentsWrite, domain.ScopeRefundsWrite}, principal.Scopes)
+	assert.True(t, principal.HasScope(domain.ScopeRefundsWrite))
+	assert.False(t, principal.HasScope(domain.ScopeAdmin))
+}
+
+func TestJWTAuthenticatorAcceptsScopeList(t *testing.T) {
+	signer := newIssuer(t, "k1")
+	authenticator := newAuthenticator(t, signer, auth2.WithScopesClaim("scp"))
+
+	token := signer.sign(claims(map[string]any{"scp": []string{"payments:read", "unknown:scope"}}))
+	principal, err := authenticator.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain.ScopePaymentsRead}, principal.Scopes)
+}
+
+func TestJWTAuthenticatorRejectsInvalidTokens(t *testing.T) {
+	signer := newIssuer(t, "k1")
+	authenticator := newAuthenticator(t, signer)
+	other := newIssuer(t, "k1")
+
+	tests := map[string]string{
+		"malformed":       "not.a.jwt",
+		"expired":         signer.sign(claims(map[string]any{"exp": testNow.Add(-5 * time.Minute).Unix()})),
+		"not yet valid":   signer.sign(claims(map[string]any{"nbf": testNow.Add(5 * time.Minute).Unix()})),
+		"without exp":     signer.sign(claims(map[string]any{"exp": nil})),
+		"wrong issuer":    signer.sign(claims(map[string]any{"iss": "https://evil.example.com"})),
+		"wrong audience":  signer.sign(claims(map[string]any{"aud": "other-api"})),
+		"wrong signature": other.sign(claims(nil)),
+		"unknown key":     newIssuer(t, "k2").sign(claims(nil)),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(context.Background(), token)
+			assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		})
+	}
+}
+
+func TestJWTAuthenticatorToleratesClockSkew(t *testing.T) {
+	signer := newIssuer(t, "k1")
+	authenticator := newAuthenticator(t, signer, auth2.WithLeeway(time.Minute))
+
+	token := signer.sign(claims(map[string]any{"exp": testNow.Add(-30 * time.Second).Unix()}))
+	_, err := authenticator.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestJWTAuthenticatorRejectsHMAC(t *testing.T) {
+	signer := newIssuer(t, "k1")
+	authenticator := newAuthenticator(t, signer)
+
+	// Um atacante não pode assinar com HS256 usando a chave pública como segredo
+	hmac, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef0123456789abcdef")},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader(jose.HeaderKey("kid"), "k1"))
+	require.NoError(t, err)
+	token, err := jwt.Signed(hmac).Claims(claims(nil)).Serialize()
+	require.NoError(t, err)
+
+	_, err = authenticator.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestJWTAuthenticatorFollowsKeyRotation(t *testing.T) {
+	first, second := newIssuer(t, "k1"), newIssuer(t, "k2")
+	path := writeJWKS(t, first.public)
+	authenticator := auth2.NewJWTAuthenticator(
+		auth2.NewKeyCache(auth2.NewFileKeySource(path), auth2.WithMinRefreshInterval(0)),
+		auth2.WithIssuer(testIssuer), auth2.WithAudience(testAudience),
+		auth2.WithJWTClock(func() time.Time { return testNow }))
+
+	_, err := authenticator.Authenticate(context.Background(), first.sign(claims(nil)))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{first.public, second.public}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	_, err = authenticator.Authenticate(context.Background(), second.sign(claims(nil)))
+	assert.NoError(t, err)
+}
+
+// apiKeys aceita apenas a chave "pk_valid"
+type apiKeys struct{}
+
+func (apiKeys) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	if key != "pk_valid" {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	return domain.Principal{Subject: "api_key:valid", Scopes: []string{domain.ScopeAdmin}}, nil
+}
+
+func TestAuthenticatorsDispatchByCredential(t *testing.T) {
+	signer := newIssuer(t, "k1")
+	token := signer.sign(claims(map[string]any{"scope": "payments:read"}))
+
+	authenticators := auth2.Authenticators{APIKeys: apiKeys{}, Tokens: newAuthenticator(t, signer)}
+	principal, err := authenticators.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "jwt:svc-checkout", principal.Subject)
+	principal, err = authenticators.Authenticate(context.Background(), "pk_valid")
+	require.NoError(t, err)
+	assert.Equal(t, "api_key:valid", principal.Subject)
+
+	// Sem JWKS configurado, só as chaves de API valem
+	withoutTokens := auth2.Authenticators{APIKeys: apiKeys{}}
+	_, err = withoutTokens.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	_, err = withoutTokens.Authenticate(context.Background(), "pk_valid")
+	assert.NoError(t, err)
+}
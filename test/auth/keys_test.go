@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auth2 "payments/auth"
+	"payments/domain"
+)
+
+// stubKeySource devolve o JWKS atual e conta as buscas; com block, cada busca
+// espera o canal ser fechado
+type stubKeySource struct {
+	mu      sync.Mutex
+	keys    jose.JSONWebKeySet
+	err     error
+	block   chan struct{}
+	fetches int
+}
+
+func (s *stubKeySource) Keys(ctx context.Context) (jose.JSONWebKeySet, error) {
+	s.mu.Lock()
+	s.fetches++
+	block := s.block
+	s.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys, s.err
+}
+
+func (s *stubKeySource) set(keys jose.JSONWebKeySet, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys, s.err = keys, err
+}
+
+func (s *stubKeySource) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+// awaitRefresh espera a busca disparada em segundo plano: um kid desconhecido
+// espera a busca em andamento e, sem busca, é recusado na hora pelo intervalo mínimo
+func awaitRefresh(cache *auth2.KeyCache) {
+	_, _ = cache.Key(context.Background(), "await-refresh")
+}
+
+func newPublicKey(t *testing.T, kid string) jose.JSONWebKey {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return jose.JSONWebKey{Key: &private.PublicKey, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"}
+}
+
+// clock é um relógio controlado pelo teste
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestKeyCacheRefreshesOnTTLAndRotation(t *testing.T) {
+	first, second := newPublicKey(t, "k1"), newPublicKey(t, "k2")
+	source := &stubKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{first}}}
+	now := &clock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	cache := auth2.NewKeyCache(source, auth2.WithTTL(time.Hour), auth2.WithMinRefreshInterval(time.Minute),
+		auth2.WithCacheClock(now.Now))
+
+	key, err := cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.KeyID)
+	_, err = cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, source.fetchCount(), "chaves em cache não devem ser buscadas de novo")
+
+	// O provedor rotaciona as chaves: o kid novo dispara a renovação
+	source.set(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{first, second}}, nil)
+	now.now = now.now.Add(2 * time.Minute)
+	key, err = cache.Key(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", key.KeyID)
+	assert.Equal(t, 2, source.fetchCount())
+
+	// Depois do TTL, o JWKS é buscado de novo; a chave em cache vale até a busca terminar
+	source.set(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{second}}, nil)
+	now.now = now.now.Add(time.Hour)
+	_, err = cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	awaitRefresh(cache)
+	assert.Equal(t, 3, source.fetchCount())
+	_, err = cache.Key(context.Background(), "k1")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized, "a chave retirada do JWKS não vale mais")
+}
+
+func TestKeyCacheThrottlesUnknownKids(t *testing.T) {
+	source := &stubKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{newPublicKey(t, "k1")}}}
+	now := &clock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	cache := auth2.NewKeyCache(source, auth2.WithMinRefreshInterval(time.Minute), auth2.WithCacheClock(now.Now))
+
+	for _, kid := range []string{"forjado-1", "forjado-2", "forjado-3"} {
+		_, err := cache.Key(context.Background(), kid)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	}
+	assert.Equal(t, 1, source.fetchCount(), "kids desconhecidos não podem disparar uma busca por token")
+
+	now.now = now.now.Add(time.Minute)
+	_, err := cache.Key(context.Background(), "forjado-4")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	assert.Equal(t, 2, source.fetchCount())
+}
+
+func TestKeyCacheKeepsStaleKeysWhenSourceFails(t *testing.T) {
+	source := &stubKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{newPublicKey(t, "k1")}}}
+	now := &clock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	cache := auth2.NewKeyCache(source, auth2.WithTTL(time.Hour), auth2.WithCacheClock(now.Now))
+	_, err := cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+
+	source.set(source.keys, errors.New("idp down"))
+	now.now = now.now.Add(2 * time.Hour)
+	_, err = cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	awaitRefresh(cache)
+	assert.Equal(t, 2, source.fetchCount())
+	key, err := cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.KeyID)
+
+	_, err = cache.Key(context.Background(), "k2")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestKeyCacheReportsSourceErrorsForUnknownKids(t *testing.T) {
+	cache := auth2.NewKeyCache(&stubKeySource{err: fmt.Errorf("idp down: %w", domain.ErrUpstream)})
+	_, err := cache.Key(context.Background(), "k1")
+	assert.ErrorIs(t, err, domain.ErrUpstream)
+}
+
+func TestKeyCacheServesCachedKeysDuringRefresh(t *testing.T) {
+	source := &stubKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{newPublicKey(t, "k1")}}}
+	now := &clock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	cache := auth2.NewKeyCache(source, auth2.WithTTL(time.Hour), auth2.WithCacheClock(now.Now))
+	_, err := cache.Key(context.Background(), "k1")
+	require.NoError(t, err)
+
+	// O provedor está lento: a renovação fica presa até release ser fechado
+	release := make(chan struct{})
+	source.mu.Lock()
+	source.block = release
+	source.keys.Keys = append(source.keys.Keys, newPublicKey(t, "k2"))
+	source.mu.Unlock()
+	now.now = now.now.Add(2 * time.Hour)
+
+	for range 3 {
+		key, err := cache.Key(context.Background(), "k1")
+		require.NoError(t, err, "a chave em cache não espera a renovação")
+		assert.Equal(t, "k1", key.KeyID)
+	}
+
+	// Quem precisa do kid novo espera a renovação em andamento, sem disparar outra
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := cache.Key(context.Background(), "k2")
+		waiting <- err
+	}()
+	select {
+	case <-waiting:
+		t.Fatal("o kid novo não deveria ser resolvido antes do fim da renovação")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-waiting)
+	assert.Equal(t, 2, source.fetchCount())
+}
+
+func TestKeyCacheHonorsCallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	cache := auth2.NewKeyCache(&stubKeySource{block: release})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.Key(ctx, "k1")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestKeyCacheWithoutKid(t *testing.T) {
+	source := &stubKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{newPublicKey(t, "k1")}}}
+	cache := auth2.NewKeyCache(source)
+	key, err := cache.Key(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.KeyID)
+
+	// Com mais de uma chave, não há como escolher
+	source.keys.Keys = append(source.keys.Keys, newPublicKey(t, "k2"))
+	cache = auth2.NewKeyCache(source)
+	_, err = cache.Key(context.Background(), "")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestKeyCacheIgnoresEncryptionKeys(t *testing.T) {
+	key := newPublicKey(t, "k1")
+	key.Use = "enc"
+	cache := auth2.NewKeyCache(&stubKeySource{keys: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{key}}})
+	_, err := cache.Key(context.Background(), "k1")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestHTTPKeySource(t *testing.T) {
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{newPublicKey(t, "k1")}}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(jwks)
+	}))
+	defer server.Close()
+	source := auth2.NewHTTPKeySource(server.URL, server.Client())
+
+	keys, err := source.Keys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 1)
+	assert.Equal(t, "k1", keys.Keys[0].KeyID)
+
+	status = http.StatusServiceUnavailable
+	_, err = source.Keys(context.Background())
+	assert.ErrorIs(t, err, domain.ErrUpstream)
+}
+
+func TestFileKeySource(t *testing.T) {
+	path := writeJWKS(t, newPublicKey(t, "k1"))
+	keys, err := auth2.NewFileKeySource(path).Keys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 1)
+	assert.Equal(t, "k1", keys.Keys[0].KeyID)
+
+	_, err = auth2.NewFileKeySource(path + ".missing").Keys(context.Background())
+	assert.Error(t, err)
+}
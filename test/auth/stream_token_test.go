@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auth2 "payments/auth"
+	"payments/domain"
+)
+
+var streamSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestStreamTokensGrantOnlyThePaymentEvents(t *testing.T) {
+	now := &clock{now: testNow}
+	tokens := auth2.NewStreamTokens(streamSecret, auth2.WithStreamTokenTTL(10*time.Minute), auth2.WithStreamTokenClock(now.Now))
+
+	token, expiresAt, err := tokens.Issue("67a8ffa093a5fa72f000452b")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, auth2.StreamTokenTag))
+	assert.Equal(t, testNow.Add(10*time.Minute), expiresAt)
+
+	principal, err := tokens.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "stream:67a8ffa093a5fa72f000452b", principal.Subject)
+	assert.True(t, principal.HasScope(domain.PaymentEventsScope("67a8ffa093a5fa72f000452b")))
+	assert.False(t, principal.HasScope(domain.PaymentEventsScope("67a8ffa093a5fa72f000452c")))
+	assert.False(t, principal.HasScope(domain.ScopePaymentsRead))
+
+	now.now = expiresAt
+	_, err = tokens.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized, "o token expira no fim da validade")
+}
+
+func TestStreamTokensRejectForgedTokens(t *testing.T) {
+	tokens := auth2.NewStreamTokens(streamSecret)
+	token, _, err := tokens.Issue("67a8ffa093a5fa72f000452b")
+	require.NoError(t, err)
+	other, _, err := auth2.NewStreamTokens([]byte("another-secret-another-secret-32")).Issue("67a8ffa093a5fa72f000452b")
+	require.NoError(t, err)
+
+	forged := map[string]string{
+		"other payment":    strings.Replace(token, "452b", "452c", 1),
+		"extended expiry":  strings.Replace(token, "_1", "_9", 1),
+		"other secret":     other,
+		"without tag":      strings.TrimPrefix(token, auth2.StreamTokenTag),
+		"without expiry":   auth2.StreamTokenTag + "67a8ffa093a5fa72f000452b",
+		"empty signature":  token[:strings.LastIndex(token, "_")+1],
+		"api key as token": "pk_0123456789abcdef_secret",
+	}
+	for name, credential := range forged {
+		t.Run(name, func(t *testing.T) {
+			_, err := tokens.Authenticate(context.Background(), credential)
+			assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		})
+	}
+}
+
+func TestAuthenticatorsDispatchStreamTokens(t *testing.T) {
+	tokens := auth2.NewStreamTokens(streamSecret)
+	token, _, err := tokens.Issue("67a8ffa093a5fa72f000452b")
+	require.NoError(t, err)
+
+	principal, err := auth2.Authenticators{APIKeys: apiKeys{}, Streams: tokens}.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "stream:67a8ffa093a5fa72f000452b", principal.Subject)
+
+	_, err = auth2.Authenticators{APIKeys: apiKeys{}}.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
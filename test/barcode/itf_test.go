@@ -0,0 +1,50 @@
+package barcode
+
+import (
+	"bytes"
+	"image/png"
+	barcode2 "payments/barcode"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeITF(t *testing.T) {
+	widths, err := barcode2.EncodeITF("12")
+
+	assert.Nil(t, err)
+	// Início, par "12" (barras do 1 intercaladas com espaços do 2) e fim
+	assert.Equal(t, []int{1, 1, 1, 1, 3, 1, 1, 3, 1, 1, 1, 1, 3, 3, 3, 1, 1}, widths)
+}
+
+func TestEncodeITF_Invalid(t *testing.T) {
+	_, err := barcode2.EncodeITF("123")
+	assert.NotNil(t, err)
+
+	_, err = barcode2.EncodeITF("1a")
+	assert.NotNil(t, err)
+
+	_, err = barcode2.EncodeITF("")
+	assert.NotNil(t, err)
+}
+
+func TestRenderPNG(t *testing.T) {
+	data, err := barcode2.RenderPNG("00193373700000001000500940144816060680935031", 2, 50)
+	assert.Nil(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.Nil(t, err)
+	// 405 módulos do código mais 10 de zona de silêncio em cada lado
+	assert.Equal(t, 425*2, img.Bounds().Dx())
+	assert.Equal(t, 50, img.Bounds().Dy())
+
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xFFFF), r) // Zona de silêncio em branco
+	r, _, _, _ = img.At(20, 0).RGBA()
+	assert.Equal(t, uint32(0), r) // Primeira barra do início
+}
+
+func TestRenderPNG_InvalidSize(t *testing.T) {
+	_, err := barcode2.RenderPNG("12", 0, 50)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	client2 "payments/client"
+	"payments/domain"
+	"payments/logging"
+)
+
+func TestOrderClient_UpdateOrderStatus_PropagatesHeaders(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var receivedPath, receivedMethod, traceparent, requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedMethod = r.Method
+		traceparent = r.Header.Get("traceparent")
+		requestID = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	orderClient := client2.NewOrderClient(server.URL, server.Client())
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+	err := orderClient.UpdateOrderStatus(ctx, "order123", "success")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/orders/order123", receivedPath)
+	assert.Equal(t, http.MethodPatch, receivedMethod)
+	assert.NotEmpty(t, traceparent) // O span do cliente deve ser propagado ao serviço de pedidos
+	assert.Equal(t, "req-123", requestID)
+}
+
+func TestOrderClient_UpdateOrderStatus_SendsMappedStatus(t *testing.T) {
+	tests := map[string]string{
+		"success":                client2.OrderStatusFinished,
+		"approved":               client2.OrderStatusFinished,
+		domain.StatusCaptured:    client2.OrderStatusFinished,
+		"failed":                 client2.OrderStatusCancelled,
+		domain.StatusCancelled:   client2.OrderStatusCancelled,
+		domain.StatusVoided:      client2.OrderStatusCancelled,
+		domain.StatusExpired:     client2.OrderStatusCancelled,
+		domain.StatusRefunded:    client2.OrderStatusCancelled,
+		domain.StatusChargedBack: client2.OrderStatusCancelled,
+	}
+	for paymentStatus, orderStatus := range tests {
+		t.Run(paymentStatus, func(t *testing.T) {
+			var body map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			err := client2.NewOrderClient(server.URL, server.Client()).UpdateOrderStatus(context.Background(), "order123", paymentStatus)
+
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"status": orderStatus}, body)
+		})
+	}
+}
+
+func TestOrderClient_UpdateOrderStatus_SkipsIntermediateStatus(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	orderClient := client2.NewOrderClient(server.URL, server.Client())
+	for _, status := range []string{domain.StatusProcessing, domain.StatusAuthorized, "pending"} {
+		assert.Nil(t, orderClient.UpdateOrderStatus(context.Background(), "order123", status))
+	}
+	assert.False(t, called, "status intermediários não alteram o pedido")
+}
+
+func TestOrderClient_UpdateOrderStatus_UnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	orderClient := client2.NewOrderClient(server.URL, server.Client())
+	err := orderClient.UpdateOrderStatus(context.Background(), "order123", "success")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "failed to update order status: received status code 500", err.Error())
+}
+
+func TestPaymentGateway_CancelPayment(t *testing.T) {
+	var receivedPath, receivedMethod, requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedMethod = r.Method
+		requestID = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	gateway := client2.NewPaymentGateway(server.URL, server.Client())
+	err := gateway.CancelPayment(logging.WithRequestID(context.Background(), "req-123"), "payment123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/payments/payment123/cancel", receivedPath)
+	assert.Equal(t, http.MethodPost, receivedMethod)
+	assert.Equal(t, "req-123", requestID)
+}
+
+func TestPaymentGateway_CancelPayment_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	err := client2.NewPaymentGateway(server.URL, server.Client()).CancelPayment(context.Background(), "payment123")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "gateway rejected the request: received status code 422", err.Error())
+}
+
+func TestNewPaymentGatewayFromEnv_NotConfigured(t *testing.T) {
+	t.Setenv("PAYMENT_GATEWAY_URL", "")
+	assert.Nil(t, client2.NewPaymentGatewayFromEnv())
+}
+
+func TestPaymentGateway_CapturePayment(t *testing.T) {
+	var receivedPath string
+	var body map[string]float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := client2.NewPaymentGateway(server.URL, server.Client()).CapturePayment(context.Background(), "payment123", 40.5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/payments/payment123/capture", receivedPath)
+	assert.Equal(t, 40.5, body["amount"])
+}
+
+func TestPaymentGateway_RefundPayment(t *testing.T) {
+	var receivedPath string
+	var body struct {
+		Amount float64                `json:"amount"`
+		Splits []domain.SplitReversal `json:"splits"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	splits := []domain.SplitReversal{{RecipientID: "seller", Amount: 7}, {RecipientID: "platform", Amount: 3}}
+	err := client2.NewPaymentGateway(server.URL, server.Client()).RefundPayment(context.Background(), "payment123", 10, splits)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/payments/payment123/refund", receivedPath)
+	assert.Equal(t, 10.0, body.Amount)
+	assert.Equal(t, splits, body.Splits)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	client2 "payments/client"
+)
+
+func writeRates(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "fx_rates.json")
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestFileRateProvider_Rate(t *testing.T) {
+	path := writeRates(t, `{
+		"source": "PTAX",
+		"base": "BRL",
+		"timestamp": "2025-01-02T10:00:00-03:00",
+		"rates": {"USD": 6.1721, "EUR": 6.3891}
+	}`)
+	provider := client2.NewFileRateProvider(path)
+
+	rate, err := provider.Rate(context.Background(), "USD", "BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", rate.From)
+	assert.Equal(t, "BRL", rate.To)
+	assert.Equal(t, 6.1721, rate.Rate)
+	assert.Equal(t, "PTAX", rate.Source)
+	assert.True(t, time.Date(2025, time.January, 2, 13, 0, 0, 0, time.UTC).Equal(rate.Timestamp))
+
+	// Cotação cruzada pela moeda base
+	rate, err = provider.Rate(context.Background(), "EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.035158, rate.Rate)
+
+	_, err = provider.Rate(context.Background(), "JPY", "BRL")
+	assert.NotNil(t, err)
+}
+
+func TestFileRateProvider_ReloadsTheFile(t *testing.T) {
+	path := writeRates(t, `{"base": "BRL", "rates": {"USD": 6}}`)
+	provider := client2.NewFileRateProvider(path)
+
+	rate, err := provider.Rate(context.Background(), "USD", "BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, 6.0, rate.Rate)
+	// Sem source nem timestamp valem o caminho e a data de modificação do arquivo
+	assert.Equal(t, "file:"+path, rate.Source)
+	assert.False(t, rate.Timestamp.IsZero())
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"base": "BRL", "rates": {"USD": 5.5}}`), 0o600))
+	rate, err = provider.Rate(context.Background(), "USD", "BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, 5.5, rate.Rate)
+}
+
+func TestFileRateProvider_InvalidFile(t *testing.T) {
+	_, err := client2.NewFileRateProvider(filepath.Join(t.TempDir(), "missing.json")).Rate(context.Background(), "USD", "BRL")
+	assert.NotNil(t, err)
+
+	_, err = client2.NewFileRateProvider(writeRates(t, `{"rates": {"USD": 6}}`)).Rate(context.Background(), "USD", "BRL")
+	assert.NotNil(t, err)
+}
+
+func TestNewRateProviderFromEnv(t *testing.T) {
+	t.Setenv("FX_RATES_FILE", "")
+	assert.Nil(t, client2.NewRateProviderFromEnv())
+
+	t.Setenv("FX_RATES_FILE", "fx_rates.json")
+	assert.NotNil(t, client2.NewRateProviderFromEnv())
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	client2 "payments/client"
+	"payments/domain"
+)
+
+func TestWebhookSender_Send_SignsPayload(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	delivery := domain.WebhookDelivery{ID: primitive.NewObjectID(), EventType: domain.EventPaymentApproved,
+		Payload: `{"id":"evt","type":"payment.approved"}`}
+	attempt := client2.NewWebhookSender(server.Client()).Send(context.Background(), server.URL, "whsec_test", delivery)
+
+	assert.True(t, attempt.Succeeded())
+	assert.Equal(t, http.StatusAccepted, attempt.StatusCode)
+	assert.Equal(t, "ok", attempt.ResponseBody)
+	assert.Equal(t, delivery.Payload, string(body))
+	assert.Equal(t, delivery.ID.Hex(), headers.Get(client2.WebhookIDHeader))
+	assert.Equal(t, domain.EventPaymentApproved, headers.Get(client2.WebhookEventHeader))
+
+	// O lojista confere a assinatura com o segredo, o timestamp e o corpo recebidos
+	timestamp, err := strconv.ParseInt(headers.Get(client2.WebhookTimestampHeader), 10, 64)
+	assert.Nil(t, err)
+	assert.Equal(t, client2.SignWebhookPayload("whsec_test", timestamp, body), headers.Get(client2.WebhookSignatureHeader))
+	assert.NotEqual(t, client2.SignWebhookPayload("other", timestamp, body), headers.Get(client2.WebhookSignatureHeader))
+}
+
+func TestWebhookSender_Send_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	sender := client2.NewWebhookSender(server.Client())
+	delivery := domain.WebhookDelivery{ID: primitive.NewObjectID(), Payload: `{}`}
+
+	attempt := sender.Send(context.Background(), server.URL, "secret", delivery)
+	assert.False(t, attempt.Succeeded())
+	assert.Equal(t, http.StatusInternalServerError, attempt.StatusCode)
+	assert.Empty(t, attempt.Error)
+
+	// Falha de rede fica no log da tentativa, sem status HTTP
+	server.Close()
+	attempt = sender.Send(context.Background(), server.URL, "secret", delivery)
+	assert.False(t, attempt.Succeeded())
+	assert.Zero(t, attempt.StatusCode)
+	assert.Contains(t, attempt.Error, "error sending HTTP request")
+}
+
+func TestWebhookSender_Send_RefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// O cliente padrão só conecta a IPs públicos; o httptest escuta no loopback
+	delivery := domain.WebhookDelivery{ID: primitive.NewObjectID(), Payload: `{}`}
+	attempt := client2.NewWebhookSender(nil).Send(context.Background(), server.URL, "secret", delivery)
+
+	assert.False(t, attempt.Succeeded())
+	assert.Zero(t, attempt.StatusCode)
+	assert.Contains(t, attempt.Error, "is not a public address")
+	assert.False(t, called)
+}
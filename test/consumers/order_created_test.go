@@ -0,0 +1,161 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	consumers2 "payments/consumers"
+	"payments/domain"
+	"payments/events"
+	"payments/repository"
+	"payments/usecase"
+)
+
+func newConsumer(useCase usecase.PaymentUseCase) (*consumers2.OrderCreatedConsumer, *events.MemoryPublisher) {
+	publisher := events.NewMemoryPublisher()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return consumers2.NewOrderCreatedConsumer(events.NewMemorySubscriber(1), useCase, publisher, logger), publisher
+}
+
+func orderCreated(t *testing.T, data string) events.CloudEvent {
+	t.Helper()
+	return events.CloudEvent{
+		SpecVersion: events.SpecVersion,
+		ID:          "evt-1",
+		Source:      "/orders",
+		Type:        "order.created",
+		Time:        time.Now(),
+		Data:        json.RawMessage(data),
+	}
+}
+
+func instructionsOf(t *testing.T, event events.CloudEvent) events.PaymentInstructions {
+	t.Helper()
+	assert.Equal(t, events.PaymentType(events.EventPaymentInstructions), event.Type)
+	var instructions events.PaymentInstructions
+	require.NoError(t, json.Unmarshal(event.Data, &instructions))
+	return instructions
+}
+
+func TestOrderCreatedConsumer_CreatesPaymentAndPublishesInstructions(t *testing.T) {
+	useCase := usecase.NewPaymentUseCase(repository.NewMemoryPaymentRepository())
+	consumer, publisher := newConsumer(useCase)
+
+	err := consumer.Handle(context.Background(), orderCreated(t, `{"order_id":"order-1","amount":150,"payment_type":"BOLETO"}`))
+
+	require.NoError(t, err)
+	order, err := useCase.GetPaymentsByOrderID(context.Background(), "order-1")
+	require.NoError(t, err)
+	require.Len(t, order.Payments, 1)
+	assert.Equal(t, "online", order.Payments[0].Method)
+
+	require.Len(t, publisher.Events(), 1)
+	instructions := instructionsOf(t, publisher.Events()[0])
+	assert.Equal(t, order.Payments[0].ID.Hex(), instructions.PaymentID)
+	assert.Equal(t, "order-1", instructions.OrderID)
+	assert.Equal(t, 150.0, instructions.Amount)
+	assert.Equal(t, domain.Boleto, instructions.PaymentType)
+	assert.NotNil(t, instructions.Boleto)
+	assert.Equal(t, "/payments/"+instructions.PaymentID+"/boleto.png", instructions.BoletoImagePath)
+}
+
+func TestOrderCreatedConsumer_RedeliveryDoesNotCreateAgain(t *testing.T) {
+	useCase := usecase.NewPaymentUseCase(repository.NewMemoryPaymentRepository())
+	consumer, publisher := newConsumer(useCase)
+	event := orderCreated(t, `{"order_id":"order-2","amount":80,"payment_type":"PIX"}`)
+
+	require.NoError(t, consumer.Handle(context.Background(), event))
+	require.NoError(t, consumer.Handle(context.Background(), event))
+
+	order, err := useCase.GetPaymentsByOrderID(context.Background(), "order-2")
+	require.NoError(t, err)
+	assert.Len(t, order.Payments, 1)
+
+	// As instruções são publicadas de novo, com o mesmo pagamento
+	require.Len(t, publisher.Events(), 2)
+	first, second := instructionsOf(t, publisher.Events()[0]), instructionsOf(t, publisher.Events()[1])
+	assert.Equal(t, first.PaymentID, second.PaymentID)
+}
+
+func TestOrderCreatedConsumer_InvalidData(t *testing.T) {
+	useCase := usecase.NewPaymentUseCase(repository.NewMemoryPaymentRepository())
+	consumer, publisher := newConsumer(useCase)
+
+	err := consumer.Handle(context.Background(), orderCreated(t, `{"order_id":"order-3","amount":-1}`))
+
+	var validationErr *domain.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.True(t, events.IsPermanent(err), "um pedido inválido não deve ser tentado de novo")
+	assert.Empty(t, publisher.Events())
+}
+
+func TestOrderCreatedConsumer_RejectsOtherEventTypes(t *testing.T) {
+	useCase := usecase.NewPaymentUseCase(repository.NewMemoryPaymentRepository())
+	consumer, publisher := newConsumer(useCase)
+	event := orderCreated(t, `{"order_id":"order-6","amount":30,"payment_type":"PIX"}`)
+	event.Type = "order.cancelled"
+
+	err := consumer.Handle(context.Background(), event)
+
+	assert.ErrorIs(t, err, events.ErrPermanent)
+	order, err := useCase.GetPaymentsByOrderID(context.Background(), "order-6")
+	require.NoError(t, err)
+	assert.Empty(t, order.Payments)
+	assert.Empty(t, publisher.Events())
+}
+
+// racingUseCase simula outra instância criando o pagamento entre a consulta e a criação
+type racingUseCase struct {
+	usecase.PaymentUseCase
+	lookups int
+	winner  domain.Payment
+}
+
+func (r *racingUseCase) GetPaymentsByOrderID(ctx context.Context, orderID string) (domain.OrderPayments, error) {
+	r.lookups++
+	if r.lookups == 1 {
+		return domain.OrderPayments{OrderID: orderID}, nil
+	}
+	return domain.OrderPayments{OrderID: orderID, ActivePaymentID: r.winner.ID.Hex(), Payments: []domain.Payment{r.winner}}, nil
+}
+
+func (r *racingUseCase) CreatePayment(ctx context.Context, payment *domain.Payment) (string, error) {
+	return "", domain.ErrConflict
+}
+
+func TestOrderCreatedConsumer_ConflictUsesExistingPayment(t *testing.T) {
+	winner := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order-4", Amount: 10, Status: domain.StatusProcessing, PaymentType: domain.Pix}
+	useCase := &racingUseCase{winner: winner}
+	consumer, publisher := newConsumer(useCase)
+
+	err := consumer.Handle(context.Background(), orderCreated(t, `{"order_id":"order-4","amount":10,"payment_type":"PIX"}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, useCase.lookups)
+	require.Len(t, publisher.Events(), 1)
+	assert.Equal(t, winner.ID.Hex(), instructionsOf(t, publisher.Events()[0]).PaymentID)
+}
+
+func TestOrderCreatedConsumer_RunConsumesSubscriber(t *testing.T) {
+	useCase := usecase.NewPaymentUseCase(repository.NewMemoryPaymentRepository())
+	publisher := events.NewMemoryPublisher()
+	subscriber := events.NewMemorySubscriber(1)
+	consumer := consumers2.NewOrderCreatedConsumer(subscriber, useCase, publisher, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+
+	require.NoError(t, subscriber.Deliver(ctx, orderCreated(t, `{"order_id":"order-5","amount":25,"payment_type":"PIX"}`)))
+	assert.Eventually(t, func() bool { return len(publisher.Events()) == 1 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,108 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	delivery2 "payments/delivery"
+	"payments/domain"
+)
+
+const APIKeysEndpoint = "/admin/api-keys"
+
+type MockAPIKeyUseCase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUseCase) IssueAPIKey(ctx context.Context, name string, scopes []string) (domain.IssuedAPIKey, error) {
+	args := m.Called(name, scopes)
+	return args.Get(0).(domain.IssuedAPIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) GetAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) RevokeAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	args := m.Called(key)
+	return args.Get(0).(domain.Principal), args.Error(1)
+}
+
+func TestAPIKeyHandler_IssueAPIKey(t *testing.T) {
+	mockUseCase := new(MockAPIKeyUseCase)
+	handler := delivery2.NewAPIKeyHandler(mockUseCase)
+	mockUseCase.On("IssueAPIKey", "checkout", []string{domain.ScopePaymentsWrite}).Return(domain.IssuedAPIKey{
+		APIKey: domain.APIKey{ID: primitive.NewObjectID(), Name: "checkout", Prefix: "pk_0123", Hash: "stored-hash", Scopes: []string{domain.ScopePaymentsWrite}},
+		Key:    "pk_0123_secret",
+	}, nil)
+
+	app := newApp()
+	app.Post(APIKeysEndpoint, handler.IssueAPIKey)
+
+	resp, err := app.Test(httptest.NewRequest("POST", APIKeysEndpoint, strings.NewReader(`{"name": "checkout", "scopes": ["payments:write"]}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	raw, _ := io.ReadAll(resp.Body)
+	var body map[string]any
+	assert.Nil(t, json.Unmarshal(raw, &body))
+	assert.Equal(t, "pk_0123_secret", body["key"])
+	assert.Equal(t, "pk_0123", body["prefix"])
+	assert.NotContains(t, string(raw), "stored-hash")
+
+	// Escopo desconhecido vira 422 sem chegar ao caso de uso
+	resp, err = app.Test(httptest.NewRequest("POST", APIKeysEndpoint, strings.NewReader(`{"name": "checkout", "scopes": ["everything"]}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	mockUseCase.AssertNumberOfCalls(t, "IssueAPIKey", 1)
+}
+
+func TestAPIKeyHandler_ListAPIKeysHidesHashes(t *testing.T) {
+	mockUseCase := new(MockAPIKeyUseCase)
+	handler := delivery2.NewAPIKeyHandler(mockUseCase)
+	mockUseCase.On("ListAPIKeys").Return([]domain.APIKey{{ID: primitive.NewObjectID(), Prefix: "pk_0123", Hash: "stored-hash"}}, nil)
+
+	app := newApp()
+	app.Get(APIKeysEndpoint, handler.ListAPIKeys)
+
+	resp, err := app.Test(httptest.NewRequest("GET", APIKeysEndpoint, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "pk_0123")
+	assert.NotContains(t, string(raw), "stored-hash")
+}
+
+func TestAPIKeyHandler_RevokeAPIKey_NotFound(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockAPIKeyUseCase)
+	handler := delivery2.NewAPIKeyHandler(mockUseCase)
+	mockUseCase.On("RevokeAPIKey", id).Return(domain.APIKey{}, domain.ErrNotFound)
+
+	app := newApp()
+	app.Post(APIKeysEndpoint+"/:id/revoke", handler.RevokeAPIKey)
+
+	resp, err := app.Test(httptest.NewRequest("POST", APIKeysEndpoint+"/"+id+"/revoke", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	mockUseCase.AssertExpectations(t)
+}
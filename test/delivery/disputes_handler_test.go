@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	delivery2 "payments/delivery"
+	"payments/domain"
+)
+
+type MockDisputeUseCase struct {
+	mock.Mock
+}
+
+func (m *MockDisputeUseCase) OpenDispute(ctx context.Context, paymentID string, input domain.Dispute) (domain.Dispute, error) {
+	args := m.Called(paymentID, input)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) GetDispute(ctx context.Context, id string) (domain.Dispute, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) ListPaymentDisputes(ctx context.Context, paymentID string) ([]domain.Dispute, error) {
+	args := m.Called(paymentID)
+	return args.Get(0).([]domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) UpdateDispute(ctx context.Context, id string, update domain.DisputeUpdate) (domain.Dispute, error) {
+	args := m.Called(id, update)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) ResolveDispute(ctx context.Context, id string, outcome string) (domain.Dispute, error) {
+	args := m.Called(id, outcome)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func TestDisputeHandler_OpenDispute(t *testing.T) {
+	paymentID := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockDisputeUseCase)
+	handler := delivery2.NewDisputeHandler(mockUseCase)
+	mockUseCase.On("OpenDispute", paymentID, mock.MatchedBy(func(input domain.Dispute) bool {
+		return input.ReasonCode == "4837" && input.Amount == 50 && len(input.Evidence) == 1
+	})).Return(domain.Dispute{ID: primitive.NewObjectID(), PaymentID: paymentID, ReasonCode: "4837", Amount: 50, Status: domain.DisputeOpen}, nil)
+
+	app := newApp()
+	app.Post(PaymentsEndpoint+"/:id/disputes", handler.OpenDispute)
+
+	resp, err := app.Test(httptest.NewRequest("POST", PaymentsEndpoint+"/"+paymentID+"/disputes", strings.NewReader(`{
+		"reason_code": "4837",
+		"amount": 50,
+		"evidence": [{"file_name": "receipt.pdf", "content_type": "application/pdf", "size": 2048, "url": "https://files.example.com/receipt.pdf"}]
+	}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var dispute domain.Dispute
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&dispute))
+	assert.Equal(t, domain.DisputeOpen, dispute.Status)
+
+	// Corpo inválido vira 422 sem chegar ao caso de uso
+	resp, err = app.Test(httptest.NewRequest("POST", PaymentsEndpoint+"/"+paymentID+"/disputes", strings.NewReader(`{"status": "won"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	mockUseCase.AssertNumberOfCalls(t, "OpenDispute", 1)
+}
+
+func TestDisputeHandler_ResolveDispute_Conflict(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockDisputeUseCase)
+	handler := delivery2.NewDisputeHandler(mockUseCase)
+	mockUseCase.On("ResolveDispute", id, domain.DisputeLost).Return(domain.Dispute{}, fmt.Errorf("%w: dispute is already won", domain.ErrConflict))
+
+	app := newApp()
+	app.Post("/disputes/:id/resolve", handler.ResolveDispute)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/disputes/"+id+"/resolve", strings.NewReader(`{"outcome": "lost"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "/problems/conflict", decodeProblem(t, resp).Type)
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/disputes/"+id+"/resolve", strings.NewReader(`{"outcome": "draw"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	mockUseCase.AssertNumberOfCalls(t, "ResolveDispute", 1)
+}
+
+func TestDisputeHandler_ListPaymentDisputes(t *testing.T) {
+	paymentID := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockDisputeUseCase)
+	handler := delivery2.NewDisputeHandler(mockUseCase)
+	mockUseCase.On("ListPaymentDisputes", paymentID).Return([]domain.Dispute{{PaymentID: paymentID, Status: domain.DisputeLost}}, nil)
+
+	app := newApp()
+	app.Get(PaymentsEndpoint+"/:id/disputes", handler.ListPaymentDisputes)
+
+	resp, err := app.Test(httptest.NewRequest("GET", PaymentsEndpoint+"/"+paymentID+"/disputes", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var disputes []domain.Dispute
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&disputes))
+	assert.Len(t, disputes, 1)
+}
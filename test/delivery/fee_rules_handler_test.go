@@ -0,0 +1,114 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	delivery2 "payments/delivery"
+	"payments/domain"
+)
+
+const FeeRulesEndpoint = "/admin/fee-rules"
+
+type MockFeeRuleUseCase struct {
+	mock.Mock
+}
+
+func (m *MockFeeRuleUseCase) ListFeeRules(ctx context.Context) ([]domain.FeeRule, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleUseCase) GetFeeRule(ctx context.Context, id string) (domain.FeeRule, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleUseCase) CreateFeeRule(ctx context.Context, rule *domain.FeeRule) (string, error) {
+	args := m.Called(rule)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFeeRuleUseCase) UpdateFeeRule(ctx context.Context, id string, rule *domain.FeeRule) error {
+	args := m.Called(id, rule)
+	return args.Error(0)
+}
+
+func (m *MockFeeRuleUseCase) DeleteFeeRule(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestFeeRuleHandler_CreateFeeRule(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockFeeRuleUseCase)
+	handler := delivery2.NewFeeRuleHandler(mockUseCase)
+	mockUseCase.On("CreateFeeRule", mock.MatchedBy(func(rule *domain.FeeRule) bool {
+		return rule.PaymentType == domain.Pix && rule.Percentage == 0.99
+	})).Return(id, nil)
+
+	app := newApp()
+	app.Post(FeeRulesEndpoint, handler.CreateFeeRule)
+
+	resp, err := app.Test(httptest.NewRequest("POST", FeeRulesEndpoint,
+		strings.NewReader(`{"payment_type": "PIX", "percentage": 0.99, "effective_from": "2025-07-01T00:00:00Z"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var body map[string]string
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, id, body["id"])
+
+	// Corpo inválido vira 422 sem chegar ao caso de uso
+	resp, err = app.Test(httptest.NewRequest("POST", FeeRulesEndpoint, strings.NewReader(`{"payment_type": "PIX"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	mockUseCase.AssertNumberOfCalls(t, "CreateFeeRule", 1)
+}
+
+func TestFeeRuleHandler_UpdateFeeRule_InEffect(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockFeeRuleUseCase)
+	handler := delivery2.NewFeeRuleHandler(mockUseCase)
+	mockUseCase.On("UpdateFeeRule", id, mock.Anything).Return(fmt.Errorf("%w: fee rule is already in effect", domain.ErrConflict))
+
+	app := newApp()
+	app.Put(FeeRulesEndpoint+"/:id", handler.UpdateFeeRule)
+
+	resp, err := app.Test(httptest.NewRequest("PUT", FeeRulesEndpoint+"/"+id,
+		strings.NewReader(`{"payment_type": "PIX", "percentage": 1, "effective_from": "2025-07-01T00:00:00Z"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "/problems/conflict", decodeProblem(t, resp).Type)
+}
+
+func TestFeeRuleHandler_ListAndDelete(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockFeeRuleUseCase)
+	handler := delivery2.NewFeeRuleHandler(mockUseCase)
+	mockUseCase.On("ListFeeRules").Return([]domain.FeeRule{{PaymentType: domain.Pix, Percentage: 1}}, nil)
+	mockUseCase.On("DeleteFeeRule", id).Return(nil)
+
+	app := newApp()
+	app.Get(FeeRulesEndpoint, handler.ListFeeRules)
+	app.Delete(FeeRulesEndpoint+"/:id", handler.DeleteFeeRule)
+
+	resp, err := app.Test(httptest.NewRequest("GET", FeeRulesEndpoint, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var rules []domain.FeeRule
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&rules))
+	assert.Len(t, rules, 1)
+
+	resp, err = app.Test(httptest.NewRequest("DELETE", FeeRulesEndpoint+"/"+id, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	mockUseCase.AssertExpectations(t)
+}
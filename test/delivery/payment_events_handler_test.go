@@ -0,0 +1,143 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	delivery2 "payments/delivery"
+	"payments/domain"
+	"payments/pubsub"
+)
+
+// streamEvents faz a requisição e devolve o corpo inteiro do stream, que termina no status terminal
+func streamEvents(t *testing.T, handler *delivery2.PaymentEventsHandler, id, lastEventID string) (int, string) {
+	app := newApp()
+	app.Get(PaymentsEndpoint+"/:id/events", handler.StreamPaymentEvents)
+	req := httptest.NewRequest("GET", PaymentsEndpoint+"/"+id+"/events", nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := app.Test(req, 2000)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestPaymentEventsHandler_StreamsUntilTerminal(t *testing.T) {
+	payment := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusProcessing, PaymentType: domain.Pix, Amount: 50}
+	broker := pubsub.NewMemoryBroker()
+	mockUseCase := new(MockPaymentUseCase)
+	// O callback chega enquanto o handler lê o pagamento: o evento não pode se perder
+	mockUseCase.On("GetPaymentByID", payment.ID.Hex()).Return(payment, nil).Run(func(mock.Arguments) {
+		approved := payment
+		approved.Status = "success"
+		broker.NotifyPayment(context.Background(), domain.EventPaymentApproved, approved)
+	})
+
+	status, body := streamEvents(t, delivery2.NewPaymentEventsHandler(mockUseCase, broker), payment.ID.Hex(), "")
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.True(t, strings.HasPrefix(body, "retry: 3000\n\n"))
+	snapshot := strings.Index(body, "id: 0\nevent: payment.snapshot\ndata: {")
+	approved := strings.Index(body, "id: 1\nevent: payment.approved\ndata: {")
+	assert.True(t, snapshot > 0 && approved > snapshot, body)
+	assert.Contains(t, body, `"status":"success"`)
+}
+
+func TestPaymentEventsHandler_Heartbeat(t *testing.T) {
+	payment := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusProcessing}
+	broker := pubsub.NewMemoryBroker()
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetPaymentByID", payment.ID.Hex()).Return(payment, nil).Run(func(mock.Arguments) {
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cancelled := payment
+			cancelled.Status = domain.StatusCancelled
+			broker.NotifyPayment(context.Background(), domain.EventPaymentCancelled, cancelled)
+		}()
+	})
+	handler := delivery2.NewPaymentEventsHandler(mockUseCase, broker, delivery2.WithHeartbeat(10*time.Millisecond))
+
+	_, body := streamEvents(t, handler, payment.ID.Hex(), "")
+
+	heartbeat := strings.Index(body, ": heartbeat\n\n")
+	cancelled := strings.Index(body, "id: 1\nevent: payment.cancelled")
+	assert.True(t, heartbeat > 0 && cancelled > heartbeat, body)
+}
+
+func TestPaymentEventsHandler_Resume(t *testing.T) {
+	payment := domain.Payment{ID: primitive.NewObjectID(), Status: "success"}
+	broker := pubsub.NewMemoryBroker()
+	other := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusProcessing}
+	broker.NotifyPayment(context.Background(), domain.EventPaymentCreated, domain.Payment{ID: payment.ID, Status: domain.StatusProcessing})
+	broker.NotifyPayment(context.Background(), domain.EventPaymentCreated, other)
+	broker.NotifyPayment(context.Background(), domain.EventPaymentApproved, payment)
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetPaymentByID", payment.ID.Hex()).Return(payment, nil)
+	handler := delivery2.NewPaymentEventsHandler(mockUseCase, broker)
+
+	t.Run("Replays missed events", func(t *testing.T) {
+		status, body := streamEvents(t, handler, payment.ID.Hex(), "1")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.NotContains(t, body, "payment.snapshot")
+		assert.NotContains(t, body, "id: 2\n")
+		assert.Contains(t, body, "id: 3\nevent: payment.approved")
+	})
+
+	t.Run("Final status already delivered", func(t *testing.T) {
+		status, body := streamEvents(t, handler, payment.ID.Hex(), "3")
+		assert.Equal(t, fiber.StatusNoContent, status)
+		assert.Empty(t, body)
+	})
+
+	t.Run("Unknown ID on a finished payment ends the reconnections", func(t *testing.T) {
+		status, body := streamEvents(t, handler, payment.ID.Hex(), "42")
+		assert.Equal(t, fiber.StatusNoContent, status)
+		assert.Empty(t, body)
+	})
+
+	t.Run("Unknown ID falls back to a snapshot", func(t *testing.T) {
+		mockUseCase.On("GetPaymentByID", other.ID.Hex()).Return(other, nil).Run(func(mock.Arguments) {
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				cancelled := other
+				cancelled.Status = domain.StatusCancelled
+				broker.NotifyPayment(context.Background(), domain.EventPaymentCancelled, cancelled)
+			}()
+		})
+		status, body := streamEvents(t, handler, other.ID.Hex(), "42")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Contains(t, body, "id: 3\nevent: payment.snapshot")
+	})
+}
+
+func TestPaymentEventsHandler_TerminalSnapshot(t *testing.T) {
+	payment := domain.Payment{ID: primitive.NewObjectID(), Status: "success"}
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetPaymentByID", payment.ID.Hex()).Return(payment, nil)
+
+	status, body := streamEvents(t, delivery2.NewPaymentEventsHandler(mockUseCase, pubsub.NewMemoryBroker()), payment.ID.Hex(), "")
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.True(t, strings.HasPrefix(body, "id: 0\nevent: payment.snapshot"), body)
+	assert.NotContains(t, body, "retry:")
+}
+
+func TestPaymentEventsHandler_NotFound(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetPaymentByID", id).Return(domain.Payment{}, fmt.Errorf("payment: %w", domain.ErrNotFound))
+
+	status, _ := streamEvents(t, delivery2.NewPaymentEventsHandler(mockUseCase, pubsub.NewMemoryBroker()), id, "")
+
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
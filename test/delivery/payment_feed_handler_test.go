@@ -0,0 +1,131 @@
+package delivery
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	delivery2 "payments/delivery"
+	"payments/domain"
+	"payments/pubsub"
+)
+
+// startFeed sobe o feed em uma porta local, já que o upgrade exige uma conexão real
+func startFeed(t *testing.T, broker pubsub.Broker) string {
+	handler := delivery2.NewPaymentFeedHandler(broker, delivery2.WithWriteTimeout(time.Second))
+	app := newApp()
+	app.Get("/ws/payments", handler.Upgrade, handler.Stream())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(listener) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return "ws://" + listener.Addr().String() + "/ws/payments"
+}
+
+func dialFeed(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readFeed(t *testing.T, conn *websocket.Conn) delivery2.FeedMessage {
+	var msg delivery2.FeedMessage
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestPaymentFeedHandler_Filters(t *testing.T) {
+	broker := pubsub.NewMemoryBroker()
+	conn := dialFeed(t, startFeed(t, broker)+"?payment_type=PIX")
+
+	subscribed := readFeed(t, conn)
+	assert.Equal(t, delivery2.FeedMessageSubscribed, subscribed.Type)
+	assert.Equal(t, []domain.PaymentType{domain.Pix}, subscribed.Filter.PaymentTypes)
+
+	broker.Publish(domain.PaymentEvent{PaymentID: "boleto", PaymentType: domain.Boleto, Status: domain.StatusProcessing})
+	broker.Publish(domain.PaymentEvent{PaymentID: "pix", PaymentType: domain.Pix, Status: domain.StatusProcessing})
+	msg := readFeed(t, conn)
+	assert.Equal(t, delivery2.FeedMessageEvent, msg.Type)
+	assert.Equal(t, "pix", msg.Event.PaymentID)
+
+	// Trocar o filtro vale para os próximos eventos
+	require.NoError(t, conn.WriteJSON(map[string]any{"action": "subscribe", "filter": map[string]any{"order_id_prefix": "shop-"}}))
+	subscribed = readFeed(t, conn)
+	assert.Equal(t, delivery2.FeedMessageSubscribed, subscribed.Type)
+	assert.Equal(t, "shop-", subscribed.Filter.OrderIDPrefix)
+
+	broker.Publish(domain.PaymentEvent{PaymentID: "other", OrderID: "mkt-1", PaymentType: domain.Pix})
+	broker.Publish(domain.PaymentEvent{PaymentID: "shop", OrderID: "shop-1", PaymentType: domain.Boleto})
+	msg = readFeed(t, conn)
+	assert.Equal(t, "shop", msg.Event.PaymentID)
+}
+
+func TestPaymentFeedHandler_InvalidMessage(t *testing.T) {
+	conn := dialFeed(t, startFeed(t, pubsub.NewMemoryBroker()))
+	readFeed(t, conn)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"action": "subscribe", "filter": {"payment_type": ["CASH"]}}`)))
+	msg := readFeed(t, conn)
+	assert.Equal(t, delivery2.FeedMessageError, msg.Type)
+	assert.Equal(t, []domain.ErrorResponse{{Field: "filter.payment_type[0]", Message: "invalid payment type: CASH"}}, msg.Errors)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`not json`)))
+	msg = readFeed(t, conn)
+	assert.Equal(t, delivery2.FeedMessageError, msg.Type)
+	assert.Contains(t, msg.Message, "invalid message")
+}
+
+// droppedBroker simula um assinante já desligado por lentidão
+type droppedBroker struct {
+	pubsub.Broker
+}
+
+type droppedSubscription struct {
+	pubsub.Subscription
+	events chan domain.PaymentEvent
+}
+
+func (b droppedBroker) Subscribe(filter pubsub.Filter, lastEventID string) pubsub.Subscription {
+	events := make(chan domain.PaymentEvent)
+	close(events)
+	return droppedSubscription{events: events}
+}
+
+func (s droppedSubscription) Events() <-chan domain.PaymentEvent { return s.events }
+func (s droppedSubscription) Close()                             {}
+
+func TestPaymentFeedHandler_DropsSlowConsumer(t *testing.T) {
+	conn := dialFeed(t, startFeed(t, droppedBroker{}))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+
+	var err error
+	for err == nil {
+		_, _, err = conn.ReadMessage()
+	}
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), err)
+}
+
+func TestPaymentFeedHandler_RequiresUpgrade(t *testing.T) {
+	handler := delivery2.NewPaymentFeedHandler(pubsub.NewMemoryBroker())
+	app := newApp()
+	app.Get("/ws/payments", handler.Upgrade, handler.Stream())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ws/payments", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUpgradeRequired, resp.StatusCode)
+
+	req := httptest.NewRequest("GET", "/ws/payments?payment_type=CASH", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err = app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+}
@@ -2,56 +2,109 @@ package delivery
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
 	"net/http/httptest"
 	delivery2 "payments/delivery"
 	"payments/domain"
+	"payments/dto"
+	"strings"
 	"testing"
+	"time"
 )
 
 const PaymentsEndpoint = "/payments"
 
+// newApp cria o app com o mesmo ErrorHandler usado em produção
+func newApp() *fiber.App {
+	return fiber.New(fiber.Config{ErrorHandler: delivery2.ErrorHandler})
+}
+
+func decodeProblem(t *testing.T, resp *http.Response) delivery2.ProblemDetails {
+	var problem delivery2.ProblemDetails
+	assert.Equal(t, delivery2.ProblemContentType, resp.Header.Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	return problem
+}
+
 type MockPaymentUseCase struct {
 	mock.Mock
 }
 
 // GetAllPayments é o método mockado para retornar uma lista de pagamentos ou erro
-func (m *MockPaymentUseCase) GetAllPayments() ([]domain.Payment, error) {
+func (m *MockPaymentUseCase) GetAllPayments(ctx context.Context) ([]domain.Payment, error) {
 	args := m.Called()
 	return args.Get(0).([]domain.Payment), args.Error(1)
 }
 
 // GetPaymentByID é um método mockado para atender à interface PaymentUseCase
-func (m *MockPaymentUseCase) GetPaymentByID(id string) (domain.Payment, error) {
+func (m *MockPaymentUseCase) GetPaymentByID(ctx context.Context, id string) (domain.Payment, error) {
 	args := m.Called(id)
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
 // CreatePayment é um método mockado para atender à interface PaymentUseCase
-func (m *MockPaymentUseCase) CreatePayment(payment *domain.Payment) (string, error) {
+func (m *MockPaymentUseCase) GetPaymentsByOrderID(ctx context.Context, orderID string) (domain.OrderPayments, error) {
+	args := m.Called(orderID)
+	return args.Get(0).(domain.OrderPayments), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) CreatePayment(ctx context.Context, payment *domain.Payment) (string, error) {
 	args := m.Called(payment)
 	return args.String(0), args.Error(1)
 }
 
 // UpdatePayment é um método mockado para atender à interface PaymentUseCase
-func (m *MockPaymentUseCase) UpdatePayment(id string, payment *domain.Payment) error {
-	args := m.Called(id, payment)
-	return args.Error(0)
+func (m *MockPaymentUseCase) UpdatePayment(ctx context.Context, id string, update domain.PaymentUpdate) (domain.Payment, error) {
+	args := m.Called(id, update)
+	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
 // DeletePayment é um método mockado para atender à interface PaymentUseCase
-func (m *MockPaymentUseCase) DeletePayment(id string) error {
+func (m *MockPaymentUseCase) DeletePayment(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
 // ProcessPaymentCallback é o método que estava faltando, agora adicionado
-func (m *MockPaymentUseCase) ProcessPaymentCallback(paymentCallback *domain.PaymentCallback) error {
+func (m *MockPaymentUseCase) CancelPayment(ctx context.Context, id string) (domain.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) CapturePayment(ctx context.Context, id string, amount float64) (domain.Payment, error) {
+	args := m.Called(id, amount)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) VoidPayment(ctx context.Context, id string) (domain.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) RefundPayment(ctx context.Context, id string, amount float64) (domain.Payment, error) {
+	args := m.Called(id, amount)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) ExpireOverdueBoletos(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) VoidExpiredAuthorizations(ctx context.Context, cutoff time.Time) (int, error) {
+	args := m.Called(cutoff)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) ProcessPaymentCallback(ctx context.Context, paymentCallback *domain.PaymentCallback) error {
 	args := m.Called(paymentCallback)
 	return args.Error(0)
 }
@@ -64,7 +117,7 @@ func TestPaymentHandler_GetAllPayments_Success(t *testing.T) {
 		{ID: primitive.NewObjectID(), Amount: 200},
 	}
 	mockUseCase.On("GetAllPayments").Return(mockPayments, nil)
-	app := fiber.New()
+	app := newApp()
 	app.Get(PaymentsEndpoint, handler.GetAllPayments)
 	req := httptest.NewRequest("GET", PaymentsEndpoint, nil)
 	resp, err := app.Test(req)
@@ -79,7 +132,7 @@ func TestPaymentHandler_GetAllPayments_Error(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 	mockUseCase.On("GetAllPayments").Return([]domain.Payment{}, errors.New("Internal server error"))
-	app := fiber.New()
+	app := newApp()
 	app.Get(PaymentsEndpoint, handler.GetAllPayments)
 	req := httptest.NewRequest("GET", PaymentsEndpoint, nil)
 	resp, err := app.Test(req)
@@ -96,7 +149,7 @@ func TestPaymentHandler_GetPaymentByID_Success(t *testing.T) {
 		Amount: 150,
 	}
 	mockUseCase.On("GetPaymentByID", mockPayment.ID.Hex()).Return(mockPayment, nil)
-	app := fiber.New()
+	app := newApp()
 	app.Get(PaymentsEndpoint+"/:id", handler.GetPaymentByID)
 	req := httptest.NewRequest("GET", PaymentsEndpoint+"/"+mockPayment.ID.Hex(), nil)
 	resp, err := app.Test(req)
@@ -110,13 +163,34 @@ func TestPaymentHandler_GetPaymentByID_Success(t *testing.T) {
 	assert.Equal(t, mockPayment.Amount, payment.Amount)
 }
 
+func TestPaymentHandler_GetPaymentsByOrderID(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+
+	latest := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order123", Status: "Recusado"}
+	mockUseCase.On("GetPaymentsByOrderID", "order123").Return(domain.NewOrderPayments("order123", []domain.Payment{latest}), nil)
+	app := newApp()
+	app.Get("/orders/:orderId/payments", handler.GetPaymentsByOrderID)
+	resp, err := app.Test(httptest.NewRequest("GET", "/orders/order123/payments", nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var summary domain.OrderPayments
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&summary))
+	assert.Equal(t, "order123", summary.OrderID)
+	assert.Equal(t, "Recusado", summary.Status)
+	assert.Equal(t, 1, summary.Attempts)
+	assert.Equal(t, latest.ID, summary.Payments[0].ID)
+}
+
 func TestPaymentHandler_GetPaymentByID_NotFound(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
-	mockUseCase.On("GetPaymentByID", "invalid-id").Return(domain.Payment{}, errors.New("Payment not found"))
+	mockUseCase.On("GetPaymentByID", "invalid-id").Return(domain.Payment{}, fmt.Errorf("payment invalid-id: %w", domain.ErrNotFound))
 
-	app := fiber.New()
+	app := newApp()
 	app.Get(PaymentsEndpoint+"/:id", handler.GetPaymentByID)
 
 	req := httptest.NewRequest("GET", PaymentsEndpoint+"/invalid-id", nil)
@@ -126,44 +200,119 @@ func TestPaymentHandler_GetPaymentByID_NotFound(t *testing.T) {
 	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
 }
 
-func TestPaymentHandler_CreatePayment_Success(t *testing.T) {
+func TestPaymentHandler_GetPaymentByID_InvalidID(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
-	mockPayment := domain.Payment{
-		Amount: 150,
-	}
-	mockUUID := "123e4567-e89b-12d3-a456-426614174000"
-	mockUseCase.On("CreatePayment", &mockPayment).Return(mockUUID, nil)
+	mockUseCase.On("GetPaymentByID", "invalid-id").Return(domain.Payment{}, fmt.Errorf("%w: invalid ObjectID format", domain.ErrInvalidID))
 
-	app := fiber.New()
-	app.Post(PaymentsEndpoint, handler.CreatePayment)
+	app := newApp()
+	app.Get(PaymentsEndpoint+"/:id", handler.GetPaymentByID)
+
+	req := httptest.NewRequest("GET", PaymentsEndpoint+"/invalid-id", nil)
+	resp, err := app.Test(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "/problems/invalid-id", decodeProblem(t, resp).Type)
+}
+
+func TestPaymentHandler_GetPaymentByID_DatabaseError(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+
+	// Uma falha no banco não pode ser confundida com pagamento inexistente
+	mockUseCase.On("GetPaymentByID", "60c72b2f9af1c88b8f8d3b4a").Return(domain.Payment{}, errors.New("server selection timeout"))
+
+	app := newApp()
+	app.Get(PaymentsEndpoint+"/:id", handler.GetPaymentByID)
+
+	req := httptest.NewRequest("GET", PaymentsEndpoint+"/60c72b2f9af1c88b8f8d3b4a", nil)
+	resp, err := app.Test(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
+
+// validCreateRequest é um corpo válido para POST /payments
+func validCreateRequest() dto.CreatePaymentRequest {
+	return dto.CreatePaymentRequest{
+		OrderID:     "order123",
+		Amount:      150,
+		Method:      dto.MethodOnline,
+		PaymentType: domain.Pix,
+	}
+}
 
-	body, _ := json.Marshal(mockPayment)
+func postPayment(t *testing.T, app *fiber.App, body []byte) *http.Response {
 	req := httptest.NewRequest("POST", PaymentsEndpoint, bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	resp, err := app.Test(req)
-
 	assert.Nil(t, err)
+	return resp
+}
+
+func TestPaymentHandler_CreatePayment_Success(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+
+	request := validCreateRequest()
+	mockUUID := "123e4567-e89b-12d3-a456-426614174000"
+	mockUseCase.On("CreatePayment", request.ToPayment()).Return(mockUUID, nil)
+
+	app := newApp()
+	app.Post(PaymentsEndpoint, handler.CreatePayment)
+
+	body, _ := json.Marshal(request)
+	resp := postPayment(t, app, body)
+
 	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
 
 	var response map[string]string
 	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
 	assert.Equal(t, mockUUID, response["uuid"])
+	mockUseCase.AssertExpectations(t)
+}
+
+// stubStreamTokens emite tokens previsíveis para o pagamento
+type stubStreamTokens struct{ expiresAt time.Time }
+
+func (s stubStreamTokens) Issue(paymentID string) (string, time.Time, error) {
+	return "pst_" + paymentID, s.expiresAt, nil
+}
+
+func TestPaymentHandler_CreatePayment_ReturnsStreamToken(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	expiresAt := time.Date(2030, time.January, 20, 12, 30, 0, 0, time.UTC)
+	handler := delivery2.NewPaymentHandler(mockUseCase, delivery2.WithStreamTokens(stubStreamTokens{expiresAt: expiresAt}))
+
+	request := validCreateRequest()
+	mockUseCase.On("CreatePayment", request.ToPayment()).Return("67a8ffa093a5fa72f000452b", nil)
+
+	app := newApp()
+	app.Post(PaymentsEndpoint, handler.CreatePayment)
+
+	body, _ := json.Marshal(request)
+	resp := postPayment(t, app, body)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var response map[string]string
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, "67a8ffa093a5fa72f000452b", response["uuid"])
+	assert.Equal(t, "pst_67a8ffa093a5fa72f000452b", response["events_token"])
+	assert.Equal(t, "2030-01-20T12:30:00Z", response["events_token_expires_at"])
+	mockUseCase.AssertExpectations(t)
 }
 
 func TestPaymentHandler_CreatePayment_InvalidInput(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
-	app := fiber.New()
+	app := newApp()
 	app.Post(PaymentsEndpoint, handler.CreatePayment)
 
-	req := httptest.NewRequest("POST", PaymentsEndpoint, bytes.NewReader([]byte("invalid")))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := app.Test(req)
+	resp := postPayment(t, app, []byte("invalid"))
 
-	assert.Nil(t, err)
 	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 }
 
@@ -171,41 +320,63 @@ func TestPaymentHandler_CreatePayment_UnprocessableEntity(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
-	mockPayment := domain.Payment{
-		Amount: 150,
-	}
-	mockUseCase.On("CreatePayment", &mockPayment).Return("", &domain.InvalidPaymentTypeError{Type: "CreditCard"})
+	app := newApp()
+	app.Post(PaymentsEndpoint, handler.CreatePayment)
+
+	request := validCreateRequest()
+	request.PaymentType = "CreditCard"
+	body, _ := json.Marshal(request)
+	resp := postPayment(t, app, body)
 
-	app := fiber.New()
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	problem := decodeProblem(t, resp)
+	assert.Equal(t, []domain.ErrorResponse{{Field: "payment_type", Message: "invalid payment type: CreditCard"}}, problem.Errors)
+	mockUseCase.AssertNotCalled(t, "CreatePayment", mock.Anything)
+}
+
+func TestPaymentHandler_CreatePayment_ValidationErrors(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+
+	app := newApp()
 	app.Post(PaymentsEndpoint, handler.CreatePayment)
 
-	body, _ := json.Marshal(mockPayment)
-	req := httptest.NewRequest("POST", PaymentsEndpoint, bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := app.Test(req)
+	// Todas as violações devem voltar de uma vez, inclusive os campos controlados pelo servidor
+	resp := postPayment(t, app, []byte(`{
+		"id": "60c72b2f9af1c88b8f8d3b4a",
+		"status": "approved",
+		"amount": -1,
+		"method": "bitcoin",
+		"payment_type": "PIX"
+	}`))
 
-	assert.Nil(t, err)
 	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	problem := decodeProblem(t, resp)
+	assert.Equal(t, "/problems/validation", problem.Type)
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "id", Message: "is controlled by the server and must not be sent"},
+		{Field: "status", Message: "is controlled by the server and must not be sent"},
+		{Field: "order_id", Message: "is required"},
+		{Field: "amount", Message: "must be greater than 0"},
+		{Field: "method", Message: "must be one of: online, in_store, app"},
+	}, problem.Errors)
+	mockUseCase.AssertNotCalled(t, "CreatePayment", mock.Anything)
 }
 
 func TestPaymentHandler_CreatePayment_InternalServerError(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
-	mockPayment := domain.Payment{
-		Amount: 150,
-	}
-	mockUseCase.On("CreatePayment", &mockPayment).Return("", errors.New("unexpected error"))
+	request := validCreateRequest()
+	mockUseCase.On("CreatePayment", request.ToPayment()).Return("", errors.New("unexpected error"))
 
-	app := fiber.New()
+	app := newApp()
 	app.Post(PaymentsEndpoint, handler.CreatePayment)
 
-	body, _ := json.Marshal(mockPayment)
-	req := httptest.NewRequest("POST", PaymentsEndpoint, bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := app.Test(req)
+	body, _ := json.Marshal(request)
+	resp := postPayment(t, app, body)
 
-	assert.Nil(t, err)
 	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
 }
 
@@ -214,27 +385,47 @@ func TestPaymentHandler_UpdatePayment_Success(t *testing.T) {
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
 	paymentID := "60c72b2f9af1c88b8f8d3b4a"
-	mockPayment := domain.Payment{Amount: 300}
+	updated := domain.Payment{Amount: 300, Method: "in_store", Status: domain.StatusCaptured}
+
+	mockUseCase.On("UpdatePayment", paymentID, domain.PaymentUpdate{Method: "in_store"}).Return(updated, nil)
+
+	app := newApp()
+	app.Put(PaymentsEndpoint+"/:id", handler.UpdatePayment)
+
+	req := httptest.NewRequest("PUT", PaymentsEndpoint+"/"+paymentID, strings.NewReader(`{"method": "in_store"}`))
+	req.Header.Set("Content-Type", "application/json")
 
-	mockUseCase.On("UpdatePayment", paymentID, &mockPayment).Return(nil)
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var payment domain.Payment
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&payment))
+	assert.Equal(t, updated.Method, payment.Method)
+}
 
-	app := fiber.New()
+func TestPaymentHandler_UpdatePayment_RejectsServerControlledFields(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+
+	app := newApp()
 	app.Put(PaymentsEndpoint+"/:id", handler.UpdatePayment)
 
-	body, _ := json.Marshal(mockPayment)
-	req := httptest.NewRequest("PUT", PaymentsEndpoint+"/"+paymentID, bytes.NewReader(body))
+	// O pagamento não pode ser reescrito: valores e status só mudam pelas operações
+	body := `{"method": "in_store", "status": "Capturado", "amount": 1, "fee_amount": 0, "refund_required": false}`
+	req := httptest.NewRequest("PUT", PaymentsEndpoint+"/60c72b2f9af1c88b8f8d3b4a", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := app.Test(req)
 	assert.Nil(t, err)
-	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	mockUseCase.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
 }
 
 func TestPaymentHandler_UpdatePayment_BadRequest(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
-	app := fiber.New()
+	app := newApp()
 	app.Put(PaymentsEndpoint+"/:id", handler.UpdatePayment)
 
 	invalidBody := "{invalid json}"
@@ -251,15 +442,13 @@ func TestPaymentHandler_UpdatePayment_NotFound(t *testing.T) {
 	handler := delivery2.NewPaymentHandler(mockUseCase)
 
 	paymentID := "60c72b2f9af1c88b8f8d3b4a"
-	mockPayment := domain.Payment{Amount: 300}
+	mockUseCase.On("UpdatePayment", paymentID, domain.PaymentUpdate{Method: "app"}).
+		Return(domain.Payment{}, fmt.Errorf("payment not found: %w", domain.ErrNotFound))
 
-	mockUseCase.On("UpdatePayment", paymentID, &mockPayment).Return(errors.New("Payment not found"))
-
-	app := fiber.New()
+	app := newApp()
 	app.Put(PaymentsEndpoint+"/:id", handler.UpdatePayment)
 
-	body, _ := json.Marshal(mockPayment)
-	req := httptest.NewRequest("PUT", PaymentsEndpoint+"/"+paymentID, bytes.NewReader(body))
+	req := httptest.NewRequest("PUT", PaymentsEndpoint+"/"+paymentID, strings.NewReader(`{"method": "app"}`))
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := app.Test(req)
@@ -274,7 +463,7 @@ func TestPaymentHandler_DeletePayment(t *testing.T) {
 
 		mockUseCase.On("DeletePayment", "valid-id").Return(nil)
 
-		app := fiber.New()
+		app := newApp()
 		app.Delete("/payments/:id", handler.DeletePayment)
 
 		req := httptest.NewRequest("DELETE", "/payments/valid-id", nil)
@@ -288,9 +477,9 @@ func TestPaymentHandler_DeletePayment(t *testing.T) {
 		mockUseCase := new(MockPaymentUseCase)
 		handler := delivery2.NewPaymentHandler(mockUseCase)
 
-		mockUseCase.On("DeletePayment", "invalid-id").Return(errors.New("payment not found"))
+		mockUseCase.On("DeletePayment", "invalid-id").Return(fmt.Errorf("payment not found: %w", domain.ErrNotFound))
 
-		app := fiber.New()
+		app := newApp()
 		app.Delete(PaymentsEndpoint+"/:id", handler.DeletePayment)
 
 		req := httptest.NewRequest("DELETE", PaymentsEndpoint+"/invalid-id", nil)
@@ -299,9 +488,173 @@ func TestPaymentHandler_DeletePayment(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
 
-		var response map[string]string
-		json.NewDecoder(resp.Body).Decode(&response)
-		assert.Equal(t, "Payment not found", response["error"])
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, fiber.StatusNotFound, problem.Status)
+		assert.Equal(t, "/problems/not-found", problem.Type)
+		assert.Equal(t, "/payments/invalid-id", problem.Instance)
+	})
+
+	t.Run("Instance omits the query string", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+
+		mockUseCase.On("DeletePayment", "invalid-id").Return(fmt.Errorf("payment not found: %w", domain.ErrNotFound))
+
+		app := newApp()
+		app.Delete(PaymentsEndpoint+"/:id", handler.DeletePayment)
+
+		req := httptest.NewRequest("DELETE", PaymentsEndpoint+"/invalid-id?access_token=secret", nil)
+		resp, err := app.Test(req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "/payments/invalid-id", decodeProblem(t, resp).Instance)
+	})
+}
+
+func TestPaymentHandler_CancelPayment(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	t.Run("Success", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+		mockUseCase.On("CancelPayment", id.Hex()).Return(domain.Payment{ID: id, Status: domain.StatusCancelled}, nil)
+
+		app := newApp()
+		app.Post(PaymentsEndpoint+"/:id/cancel", handler.CancelPayment)
+		resp, err := app.Test(httptest.NewRequest("POST", PaymentsEndpoint+"/"+id.Hex()+"/cancel", nil))
+
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var payment domain.Payment
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&payment))
+		assert.Equal(t, domain.StatusCancelled, payment.Status)
+	})
+
+	t.Run("Settled payment", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+		mockUseCase.On("CancelPayment", id.Hex()).Return(domain.Payment{}, fmt.Errorf("payment is settled: %w", domain.ErrConflict))
+
+		app := newApp()
+		app.Post(PaymentsEndpoint+"/:id/cancel", handler.CancelPayment)
+		resp, err := app.Test(httptest.NewRequest("POST", PaymentsEndpoint+"/"+id.Hex()+"/cancel", nil))
+
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		assert.Equal(t, "/problems/conflict", decodeProblem(t, resp).Type)
+	})
+}
+
+func TestPaymentHandler_CapturePayment(t *testing.T) {
+	id := primitive.NewObjectID()
+	capture := func(app *fiber.App, body string) *http.Response {
+		req := httptest.NewRequest("POST", PaymentsEndpoint+"/"+id.Hex()+"/capture", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Run("Partial capture", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+		mockUseCase.On("CapturePayment", id.Hex(), 40.5).Return(domain.Payment{ID: id, Status: domain.StatusCaptured, CapturedAmount: 40.5}, nil)
+
+		app := newApp()
+		app.Post(PaymentsEndpoint+"/:id/capture", handler.CapturePayment)
+		resp := capture(app, `{"amount": 40.50}`)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var payment domain.Payment
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&payment))
+		assert.Equal(t, 40.5, payment.CapturedAmount)
+	})
+
+	t.Run("Empty body captures the full amount", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+		mockUseCase.On("CapturePayment", id.Hex(), 0.0).Return(domain.Payment{ID: id, Status: domain.StatusCaptured}, nil)
+
+		app := newApp()
+		app.Post(PaymentsEndpoint+"/:id/capture", handler.CapturePayment)
+
+		assert.Equal(t, fiber.StatusOK, capture(app, "").StatusCode)
+	})
+
+	t.Run("Invalid amount", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+
+		app := newApp()
+		app.Post(PaymentsEndpoint+"/:id/capture", handler.CapturePayment)
+		resp := capture(app, `{"amount": -1}`)
+
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+		mockUseCase.AssertNotCalled(t, "CapturePayment", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPaymentHandler_VoidPayment(t *testing.T) {
+	id := primitive.NewObjectID()
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+	mockUseCase.On("VoidPayment", id.Hex()).Return(domain.Payment{}, fmt.Errorf("payment is captured: %w", domain.ErrConflict))
+
+	app := newApp()
+	app.Post(PaymentsEndpoint+"/:id/void", handler.VoidPayment)
+	resp, err := app.Test(httptest.NewRequest("POST", PaymentsEndpoint+"/"+id.Hex()+"/void", nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestPaymentHandler_RefundPayment(t *testing.T) {
+	id := primitive.NewObjectID()
+	mockUseCase := new(MockPaymentUseCase)
+	handler := delivery2.NewPaymentHandler(mockUseCase)
+	mockUseCase.On("RefundPayment", id.Hex(), 30.0).Return(domain.Payment{ID: id, Amount: 100, RefundedAmount: 30}, nil)
+
+	app := newApp()
+	app.Post(PaymentsEndpoint+"/:id/refund", handler.RefundPayment)
+	resp, err := app.Test(httptest.NewRequest("POST", PaymentsEndpoint+"/"+id.Hex()+"/refund", strings.NewReader(`{"amount": 30}`)))
+
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var payment domain.Payment
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&payment))
+	assert.Equal(t, 30.0, payment.RefundedAmount)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestPaymentHandler_GetBoletoImage(t *testing.T) {
+	id := primitive.NewObjectID()
+	boleto, _ := domain.NewBoleto(domain.DefaultBoletoIssuer, domain.Payment{ID: id, Amount: 100}, time.Now())
+
+	t.Run("Renders the barcode", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+		mockUseCase.On("GetPaymentByID", id.Hex()).Return(domain.Payment{ID: id, PaymentType: domain.Boleto, Boleto: &boleto}, nil)
+
+		app := newApp()
+		app.Get(PaymentsEndpoint+"/:id/boleto.png", handler.GetBoletoImage)
+		resp, err := app.Test(httptest.NewRequest("GET", PaymentsEndpoint+"/"+id.Hex()+"/boleto.png", nil))
+
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("Payment without boleto", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+		mockUseCase.On("GetPaymentByID", id.Hex()).Return(domain.Payment{ID: id, PaymentType: domain.Pix}, nil)
+
+		app := newApp()
+		app.Get(PaymentsEndpoint+"/:id/boleto.png", handler.GetBoletoImage)
+		resp, err := app.Test(httptest.NewRequest("GET", PaymentsEndpoint+"/"+id.Hex()+"/boleto.png", nil))
+
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
 	})
 }
 
@@ -320,7 +673,7 @@ func TestPaymentHandler_Callback(t *testing.T) {
 
 		mockUseCase.On("ProcessPaymentCallback", &callbackData).Return(nil)
 
-		app := fiber.New()
+		app := newApp()
 		app.Post("/callback", handler.Callback)
 
 		body, _ := json.Marshal(callbackData)
@@ -341,7 +694,7 @@ func TestPaymentHandler_Callback(t *testing.T) {
 		mockUseCase := new(MockPaymentUseCase)
 		handler := delivery2.NewPaymentHandler(mockUseCase)
 
-		app := fiber.New()
+		app := newApp()
 		app.Post("/callback", handler.Callback)
 
 		req := httptest.NewRequest("POST", "/callback", bytes.NewReader([]byte("{invalid json}")))
@@ -352,9 +705,9 @@ func TestPaymentHandler_Callback(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 
-		var response map[string]string
-		json.NewDecoder(resp.Body).Decode(&response)
-		assert.Equal(t, "Invalid request body", response["error"])
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, fiber.StatusBadRequest, problem.Status)
+		assert.Contains(t, problem.Detail, "Invalid request body")
 	})
 
 	t.Run("Internal Server Error", func(t *testing.T) {
@@ -371,7 +724,7 @@ func TestPaymentHandler_Callback(t *testing.T) {
 
 		mockUseCase.On("ProcessPaymentCallback", &callbackData).Return(errors.New("processing error"))
 
-		app := fiber.New()
+		app := newApp()
 		app.Post("/callback", handler.Callback)
 
 		body, _ := json.Marshal(callbackData)
@@ -383,8 +736,34 @@ func TestPaymentHandler_Callback(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
 
-		var response map[string]string
-		json.NewDecoder(resp.Body).Decode(&response)
-		assert.Equal(t, "Error processing payment callback", response["error"])
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, "Internal Server Error", problem.Title)
+		assert.Empty(t, problem.Detail) // Detalhes internos não são expostos
+	})
+
+	t.Run("Upstream Failure", func(t *testing.T) {
+		mockUseCase := new(MockPaymentUseCase)
+		handler := delivery2.NewPaymentHandler(mockUseCase)
+
+		callbackData := domain.PaymentCallback{PaymentID: "123456", Status: "success"}
+		mockUseCase.On("ProcessPaymentCallback", &callbackData).
+			Return(fmt.Errorf("%w: fetching http://fx.internal:8080/rates: connection refused", domain.ErrUpstream))
+
+		app := newApp()
+		app.Post("/callback", handler.Callback)
+
+		body, _ := json.Marshal(callbackData)
+		req := httptest.NewRequest("POST", "/callback", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+		problem := decodeProblem(t, resp)
+		assert.Equal(t, "/problems/upstream", problem.Type)
+		// O endereço do serviço interno fica só no log
+		assert.NotContains(t, problem.Detail, "fx.internal")
+		assert.NotEmpty(t, problem.Detail)
 	})
 }
@@ -0,0 +1,134 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	delivery2 "payments/delivery"
+	"payments/domain"
+)
+
+const WebhooksEndpoint = "/webhooks"
+
+type MockWebhookUseCase struct {
+	mock.Mock
+}
+
+func (m *MockWebhookUseCase) NotifyPayment(ctx context.Context, eventType string, payment domain.Payment) {
+	m.Called(eventType, payment)
+}
+
+func (m *MockWebhookUseCase) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) GetSubscription(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	args := m.Called(subscription)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	args := m.Called(id, subscription)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DeleteSubscription(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookUseCase) ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) SendTestEvent(ctx context.Context, subscriptionID string) (domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Redeliver(ctx context.Context, subscriptionID string, deliveryID string) (domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID, deliveryID)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	mockUseCase := new(MockWebhookUseCase)
+	handler := delivery2.NewWebhookHandler(mockUseCase)
+	mockUseCase.On("CreateSubscription", mock.MatchedBy(func(s *domain.WebhookSubscription) bool {
+		return s.URL == "https://shop.example.com/hooks" && s.Active
+	})).Return(domain.WebhookSubscription{ID: primitive.NewObjectID(), URL: "https://shop.example.com/hooks", Secret: "whsec_abc"}, nil)
+
+	app := newApp()
+	app.Post(WebhooksEndpoint, handler.CreateWebhook)
+
+	resp, err := app.Test(httptest.NewRequest("POST", WebhooksEndpoint,
+		strings.NewReader(`{"url": "https://shop.example.com/hooks", "events": ["payment.approved"]}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var body domain.WebhookSubscription
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "whsec_abc", body.Secret)
+
+	// Evento desconhecido vira 422 sem chegar ao caso de uso
+	resp, err = app.Test(httptest.NewRequest("POST", WebhooksEndpoint,
+		strings.NewReader(`{"url": "https://shop.example.com/hooks", "events": ["order.created"]}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	mockUseCase.AssertNumberOfCalls(t, "CreateSubscription", 1)
+}
+
+func TestWebhookHandler_SendTestEvent(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUseCase := new(MockWebhookUseCase)
+	handler := delivery2.NewWebhookHandler(mockUseCase)
+	// A falha do endpoint do lojista é o resultado do teste, não um erro da requisição
+	mockUseCase.On("SendTestEvent", id).Return(domain.WebhookDelivery{EventType: domain.EventWebhookTest, Status: domain.DeliveryFailed,
+		Attempts: []domain.WebhookAttempt{{Number: 1, StatusCode: 500}}}, nil)
+
+	app := newApp()
+	app.Post(WebhooksEndpoint+"/:id/test", handler.SendTestEvent)
+
+	resp, err := app.Test(httptest.NewRequest("POST", WebhooksEndpoint+"/"+id+"/test", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var delivery domain.WebhookDelivery
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&delivery))
+	assert.Equal(t, domain.DeliveryFailed, delivery.Status)
+	assert.Equal(t, 500, delivery.Attempts[0].StatusCode)
+}
+
+func TestWebhookHandler_Redeliver_NotFound(t *testing.T) {
+	id, deliveryID := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+	mockUseCase := new(MockWebhookUseCase)
+	handler := delivery2.NewWebhookHandler(mockUseCase)
+	mockUseCase.On("Redeliver", id, deliveryID).Return(domain.WebhookDelivery{}, fmt.Errorf("webhook delivery: %w", domain.ErrNotFound))
+
+	app := newApp()
+	app.Post(WebhooksEndpoint+"/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+
+	resp, err := app.Test(httptest.NewRequest("POST", WebhooksEndpoint+"/"+id+"/deliveries/"+deliveryID+"/redeliver", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	mockUseCase.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+)
+
+func TestAPIKeyPrefix(t *testing.T) {
+	prefix, ok := domain.APIKeyPrefix("pk_0123abcd_se_cr-et")
+	assert.True(t, ok)
+	assert.Equal(t, "pk_0123abcd", prefix)
+
+	for _, key := range []string{"", "pk_", "pk_0123abcd", "pk__secret", "pk_0123abcd_", "sk_0123abcd_secret"} {
+		_, ok := domain.APIKeyPrefix(key)
+		assert.False(t, ok, key)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	assert.Nil(t, domain.ValidateScopes([]string{domain.ScopePaymentsRead, domain.ScopeAdmin}))
+	assert.True(t, errors.Is(domain.ValidateScopes(nil), domain.ErrValidation))
+	assert.True(t, errors.Is(domain.ValidateScopes([]string{"payments:*"}), domain.ErrValidation))
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	reader := domain.Principal{Scopes: []string{domain.ScopePaymentsRead}}
+	assert.True(t, reader.HasScope(domain.ScopePaymentsRead))
+	assert.False(t, reader.HasScope(domain.ScopeRefundsWrite))
+
+	admin := domain.Principal{Scopes: []string{domain.ScopeAdmin}}
+	for _, scope := range domain.Scopes {
+		assert.True(t, admin.HasScope(scope), scope)
+	}
+}
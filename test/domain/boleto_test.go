@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDigitableLine_KnownBarcode(t *testing.T) {
+	barcode := "00193373700000001000500940144816060680935031"
+
+	assert.True(t, domain2.ValidBoletoBarcode(barcode))
+	assert.Equal(t, "00190.50095 40144.816069 06809.350314 3 37370000000100",
+		domain2.FormatDigitableLine(domain2.DigitableLine(barcode)))
+}
+
+func TestValidBoletoBarcode_WrongCheckDigit(t *testing.T) {
+	assert.False(t, domain2.ValidBoletoBarcode("00194373700000001000500940144816060680935031"))
+	assert.False(t, domain2.ValidBoletoBarcode("0019337370000000100050094014481606068093503"))
+}
+
+func TestNewBoleto(t *testing.T) {
+	payment := domain2.Payment{ID: primitive.NewObjectID(), Amount: 150.75, PaymentType: domain2.Boleto}
+	dueDate := time.Date(2024, time.March, 10, 15, 30, 0, 0, time.UTC)
+
+	boleto, err := domain2.NewBoleto(domain2.DefaultBoletoIssuer, payment, dueDate)
+
+	assert.Nil(t, err)
+	assert.Len(t, boleto.Barcode, 44)
+	assert.Len(t, boleto.DigitableLine, 47)
+	assert.True(t, domain2.ValidBoletoBarcode(boleto.Barcode))
+	assert.Equal(t, "0019", boleto.Barcode[:4])
+	assert.Equal(t, "9651", boleto.Barcode[5:9]) // Dias desde 07/10/1997
+	assert.Equal(t, "0000015075", boleto.Barcode[9:19])
+	assert.Equal(t, "0000000000000", boleto.Barcode[19:32]) // Zeros e convênio padrão
+	assert.Equal(t, time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC), boleto.DueDate)
+	assert.Equal(t, 2.0, boleto.FinePercent)
+}
+
+func TestNewBoleto_DueDateFactorRollover(t *testing.T) {
+	payment := domain2.Payment{ID: primitive.NewObjectID(), Amount: 10}
+
+	last, _ := domain2.NewBoleto(domain2.DefaultBoletoIssuer, payment, time.Date(2025, time.February, 21, 0, 0, 0, 0, time.UTC))
+	first, _ := domain2.NewBoleto(domain2.DefaultBoletoIssuer, payment, time.Date(2025, time.February, 22, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, "9999", last.Barcode[5:9])
+	assert.Equal(t, "1000", first.Barcode[5:9])
+}
+
+func TestNewBoleto_Invalid(t *testing.T) {
+	dueDate := time.Now()
+
+	_, err := domain2.NewBoleto(domain2.DefaultBoletoIssuer, domain2.Payment{Amount: 10}, dueDate)
+	assert.NotNil(t, err) // Sem ID não há nosso número
+
+	_, err = domain2.NewBoleto(domain2.BoletoIssuer{BankCode: "1", Covenant: "0000000"}, domain2.Payment{ID: primitive.NewObjectID(), Amount: 10}, dueDate)
+	assert.NotNil(t, err)
+
+	_, err = domain2.NewBoleto(domain2.DefaultBoletoIssuer, domain2.Payment{ID: primitive.NewObjectID(), Amount: 100000000}, dueDate)
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+}
+
+func TestBoletoDetails_ExpiryAndAmountDue(t *testing.T) {
+	issuer := domain2.DefaultBoletoIssuer
+	issuer.GraceDays = 2
+	payment := domain2.Payment{ID: primitive.NewObjectID(), Amount: 100}
+	boleto, _ := domain2.NewBoleto(issuer, payment, time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC))
+
+	assert.False(t, boleto.IsExpired(time.Date(2024, time.March, 12, 23, 59, 0, 0, time.UTC)))
+	assert.True(t, boleto.IsExpired(time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, 100.0, boleto.AmountDue(100, time.Date(2024, time.March, 10, 18, 0, 0, 0, time.UTC)))
+	// Multa de 2% e juros de 1% ao mês por 15 dias (0,5%)
+	assert.Equal(t, 102.5, boleto.AmountDue(100, time.Date(2024, time.March, 25, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestPayment_Expire(t *testing.T) {
+	payment := domain2.Payment{Status: domain2.StatusProcessing}
+	assert.Nil(t, payment.Expire())
+	assert.Equal(t, domain2.StatusExpired, payment.Status)
+
+	captured := domain2.Payment{Status: domain2.StatusCaptured}
+	assert.True(t, errors.Is(captured.Expire(), domain2.ErrConflict))
+}
+
+func TestValidateDetails_DueDateOnlyForBoleto(t *testing.T) {
+	payment := domain2.Payment{Amount: 10, PaymentType: domain2.Pix, Boleto: &domain2.BoletoDetails{DueDate: time.Now()}}
+
+	assert.True(t, errors.Is(payment.ValidateDetails(), domain2.ErrValidation))
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLuhnValid(t *testing.T) {
+	assert.True(t, domain2.LuhnValid("4111111111111111"))
+	assert.True(t, domain2.LuhnValid("5555555555554444"))
+	assert.True(t, domain2.LuhnValid("378282246310005"))
+	assert.False(t, domain2.LuhnValid("4111111111111112"))
+	assert.False(t, domain2.LuhnValid("4111-1111-1111-1111"))
+	assert.False(t, domain2.LuhnValid("0"))
+}
+
+func TestDetectBrand(t *testing.T) {
+	cases := map[string]string{
+		"4111111111111111": domain2.BrandVisa,
+		"5555555555554444": domain2.BrandMastercard,
+		"2223003122003222": domain2.BrandMastercard,
+		"378282246310005":  domain2.BrandAmex,
+		"6362970000457013": domain2.BrandElo,
+		"6062825624254001": domain2.BrandHipercard,
+		"6011111111111117": "",
+	}
+	for number, brand := range cases {
+		assert.Equal(t, brand, domain2.DetectBrand(number), number)
+	}
+}
+
+func TestValidatePAN(t *testing.T) {
+	assert.Nil(t, domain2.ValidatePAN("4111111111111111"))
+	assert.True(t, errors.Is(domain2.ValidatePAN("4111111111111112"), domain2.ErrValidation))
+	assert.True(t, errors.Is(domain2.ValidatePAN("411111"), domain2.ErrValidation))
+	assert.True(t, errors.Is(domain2.ValidatePAN("6011111111111117"), domain2.ErrValidation)) // Discover não é aceito
+}
+
+func TestMaxInstallmentsFor(t *testing.T) {
+	assert.Equal(t, 1, domain2.MaxInstallmentsFor(4.99))
+	assert.Equal(t, 4, domain2.MaxInstallmentsFor(20))
+	assert.Equal(t, 12, domain2.MaxInstallmentsFor(1000))
+}
+
+func TestPayment_ValidateDetails(t *testing.T) {
+	card := &domain2.Card{Token: "tok_123", Brand: domain2.BrandVisa, Last4: "1111"}
+
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.CreditCard, Amount: 100, Card: card, Installments: 10}.ValidateDetails())
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.Pix, Amount: 100}.ValidateDetails())
+
+	err := domain2.Payment{PaymentType: domain2.Pix, Amount: 100, Card: card}.ValidateDetails()
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+}
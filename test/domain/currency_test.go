@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayment_ValidateDetails_Currency(t *testing.T) {
+	card := &domain2.Card{Token: "tok_123", Brand: "visa", Last4: "4242"}
+
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.Pix}.ValidateDetails())
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.Pix, Currency: "BRL"}.ValidateDetails())
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.CreditCard, Currency: "USD", Card: card}.ValidateDetails())
+
+	cases := map[string]struct {
+		payment domain2.Payment
+		message string
+	}{
+		"Pix is BRL-only":      {domain2.Payment{PaymentType: domain2.Pix, Currency: "USD"}, "is not accepted for PIX payments"},
+		"Boleto is BRL-only":   {domain2.Payment{PaymentType: domain2.Boleto, Currency: "EUR"}, "is not accepted for BOLETO payments"},
+		"Unsupported currency": {domain2.Payment{PaymentType: domain2.CreditCard, Currency: "JPY", Card: card}, "must be one of: BRL, EUR, USD"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var validationErr *domain2.ValidationError
+			assert.True(t, errors.As(tc.payment.ValidateDetails(), &validationErr))
+			assert.Contains(t, validationErr.Errors, domain2.ErrorResponse{Field: "currency", Message: tc.message})
+		})
+	}
+}
+
+func TestPayment_ApplyFXRate(t *testing.T) {
+	rate := domain2.FXRate{From: "USD", To: "BRL", Rate: 6.1721, Source: "local", Timestamp: time.Now()}
+
+	payment := domain2.Payment{Amount: 19.99, Currency: "USD"}
+	assert.True(t, payment.IsCrossCurrency())
+	assert.Nil(t, payment.ApplyFXRate(rate))
+	assert.Equal(t, 123.38, payment.SettlementAmount)
+	assert.Equal(t, &rate, payment.FXRate)
+	// Conversões posteriores usam a cotação registrada
+	assert.Equal(t, 61.72, payment.SettlementValue(10))
+
+	// A cotação precisa ser da moeda do pagamento para a moeda de liquidação
+	euro := domain2.Payment{Amount: 10, Currency: "EUR"}
+	assert.NotNil(t, euro.ApplyFXRate(rate))
+	assert.Nil(t, euro.FXRate)
+
+	// Sem cotação o valor já está na moeda de liquidação
+	assert.False(t, domain2.Payment{Amount: 10}.IsCrossCurrency())
+	assert.Equal(t, 10.0, domain2.Payment{Amount: 10}.SettlementValue(10))
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var disputeNow = time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func settledPayment() domain2.Payment {
+	return domain2.Payment{ID: primitive.NewObjectID(), Amount: 100, RefundedAmount: 30, Status: domain2.StatusCaptured, CapturedAmount: 100}
+}
+
+func TestNewDispute(t *testing.T) {
+	payment := settledPayment()
+
+	dispute, err := domain2.NewDispute(payment, domain2.Dispute{ReasonCode: "4837"}, disputeNow)
+	assert.Nil(t, err)
+	assert.Equal(t, payment.ID.Hex(), dispute.PaymentID)
+	assert.Equal(t, domain2.DisputeOpen, dispute.Status)
+	// Sem valor, contesta o saldo ainda não estornado
+	assert.Equal(t, 70.0, dispute.Amount)
+	assert.Equal(t, disputeNow.Add(domain2.DefaultDisputeEvidenceWindow), dispute.EvidenceDueBy)
+
+	evidence := []domain2.EvidenceAttachment{{FileName: "receipt.pdf", ContentType: "application/pdf", Size: 1024, URL: "https://files.example.com/receipt.pdf"}}
+	dispute, err = domain2.NewDispute(payment, domain2.Dispute{ReasonCode: "10.4", Amount: 20, Evidence: evidence}, disputeNow)
+	assert.Nil(t, err)
+	assert.Equal(t, 20.0, dispute.Amount)
+	assert.Equal(t, disputeNow, dispute.Evidence[0].UploadedAt)
+}
+
+func TestNewDispute_Violations(t *testing.T) {
+	_, err := domain2.NewDispute(settledPayment(), domain2.Dispute{Amount: 80, EvidenceDueBy: disputeNow.Add(-time.Hour),
+		Evidence: []domain2.EvidenceAttachment{{ContentType: "image/png"}}}, disputeNow)
+
+	var validationErr *domain2.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain2.ErrorResponse{
+		{Field: "reason_code", Message: "is required"},
+		{Field: "amount", Message: "must be at most 70.00"},
+		{Field: "evidence_due_by", Message: "must be in the future"},
+		{Field: "evidence[0].file_name", Message: "is required"},
+		{Field: "evidence[0].url", Message: "is required"},
+	}, validationErr.Errors)
+
+	// Só pagamentos liquidados podem ser contestados
+	_, err = domain2.NewDispute(domain2.Payment{Amount: 100, Status: domain2.StatusProcessing}, domain2.Dispute{ReasonCode: "4837"}, disputeNow)
+	assert.True(t, errors.Is(err, domain2.ErrConflict))
+}
+
+func TestDispute_Apply(t *testing.T) {
+	dispute, _ := domain2.NewDispute(settledPayment(), domain2.Dispute{ReasonCode: "4837"}, disputeNow)
+	newDeadline := disputeNow.AddDate(0, 0, 14)
+
+	err := dispute.Apply(domain2.DisputeUpdate{ReasonCode: "4853", EvidenceDueBy: &newDeadline,
+		Evidence: []domain2.EvidenceAttachment{{FileName: "tracking.png", URL: "https://files.example.com/tracking.png"}}}, disputeNow)
+	assert.Nil(t, err)
+	assert.Equal(t, "4853", dispute.ReasonCode)
+	assert.Equal(t, newDeadline, dispute.EvidenceDueBy)
+	assert.Len(t, dispute.Evidence, 1)
+
+	// Depois do prazo não se anexam evidências
+	err = dispute.Apply(domain2.DisputeUpdate{Evidence: []domain2.EvidenceAttachment{{FileName: "late.pdf", URL: "https://files.example.com/late.pdf"}}},
+		newDeadline.Add(time.Minute))
+	assert.True(t, errors.Is(err, domain2.ErrConflict))
+	assert.Len(t, dispute.Evidence, 1)
+}
+
+func TestDispute_Resolve(t *testing.T) {
+	dispute, _ := domain2.NewDispute(settledPayment(), domain2.Dispute{ReasonCode: "4837"}, disputeNow)
+
+	assert.True(t, errors.Is(dispute.Resolve("pending", disputeNow), domain2.ErrValidation))
+	assert.Nil(t, dispute.Resolve(domain2.DisputeLost, disputeNow))
+	assert.Equal(t, domain2.DisputeLost, dispute.Status)
+	assert.Equal(t, disputeNow, *dispute.ResolvedAt)
+
+	// Disputa encerrada não muda mais
+	assert.True(t, errors.Is(dispute.Resolve(domain2.DisputeWon, disputeNow), domain2.ErrConflict))
+	assert.True(t, errors.Is(dispute.Apply(domain2.DisputeUpdate{ReasonCode: "4853"}, disputeNow), domain2.ErrConflict))
+}
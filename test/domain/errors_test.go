@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain2 "payments/domain"
+)
+
+func TestInvalidPaymentTypeError_IsValidationError(t *testing.T) {
+	err := domain2.PaymentType("CreditCard").IsValid()
+
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+	assert.Equal(t, "invalid payment type: CreditCard", err.Error())
+}
+
+func TestValidationError(t *testing.T) {
+	validationErr := domain2.NewValidationError()
+	assert.False(t, validationErr.HasErrors())
+
+	validationErr.Add("amount", "must be greater than 0")
+	validationErr.Add("order_id", "is required")
+
+	wrapped := fmt.Errorf("create payment: %w", validationErr)
+	assert.True(t, validationErr.HasErrors())
+	assert.True(t, errors.Is(wrapped, domain2.ErrValidation))
+	assert.Equal(t, "validation failed: amount: must be greater than 0; order_id: is required", validationErr.Error())
+}
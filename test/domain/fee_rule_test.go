@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFeeRule_Calculate(t *testing.T) {
+	rule := domain2.FeeRule{FixedAmount: 0.39, Percentage: 3.49}
+	assert.Equal(t, 3.88, rule.Calculate(100))
+	assert.Equal(t, 0.42, rule.Calculate(1))
+
+	// Os limites prevalecem sobre o cálculo
+	rule.MinFee, rule.MaxFee = 1, 10
+	assert.Equal(t, 1.0, rule.Calculate(10))
+	assert.Equal(t, 10.0, rule.Calculate(1000))
+
+	// A tarifa nunca passa do valor bruto
+	assert.Equal(t, 0.5, domain2.FeeRule{FixedAmount: 2}.Calculate(0.5))
+}
+
+func TestFeeRule_Validate(t *testing.T) {
+	valid := domain2.FeeRule{PaymentType: domain2.Pix, Percentage: 1, EffectiveFrom: time.Now()}
+	assert.Nil(t, valid.Validate())
+
+	err := domain2.FeeRule{PaymentType: "CASH", FixedAmount: -1, Percentage: 101, MinFee: 5, MaxFee: 2}.Validate()
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+	var validationErr *domain2.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.ElementsMatch(t, []string{"payment_type", "fixed_amount", "percentage", "max_fee", "effective_from"}, fields)
+}
+
+func TestEffectiveFeeRule(t *testing.T) {
+	jan := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	jul := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	rules := []domain2.FeeRule{
+		{PaymentType: domain2.Pix, Percentage: 0.8, EffectiveFrom: jul},
+		{PaymentType: domain2.Pix, Percentage: 1, EffectiveFrom: jan},
+		{PaymentType: domain2.Boleto, FixedAmount: 2.5, EffectiveFrom: jan},
+	}
+
+	rule, ok := domain2.EffectiveFeeRule(rules, domain2.Pix, jul.Add(-time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rule.Percentage)
+
+	rule, ok = domain2.EffectiveFeeRule(rules, domain2.Pix, jul)
+	assert.True(t, ok)
+	assert.Equal(t, 0.8, rule.Percentage)
+
+	_, ok = domain2.EffectiveFeeRule(rules, domain2.CreditCard, jul)
+	assert.False(t, ok)
+}
+
+func TestPayment_ApplyFee(t *testing.T) {
+	rule := domain2.FeeRule{ID: primitive.NewObjectID(), FixedAmount: 0.39, Percentage: 3.49}
+
+	payment := domain2.Payment{Amount: 100}
+	payment.ApplyFee(rule)
+	assert.Equal(t, 100.0, payment.GrossAmount)
+	assert.Equal(t, 3.88, payment.FeeAmount)
+	assert.Equal(t, 96.12, payment.NetAmount)
+	assert.Equal(t, rule.ID.Hex(), payment.FeeRuleID)
+
+	// Na captura parcial a tarifa incide sobre o valor capturado
+	captured := domain2.Payment{Amount: 100, CapturedAmount: 40}
+	captured.ApplyFee(rule)
+	assert.Equal(t, 40.0, captured.GrossAmount)
+	assert.Equal(t, 1.79, captured.FeeAmount)
+	assert.Equal(t, 38.21, captured.NetAmount)
+}
+
+func TestPayment_ApplyFee_CrossCurrency(t *testing.T) {
+	// A tarifa fixa e os limites da regra são em real: o pagamento em dólar é convertido antes
+	rule := domain2.FeeRule{ID: primitive.NewObjectID(), FixedAmount: 0.5, Percentage: 2, MaxFee: 8}
+	rate := domain2.FXRate{From: "USD", To: domain2.SettlementCurrency, Rate: 5}
+
+	payment := domain2.Payment{Amount: 100, Currency: "USD"}
+	assert.NoError(t, payment.ApplyFXRate(rate))
+	payment.ApplyFee(rule)
+	assert.Equal(t, 500.0, payment.GrossAmount)
+	assert.Equal(t, 8.0, payment.FeeAmount)
+	assert.Equal(t, 492.0, payment.NetAmount)
+
+	// Na captura parcial, o valor capturado é convertido pela mesma cotação
+	captured := domain2.Payment{Amount: 100, Currency: "USD", CapturedAmount: 20}
+	assert.NoError(t, captured.ApplyFXRate(rate))
+	captured.ApplyFee(rule)
+	assert.Equal(t, 100.0, captured.GrossAmount)
+	assert.Equal(t, 2.5, captured.FeeAmount)
+	assert.Equal(t, 97.5, captured.NetAmount)
+}
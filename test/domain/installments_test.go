@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInstallmentPlan_InterestFreeRemainder(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+	policy := domain2.DefaultInstallmentPolicies()[domain2.CreditCard]
+
+	plan := domain2.NewInstallmentPlan(100, 3, policy, start)
+
+	assert.True(t, plan.InterestFree)
+	assert.Equal(t, 100.0, plan.TotalAmount)
+	// O centavo que sobra da divisão vai para a primeira parcela
+	assert.Equal(t, []float64{33.34, 33.33, 33.33}, amounts(plan))
+	assert.Equal(t, 1, plan.Schedule[0].Number)
+	assert.Equal(t, start.AddDate(0, 1, 0), plan.Schedule[0].DueDate)
+	assert.Equal(t, start.AddDate(0, 3, 0), plan.Schedule[2].DueDate)
+}
+
+func TestNewInstallmentPlan_WithInterest(t *testing.T) {
+	policy := domain2.InstallmentPolicy{MaxInstallments: 12, InterestFreeInstallments: 3, MonthlyInterestRate: 2}
+
+	plan := domain2.NewInstallmentPlan(1000, 10, policy, time.Now())
+
+	assert.False(t, plan.InterestFree)
+	assert.Equal(t, 2.0, plan.MonthlyInterestRate)
+	assert.Equal(t, 1113.27, plan.TotalAmount)
+	assert.Equal(t, 111.33, plan.Schedule[0].Amount)
+	assert.Equal(t, 111.32, plan.Schedule[9].Amount)
+
+	// Até o limite sem juros o valor não muda
+	assert.Equal(t, 1000.0, domain2.NewInstallmentPlan(1000, 3, policy, time.Now()).TotalAmount)
+}
+
+func TestPayment_ValidateInstallments(t *testing.T) {
+	policies := domain2.DefaultInstallmentPolicies()
+
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.CreditCard, Amount: 100, Installments: 12}.ValidateInstallments(policies))
+	assert.Nil(t, domain2.Payment{PaymentType: domain2.Pix, Amount: 100, Installments: 1}.ValidateInstallments(policies))
+
+	var validationErr *domain2.ValidationError
+	err := domain2.Payment{PaymentType: domain2.CreditCard, Amount: 20, Installments: 6}.ValidateInstallments(policies)
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain2.ErrorResponse{{Field: "installments", Message: "must be at most 4 for this amount"}}, validationErr.Errors)
+
+	err = domain2.Payment{PaymentType: domain2.Pix, Amount: 100, Installments: 2}.ValidateInstallments(policies)
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain2.ErrorResponse{{Field: "installments", Message: "is not accepted for PIX payments"}}, validationErr.Errors)
+}
+
+func amounts(plan domain2.InstallmentPlan) []float64 {
+	values := make([]float64, 0, len(plan.Schedule))
+	for _, installment := range plan.Schedule {
+		values = append(values, installment.Amount)
+	}
+	return values
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	domain2 "payments/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewOrderPayments(t *testing.T) {
+	t.Run("Active payment defines the status", func(t *testing.T) {
+		active := domain2.Payment{ID: primitive.NewObjectID(), Status: domain2.StatusProcessing}
+		older := domain2.Payment{ID: primitive.NewObjectID(), Status: "Recusado"}
+
+		summary := domain2.NewOrderPayments("order123", []domain2.Payment{older, active})
+
+		assert.Equal(t, domain2.StatusProcessing, summary.Status)
+		assert.Equal(t, active.ID.Hex(), summary.ActivePaymentID)
+		assert.Equal(t, 2, summary.Attempts)
+	})
+
+	t.Run("Without an active payment the latest attempt wins", func(t *testing.T) {
+		summary := domain2.NewOrderPayments("order123", []domain2.Payment{{Status: "Aprovado"}, {Status: "Recusado"}})
+
+		assert.Equal(t, "Aprovado", summary.Status)
+		assert.Empty(t, summary.ActivePaymentID)
+	})
+
+	t.Run("No attempts", func(t *testing.T) {
+		summary := domain2.NewOrderPayments("order123", nil)
+
+		assert.Empty(t, summary.Status)
+		assert.Equal(t, 0, summary.Attempts)
+		assert.NotNil(t, summary.Payments)
+	})
+}
@@ -21,3 +21,10 @@ func TestPaymentCallbackInitialization(t *testing.T) {
 	assert.Equal(t, "Payment completed successfully", callback.Message)
 	assert.Equal(t, "order123", callback.OrderId)
 }
+
+func TestPaymentCallback_IsApproval(t *testing.T) {
+	assert.True(t, domain2.PaymentCallback{Status: "approved"}.IsApproval())
+	assert.True(t, domain2.PaymentCallback{Status: "SUCCESS"}.IsApproval())
+	assert.False(t, domain2.PaymentCallback{Status: "failed"}.IsApproval())
+	assert.False(t, domain2.PaymentCallback{Status: ""}.IsApproval())
+}
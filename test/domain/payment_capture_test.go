@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayment_InitialStatus(t *testing.T) {
+	assert.Equal(t, domain2.StatusProcessing, domain2.Payment{}.InitialStatus())
+	assert.Equal(t, domain2.StatusAuthorized, domain2.Payment{CaptureMethod: domain2.CaptureManual}.InitialStatus())
+}
+
+func TestPayment_Capture(t *testing.T) {
+	t.Run("Partial capture", func(t *testing.T) {
+		payment := domain2.Payment{Amount: 100, Status: domain2.StatusAuthorized}
+
+		assert.Nil(t, payment.Capture(30))
+		assert.Equal(t, domain2.StatusCaptured, payment.Status)
+		assert.Equal(t, 30.0, payment.CapturedAmount)
+	})
+
+	t.Run("Above the authorized amount", func(t *testing.T) {
+		payment := domain2.Payment{Amount: 100, Status: domain2.StatusAuthorized}
+
+		assert.True(t, errors.Is(payment.Capture(100.01), domain2.ErrValidation))
+		assert.Equal(t, domain2.StatusAuthorized, payment.Status)
+	})
+
+	t.Run("Only authorized payments", func(t *testing.T) {
+		payment := domain2.Payment{Amount: 100, Status: domain2.StatusProcessing}
+
+		assert.True(t, errors.Is(payment.Capture(0), domain2.ErrConflict))
+	})
+}
+
+func TestPayment_Void(t *testing.T) {
+	authorized := domain2.Payment{Status: domain2.StatusAuthorized}
+	assert.Nil(t, authorized.Void())
+	assert.Equal(t, domain2.StatusVoided, authorized.Status)
+
+	captured := domain2.Payment{Status: domain2.StatusCaptured}
+	assert.True(t, errors.Is(captured.Void(), domain2.ErrConflict))
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+)
+
+func TestNewPaymentEvent(t *testing.T) {
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	payment := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order-1", Amount: 10, Status: domain.StatusAuthorized, PaymentType: domain.CreditCard}
+
+	event := domain.NewPaymentEvent(domain.EventPaymentCreated, payment, at)
+
+	assert.Equal(t, payment.ID.Hex(), event.PaymentID)
+	assert.Equal(t, "order-1", event.OrderID)
+	assert.Equal(t, domain.SettlementCurrency, event.Currency)
+	assert.Equal(t, at, event.OccurredAt)
+	assert.False(t, event.Terminal())
+
+	payment.Status = domain.StatusCaptured
+	assert.True(t, domain.NewPaymentEvent(domain.EventPaymentCaptured, payment, at).Terminal())
+}
+
+func TestPaymentEventFilter_Matches(t *testing.T) {
+	event := domain.PaymentEvent{OrderID: "shop-42", Status: domain.StatusCancelled, PaymentType: domain.Pix}
+
+	cases := map[string]struct {
+		filter  domain.PaymentEventFilter
+		matches bool
+	}{
+		"Empty filter":        {domain.PaymentEventFilter{}, true},
+		"Status":              {domain.PaymentEventFilter{Statuses: []string{domain.StatusProcessing, domain.StatusCancelled}}, true},
+		"Other status":        {domain.PaymentEventFilter{Statuses: []string{domain.StatusProcessing}}, false},
+		"Payment type":        {domain.PaymentEventFilter{PaymentTypes: []domain.PaymentType{domain.Pix}}, true},
+		"Other payment type":  {domain.PaymentEventFilter{PaymentTypes: []domain.PaymentType{domain.Boleto}}, false},
+		"Order prefix":        {domain.PaymentEventFilter{OrderIDPrefix: "shop-"}, true},
+		"Other order prefix":  {domain.PaymentEventFilter{OrderIDPrefix: "mkt-"}, false},
+		"All criteria":        {domain.PaymentEventFilter{Statuses: []string{domain.StatusCancelled}, PaymentTypes: []domain.PaymentType{domain.Pix}, OrderIDPrefix: "shop"}, true},
+		"One criterion fails": {domain.PaymentEventFilter{Statuses: []string{domain.StatusCancelled}, OrderIDPrefix: "mkt"}, false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.matches, tc.filter.Matches(event))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func splitPayment(t *testing.T) domain2.Payment {
+	payment := domain2.Payment{Amount: 100, Status: "approved", Splits: []domain2.Split{
+		{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 70, Liable: true},
+		{RecipientID: "platform", Type: domain2.SplitPercentage, Value: 30, ChargeProcessingFee: true},
+	}}
+	assert.Nil(t, payment.ResolveSplits())
+	return payment
+}
+
+func TestPayment_Refund_ProportionalSplits(t *testing.T) {
+	payment := splitPayment(t)
+
+	refunded, reversals, err := payment.Refund(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10.0, refunded)
+	assert.Equal(t, []domain2.SplitReversal{{RecipientID: "seller", Amount: 7}, {RecipientID: "platform", Amount: 3}}, reversals)
+	assert.Equal(t, "approved", payment.Status)
+
+	// Zero estorna o saldo restante
+	refunded, reversals, err = payment.Refund(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 90.0, refunded)
+	assert.Equal(t, []domain2.SplitReversal{{RecipientID: "seller", Amount: 63}, {RecipientID: "platform", Amount: 27}}, reversals)
+	assert.Equal(t, domain2.StatusRefunded, payment.Status)
+	assert.Equal(t, 70.0, payment.Splits[0].RefundedAmount)
+}
+
+func TestPayment_Refund_RoundsByLargestRemainder(t *testing.T) {
+	payment := domain2.Payment{Amount: 100, Status: domain2.StatusCaptured, CapturedAmount: 100, Splits: []domain2.Split{
+		{RecipientID: "a", Type: domain2.SplitPercentage, Value: 33.33, Liable: true, ChargeProcessingFee: true},
+		{RecipientID: "b", Type: domain2.SplitPercentage, Value: 33.33},
+		{RecipientID: "c", Type: domain2.SplitPercentage, Value: 33.34},
+	}}
+	assert.Nil(t, payment.ResolveSplits())
+
+	_, reversals, err := payment.Refund(0.10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []domain2.SplitReversal{{RecipientID: "a", Amount: 0.03}, {RecipientID: "b", Amount: 0.03}, {RecipientID: "c", Amount: 0.04}}, reversals)
+}
+
+func TestPayment_Refund_Rejected(t *testing.T) {
+	processing := domain2.Payment{Amount: 100, Status: domain2.StatusProcessing}
+	_, _, err := processing.Refund(10)
+	assert.True(t, errors.Is(err, domain2.ErrConflict))
+
+	payment := splitPayment(t)
+	_, _, err = payment.Refund(100.01)
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+	assert.Equal(t, 0.0, payment.RefundedAmount)
+
+	// Captura parcial limita o saldo estornável
+	captured := domain2.Payment{Amount: 100, Status: domain2.StatusCaptured, CapturedAmount: 40}
+	_, _, err = captured.Refund(50)
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+}
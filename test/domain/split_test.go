@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func splitAmounts(splits []domain2.Split) []float64 {
+	amounts := make([]float64, 0, len(splits))
+	for _, split := range splits {
+		amounts = append(amounts, split.Amount)
+	}
+	return amounts
+}
+
+func TestResolveSplits_Percentages(t *testing.T) {
+	payment := domain2.Payment{Amount: 10.01, Splits: []domain2.Split{
+		{RecipientID: "seller-1", Type: domain2.SplitPercentage, Value: 50, Liable: true},
+		{RecipientID: "seller-2", Type: domain2.SplitPercentage, Value: 50, ChargeProcessingFee: true},
+	}}
+
+	assert.Nil(t, payment.ResolveSplits())
+	// Empate na fração: o centavo restante vai para o primeiro da lista
+	assert.Equal(t, []float64{5.01, 5.00}, splitAmounts(payment.Splits))
+}
+
+func TestResolveSplits_LargestRemainder(t *testing.T) {
+	payment := domain2.Payment{Amount: 0.10, Splits: []domain2.Split{
+		{RecipientID: "a", Type: domain2.SplitPercentage, Value: 33.33, Liable: true, ChargeProcessingFee: true},
+		{RecipientID: "b", Type: domain2.SplitPercentage, Value: 33.33},
+		{RecipientID: "c", Type: domain2.SplitPercentage, Value: 33.34},
+	}}
+
+	assert.Nil(t, payment.ResolveSplits())
+	assert.Equal(t, []float64{0.03, 0.03, 0.04}, splitAmounts(payment.Splits))
+}
+
+func TestResolveSplits_FixedAndPercentage(t *testing.T) {
+	// Os percentuais dividem o que sobra depois das partes fixas
+	cases := []struct {
+		name    string
+		amount  float64
+		splits  []domain2.Split
+		amounts []float64
+	}{
+		{"fixed fee and seller", 250, []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 25, ChargeProcessingFee: true},
+			{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 100, Liable: true},
+		}, []float64{25, 225}},
+		{"remainder with odd cents", 100.01, []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 50, ChargeProcessingFee: true},
+			{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 100, Liable: true},
+		}, []float64{50, 50.01}},
+		{"remainder split by percentages", 100.01, []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 50, ChargeProcessingFee: true},
+			{RecipientID: "seller-1", Type: domain2.SplitPercentage, Value: 50, Liable: true},
+			{RecipientID: "seller-2", Type: domain2.SplitPercentage, Value: 50},
+		}, []float64{50, 25.01, 25}},
+		{"several fixed splits", 99.99, []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 9.99, ChargeProcessingFee: true},
+			{RecipientID: "shipping", Type: domain2.SplitFixed, Value: 15},
+			{RecipientID: "seller-1", Type: domain2.SplitPercentage, Value: 33.33, Liable: true},
+			{RecipientID: "seller-2", Type: domain2.SplitPercentage, Value: 66.67},
+		}, []float64{9.99, 15, 25, 50}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payment := domain2.Payment{Amount: tc.amount, Splits: tc.splits}
+
+			assert.Nil(t, payment.ResolveSplits())
+			assert.Equal(t, tc.amounts, splitAmounts(payment.Splits))
+		})
+	}
+}
+
+func TestResolveSplits_Violations(t *testing.T) {
+	payment := domain2.Payment{Amount: 100, Splits: []domain2.Split{
+		{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 60},
+		{RecipientID: "seller", Type: "share", Value: 40},
+	}}
+
+	var validationErr *domain2.ValidationError
+	assert.True(t, errors.As(payment.ResolveSplits(), &validationErr))
+	assert.ElementsMatch(t, []domain2.ErrorResponse{
+		{Field: "splits[1].recipient_id", Message: "must not repeat a recipient"},
+		{Field: "splits[1].type", Message: "must be one of: fixed, percentage"},
+		{Field: "splits", Message: "must have a recipient that pays the processing fees"},
+		{Field: "splits", Message: "must have a recipient liable for chargebacks"},
+	}, validationErr.Errors)
+}
+
+func TestResolveSplits_MustAddUpToTotal(t *testing.T) {
+	cases := []struct {
+		name    string
+		splits  []domain2.Split
+		message string
+	}{
+		{"fixed splits below the amount", []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 10, ChargeProcessingFee: true},
+			{RecipientID: "seller", Type: domain2.SplitFixed, Value: 80, Liable: true},
+		}, "must add up exactly to the payment amount"},
+		{"percentages below 100", []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 10, ChargeProcessingFee: true},
+			{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 80, Liable: true},
+		}, "percentage splits must add up to 100% of the amount left after the fixed splits"},
+		{"percentages of the total", []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 10, ChargeProcessingFee: true},
+			{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 90, Liable: true},
+		}, "percentage splits must add up to 100% of the amount left after the fixed splits"},
+		{"nothing left for percentages", []domain2.Split{
+			{RecipientID: "platform", Type: domain2.SplitFixed, Value: 100, ChargeProcessingFee: true},
+			{RecipientID: "seller", Type: domain2.SplitPercentage, Value: 100, Liable: true},
+		}, "fixed splits must leave part of the payment amount to the percentage splits"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payment := domain2.Payment{Amount: 100, Splits: tc.splits}
+
+			var validationErr *domain2.ValidationError
+			assert.True(t, errors.As(payment.ResolveSplits(), &validationErr))
+			assert.Equal(t, []domain2.ErrorResponse{{Field: "splits", Message: tc.message}}, validationErr.Errors)
+			assert.True(t, errors.Is(payment.ValidateDetails(), domain2.ErrValidation))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"errors"
+	domain2 "payments/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWebhookSubscription_Validate(t *testing.T) {
+	valid := domain2.WebhookSubscription{URL: "https://shop.example.com/hooks", Events: []string{domain2.EventPaymentApproved}}
+	assert.Nil(t, valid.Validate())
+
+	err := domain2.WebhookSubscription{URL: "shop.example.com/hooks", Events: []string{"payment.approved", "order.created"}}.Validate()
+	assert.True(t, errors.Is(err, domain2.ErrValidation))
+	var validationErr *domain2.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.ElementsMatch(t, []string{"url", "events[1]"}, fields)
+
+	err = domain2.WebhookSubscription{URL: "https://shop.example.com/hooks"}.Validate()
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "events", validationErr.Errors[0].Field)
+}
+
+func TestWebhookSubscription_Validate_RejectsInternalHosts(t *testing.T) {
+	for _, url := range []string{
+		"http://localhost:8080/hooks",
+		"https://api.localhost/hooks",
+		"http://127.0.0.1/hooks",
+		"http://10.0.0.5/hooks",
+		"http://192.168.1.10/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hooks",
+		"http://0.0.0.0/hooks",
+		"http://[::1]/hooks",
+		"http://[fe80::1]/hooks",
+		"http://[fd00::1]/hooks",
+	} {
+		err := domain2.WebhookSubscription{URL: url, Events: []string{domain2.EventAll}}.Validate()
+		var validationErr *domain2.ValidationError
+		if assert.True(t, errors.As(err, &validationErr), url) {
+			assert.Equal(t, "url", validationErr.Errors[0].Field, url)
+			assert.Equal(t, "must point to a public host", validationErr.Errors[0].Message, url)
+		}
+	}
+
+	for _, url := range []string{"https://8.8.8.8/hooks", "https://[2001:4860:4860::8888]/hooks", "https://shop.example.com/hooks"} {
+		assert.Nil(t, domain2.WebhookSubscription{URL: url, Events: []string{domain2.EventAll}}.Validate(), url)
+	}
+}
+
+func TestNewWebhookPaymentData(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	payment := domain2.Payment{ID: primitive.NewObjectID(), OrderId: "order123", Amount: 100, Currency: "USD", Status: "Capturado",
+		PaymentType: domain2.CreditCard, Installments: 3, CapturedAmount: 100, CreatedAt: created,
+		Card: &domain2.Card{Token: "tok_secret", Brand: "visa", Last4: "4242"}, FeeAmount: 3.5, FeeRuleID: "rule",
+		Splits: []domain2.Split{{RecipientID: "seller", Amount: 100}}}
+
+	data := domain2.NewWebhookPaymentData(payment)
+	assert.Equal(t, domain2.WebhookPaymentData{ID: payment.ID.Hex(), OrderID: "order123", Amount: 100, Currency: "USD",
+		Status: "Capturado", PaymentType: domain2.CreditCard, Installments: 3, CapturedAmount: 100, CreatedAt: created}, data)
+
+	// Sem moeda, vale a padrão do pagamento
+	payment.Currency = ""
+	assert.Equal(t, payment.CurrencyCode(), domain2.NewWebhookPaymentData(payment).Currency)
+}
+
+func TestWebhookSubscription_Matches(t *testing.T) {
+	subscription := domain2.WebhookSubscription{Active: true, Events: []string{domain2.EventPaymentApproved, domain2.EventPaymentRefunded}}
+	assert.True(t, subscription.Matches(domain2.EventPaymentRefunded))
+	assert.False(t, subscription.Matches(domain2.EventPaymentCreated))
+
+	all := domain2.WebhookSubscription{Active: true, Events: []string{domain2.EventAll}}
+	assert.True(t, all.Matches(domain2.EventPaymentChargedBack))
+
+	// Inscrições desativadas não recebem eventos
+	all.Active = false
+	assert.False(t, all.Matches(domain2.EventPaymentChargedBack))
+}
+
+func TestCallbackEvent(t *testing.T) {
+	assert.Equal(t, domain2.EventPaymentApproved, domain2.CallbackEvent("Approved"))
+	assert.Equal(t, domain2.EventPaymentApproved, domain2.CallbackEvent("success"))
+	assert.Equal(t, domain2.EventPaymentFailed, domain2.CallbackEvent("failed"))
+	assert.Equal(t, domain2.EventPaymentUpdated, domain2.CallbackEvent("pending"))
+}
+
+func TestWebhookRetryPolicy_Backoff(t *testing.T) {
+	policy := domain2.WebhookRetryPolicy{MaxAttempts: 10, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+	assert.Equal(t, 30*time.Second, policy.Backoff(1))
+	assert.Equal(t, time.Minute, policy.Backoff(2))
+	assert.Equal(t, 4*time.Minute, policy.Backoff(4))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(5))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(50))
+}
+
+func TestWebhookDelivery_Record(t *testing.T) {
+	policy := domain2.WebhookRetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	now := time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC)
+	delivery := domain2.NewWebhookDelivery("sub", domain2.WebhookEvent{ID: "evt", Type: domain2.EventPaymentCreated}, []byte(`{}`), now)
+	assert.Equal(t, domain2.DeliveryPending, delivery.Status)
+	assert.True(t, now.Equal(*delivery.NextAttemptAt))
+
+	// Falha: nova tentativa após o backoff
+	delivery.Record(domain2.WebhookAttempt{AttemptedAt: now, StatusCode: 500}, policy)
+	assert.Equal(t, domain2.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts[0].Number)
+	assert.True(t, now.Add(time.Minute).Equal(*delivery.NextAttemptAt))
+
+	// Tentativas esgotadas
+	delivery.Record(domain2.WebhookAttempt{AttemptedAt: now.Add(time.Minute), Error: "connection refused"}, policy)
+	assert.Equal(t, domain2.DeliveryFailed, delivery.Status)
+	assert.Nil(t, delivery.NextAttemptAt)
+
+	// A reentrega manual bem-sucedida conclui a entrega
+	delivery.Record(domain2.WebhookAttempt{AttemptedAt: now.Add(time.Hour), StatusCode: 204}, policy)
+	assert.Equal(t, domain2.DeliverySucceeded, delivery.Status)
+	assert.Len(t, delivery.Attempts, 3)
+	assert.Equal(t, 3, delivery.Attempts[2].Number)
+}
+
+func TestWebhookDelivery_Abandon(t *testing.T) {
+	now := time.Now()
+	delivery := domain2.NewWebhookDelivery("sub", domain2.WebhookEvent{ID: "evt", Type: domain2.EventPaymentCreated}, []byte(`{}`), now)
+	delivery.Abandon("webhook subscription is inactive", now)
+
+	assert.Equal(t, domain2.DeliveryFailed, delivery.Status)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Equal(t, "webhook subscription is inactive", delivery.Attempts[0].Error)
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	dto2 "payments/dto"
+)
+
+func TestDecodeAPIKeyRequest(t *testing.T) {
+	req, err := dto2.DecodeAPIKeyRequest([]byte(`{"name": "checkout", "scopes": ["payments:read", "refunds:write"]}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, dto2.APIKeyRequest{Name: "checkout", Scopes: []string{domain.ScopePaymentsRead, domain.ScopeRefundsWrite}}, req)
+}
+
+func TestDecodeAPIKeyRequest_Invalid(t *testing.T) {
+	_, err := dto2.DecodeAPIKeyRequest([]byte(`{"name": "", "scopes": ["payments:delete"], "hash": "abc"}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.ElementsMatch(t, []string{"name", "scopes", "hash"}, fields)
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	dto2 "payments/dto"
+)
+
+func TestDecodeOpenDisputeRequest_Valid(t *testing.T) {
+	req, err := dto2.DecodeOpenDisputeRequest([]byte(`{
+		"reason_code": "4837",
+		"evidence_due_by": "2030-01-15T12:00:00Z",
+		"evidence": [{"file_name": "receipt.pdf", "content_type": "application/pdf", "size": 2048, "url": "https://files.example.com/receipt.pdf"}]
+	}`))
+
+	assert.Nil(t, err)
+	dispute := req.ToDispute()
+	assert.Equal(t, "4837", dispute.ReasonCode)
+	assert.Zero(t, dispute.Amount)
+	assert.True(t, time.Date(2030, time.January, 15, 12, 0, 0, 0, time.UTC).Equal(dispute.EvidenceDueBy))
+	if assert.Len(t, dispute.Evidence, 1) {
+		assert.Equal(t, int64(2048), dispute.Evidence[0].Size)
+	}
+}
+
+func TestDecodeOpenDisputeRequest_Violations(t *testing.T) {
+	_, err := dto2.DecodeOpenDisputeRequest([]byte(`{
+		"status": "won",
+		"amount": 10.001,
+		"evidence": [{"file_name": "receipt.pdf", "content_type": "application/pdf", "size": 0, "url": "receipt"}]
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "status", Message: "is controlled by the server and must not be sent"},
+		{Field: "reason_code", Message: "is required"},
+		{Field: "amount", Message: "must have at most 2 decimal places"},
+		{Field: "evidence[0].size", Message: "must be greater than 0"},
+		{Field: "evidence[0].url", Message: "must be a valid URL"},
+	}, validationErr.Errors)
+}
+
+func TestDecodeResolveDisputeRequest(t *testing.T) {
+	req, err := dto2.DecodeResolveDisputeRequest([]byte(`{"outcome": "lost"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, domain.DisputeLost, req.Outcome)
+
+	_, err = dto2.DecodeResolveDisputeRequest([]byte(`{"outcome": "draw"}`))
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+}
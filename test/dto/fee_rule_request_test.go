@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	dto2 "payments/dto"
+)
+
+func TestDecodeFeeRuleRequest_Valid(t *testing.T) {
+	req, err := dto2.DecodeFeeRuleRequest([]byte(`{
+		"payment_type": "CREDIT_CARD",
+		"fixed_amount": 0.39,
+		"percentage": 3.49,
+		"max_fee": 50,
+		"effective_from": "2025-07-01T00:00:00-03:00"
+	}`))
+
+	assert.Nil(t, err)
+	rule := req.ToFeeRule()
+	assert.Equal(t, domain.CreditCard, rule.PaymentType)
+	assert.Equal(t, 3.49, rule.Percentage)
+	assert.True(t, time.Date(2025, time.July, 1, 3, 0, 0, 0, time.UTC).Equal(rule.EffectiveFrom))
+}
+
+func TestDecodeFeeRuleRequest_Violations(t *testing.T) {
+	_, err := dto2.DecodeFeeRuleRequest([]byte(`{
+		"id": "507f191e810c19729de860ea",
+		"payment_type": "CASH",
+		"fixed_amount": 0.001,
+		"percentage": 120,
+		"min_fee": 10,
+		"max_fee": 5,
+		"effective_from": "tomorrow"
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "id", Message: "is controlled by the server and must not be sent"},
+		{Field: "payment_type", Message: "invalid payment type: CASH"},
+		{Field: "fixed_amount", Message: "must have at most 2 decimal places"},
+		{Field: "percentage", Message: "must be less than or equal to 100"},
+		{Field: "max_fee", Message: "must not be negative nor lower than min_fee"},
+		{Field: "effective_from", Message: "has an invalid type"},
+	}, validationErr.Errors)
+}
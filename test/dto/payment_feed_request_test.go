@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	dto2 "payments/dto"
+)
+
+func TestDecodePaymentFeedMessage(t *testing.T) {
+	msg, err := dto2.DecodePaymentFeedMessage([]byte(`{"action": "subscribe", "filter": {"status": ["Cancelado"], "payment_type": ["PIX"], "order_id_prefix": "shop-"}}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, domain.PaymentEventFilter{
+		Statuses:      []string{domain.StatusCancelled},
+		PaymentTypes:  []domain.PaymentType{domain.Pix},
+		OrderIDPrefix: "shop-",
+	}, msg.Filter.ToFilter())
+}
+
+func TestDecodePaymentFeedMessage_Violations(t *testing.T) {
+	_, err := dto2.DecodePaymentFeedMessage([]byte(`{"action": "unsubscribe", "filter": {"payment_type": ["CASH"]}}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "action", Message: "must be one of: subscribe"},
+		{Field: "filter.payment_type[0]", Message: "invalid payment type: CASH"},
+	}, validationErr.Errors)
+}
+
+func TestDecodePaymentFeedQuery(t *testing.T) {
+	filter, err := dto2.DecodePaymentFeedQuery("Cancelado, Estornado", "", "shop-")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{domain.StatusCancelled, domain.StatusRefunded}, filter.Status)
+	assert.Empty(t, filter.ToFilter().PaymentTypes)
+
+	_, err = dto2.DecodePaymentFeedQuery("", "PIX,CASH", "")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
@@ -0,0 +1,337 @@
+package dto
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	dto2 "payments/dto"
+)
+
+func TestDecodeCreatePaymentRequest_Valid(t *testing.T) {
+	req, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 99.90,
+		"method": "app",
+		"payment_type": "QR_CODE"
+	}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &domain.Payment{
+		OrderId:     "order123",
+		Amount:      99.90,
+		Method:      "app",
+		PaymentType: domain.QRCode,
+	}, req.ToPayment())
+}
+
+func TestDecodeCreatePaymentRequest_InvalidTypes(t *testing.T) {
+	_, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": 123,
+		"amount": 10.555,
+		"method": "online",
+		"payment_type": "PIX"
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "order_id", Message: "has an invalid type"},
+		{Field: "amount", Message: "must have at most 2 decimal places"},
+	}, validationErr.Errors)
+}
+
+func TestDecodeCreatePaymentRequest_ServerControlledFields(t *testing.T) {
+	cases := map[string]string{
+		"id":                `"67a8ffa093a5fa72f000452b"`,
+		"_id":               `"67a8ffa093a5fa72f000452b"`,
+		"status":            `"Capturado"`,
+		"created_at":        `"2024-01-01T00:00:00Z"`,
+		"gross_amount":      `10`,
+		"fee_amount":        `0`,
+		"net_amount":        `10`,
+		"fee_rule_id":       `"rule"`,
+		"capture_method":    `"automatic"`,
+		"captured_amount":   `10`,
+		"refunded_amount":   `0`,
+		"refund_required":   `false`,
+		"boleto":            `{"barcode": "0"}`,
+		"fx_rate":           `{"rate": 1}`,
+		"settlement_amount": `10`,
+		"installment_plan":  `{"installments": 1}`,
+	}
+	for field, value := range cases {
+		t.Run(field, func(t *testing.T) {
+			_, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+				"order_id": "order123",
+				"amount": 10,
+				"method": "online",
+				"payment_type": "PIX",
+				"` + field + `": ` + value + `
+			}`))
+
+			var validationErr *domain.ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, []domain.ErrorResponse{{Field: field, Message: "is controlled by the server and must not be sent"}}, validationErr.Errors)
+		})
+	}
+}
+
+func TestDecodeCreatePaymentRequest_Malformed(t *testing.T) {
+	_, err := dto2.DecodeCreatePaymentRequest([]byte(`[1, 2]`))
+
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, domain.ErrValidation))
+}
+
+func TestDecodeCreatePaymentRequest_AuthorizeOnly(t *testing.T) {
+	req, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 50,
+		"method": "online",
+		"payment_type": "CREDIT_CARD",
+		"card": {"token": "tok_123", "brand": "visa", "last4": "4242"},
+		"capture": false
+	}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, domain.CaptureManual, req.ToPayment().CaptureMethod)
+
+	// PIX é liquidado na hora e não pode ser apenas autorizado
+	_, err = dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 50,
+		"method": "online",
+		"payment_type": "PIX",
+		"capture": false
+	}`))
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "capture", validationErr.Errors[0].Field)
+}
+
+func TestDecodeUpdatePaymentRequest(t *testing.T) {
+	req, err := dto2.DecodeUpdatePaymentRequest([]byte(`{"method": "in_store"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, domain.PaymentUpdate{Method: "in_store"}, req.ToUpdate())
+
+	_, err = dto2.DecodeUpdatePaymentRequest([]byte(`{
+		"method": "pix",
+		"status": "Capturado",
+		"amount": 1,
+		"captured_amount": 1,
+		"card": {"token": "tok"}
+	}`))
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "status", Message: "is controlled by the server and must not be sent"},
+		{Field: "amount", Message: "cannot be updated"},
+		{Field: "captured_amount", Message: "is controlled by the server and must not be sent"},
+		{Field: "card", Message: "cannot be updated"},
+		{Field: "method", Message: "must be one of: online, in_store, app"},
+	}, validationErr.Errors)
+
+	_, err = dto2.DecodeUpdatePaymentRequest([]byte(`{}`))
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "method", Message: "is required"}}, validationErr.Errors)
+}
+
+func TestDecodeCaptureRequest(t *testing.T) {
+	req, err := dto2.DecodeCaptureRequest(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, req.Amount)
+
+	req, err = dto2.DecodeCaptureRequest([]byte(`{"amount": 25.50}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 25.5, req.Amount)
+
+	_, err = dto2.DecodeCaptureRequest([]byte(`{"amount": 0.001}`))
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+}
+
+func TestDecodeCreatePaymentRequest_CreditCard(t *testing.T) {
+	req, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 120,
+		"method": "app",
+		"payment_type": "CREDIT_CARD",
+		"card": {"token": "tok_abc", "brand": "mastercard", "last4": "0004"},
+		"installments": 3
+	}`))
+
+	assert.Nil(t, err)
+	payment := req.ToPayment()
+	assert.Equal(t, &domain.Card{Token: "tok_abc", Brand: "mastercard", Last4: "0004"}, payment.Card)
+	assert.Equal(t, 3, payment.Installments)
+}
+
+func TestDecodeCreatePaymentRequest_CreditCardViolations(t *testing.T) {
+	_, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 20,
+		"method": "app",
+		"payment_type": "CREDIT_CARD",
+		"card": {"token": "4111111111111111", "brand": "diners", "last4": "42", "cvv": "123"},
+		"card_number": "4111111111111111",
+		"installments": 6
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "card_number", Message: "must not be sent; tokenize the card on the gateway"},
+		{Field: "card.cvv", Message: "must not be sent; tokenize the card on the gateway"},
+		{Field: "card.brand", Message: "must be one of: visa, mastercard, amex, elo, hipercard"},
+		{Field: "card.last4", Message: "must be exactly 4 characters long"},
+		{Field: "card.token", Message: "must be a gateway token, not a card number"},
+	}, validationErr.Errors)
+}
+
+func TestDecodeCreatePaymentRequest_CardRequiredForCreditCard(t *testing.T) {
+	_, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 20,
+		"method": "app",
+		"payment_type": "CREDIT_CARD"
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "card.token", Message: "is required for CREDIT_CARD payments"}}, validationErr.Errors)
+}
+
+func TestDecodeCreatePaymentRequest_BoletoDueDate(t *testing.T) {
+	dueDate := time.Now().AddDate(0, 0, 5).Format("2006-01-02")
+	req, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 250,
+		"method": "online",
+		"payment_type": "BOLETO",
+		"due_date": "` + dueDate + `"
+	}`))
+
+	assert.Nil(t, err)
+	payment := req.ToPayment()
+	assert.NotNil(t, payment.Boleto)
+	assert.Equal(t, dueDate, payment.Boleto.DueDate.Format("2006-01-02"))
+}
+
+func TestDecodeCreatePaymentRequest_BoletoDueDateViolations(t *testing.T) {
+	cases := map[string]domain.ErrorResponse{
+		`"BOLETO", "due_date": "10/03/2030"`: {Field: "due_date", Message: "must be a date in the format 2006-01-02"},
+		`"BOLETO", "due_date": "2020-01-01"`: {Field: "due_date", Message: "must not be in the past"},
+		`"PIX", "due_date": "2099-01-01"`:    {Field: "due_date", Message: "is only accepted for BOLETO payments"},
+	}
+	for fields, expected := range cases {
+		_, err := dto2.DecodeCreatePaymentRequest([]byte(`{"order_id": "order123", "amount": 10, "method": "online", "payment_type": ` + fields + `}`))
+
+		var validationErr *domain.ValidationError
+		assert.True(t, errors.As(err, &validationErr), fields)
+		assert.Equal(t, []domain.ErrorResponse{expected}, validationErr.Errors, fields)
+	}
+}
+
+func TestDecodeCreatePaymentRequest_Splits(t *testing.T) {
+	req, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 100,
+		"method": "online",
+		"payment_type": "PIX",
+		"splits": [
+			{"recipient_id": "seller", "type": "percentage", "value": 100, "liable": true},
+			{"recipient_id": "platform", "type": "fixed", "value": 15, "charge_processing_fee": true}
+		]
+	}`))
+
+	assert.Nil(t, err)
+	payment := req.ToPayment()
+	assert.Len(t, payment.Splits, 2)
+	assert.Equal(t, domain.Split{RecipientID: "platform", Type: domain.SplitFixed, Value: 15, ChargeProcessingFee: true}, payment.Splits[1])
+}
+
+func TestDecodeCreatePaymentRequest_SplitViolations(t *testing.T) {
+	_, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 100,
+		"method": "online",
+		"payment_type": "PIX",
+		"splits": [
+			{"type": "percentage", "value": 50, "liable": true, "charge_processing_fee": true},
+			{"recipient_id": "platform", "type": "fixed", "value": 0.001}
+		]
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "splits[0].recipient_id", Message: "is required"},
+		{Field: "splits[1].value", Message: "must have at most 2 decimal places"},
+	}, validationErr.Errors)
+}
+
+func TestDecodeRefundRequest(t *testing.T) {
+	req, err := dto2.DecodeRefundRequest(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, req.Amount)
+
+	req, err = dto2.DecodeRefundRequest([]byte(`{"amount": 12.5}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 12.5, req.Amount)
+
+	_, err = dto2.DecodeRefundRequest([]byte(`{"amount": -3}`))
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+}
+
+func TestDecodeCreatePaymentRequest_Currency(t *testing.T) {
+	req, err := dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 25,
+		"currency": "USD",
+		"method": "online",
+		"payment_type": "CREDIT_CARD",
+		"card": {"token": "tok_123", "brand": "visa", "last4": "4242"}
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", req.ToPayment().Currency)
+
+	_, err = dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 25,
+		"currency": "USD",
+		"method": "online",
+		"payment_type": "PIX"
+	}`))
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "currency", Message: "is not accepted for PIX payments"}}, validationErr.Errors)
+
+	_, err = dto2.DecodeCreatePaymentRequest([]byte(`{
+		"order_id": "order123",
+		"amount": 25,
+		"currency": "dollar",
+		"method": "online",
+		"payment_type": "PIX"
+	}`))
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "currency", Message: "must be exactly 3 characters long"}}, validationErr.Errors)
+}
+
+func TestDecodeOrderCreated(t *testing.T) {
+	req, err := dto2.DecodeOrderCreated([]byte(`{"order_id":"order-9","amount":30,"payment_type":"PIX"}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "online", req.Method)
+	assert.Equal(t, "order-9", req.OrderID)
+
+	// O canal informado pelo pedido prevalece; as validações são as de POST /payments
+	req, err = dto2.DecodeOrderCreated([]byte(`{"order_id":"order-9","amount":30,"method":"app","payment_type":"PIX"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", req.Method)
+
+	_, err = dto2.DecodeOrderCreated([]byte(`{"order_id":"order-9","amount":30,"payment_type":"CHEQUE"}`))
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
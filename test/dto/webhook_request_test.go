@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	dto2 "payments/dto"
+)
+
+func TestDecodeWebhookSubscriptionRequest_Valid(t *testing.T) {
+	req, err := dto2.DecodeWebhookSubscriptionRequest([]byte(`{
+		"url": "https://shop.example.com/hooks",
+		"events": ["payment.approved", "payment.refunded"],
+		"description": "Loja principal"
+	}`))
+
+	assert.Nil(t, err)
+	subscription := req.ToSubscription()
+	assert.Equal(t, "https://shop.example.com/hooks", subscription.URL)
+	assert.Len(t, subscription.Events, 2)
+	assert.True(t, subscription.Active) // active omitido cria a inscrição ativa
+
+	req, err = dto2.DecodeWebhookSubscriptionRequest([]byte(`{"url": "https://shop.example.com/hooks", "events": ["*"], "active": false}`))
+	assert.Nil(t, err)
+	assert.False(t, req.ToSubscription().Active)
+}
+
+func TestDecodeWebhookSubscriptionRequest_Violations(t *testing.T) {
+	_, err := dto2.DecodeWebhookSubscriptionRequest([]byte(`{
+		"secret": "mine",
+		"url": "ftp://shop.example.com",
+		"events": ["payment.approved", "order.created"]
+	}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.ElementsMatch(t, []string{"secret", "url", "events[1]"}, fields)
+
+	_, err = dto2.DecodeWebhookSubscriptionRequest([]byte(`{"url": "https://shop.example.com/hooks", "events": []}`))
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "events", Message: "is required"}}, validationErr.Errors)
+}
+
+func TestDecodeWebhookSubscriptionRequest_TooManyEvents(t *testing.T) {
+	events := strings.Repeat(`"payment.approved",`, 21)
+	_, err := dto2.DecodeWebhookSubscriptionRequest([]byte(`{"url": "https://shop.example.com/hooks", "events": [` + strings.TrimSuffix(events, ",") + `]}`))
+
+	var validationErr *domain.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "events", Message: "must have at most 20 items"}}, validationErr.Errors)
+}
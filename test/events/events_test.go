@@ -0,0 +1,290 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"payments/domain"
+	events2 "payments/events"
+)
+
+var eventNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testPayment() domain.Payment {
+	return domain.Payment{
+		ID:          primitive.NewObjectID(),
+		OrderId:     "order-1",
+		Amount:      120.5,
+		Status:      domain.StatusRefunded,
+		PaymentType: domain.Pix,
+		CreatedAt:   eventNow.Add(-time.Hour),
+	}
+}
+
+func TestPaymentType(t *testing.T) {
+	assert.Equal(t, "payments.payment.approved.v1", events2.PaymentType(domain.EventPaymentApproved))
+}
+
+func TestNewPaymentEvent(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	ctx, span := otel.Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	payment := testPayment()
+
+	event, err := events2.NewPaymentEvent(ctx, events2.DefaultSource, domain.EventPaymentRefunded, payment, eventNow)
+
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", event.SpecVersion)
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, "/payments", event.Source)
+	assert.Equal(t, "payments.payment.refunded.v1", event.Type)
+	assert.Equal(t, payment.ID.Hex(), event.Subject)
+	assert.Equal(t, payment.ID.Hex(), event.PartitionKey)
+	assert.Equal(t, eventNow, event.Time)
+	assert.Equal(t, "application/json", event.DataContentType)
+	assert.Contains(t, event.TraceParent, span.SpanContext().TraceID().String())
+
+	var data events2.PaymentData
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+	assert.Equal(t, events2.PaymentData{
+		ID:          payment.ID.Hex(),
+		OrderID:     "order-1",
+		Amount:      120.5,
+		Currency:    domain.SettlementCurrency,
+		Status:      domain.StatusRefunded,
+		PaymentType: domain.Pix,
+		CreatedAt:   payment.CreatedAt,
+	}, data)
+
+	// Os atributos seguem os nomes da especificação CloudEvents
+	raw, _ := json.Marshal(event)
+	for _, attribute := range []string{`"specversion":"1.0"`, `"datacontenttype"`, `"partitionkey"`, `"traceparent"`, `"data":{`} {
+		assert.Contains(t, string(raw), attribute)
+	}
+}
+
+func TestPaymentNotifier(t *testing.T) {
+	publisher := events2.NewMemoryPublisher()
+	notifier := events2.NewPaymentNotifier(publisher, events2.WithSource("/payments-test"),
+		events2.WithClock(func() time.Time { return eventNow }))
+	payment := testPayment()
+
+	notifier.NotifyPayment(context.Background(), domain.EventPaymentCreated, payment)
+	notifier.NotifyPayment(context.Background(), domain.EventPaymentApproved, payment)
+
+	published := publisher.Events()
+	require.Len(t, published, 2)
+	assert.Equal(t, "payments.payment.created.v1", published[0].Type)
+	assert.Equal(t, "payments.payment.approved.v1", published[1].Type)
+	assert.Equal(t, "/payments-test", published[1].Source)
+	assert.Equal(t, eventNow, published[1].Time)
+	assert.NotEqual(t, published[0].ID, published[1].ID)
+}
+
+type failingPublisher struct {
+	events2.Publisher
+}
+
+func (failingPublisher) Publish(context.Context, events2.CloudEvent) error {
+	return errors.New("broker down")
+}
+
+func TestPaymentNotifier_PublishFailureDoesNotPanic(t *testing.T) {
+	notifier := events2.NewPaymentNotifier(failingPublisher{})
+
+	assert.NotPanics(t, func() {
+		notifier.NotifyPayment(context.Background(), domain.EventPaymentCreated, testPayment())
+	})
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher, err := events2.NewFilePublisher(path)
+	require.NoError(t, err)
+
+	for _, id := range []string{"evt-1", "evt-2"} {
+		require.NoError(t, publisher.Publish(context.Background(), events2.CloudEvent{SpecVersion: events2.SpecVersion, ID: id, Data: json.RawMessage(`{}`)}))
+	}
+	require.NoError(t, publisher.Close())
+
+	// Reabrir acrescenta ao arquivo existente
+	publisher, err = events2.NewFilePublisher(path)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), events2.CloudEvent{ID: "evt-3", Data: json.RawMessage(`{}`)}))
+	require.NoError(t, publisher.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event events2.CloudEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []string{"evt-1", "evt-2", "evt-3"}, ids)
+}
+
+// TestNATSPublisher exige um servidor NATS em PAYMENTS_TEST_NATS_URL
+func TestNATSPublisher(t *testing.T) {
+	url := os.Getenv("PAYMENTS_TEST_NATS_URL")
+	if url == "" {
+		t.Skip("PAYMENTS_TEST_NATS_URL não definido")
+	}
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	received := make(chan *nats.Msg, 1)
+	sub, err := conn.ChanSubscribe("payments-test.payment.>", received)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	publisher := events2.NewNATSPublisher(conn, "payments-test")
+	event, err := events2.NewPaymentEvent(context.Background(), events2.DefaultSource, domain.EventPaymentApproved, testPayment(), eventNow)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), event))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "payments-test.payment.approved.v1", msg.Subject)
+		assert.Equal(t, events2.ContentType, msg.Header.Get("Content-Type"))
+		assert.Equal(t, event.ID, msg.Header.Get(nats.MsgIdHdr))
+		assert.True(t, strings.Contains(string(msg.Data), event.ID))
+	case <-time.After(2 * time.Second):
+		t.Fatal("evento não recebido")
+	}
+	require.NoError(t, publisher.Close())
+}
+
+func TestNewPaymentInstructionsEvent(t *testing.T) {
+	payment := testPayment()
+	payment.Status = domain.StatusProcessing
+	payment.PaymentType = domain.Boleto
+	payment.Boleto = &domain.BoletoDetails{}
+
+	event, err := events2.NewPaymentInstructionsEvent(context.Background(), events2.DefaultSource, payment, eventNow)
+
+	require.NoError(t, err)
+	assert.Equal(t, "payments.payment.instructions.v1", event.Type)
+	assert.Equal(t, payment.ID.Hex(), event.PartitionKey)
+	var instructions events2.PaymentInstructions
+	require.NoError(t, json.Unmarshal(event.Data, &instructions))
+	assert.Equal(t, payment.ID.Hex(), instructions.PaymentID)
+	assert.Equal(t, "order-1", instructions.OrderID)
+	assert.Equal(t, "/payments/"+payment.ID.Hex()+"/boleto.png", instructions.BoletoImagePath)
+}
+
+func TestMemorySubscriber(t *testing.T) {
+	subscriber := events2.NewMemorySubscriber(2, events2.WithRetryBackoff(time.Millisecond, 4*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, subscriber.Deliver(ctx, events2.CloudEvent{ID: "evt-1"}))
+	require.NoError(t, subscriber.Deliver(ctx, events2.CloudEvent{ID: "evt-2"}))
+
+	received := make(chan string, 10)
+	done := make(chan error)
+	attempts := 0
+	go func() {
+		done <- subscriber.Subscribe(ctx, func(ctx context.Context, event events2.CloudEvent) error {
+			received <- event.ID
+			if event.ID == "evt-1" {
+				// Falhas transitórias são repetidas até o sucesso
+				if attempts++; attempts < 3 {
+					return errors.New("banco fora do ar")
+				}
+				return nil
+			}
+			// Uma falha permanente descarta a mensagem sem nova tentativa
+			return fmt.Errorf("mensagem inválida: %w", events2.ErrPermanent)
+		})
+	}()
+
+	for _, want := range []string{"evt-1", "evt-1", "evt-1", "evt-2"} {
+		assert.Equal(t, want, <-received)
+	}
+	require.NoError(t, subscriber.Deliver(ctx, events2.CloudEvent{ID: "evt-3"}))
+	assert.Equal(t, "evt-3", <-received, "a assinatura segue depois da falha permanente")
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestMemorySubscriberStopsRetryingOnCancel(t *testing.T) {
+	subscriber := events2.NewMemorySubscriber(1, events2.WithRetryBackoff(time.Hour, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, subscriber.Deliver(ctx, events2.CloudEvent{ID: "evt-1"}))
+
+	failed := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- subscriber.Subscribe(ctx, func(ctx context.Context, event events2.CloudEvent) error {
+			failed <- struct{}{}
+			return errors.New("banco fora do ar")
+		})
+	}()
+
+	<-failed
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, events2.IsPermanent(fmt.Errorf("tipo inesperado: %w", events2.ErrPermanent)))
+	assert.True(t, events2.IsPermanent(domain.NewValidationError(domain.ErrorResponse{Field: "amount", Message: "must be positive"})))
+	assert.False(t, events2.IsPermanent(errors.New("connection refused")))
+	assert.False(t, events2.IsPermanent(domain.ErrUpstream))
+}
+
+// TestNATSSubscriber exige um servidor NATS em PAYMENTS_TEST_NATS_URL
+func TestNATSSubscriber(t *testing.T) {
+	url := os.Getenv("PAYMENTS_TEST_NATS_URL")
+	if url == "" {
+		t.Skip("PAYMENTS_TEST_NATS_URL não definido")
+	}
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer conn.Close()
+	subscriber := events2.NewNATSSubscriber(conn, "orders-test.order.created", "payments-test")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 1)
+	done := make(chan error)
+	go func() {
+		done <- subscriber.Subscribe(ctx, func(ctx context.Context, event events2.CloudEvent) error {
+			received <- event.ID
+			return nil
+		})
+	}()
+
+	publisher, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer publisher.Close()
+	raw, _ := json.Marshal(events2.CloudEvent{ID: "order-evt-1", Type: "order.created"})
+	// A assinatura é feita na goroutine: reenvia até a mensagem chegar
+	assert.Eventually(t, func() bool {
+		require.NoError(t, publisher.Publish("orders-test.order.created", raw))
+		select {
+		case id := <-received:
+			return id == "order-evt-1"
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -1,75 +1,82 @@
 package godog_tests
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/cucumber/godog"
 	"payments/domain"
+	"payments/repository"
 	"payments/usecase"
 )
 
-// Mock do repositório
-type mockPaymentRepository struct {
-	CreateFunc func(payment *domain.Payment) (string, error)
-}
-
-func (m *mockPaymentRepository) Create(payment *domain.Payment) (string, error) {
-	return m.CreateFunc(payment)
-}
-
-// Outros métodos vazios para satisfazer a interface
-func (m *mockPaymentRepository) GetAll() ([]domain.Payment, error) { return nil, nil }
-func (m *mockPaymentRepository) GetByID(id string) (domain.Payment, error) {
-	return domain.Payment{}, nil
-}
-func (m *mockPaymentRepository) Update(id string, payment *domain.Payment) error { return nil }
-func (m *mockPaymentRepository) Delete(id string) error                          { return nil }
-
-// Variáveis globais usadas nos testes
+// Variáveis globais usadas nos testes, reiniciadas a cada cenário
 var (
 	payment   *domain.Payment
-	useCase   *usecase.PaymentUseCase
+	useCase   usecase.PaymentUseCase
+	createdID string
 	lastError error
 )
 
 // Passos do Gherkin
 func queTenhoUmPagamentoValido() error {
 	// Criando um pagamento válido com tipo Pix
-	payment = &domain.Payment{PaymentType: domain.Pix}
+	payment = &domain.Payment{OrderId: "order123", Amount: 150, Method: "online", PaymentType: domain.Pix}
 	return nil
 }
 
-func euTentoCriarOPagamento() error {
-	// Mock do repositório com a lógica para simular sucesso
-	mockRepo := &mockPaymentRepository{
-		CreateFunc: func(payment *domain.Payment) (string, error) {
-			if payment.PaymentType == domain.Pix { // Simulando sucesso para tipo Pix
-				return "payment-123", nil // Pagamento criado com sucesso
-			}
-			return "", errors.New("invalid payment type")
-		},
-	}
+func queTenhoUmPagamentoComTipo(paymentType string) error {
+	payment = &domain.Payment{OrderId: "order123", Amount: 150, Method: "online", PaymentType: domain.PaymentType(paymentType)}
+	return nil
+}
 
-	// Inicializando o caso de uso com o repositório simulado
-	useCase := usecase.NewPaymentUseCase(mockRepo)
-	_, lastError = useCase.CreatePayment(payment)
+func euTentoCriarOPagamento() error {
+	createdID, lastError = useCase.CreatePayment(context.Background(), payment)
 	return nil
 }
 
 func oPagamentoDeveSerCriadoComSucesso() error {
 	if lastError != nil {
-		return errors.New("esperava sucesso, mas ocorreu um erro")
+		return fmt.Errorf("esperava sucesso, mas ocorreu um erro: %v", lastError)
 	}
 	return nil
 }
 
-// Inicializa os testes
+func aCriacaoDeveFalharPorValidacao() error {
+	if !errors.Is(lastError, domain.ErrValidation) {
+		return fmt.Errorf("esperava erro de validação, mas recebi: %v", lastError)
+	}
+	return nil
+}
+
+func oPagamentoDeveEstarComStatus(status string) error {
+	stored, err := useCase.GetPaymentByID(context.Background(), createdID)
+	if err != nil {
+		return err
+	}
+	if stored.Status != status {
+		return fmt.Errorf("esperava status %q, mas o pagamento está com %q", status, stored.Status)
+	}
+	return nil
+}
+
+// Inicializa os testes usando o use case real sobre o repositório em memória
 func InitializeScenario(ctx *godog.ScenarioContext) {
+	ctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		useCase = usecase.NewPaymentUseCase(repository.NewMemoryPaymentRepository())
+		payment, createdID, lastError = nil, "", nil
+		return ctx, nil
+	})
+
 	ctx.Step(`^que tenho um pagamento válido$`, queTenhoUmPagamentoValido)
+	ctx.Step(`^que tenho um pagamento com tipo "([^"]*)"$`, queTenhoUmPagamentoComTipo)
 	ctx.Step(`^eu tentar criar o pagamento$`, euTentoCriarOPagamento)
 	ctx.Step(`^o pagamento deve ser criado com sucesso$`, oPagamentoDeveSerCriadoComSucesso)
+	ctx.Step(`^a criação deve falhar por validação$`, aCriacaoDeveFalharPorValidacao)
+	ctx.Step(`^o pagamento deve estar com status "([^"]*)"$`, oPagamentoDeveEstarComStatus)
 }
 
 // Rodando os testes
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jobs2 "payments/jobs"
+	"payments/usecase"
+)
+
+// stubUseCase implementa apenas os métodos usados pelos jobs
+type stubUseCase struct {
+	usecase.PaymentUseCase
+	cutoffs []time.Time
+	voided  int
+	err     error
+}
+
+func (s *stubUseCase) ExpireOverdueBoletos(ctx context.Context, now time.Time) (int, error) {
+	s.cutoffs = append(s.cutoffs, now)
+	return s.voided, s.err
+}
+
+func (s *stubUseCase) VoidExpiredAuthorizations(ctx context.Context, cutoff time.Time) (int, error) {
+	s.cutoffs = append(s.cutoffs, cutoff)
+	return s.voided, s.err
+}
+
+func TestAutoVoidJob_RunOnce(t *testing.T) {
+	stub := &stubUseCase{voided: 2}
+	job := jobs2.NewAutoVoidJob(stub, time.Hour, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	before := time.Now().Add(-time.Hour)
+	assert.Equal(t, 2, job.RunOnce(context.Background()))
+
+	assert.Len(t, stub.cutoffs, 1)
+	assert.WithinDuration(t, before, stub.cutoffs[0], time.Second)
+}
+
+func TestAutoVoidJob_RunOnce_PartialFailure(t *testing.T) {
+	stub := &stubUseCase{voided: 1, err: errors.New("gateway timeout")}
+	job := jobs2.NewAutoVoidJob(stub, time.Hour, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Equal(t, 1, job.RunOnce(context.Background()))
+}
+
+func TestAutoVoidJob_RunStopsWithContext(t *testing.T) {
+	stub := &stubUseCase{}
+	job := jobs2.NewAutoVoidJob(stub, time.Hour, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	job.Run(ctx) // Retorna quando o contexto expira
+
+	assert.GreaterOrEqual(t, len(stub.cutoffs), 2)
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jobs2 "payments/jobs"
+)
+
+func TestBoletoExpiryJob_RunOnce(t *testing.T) {
+	stub := &stubUseCase{voided: 3}
+	job := jobs2.NewBoletoExpiryJob(stub, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Equal(t, 3, job.RunOnce(context.Background()))
+	assert.Len(t, stub.cutoffs, 1)
+	assert.WithinDuration(t, time.Now(), stub.cutoffs[0], time.Second)
+}
+
+func TestBoletoExpiryJob_RunStopsWithContext(t *testing.T) {
+	stub := &stubUseCase{}
+	job := jobs2.NewBoletoExpiryJob(stub, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	job.Run(ctx)
+
+	assert.GreaterOrEqual(t, len(stub.cutoffs), 2)
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jobs2 "payments/jobs"
+	"payments/usecase"
+)
+
+// stubWebhookUseCase implementa apenas o método usado pelo job
+type stubWebhookUseCase struct {
+	usecase.WebhookUseCase
+	calls     []time.Time
+	attempted int
+}
+
+func (s *stubWebhookUseCase) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	s.calls = append(s.calls, now)
+	return s.attempted, nil
+}
+
+func TestWebhookDeliveryJob_RunOnce(t *testing.T) {
+	stub := &stubWebhookUseCase{attempted: 4}
+	job := jobs2.NewWebhookDeliveryJob(stub, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Equal(t, 4, job.RunOnce(context.Background()))
+	assert.Len(t, stub.calls, 1)
+	assert.WithinDuration(t, time.Now(), stub.calls[0], time.Second)
+}
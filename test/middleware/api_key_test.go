@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"payments/delivery"
+	"payments/domain"
+	middleware2 "payments/middleware"
+)
+
+// keyAuthenticator aceita as chaves do mapa; "broken" simula o banco fora do ar
+type keyAuthenticator map[string][]string
+
+func (a keyAuthenticator) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	if key == "broken" {
+		return domain.Principal{}, errors.New("connection refused")
+	}
+	scopes, ok := a[key]
+	if !ok {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	return domain.Principal{Subject: "api_key:" + key, Scopes: scopes}, nil
+}
+
+func TestAuthenticateAndRequireScope(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+	app.Use(middleware2.Authenticate(keyAuthenticator{"reader": {domain.ScopePaymentsRead}, "admin": {domain.ScopeAdmin}}))
+	app.Get("/payments", middleware2.RequireScope(domain.ScopePaymentsRead), func(c *fiber.Ctx) error {
+		principal, _ := middleware2.PrincipalFrom(c)
+		return c.SendString(principal.Subject)
+	})
+	app.Delete("/payments/1", middleware2.RequireScope(domain.ScopePaymentsWrite), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	cases := []struct {
+		name, method, path string
+		headers            map[string]string
+		status             int
+	}{
+		{"X-API-Key header", "GET", "/payments", map[string]string{"X-API-Key": "reader"}, fiber.StatusOK},
+		{"Bearer header", "GET", "/payments", map[string]string{"Authorization": "Bearer reader"}, fiber.StatusOK},
+		{"Missing credentials", "GET", "/payments", nil, fiber.StatusUnauthorized},
+		{"Unknown key", "GET", "/payments", map[string]string{"X-API-Key": "stolen"}, fiber.StatusUnauthorized},
+		{"Missing scope", "DELETE", "/payments/1", map[string]string{"X-API-Key": "reader"}, fiber.StatusForbidden},
+		{"Admin has every scope", "DELETE", "/payments/1", map[string]string{"X-API-Key": "admin"}, fiber.StatusNoContent},
+		{"Routes without scope stay public", "GET", "/health", nil, fiber.StatusOK},
+		{"Invalid keys are refused even on public routes", "GET", "/health", map[string]string{"X-API-Key": "stolen"}, fiber.StatusUnauthorized},
+		{"Authenticator failure is not a 401", "GET", "/payments", map[string]string{"X-API-Key": "broken"}, fiber.StatusInternalServerError},
+		{"Query only on WebSocket handshakes", "GET", "/payments?access_token=reader", nil, fiber.StatusUnauthorized},
+		{"Query on WebSocket handshake", "GET", "/payments?access_token=reader",
+			map[string]string{"Connection": "Upgrade", "Upgrade": "websocket"}, fiber.StatusOK},
+		{"Query on SSE stream", "GET", "/payments?access_token=reader",
+			map[string]string{"Accept": "text/event-stream"}, fiber.StatusOK},
+		{"Invalid query token on SSE stream", "GET", "/payments?access_token=stolen",
+			map[string]string{"Accept": "text/event-stream"}, fiber.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.status, resp.StatusCode)
+			if tc.status == fiber.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="payments"`, resp.Header.Get("WWW-Authenticate"))
+			} else {
+				assert.Empty(t, resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRequireResourceScope(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+	app.Use(middleware2.Authenticate(keyAuthenticator{
+		"reader":   {domain.ScopePaymentsRead},
+		"stream-1": {domain.PaymentEventsScope("1")},
+	}))
+	app.Get("/payments/:id/events", middleware2.RequireResourceScope(domain.ScopePaymentsRead, func(c *fiber.Ctx) string {
+		return domain.PaymentEventsScope(c.Params("id"))
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	cases := []struct {
+		name, path, key string
+		status          int
+	}{
+		{"anonymous", "/payments/1/events", "", fiber.StatusUnauthorized},
+		{"route scope", "/payments/2/events", "reader", fiber.StatusOK},
+		{"resource scope", "/payments/1/events", "stream-1", fiber.StatusOK},
+		{"resource scope of another payment", "/payments/2/events", "stream-1", fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"payments/delivery"
+	middleware2 "payments/middleware"
+)
+
+func TestGatewaySignature(t *testing.T) {
+	newApp := func(secret string) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+		app.Post("/payment/callback", middleware2.GatewaySignature(secret), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+		return app
+	}
+	body := `{"id":"67a8ffa093a5fa72f000452b","status":"approved"}`
+	now := time.Now().Unix()
+	signed := middleware2.SignGatewayCallback("secret", now, []byte(body))
+
+	cases := []struct {
+		name, secret, body, signature string
+		timestamp                     int64
+		status                        int
+	}{
+		{"valid signature", "secret", body, signed, now, 200},
+		{"missing signature", "secret", body, "", now, 401},
+		{"other secret", "secret", body, middleware2.SignGatewayCallback("other", now, []byte(body)), now, 401},
+		{"tampered body", "secret", strings.Replace(body, "approved", "refunded", 1), signed, now, 401},
+		{"replayed callback", "secret", body, middleware2.SignGatewayCallback("secret", now-600, []byte(body)), now - 600, 401},
+		{"missing timestamp", "secret", body, signed, 0, 401},
+		{"no secret configured", "", body, middleware2.SignGatewayCallback("", now, []byte(body)), now, 401},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/payment/callback", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.timestamp != 0 {
+				req.Header.Set(middleware2.GatewayTimestampHeader, strconv.FormatInt(tc.timestamp, 10))
+			}
+			req.Header.Set(middleware2.GatewaySignatureHeader, tc.signature)
+			resp, _ := newApp(tc.secret).Test(req)
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
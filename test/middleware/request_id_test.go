@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"payments/logging"
+	middleware2 "payments/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(middleware2.RequestID())
+
+	var contextRequestID string
+	app.Get("/payments", func(c *fiber.Ctx) error {
+		contextRequestID = logging.RequestIDFromContext(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	t.Run("Reuses incoming header", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/payments", nil)
+		req.Header.Set(middleware2.RequestIDHeader, "req-123")
+		resp, err := app.Test(req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "req-123", resp.Header.Get(middleware2.RequestIDHeader))
+		assert.Equal(t, "req-123", contextRequestID)
+	})
+
+	t.Run("Generates when missing", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/payments", nil)
+		resp, err := app.Test(req)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, resp.Header.Get(middleware2.RequestIDHeader))
+		assert.Equal(t, resp.Header.Get(middleware2.RequestIDHeader), contextRequestID)
+	})
+}
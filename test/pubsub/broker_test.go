@@ -0,0 +1,131 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	pubsub2 "payments/pubsub"
+)
+
+var brokerNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func newBroker(opts ...pubsub2.Option) pubsub2.Broker {
+	return pubsub2.NewMemoryBroker(append([]pubsub2.Option{pubsub2.WithClock(func() time.Time { return brokerNow })}, opts...)...)
+}
+
+func publish(broker pubsub2.Broker, paymentID, status string) domain.PaymentEvent {
+	return broker.Publish(domain.PaymentEvent{Type: domain.EventPaymentUpdated, PaymentID: paymentID, Status: status})
+}
+
+// drain lê os eventos já entregues, sem bloquear
+func drain(sub pubsub2.Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestMemoryBroker_NotifyPayment(t *testing.T) {
+	broker := newBroker()
+	payment := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order-1", Amount: 10, Status: "success", PaymentType: domain.Pix}
+	sub := broker.Subscribe(pubsub2.ForPayment(payment.ID.Hex()), "")
+	defer sub.Close()
+
+	broker.NotifyPayment(context.Background(), domain.EventPaymentApproved, payment)
+
+	event := <-sub.Events()
+	assert.Equal(t, "1", event.ID)
+	assert.Equal(t, domain.EventPaymentApproved, event.Type)
+	assert.Equal(t, payment.ID.Hex(), event.PaymentID)
+	assert.Equal(t, "order-1", event.OrderID)
+	assert.Equal(t, domain.SettlementCurrency, event.Currency)
+	assert.Equal(t, brokerNow, event.OccurredAt)
+	assert.True(t, event.Terminal())
+}
+
+func TestMemoryBroker_Filter(t *testing.T) {
+	broker := newBroker()
+	sub := broker.Subscribe(pubsub2.ForPayment("a"), "")
+	all := broker.Subscribe(nil, "")
+
+	publish(broker, "a", domain.StatusProcessing)
+	publish(broker, "b", domain.StatusProcessing)
+	publish(broker, "a", domain.StatusCancelled)
+
+	assert.Equal(t, []string{"1", "3"}, drain(sub))
+	assert.Equal(t, []string{"1", "2", "3"}, drain(all))
+	assert.Equal(t, "3", broker.Subscribe(nil, "").Cursor())
+}
+
+func TestMemoryBroker_Resume(t *testing.T) {
+	broker := newBroker(pubsub2.WithHistorySize(3))
+	for range 4 {
+		publish(broker, "a", domain.StatusProcessing)
+	}
+
+	t.Run("Replays retained events after the last ID", func(t *testing.T) {
+		sub := broker.Subscribe(pubsub2.ForPayment("a"), "2")
+		defer sub.Close()
+		assert.True(t, sub.Resumed())
+		assert.Equal(t, []string{"3", "4"}, drain(sub))
+	})
+
+	t.Run("Up to date", func(t *testing.T) {
+		sub := broker.Subscribe(nil, "4")
+		defer sub.Close()
+		assert.True(t, sub.Resumed())
+		assert.Empty(t, drain(sub))
+	})
+
+	for name, lastEventID := range map[string]string{
+		"Evicted from history": "0",
+		"From before restart":  "9",
+		"Malformed":            "abc",
+		"Absent":               "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			sub := broker.Subscribe(nil, lastEventID)
+			defer sub.Close()
+			assert.False(t, sub.Resumed())
+			assert.Empty(t, drain(sub))
+		})
+	}
+}
+
+func TestMemoryBroker_DropsSlowSubscriber(t *testing.T) {
+	broker := newBroker(pubsub2.WithBufferSize(2))
+	slow := broker.Subscribe(nil, "")
+	fast := broker.Subscribe(nil, "")
+
+	for i := range 3 {
+		publish(broker, "a", domain.StatusProcessing)
+		if i < 2 {
+			<-fast.Events()
+		}
+	}
+
+	assert.True(t, slow.Dropped())
+	assert.Equal(t, []string{"1", "2"}, drain(slow))
+	_, open := <-slow.Events()
+	assert.False(t, open)
+	assert.False(t, fast.Dropped())
+	assert.Equal(t, []string{"3"}, drain(fast))
+
+	// Close depois do desligamento não fecha o canal de novo
+	slow.Close()
+	fast.Close()
+	_, open = <-fast.Events()
+	assert.False(t, open)
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+type apiKeyRepositoryFactory func(t *testing.T) repository2.APIKeyRepository
+
+// runAPIKeyRepositoryContract define o comportamento comum aos backends de APIKeyRepository
+func runAPIKeyRepositoryContract(t *testing.T, newRepo apiKeyRepositoryFactory) {
+	ctx := context.Background()
+	revokedAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	newKey := func(prefix string) *domain.APIKey {
+		return &domain.APIKey{
+			Name:   "checkout " + prefix,
+			Prefix: prefix,
+			Hash:   domain.HashAPIKey(prefix + "_secret"),
+			Scopes: []string{domain.ScopePaymentsRead, domain.ScopePaymentsWrite},
+		}
+	}
+
+	t.Run("Create, GetByID and FindByPrefix", func(t *testing.T) {
+		repo := newRepo(t)
+
+		key := newKey("pk_0001")
+		id, err := repo.Create(ctx, key)
+		assert.Nil(t, err)
+		assert.True(t, primitive.IsValidObjectID(id))
+		assert.False(t, key.CreatedAt.IsZero())
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, "checkout pk_0001", stored.Name)
+		assert.Equal(t, key.Hash, stored.Hash)
+		assert.Equal(t, []string{domain.ScopePaymentsRead, domain.ScopePaymentsWrite}, stored.Scopes)
+		assert.True(t, key.CreatedAt.Equal(stored.CreatedAt))
+		assert.Nil(t, stored.RevokedAt)
+
+		found, err := repo.FindByPrefix(ctx, "pk_0001")
+		assert.Nil(t, err)
+		assert.Equal(t, stored.ID, found.ID)
+		_, err = repo.FindByPrefix(ctx, "pk_0002")
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+	})
+
+	t.Run("Prefix is unique", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Create(ctx, newKey("pk_0001"))
+		assert.Nil(t, err)
+		_, err = repo.Create(ctx, newKey("pk_0001"))
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+	})
+
+	t.Run("Revoke keeps the first revocation date", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, _ := repo.Create(ctx, newKey("pk_0001"))
+		assert.Nil(t, repo.Revoke(ctx, id, revokedAt))
+		assert.Nil(t, repo.Revoke(ctx, id, revokedAt.Add(time.Hour)))
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.True(t, stored.Revoked())
+		assert.True(t, revokedAt.Equal(*stored.RevokedAt))
+	})
+
+	t.Run("List keeps revoked keys in creation order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first := newKey("pk_0001")
+		first.CreatedAt = revokedAt.Add(-2 * time.Hour)
+		second := newKey("pk_0002")
+		second.CreatedAt = revokedAt.Add(-time.Hour)
+		_, _ = repo.Create(ctx, second)
+		firstID, _ := repo.Create(ctx, first)
+		assert.Nil(t, repo.Revoke(ctx, firstID, revokedAt))
+
+		keys, err := repo.List(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, keys, 2)
+		assert.Equal(t, "pk_0001", keys[0].Prefix)
+		assert.True(t, keys[0].Revoked())
+		assert.Equal(t, "pk_0002", keys[1].Prefix)
+	})
+
+	t.Run("Domain errors", func(t *testing.T) {
+		repo := newRepo(t)
+		missingID := primitive.NewObjectID().Hex()
+
+		_, err := repo.GetByID(ctx, "invalid-id")
+		assert.True(t, errors.Is(err, domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.Revoke(ctx, "invalid-id", revokedAt), domain.ErrInvalidID))
+		_, err = repo.GetByID(ctx, missingID)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.Revoke(ctx, missingID, revokedAt), domain.ErrNotFound))
+	})
+}
+
+func TestMemoryAPIKeyRepository_Contract(t *testing.T) {
+	runAPIKeyRepositoryContract(t, func(t *testing.T) repository2.APIKeyRepository {
+		return repository2.NewMemoryAPIKeyRepository()
+	})
+}
+
+func TestSQLiteAPIKeyRepository_Contract(t *testing.T) {
+	runAPIKeyRepositoryContract(t, func(t *testing.T) repository2.APIKeyRepository {
+		sqliteDB, err := repository2.OpenSQLite(context.Background(), ":memory:")
+		assert.Nil(t, err)
+		t.Cleanup(func() { sqliteDB.Close() })
+		return repository2.NewSQLiteAPIKeyRepository(sqliteDB)
+	})
+}
+
+func TestPostgresAPIKeyRepository_Contract(t *testing.T) {
+	pg := openTestPostgres(t)
+
+	runAPIKeyRepositoryContract(t, func(t *testing.T) repository2.APIKeyRepository {
+		_, err := pg.Exec("TRUNCATE api_keys")
+		assert.Nil(t, err)
+		return repository2.NewPostgresAPIKeyRepository(pg)
+	})
+}
+
+func TestMongoAPIKeyRepository_Contract(t *testing.T) {
+	if db == nil {
+		t.Skip("MongoDB indisponível")
+	}
+	// O índice único de prefix vem das migrações; limpar com DeleteMany o preserva
+	assert.Nil(t, repository2.MigrateMongo(context.Background(), db))
+	runAPIKeyRepositoryContract(t, func(t *testing.T) repository2.APIKeyRepository {
+		_, _ = db.Collection("api_keys").DeleteMany(context.Background(), bson.M{})
+		return repository2.NewAPIKeyRepository(db)
+	})
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+// repositoryFactory devolve um repositório vazio para cada cenário do contrato
+type repositoryFactory func(t *testing.T) repository2.PaymentRepository
+
+// runPaymentRepositoryContract define o comportamento que todo backend de
+// PaymentRepository deve seguir (mesma semântica do MongoDB)
+func runPaymentRepositoryContract(t *testing.T, newRepo repositoryFactory) {
+	ctx := context.Background()
+
+	t.Run("Create sets ID and initial status", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{OrderId: "order123", Amount: 100.50, Method: "online", Status: "approved", PaymentType: domain.Pix}
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+		assert.True(t, primitive.IsValidObjectID(id))
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, id, stored.ID.Hex())
+		assert.Equal(t, "order123", stored.OrderId)
+		assert.Equal(t, 100.50, stored.Amount)
+		assert.Equal(t, "online", stored.Method)
+		assert.Equal(t, domain.Pix, stored.PaymentType)
+		assert.Equal(t, domain.StatusProcessing, stored.Status)
+	})
+
+	t.Run("Create keeps a preset ID and rejects duplicates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{ID: primitive.NewObjectID(), Amount: 10}
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+		assert.Equal(t, payment.ID.Hex(), id)
+
+		_, err = repo.Create(ctx, payment)
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		assert.Equal(t, "payment "+id+": "+domain.ErrConflict.Error(), err.Error())
+	})
+
+	t.Run("GetAll returns every payment", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payments, err := repo.GetAll(ctx)
+		assert.Nil(t, err)
+		assert.Empty(t, payments)
+
+		_, _ = repo.Create(ctx, &domain.Payment{Amount: 10})
+		_, _ = repo.Create(ctx, &domain.Payment{Amount: 20})
+
+		payments, err = repo.GetAll(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, payments, 2)
+	})
+
+	t.Run("Update replaces fields and keeps ID", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, _ := repo.Create(ctx, &domain.Payment{Amount: 300})
+		err := repo.Update(ctx, id, &domain.Payment{Amount: 350, Status: "approved", RefundRequired: true})
+		assert.Nil(t, err)
+
+		updated, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, id, updated.ID.Hex())
+		assert.Equal(t, 350.0, updated.Amount)
+		assert.Equal(t, "approved", updated.Status)
+		assert.True(t, updated.RefundRequired)
+	})
+
+	t.Run("Update clears emptied fields", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{Amount: 100, PaymentType: domain.Boleto, RefundRequired: true, FeeAmount: 2.5, FeeRuleID: "rule",
+			Boleto: &domain.BoletoDetails{Barcode: "00190000090000000000000000000000000000000000"},
+			Splits: []domain.Split{
+				{RecipientID: "seller", Type: domain.SplitPercentage, Value: 100, Liable: true},
+				{RecipientID: "platform", Type: domain.SplitFixed, Value: 10, ChargeProcessingFee: true},
+			}}
+		assert.Nil(t, payment.ResolveSplits())
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+
+		// O pagamento atualizado substitui o salvo, inclusive nos campos que ficaram vazios
+		assert.Nil(t, repo.Update(ctx, id, &domain.Payment{Amount: 100, PaymentType: domain.Boleto}))
+		updated, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.False(t, updated.RefundRequired)
+		assert.Zero(t, updated.FeeAmount)
+		assert.Empty(t, updated.FeeRuleID)
+		assert.Nil(t, updated.Boleto)
+		assert.Empty(t, updated.Splits)
+		assert.True(t, payment.CreatedAt.Equal(updated.CreatedAt))
+	})
+
+	t.Run("Update keeps created_at", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{Amount: 300}
+		id, _ := repo.Create(ctx, payment)
+		assert.False(t, payment.CreatedAt.IsZero())
+
+		created, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.True(t, payment.CreatedAt.Equal(created.CreatedAt))
+
+		assert.Nil(t, repo.Update(ctx, id, &domain.Payment{Amount: 350}))
+		updated, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.True(t, created.CreatedAt.Equal(updated.CreatedAt))
+	})
+
+	t.Run("Delete removes the payment", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, _ := repo.Create(ctx, &domain.Payment{Amount: 150})
+		assert.Nil(t, repo.Delete(ctx, id))
+
+		_, err := repo.GetByID(ctx, id)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+	})
+
+	t.Run("FindByOrderID returns the attempts newest first", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first := &domain.Payment{OrderId: "order123", Amount: 10}
+		_, _ = repo.Create(ctx, first)
+		// Finaliza a primeira tentativa para liberar uma nova
+		first.Status = "Recusado"
+		assert.Nil(t, repo.Update(ctx, first.ID.Hex(), first))
+		second := &domain.Payment{OrderId: "order123", Amount: 10}
+		_, err := repo.Create(ctx, second)
+		assert.Nil(t, err)
+		_, _ = repo.Create(ctx, &domain.Payment{OrderId: "order456", Amount: 20})
+
+		payments, err := repo.FindByOrderID(ctx, "order123")
+		assert.Nil(t, err)
+		assert.Len(t, payments, 2)
+		assert.Equal(t, second.ID, payments[0].ID)
+		assert.Equal(t, first.ID, payments[1].ID)
+
+		payments, err = repo.FindByOrderID(ctx, "unknown")
+		assert.Nil(t, err)
+		assert.Empty(t, payments)
+	})
+
+	t.Run("Only one active payment per order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		active := &domain.Payment{OrderId: "order123", Amount: 10}
+		_, err := repo.Create(ctx, active)
+		assert.Nil(t, err)
+
+		_, err = repo.Create(ctx, &domain.Payment{OrderId: "order123", Amount: 10})
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		assert.Contains(t, err.Error(), "order order123 already has an active payment")
+
+		active.Status = "Recusado"
+		assert.Nil(t, repo.Update(ctx, active.ID.Hex(), active))
+		retry := &domain.Payment{OrderId: "order123", Amount: 10}
+		_, err = repo.Create(ctx, retry)
+		assert.Nil(t, err)
+
+		// Reativar a tentativa antiga colidiria com a nova
+		active.Status = domain.StatusProcessing
+		err = repo.Update(ctx, active.ID.Hex(), active)
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		assert.Contains(t, err.Error(), "already has an active payment")
+	})
+
+	t.Run("Manual capture starts authorized and keeps the order busy", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{OrderId: "order123", Amount: 100, CaptureMethod: domain.CaptureManual}
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.StatusAuthorized, payment.Status)
+
+		_, err = repo.Create(ctx, &domain.Payment{OrderId: "order123", Amount: 100})
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+
+		assert.Nil(t, payment.Capture(40))
+		assert.Nil(t, repo.Update(ctx, id, payment))
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.StatusCaptured, stored.Status)
+		assert.Equal(t, domain.CaptureManual, stored.CaptureMethod)
+		assert.Equal(t, 40.0, stored.CapturedAmount)
+	})
+
+	t.Run("FindByStatus filters by creation time", func(t *testing.T) {
+		repo := newRepo(t)
+
+		old := &domain.Payment{Amount: 10, CaptureMethod: domain.CaptureManual, CreatedAt: time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Millisecond)}
+		_, _ = repo.Create(ctx, old)
+		_, _ = repo.Create(ctx, &domain.Payment{Amount: 20, CaptureMethod: domain.CaptureManual})
+		_, _ = repo.Create(ctx, &domain.Payment{Amount: 30})
+
+		payments, err := repo.FindByStatus(ctx, domain.StatusAuthorized, time.Now().Add(-24*time.Hour))
+		assert.Nil(t, err)
+		assert.Len(t, payments, 1)
+		assert.Equal(t, old.ID, payments[0].ID)
+	})
+
+	t.Run("Credit card keeps only the token and display data", func(t *testing.T) {
+		repo := newRepo(t)
+
+		card := &domain.Card{Token: "tok_123", Brand: domain.BrandVisa, Last4: "1111"}
+		plan := domain.NewInstallmentPlan(60, 3, domain.DefaultInstallmentPolicies()[domain.CreditCard], time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC))
+		id, err := repo.Create(ctx, &domain.Payment{Amount: 60, PaymentType: domain.CreditCard, Card: card, Installments: 3, InstallmentPlan: &plan})
+		assert.Nil(t, err)
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, card, stored.Card)
+		assert.Equal(t, 3, stored.Installments)
+		assert.NotNil(t, stored.InstallmentPlan)
+		assert.Equal(t, plan.TotalAmount, stored.InstallmentPlan.TotalAmount)
+		assert.Len(t, stored.InstallmentPlan.Schedule, 3)
+		assert.Equal(t, 20.0, stored.InstallmentPlan.Schedule[2].Amount)
+		assert.True(t, plan.Schedule[2].DueDate.Equal(stored.InstallmentPlan.Schedule[2].DueDate))
+
+		id, _ = repo.Create(ctx, &domain.Payment{Amount: 60, PaymentType: domain.Pix})
+		stored, _ = repo.GetByID(ctx, id)
+		assert.Nil(t, stored.Card)
+		assert.Nil(t, stored.InstallmentPlan)
+	})
+
+	t.Run("Boleto keeps barcode, due date and charges", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{ID: primitive.NewObjectID(), Amount: 90, PaymentType: domain.Boleto}
+		boleto, err := domain.NewBoleto(domain.DefaultBoletoIssuer, *payment, time.Now().AddDate(0, 0, 3))
+		assert.Nil(t, err)
+		payment.Boleto = &boleto
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.NotNil(t, stored.Boleto)
+		assert.Equal(t, boleto.Barcode, stored.Boleto.Barcode)
+		assert.Equal(t, boleto.DigitableLine, stored.Boleto.DigitableLine)
+		assert.True(t, boleto.DueDate.Equal(stored.Boleto.DueDate))
+		assert.WithinDuration(t, boleto.ExpiresAt, stored.Boleto.ExpiresAt, time.Millisecond)
+		assert.Equal(t, boleto.FinePercent, stored.Boleto.FinePercent)
+
+		assert.Nil(t, stored.Expire())
+		assert.Nil(t, repo.Update(ctx, id, &stored))
+		stored, _ = repo.GetByID(ctx, id)
+		assert.Equal(t, domain.StatusExpired, stored.Status)
+		assert.Equal(t, boleto.Barcode, stored.Boleto.Barcode)
+	})
+
+	t.Run("Splits and refunds are stored", func(t *testing.T) {
+		repo := newRepo(t)
+
+		payment := &domain.Payment{Amount: 100, PaymentType: domain.Pix, Splits: []domain.Split{
+			{RecipientID: "seller", Type: domain.SplitPercentage, Value: 100, Liable: true},
+			{RecipientID: "platform", Type: domain.SplitFixed, Value: 10, ChargeProcessingFee: true},
+		}}
+		assert.Nil(t, payment.ResolveSplits())
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, payment.Splits, stored.Splits)
+
+		stored.Status = "approved"
+		_, _, err = stored.Refund(50)
+		assert.Nil(t, err)
+		assert.Nil(t, repo.Update(ctx, id, &stored))
+
+		stored, _ = repo.GetByID(ctx, id)
+		assert.Equal(t, 50.0, stored.RefundedAmount)
+		assert.Equal(t, 45.0, stored.Splits[0].RefundedAmount)
+		assert.Equal(t, 5.0, stored.Splits[1].RefundedAmount)
+	})
+
+	t.Run("Currency and FX snapshot are stored", func(t *testing.T) {
+		repo := newRepo(t)
+
+		rate := domain.FXRate{From: "USD", To: "BRL", Rate: 6.1721, Source: "PTAX", Timestamp: time.Date(2025, time.January, 2, 13, 0, 0, 0, time.UTC)}
+		payment := &domain.Payment{Amount: 100, Currency: "USD", PaymentType: domain.CreditCard}
+		assert.Nil(t, payment.ApplyFXRate(rate))
+		id, err := repo.Create(ctx, payment)
+		assert.Nil(t, err)
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, "USD", stored.CurrencyCode())
+		assert.Equal(t, 617.21, stored.SettlementAmount)
+		if assert.NotNil(t, stored.FXRate) {
+			assert.Equal(t, rate.Rate, stored.FXRate.Rate)
+			assert.Equal(t, rate.Source, stored.FXRate.Source)
+			assert.True(t, rate.Timestamp.Equal(stored.FXRate.Timestamp))
+		}
+
+		id, _ = repo.Create(ctx, &domain.Payment{Amount: 10, PaymentType: domain.Pix})
+		stored, _ = repo.GetByID(ctx, id)
+		assert.Equal(t, domain.SettlementCurrency, stored.CurrencyCode())
+		assert.Nil(t, stored.FXRate)
+	})
+
+	t.Run("Domain errors", func(t *testing.T) {
+		repo := newRepo(t)
+		missingID := primitive.NewObjectID().Hex()
+
+		_, err := repo.GetByID(ctx, "invalid-id")
+		assert.True(t, errors.Is(err, domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.Update(ctx, "invalid-id", &domain.Payment{}), domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.Delete(ctx, "invalid-id"), domain.ErrInvalidID))
+
+		_, err = repo.GetByID(ctx, missingID)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.Update(ctx, missingID, &domain.Payment{}), domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.Delete(ctx, missingID), domain.ErrNotFound))
+	})
+}
+
+func TestMongoPaymentRepository_Contract(t *testing.T) {
+	if db == nil {
+		t.Skip("MongoDB indisponível")
+	}
+	runPaymentRepositoryContract(t, func(t *testing.T) repository2.PaymentRepository {
+		_ = db.Collection("payments").Drop(context.Background())
+		t.Cleanup(func() { _ = db.Collection("payments").Drop(context.Background()) })
+		return repository2.NewPaymentRepository(db)
+	})
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+type disputeRepositoryFactory func(t *testing.T) repository2.DisputeRepository
+
+// runDisputeRepositoryContract define o comportamento comum aos backends de DisputeRepository
+func runDisputeRepositoryContract(t *testing.T, newRepo disputeRepositoryFactory) {
+	ctx := context.Background()
+	dueBy := time.Date(2030, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	newDispute := func(paymentID string) *domain.Dispute {
+		return &domain.Dispute{PaymentID: paymentID, ReasonCode: "4837", Amount: 70, Status: domain.DisputeOpen, EvidenceDueBy: dueBy}
+	}
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+		paymentID := primitive.NewObjectID().Hex()
+
+		dispute := newDispute(paymentID)
+		dispute.Evidence = []domain.EvidenceAttachment{{FileName: "receipt.pdf", ContentType: "application/pdf", Size: 2048,
+			URL: "https://files.example.com/receipt.pdf", UploadedAt: dueBy.AddDate(0, 0, -7)}}
+		id, err := repo.Create(ctx, dispute)
+		assert.Nil(t, err)
+		assert.True(t, primitive.IsValidObjectID(id))
+		assert.False(t, dispute.CreatedAt.IsZero())
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, paymentID, stored.PaymentID)
+		assert.Equal(t, "4837", stored.ReasonCode)
+		assert.Equal(t, 70.0, stored.Amount)
+		assert.Equal(t, domain.DisputeOpen, stored.Status)
+		assert.True(t, dueBy.Equal(stored.EvidenceDueBy))
+		assert.Nil(t, stored.ResolvedAt)
+		if assert.Len(t, stored.Evidence, 1) {
+			assert.Equal(t, "receipt.pdf", stored.Evidence[0].FileName)
+			assert.Equal(t, int64(2048), stored.Evidence[0].Size)
+			assert.True(t, dispute.Evidence[0].UploadedAt.Equal(stored.Evidence[0].UploadedAt))
+		}
+	})
+
+	t.Run("One open dispute per payment", func(t *testing.T) {
+		repo := newRepo(t)
+		paymentID := primitive.NewObjectID().Hex()
+
+		first := newDispute(paymentID)
+		_, err := repo.Create(ctx, first)
+		assert.Nil(t, err)
+		_, err = repo.Create(ctx, newDispute(paymentID))
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+
+		// Resolvida a primeira, uma nova disputa pode ser aberta
+		resolvedAt := dueBy
+		first.Status, first.ResolvedAt = domain.DisputeWon, &resolvedAt
+		assert.Nil(t, repo.Update(ctx, first.ID.Hex(), first))
+		second := newDispute(paymentID)
+		second.CreatedAt = first.CreatedAt.Add(time.Second)
+		_, err = repo.Create(ctx, second)
+		assert.Nil(t, err)
+
+		disputes, err := repo.FindByPaymentID(ctx, paymentID)
+		assert.Nil(t, err)
+		if assert.Len(t, disputes, 2) {
+			assert.Equal(t, second.ID, disputes[0].ID)
+			assert.Equal(t, domain.DisputeWon, disputes[1].Status)
+			assert.True(t, resolvedAt.Equal(*disputes[1].ResolvedAt))
+		}
+
+		disputes, err = repo.FindByPaymentID(ctx, primitive.NewObjectID().Hex())
+		assert.Nil(t, err)
+		assert.NotNil(t, disputes)
+		assert.Empty(t, disputes)
+	})
+
+	t.Run("Update keeps payment and created_at", func(t *testing.T) {
+		repo := newRepo(t)
+		paymentID := primitive.NewObjectID().Hex()
+
+		dispute := newDispute(paymentID)
+		id, _ := repo.Create(ctx, dispute)
+		created, _ := repo.GetByID(ctx, id)
+
+		update := created
+		update.PaymentID = primitive.NewObjectID().Hex()
+		update.ReasonCode = "4853"
+		update.Evidence = append(update.Evidence, domain.EvidenceAttachment{FileName: "tracking.png", URL: "https://files.example.com/tracking.png", UploadedAt: dueBy})
+		assert.Nil(t, repo.Update(ctx, id, &update))
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, paymentID, stored.PaymentID)
+		assert.Equal(t, "4853", stored.ReasonCode)
+		assert.Len(t, stored.Evidence, 1)
+		assert.True(t, created.CreatedAt.Equal(stored.CreatedAt))
+	})
+
+	t.Run("Domain errors", func(t *testing.T) {
+		repo := newRepo(t)
+		missingID := primitive.NewObjectID().Hex()
+
+		_, err := repo.GetByID(ctx, "invalid-id")
+		assert.True(t, errors.Is(err, domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.Update(ctx, "invalid-id", newDispute(missingID)), domain.ErrInvalidID))
+		_, err = repo.GetByID(ctx, missingID)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.Update(ctx, missingID, newDispute(missingID)), domain.ErrNotFound))
+	})
+}
+
+func TestMemoryDisputeRepository_Contract(t *testing.T) {
+	runDisputeRepositoryContract(t, func(t *testing.T) repository2.DisputeRepository {
+		return repository2.NewMemoryDisputeRepository()
+	})
+}
+
+func TestSQLiteDisputeRepository_Contract(t *testing.T) {
+	runDisputeRepositoryContract(t, func(t *testing.T) repository2.DisputeRepository {
+		sqliteDB, err := repository2.OpenSQLite(context.Background(), ":memory:")
+		assert.Nil(t, err)
+		t.Cleanup(func() { sqliteDB.Close() })
+		return repository2.NewSQLiteDisputeRepository(sqliteDB)
+	})
+}
+
+func TestPostgresDisputeRepository_Contract(t *testing.T) {
+	pg := openTestPostgres(t)
+
+	runDisputeRepositoryContract(t, func(t *testing.T) repository2.DisputeRepository {
+		_, err := pg.Exec("TRUNCATE disputes")
+		assert.Nil(t, err)
+		return repository2.NewPostgresDisputeRepository(pg)
+	})
+}
+
+func TestMongoDisputeRepository_Contract(t *testing.T) {
+	if db == nil {
+		t.Skip("MongoDB indisponível")
+	}
+	// O índice de disputa aberta vem das migrações; limpar com DeleteMany o preserva
+	assert.Nil(t, repository2.MigrateMongo(context.Background(), db))
+	runDisputeRepositoryContract(t, func(t *testing.T) repository2.DisputeRepository {
+		_, _ = db.Collection("disputes").DeleteMany(context.Background(), bson.M{})
+		return repository2.NewDisputeRepository(db)
+	})
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+type feeRuleRepositoryFactory func(t *testing.T) repository2.FeeRuleRepository
+
+// runFeeRuleRepositoryContract define o comportamento comum aos backends de FeeRuleRepository
+func runFeeRuleRepositoryContract(t *testing.T, newRepo feeRuleRepositoryFactory) {
+	ctx := context.Background()
+	jan := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	jul := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+
+		rule := &domain.FeeRule{PaymentType: domain.CreditCard, FixedAmount: 0.39, Percentage: 3.49, MinFee: 0.5, MaxFee: 50, EffectiveFrom: jan}
+		id, err := repo.Create(ctx, rule)
+		assert.Nil(t, err)
+		assert.True(t, primitive.IsValidObjectID(id))
+		assert.False(t, rule.CreatedAt.IsZero())
+
+		stored, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.CreditCard, stored.PaymentType)
+		assert.Equal(t, 0.39, stored.FixedAmount)
+		assert.Equal(t, 3.49, stored.Percentage)
+		assert.Equal(t, 0.5, stored.MinFee)
+		assert.Equal(t, 50.0, stored.MaxFee)
+		assert.True(t, jan.Equal(stored.EffectiveFrom))
+	})
+
+	t.Run("FindEffective picks the latest version in effect", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, _ = repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 1, EffectiveFrom: jan})
+		_, _ = repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 0.8, EffectiveFrom: jul})
+		_, _ = repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Boleto, FixedAmount: 2.5, EffectiveFrom: jan})
+
+		rule, err := repo.FindEffective(ctx, domain.Pix, jul.AddDate(0, 0, -1))
+		assert.Nil(t, err)
+		assert.Equal(t, 1.0, rule.Percentage)
+
+		rule, err = repo.FindEffective(ctx, domain.Pix, jul)
+		assert.Nil(t, err)
+		assert.Equal(t, 0.8, rule.Percentage)
+
+		_, err = repo.FindEffective(ctx, domain.Pix, jan.Add(-time.Second))
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+		_, err = repo.FindEffective(ctx, domain.CreditCard, jul)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+
+		rules, err := repo.List(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, rules, 3)
+	})
+
+	t.Run("One version per payment type and effective date", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 1, EffectiveFrom: jan})
+		assert.Nil(t, err)
+		_, err = repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 2, EffectiveFrom: jan})
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+
+		id, err := repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 2, EffectiveFrom: jul})
+		assert.Nil(t, err)
+		err = repo.Update(ctx, id, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 2, EffectiveFrom: jan})
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+	})
+
+	t.Run("Update replaces fields and keeps created_at", func(t *testing.T) {
+		repo := newRepo(t)
+
+		rule := &domain.FeeRule{PaymentType: domain.Pix, Percentage: 1, MinFee: 0.1, EffectiveFrom: jul}
+		id, _ := repo.Create(ctx, rule)
+		created, _ := repo.GetByID(ctx, id)
+
+		assert.Nil(t, repo.Update(ctx, id, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 1.2, EffectiveFrom: jul}))
+		updated, err := repo.GetByID(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, 1.2, updated.Percentage)
+		assert.Equal(t, 0.0, updated.MinFee)
+		assert.True(t, created.CreatedAt.Equal(updated.CreatedAt))
+	})
+
+	t.Run("Delete removes the version", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, _ := repo.Create(ctx, &domain.FeeRule{PaymentType: domain.Pix, Percentage: 1, EffectiveFrom: jul})
+		assert.Nil(t, repo.Delete(ctx, id))
+		_, err := repo.GetByID(ctx, id)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+	})
+
+	t.Run("Domain errors", func(t *testing.T) {
+		repo := newRepo(t)
+		missingID := primitive.NewObjectID().Hex()
+
+		_, err := repo.GetByID(ctx, "invalid-id")
+		assert.True(t, errors.Is(err, domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.Delete(ctx, "invalid-id"), domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.Update(ctx, missingID, &domain.FeeRule{PaymentType: domain.Pix, EffectiveFrom: jan}), domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.Delete(ctx, missingID), domain.ErrNotFound))
+	})
+}
+
+func TestMemoryFeeRuleRepository_Contract(t *testing.T) {
+	runFeeRuleRepositoryContract(t, func(t *testing.T) repository2.FeeRuleRepository {
+		return repository2.NewMemoryFeeRuleRepository()
+	})
+}
+
+func TestSQLiteFeeRuleRepository_Contract(t *testing.T) {
+	runFeeRuleRepositoryContract(t, func(t *testing.T) repository2.FeeRuleRepository {
+		sqliteDB, err := repository2.OpenSQLite(context.Background(), ":memory:")
+		assert.Nil(t, err)
+		t.Cleanup(func() { sqliteDB.Close() })
+		return repository2.NewSQLiteFeeRuleRepository(sqliteDB)
+	})
+}
+
+func TestPostgresFeeRuleRepository_Contract(t *testing.T) {
+	pg := openTestPostgres(t)
+
+	runFeeRuleRepositoryContract(t, func(t *testing.T) repository2.FeeRuleRepository {
+		_, err := pg.Exec("TRUNCATE fee_rules")
+		assert.Nil(t, err)
+		return repository2.NewPostgresFeeRuleRepository(pg)
+	})
+}
+
+func TestMongoFeeRuleRepository_Contract(t *testing.T) {
+	if db == nil {
+		t.Skip("MongoDB indisponível")
+	}
+	// O índice único de vigência vem das migrações; limpar com DeleteMany o preserva
+	assert.Nil(t, repository2.MigrateMongo(context.Background(), db))
+	runFeeRuleRepositoryContract(t, func(t *testing.T) repository2.FeeRuleRepository {
+		_, _ = db.Collection("fee_rules").DeleteMany(context.Background(), bson.M{})
+		return repository2.NewFeeRuleRepository(db)
+	})
+}
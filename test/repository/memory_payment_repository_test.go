@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+func TestMemoryPaymentRepository_Contract(t *testing.T) {
+	runPaymentRepositoryContract(t, func(t *testing.T) repository2.PaymentRepository {
+		return repository2.NewMemoryPaymentRepository()
+	})
+}
+
+func TestMemoryPaymentRepository_Concurrent(t *testing.T) {
+	repo := repository2.NewMemoryPaymentRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := repo.Create(ctx, &domain.Payment{Amount: 10})
+			assert.Nil(t, err)
+			_, err = repo.GetByID(ctx, id)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	payments, _ := repo.GetAll(ctx)
+	assert.Len(t, payments, 50)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	repository2 "payments/repository"
+)
+
+func TestMongoMigrations_AreOrderedAndUnique(t *testing.T) {
+	var versions []string
+	seen := map[string]bool{}
+	for _, migration := range repository2.MongoMigrations() {
+		assert.False(t, seen[migration.Version], "versão duplicada: %s", migration.Version)
+		assert.NotNil(t, migration.Up)
+		seen[migration.Version] = true
+		versions = append(versions, migration.Version)
+	}
+	assert.True(t, sort.StringsAreSorted(versions))
+}
+
+func TestMigrateMongo(t *testing.T) {
+	if db == nil {
+		t.Skip("MongoDB indisponível")
+	}
+	ctx := context.Background()
+	_ = db.Collection("payments").Drop(ctx)
+	_ = db.Collection("schema_migrations").Drop(ctx)
+	t.Cleanup(func() {
+		_ = db.Collection("payments").Drop(ctx)
+		_ = db.Collection("schema_migrations").Drop(ctx)
+	})
+
+	// Documento gravado antes da existência do created_at
+	legacyID := primitive.NewObjectID()
+	_, err := db.Collection("payments").InsertOne(ctx, bson.M{"_id": legacyID, "status": "Em processamento"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, repository2.MigrateMongo(ctx, db))
+	// Reexecutar não aplica nada de novo
+	assert.Nil(t, repository2.MigrateMongo(ctx, db))
+
+	applied, err := repository2.AppliedMongoMigrations(ctx, db)
+	assert.Nil(t, err)
+	assert.Len(t, applied, len(repository2.MongoMigrations()))
+
+	legacy, err := repository2.NewPaymentRepository(db).GetByID(ctx, legacyID.Hex())
+	assert.Nil(t, err)
+	assert.True(t, legacyID.Timestamp().Equal(legacy.CreatedAt))
+
+	indexes, err := db.Collection("payments").Indexes().ListSpecifications(ctx)
+	assert.Nil(t, err)
+	var names []string
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	assert.Contains(t, names, "order_id_1_created_at_-1")
+	assert.NotContains(t, names, "order_id_1")
+	assert.Contains(t, names, "status_1_created_at_-1")
+	assert.Contains(t, names, "uniq_active_order_id")
+
+	collections, err := db.ListCollectionNames(ctx, bson.M{"name": "idempotency_keys"})
+	assert.Nil(t, err)
+	assert.Empty(t, collections)
+
+	// O validador rejeita documentos sem status
+	_, err = db.Collection("payments").InsertOne(ctx, bson.M{"order_id": "order123"})
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -28,6 +32,25 @@ func TestMain(m *testing.M) {
 		fmt.Println("Error connecting to MongoDB:", err)
 		return
 	}
+
+	// Sem MongoDB local, os testes do repositório rodam contra um banco SQLite real
+	pingCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(pingCtx, nil); err != nil {
+		fmt.Println("MongoDB unavailable, running repository tests on SQLite:", err)
+		dir, _ := os.MkdirTemp("", "payments-repository-test")
+		sqliteDB, err := repository2.OpenSQLite(context.Background(), filepath.Join(dir, "payments.db"))
+		if err != nil {
+			fmt.Println("Error opening SQLite:", err)
+			return
+		}
+		paymentRepo = repository2.NewSQLitePaymentRepository(sqliteDB)
+		code := m.Run()
+		sqliteDB.Close()
+		os.RemoveAll(dir)
+		os.Exit(code)
+	}
+
 	db = client.Database("testdb")
 	paymentRepo = repository2.NewPaymentRepository(db)
 
@@ -46,10 +69,10 @@ func TestPaymentRepository_GetAll(t *testing.T) {
 		Status: "completed",
 	}
 
-	_, err := paymentRepo.Create(&payment)
+	_, err := paymentRepo.Create(context.Background(), &payment)
 	assert.Nil(t, err)
 
-	payments, err := paymentRepo.GetAll()
+	payments, err := paymentRepo.GetAll(context.Background())
 	assert.Nil(t, err)
 	assert.Len(t, payments, 1)
 
@@ -65,16 +88,16 @@ func TestPaymentRepository_GetByID(t *testing.T) {
 		Status: "pending",
 	}
 
-	createdID, err := paymentRepo.Create(&payment)
+	createdID, err := paymentRepo.Create(context.Background(), &payment)
 	assert.Nil(t, err)
 
 	// Tentando recuperar com um ID válido
-	payment, err = paymentRepo.GetByID(createdID) // Convertendo ObjectID para string com Hex()
+	payment, err = paymentRepo.GetByID(context.Background(), createdID) // Convertendo ObjectID para string com Hex()
 	assert.Nil(t, err)
 	assert.Equal(t, payment.ID.Hex(), createdID) // Comparando ObjectIDs com Hex()
 
 	// Tentando recuperar com um ID inválido
-	_, err = paymentRepo.GetByID("invalid-id")
+	_, err = paymentRepo.GetByID(context.Background(), "invalid-id")
 	assert.NotNil(t, err)
 }
 
@@ -86,12 +109,12 @@ func TestPaymentRepository_Create(t *testing.T) {
 		Status: "completed",
 	}
 
-	id, err := paymentRepo.Create(payment)
+	id, err := paymentRepo.Create(context.Background(), payment)
 	assert.Nil(t, err)
 	assert.NotEmpty(t, id)
 
 	// Verifica se o pagamento foi criado corretamente
-	storedPayment, err := paymentRepo.GetByID(id) // Convertendo ObjectID para string com Hex()
+	storedPayment, err := paymentRepo.GetByID(context.Background(), id) // Convertendo ObjectID para string com Hex()
 	assert.Nil(t, err)
 	assert.Equal(t, payment.Amount, storedPayment.Amount)
 	assert.Equal(t, payment.Status, storedPayment.Status)
@@ -105,16 +128,16 @@ func TestPaymentRepository_Update(t *testing.T) {
 		Status: "completed",
 	}
 
-	createdID, err := paymentRepo.Create(&payment)
+	createdID, err := paymentRepo.Create(context.Background(), &payment)
 	assert.Nil(t, err)
 
 	// Atualizando o pagamento
 	payment.Amount = 350.00
-	err = paymentRepo.Update(createdID, &payment) // Convertendo ObjectID para string com Hex()
+	err = paymentRepo.Update(context.Background(), createdID, &payment) // Convertendo ObjectID para string com Hex()
 	assert.Nil(t, err)
 
 	// Verifica se a atualização ocorreu corretamente
-	updatedPayment, err := paymentRepo.GetByID(createdID) // Convertendo ObjectID para string com Hex()
+	updatedPayment, err := paymentRepo.GetByID(context.Background(), createdID) // Convertendo ObjectID para string com Hex()
 	assert.Nil(t, err)
 	assert.Equal(t, payment.Amount, updatedPayment.Amount)
 }
@@ -127,14 +150,14 @@ func TestPaymentRepository_Delete(t *testing.T) {
 		Status: "completed",
 	}
 
-	createdID, err := paymentRepo.Create(&payment)
+	createdID, err := paymentRepo.Create(context.Background(), &payment)
 	assert.Nil(t, err)
 
 	// Deletando o pagamento
-	err = paymentRepo.Delete(createdID) // Convertendo ObjectID para string com Hex()
+	err = paymentRepo.Delete(context.Background(), createdID) // Convertendo ObjectID para string com Hex()
 	assert.Nil(t, err)
 
 	// Verificando se o pagamento foi deletado
-	_, err = paymentRepo.GetByID(createdID) // Convertendo ObjectID para string com Hex()
+	_, err = paymentRepo.GetByID(context.Background(), createdID) // Convertendo ObjectID para string com Hex()
 	assert.NotNil(t, err)
 }
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	repository2 "payments/repository"
+)
+
+// openTestPostgres conecta ao banco de PAYMENTS_TEST_POSTGRES_DSN ou pula o teste
+func openTestPostgres(t *testing.T) *sql.DB {
+	dsn := os.Getenv("PAYMENTS_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PAYMENTS_TEST_POSTGRES_DSN não definido")
+	}
+	pg, err := repository2.OpenPostgres(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("Error connecting to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() { pg.Close() })
+	return pg
+}
+
+func TestPostgresPaymentRepository_Contract(t *testing.T) {
+	pg := openTestPostgres(t)
+
+	runPaymentRepositoryContract(t, func(t *testing.T) repository2.PaymentRepository {
+		_, err := pg.Exec("TRUNCATE payments")
+		assert.Nil(t, err)
+		return repository2.NewPostgresPaymentRepository(pg)
+	})
+}
+
+func TestPostgresPaymentRepository_MigrationsAreIdempotent(t *testing.T) {
+	pg := openTestPostgres(t)
+
+	var before, after int
+	assert.Nil(t, pg.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&before))
+
+	// Reabrir o banco não deve reaplicar as migrações já registradas
+	again, err := repository2.OpenPostgres(context.Background(), os.Getenv("PAYMENTS_TEST_POSTGRES_DSN"))
+	assert.Nil(t, err)
+	defer again.Close()
+
+	assert.Nil(t, pg.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&after))
+	assert.Equal(t, before, after)
+}
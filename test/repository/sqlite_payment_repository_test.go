@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+func TestSQLitePaymentRepository_Contract(t *testing.T) {
+	runPaymentRepositoryContract(t, func(t *testing.T) repository2.PaymentRepository {
+		sqliteDB, err := repository2.OpenSQLite(context.Background(), ":memory:")
+		assert.Nil(t, err)
+		t.Cleanup(func() { sqliteDB.Close() })
+		return repository2.NewSQLitePaymentRepository(sqliteDB)
+	})
+}
+
+func TestSQLitePaymentRepository_DurableFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "payments.db")
+
+	sqliteDB, err := repository2.OpenSQLite(ctx, path)
+	assert.Nil(t, err)
+
+	var journalMode string
+	assert.Nil(t, sqliteDB.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+
+	id, err := repository2.NewSQLitePaymentRepository(sqliteDB).Create(ctx, &domain.Payment{Amount: 99.90})
+	assert.Nil(t, err)
+	assert.Nil(t, sqliteDB.Close())
+
+	// Reabrir o arquivo preserva os dados e não reaplica as migrações
+	reopened, err := repository2.OpenSQLite(ctx, path)
+	assert.Nil(t, err)
+	defer reopened.Close()
+
+	stored, err := repository2.NewSQLitePaymentRepository(reopened).GetByID(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, 99.90, stored.Amount)
+}
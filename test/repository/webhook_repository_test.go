@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	repository2 "payments/repository"
+)
+
+type webhookRepositoryFactory func(t *testing.T) repository2.WebhookRepository
+
+// runWebhookRepositoryContract define o comportamento comum aos backends de WebhookRepository
+func runWebhookRepositoryContract(t *testing.T, newRepo webhookRepositoryFactory) {
+	ctx := context.Background()
+	base := time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+	newSubscription := func(createdAt time.Time) *domain.WebhookSubscription {
+		return &domain.WebhookSubscription{URL: "https://shop.example.com/hooks", Events: []string{domain.EventPaymentApproved},
+			Active: true, Secret: "whsec_test", CreatedAt: createdAt}
+	}
+	newDelivery := func(subscriptionID string, next time.Time) *domain.WebhookDelivery {
+		delivery := domain.NewWebhookDelivery(subscriptionID, domain.WebhookEvent{ID: primitive.NewObjectID().Hex(), Type: domain.EventPaymentApproved},
+			[]byte(`{"type":"payment.approved"}`), next)
+		return &delivery
+	}
+
+	t.Run("Subscription lifecycle", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first, second := newSubscription(base.Add(time.Minute)), newSubscription(base)
+		id, err := repo.CreateSubscription(ctx, first)
+		assert.Nil(t, err)
+		_, err = repo.CreateSubscription(ctx, second)
+		assert.Nil(t, err)
+
+		subscriptions, err := repo.ListSubscriptions(ctx)
+		assert.Nil(t, err)
+		if assert.Len(t, subscriptions, 2) {
+			assert.Equal(t, second.ID, subscriptions[0].ID)
+		}
+
+		update := newSubscription(base)
+		update.URL, update.Events, update.Active, update.Description = "https://shop.example.com/v2", []string{domain.EventAll}, false, "v2"
+		update.Secret = "changed"
+		update.UpdatedAt = base.Add(time.Hour)
+		assert.Nil(t, repo.UpdateSubscription(ctx, id, update))
+
+		stored, err := repo.GetSubscription(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://shop.example.com/v2", stored.URL)
+		assert.Equal(t, []string{domain.EventAll}, stored.Events)
+		assert.False(t, stored.Active)
+		assert.Equal(t, "v2", stored.Description)
+		assert.Equal(t, "whsec_test", stored.Secret) // O segredo não muda na atualização
+		assert.True(t, base.Add(time.Minute).Equal(stored.CreatedAt))
+		assert.True(t, base.Add(time.Hour).Equal(stored.UpdatedAt))
+
+		assert.Nil(t, repo.DeleteSubscription(ctx, id))
+		_, err = repo.GetSubscription(ctx, id)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.DeleteSubscription(ctx, id), domain.ErrNotFound))
+	})
+
+	t.Run("Deliveries by subscription and due", func(t *testing.T) {
+		repo := newRepo(t)
+		subscriptionID := primitive.NewObjectID().Hex()
+
+		late := newDelivery(subscriptionID, base)
+		soon := newDelivery(subscriptionID, base.Add(time.Minute))
+		future := newDelivery(subscriptionID, base.Add(time.Hour))
+		for _, delivery := range []*domain.WebhookDelivery{soon, late, future} {
+			_, err := repo.CreateDelivery(ctx, delivery)
+			assert.Nil(t, err)
+		}
+		_, err := repo.CreateDelivery(ctx, newDelivery(primitive.NewObjectID().Hex(), base))
+		assert.Nil(t, err)
+
+		due, err := repo.FindDueDeliveries(ctx, base.Add(time.Minute), 10)
+		assert.Nil(t, err)
+		assert.Len(t, due, 3)
+		due, err = repo.FindDueDeliveries(ctx, base.Add(time.Minute), 1)
+		assert.Nil(t, err)
+		if assert.Len(t, due, 1) {
+			assert.True(t, base.Equal(*due[0].NextAttemptAt))
+		}
+
+		deliveries, err := repo.ListDeliveries(ctx, subscriptionID, 2)
+		assert.Nil(t, err)
+		if assert.Len(t, deliveries, 2) {
+			assert.Equal(t, future.ID, deliveries[0].ID)
+			assert.Equal(t, soon.ID, deliveries[1].ID)
+		}
+		deliveries, err = repo.ListDeliveries(ctx, primitive.NewObjectID().Hex(), 10)
+		assert.Nil(t, err)
+		assert.NotNil(t, deliveries)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("UpdateDelivery records attempts", func(t *testing.T) {
+		repo := newRepo(t)
+		delivery := newDelivery(primitive.NewObjectID().Hex(), base)
+		id, err := repo.CreateDelivery(ctx, delivery)
+		assert.Nil(t, err)
+
+		delivery.Record(domain.WebhookAttempt{AttemptedAt: base, StatusCode: 503, ResponseBody: "unavailable", DurationMs: 42},
+			domain.WebhookRetryPolicy{MaxAttempts: 1})
+		assert.Nil(t, repo.UpdateDelivery(ctx, id, delivery))
+
+		stored, err := repo.GetDelivery(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DeliveryFailed, stored.Status)
+		assert.Nil(t, stored.NextAttemptAt)
+		assert.Equal(t, `{"type":"payment.approved"}`, stored.Payload)
+		if assert.Len(t, stored.Attempts, 1) {
+			assert.Equal(t, 503, stored.Attempts[0].StatusCode)
+			assert.Equal(t, "unavailable", stored.Attempts[0].ResponseBody)
+			assert.Equal(t, int64(42), stored.Attempts[0].DurationMs)
+			assert.True(t, base.Equal(stored.Attempts[0].AttemptedAt))
+		}
+
+		due, err := repo.FindDueDeliveries(ctx, base.Add(time.Hour), 10)
+		assert.Nil(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("Domain errors", func(t *testing.T) {
+		repo := newRepo(t)
+		missingID := primitive.NewObjectID().Hex()
+
+		_, err := repo.GetSubscription(ctx, "invalid-id")
+		assert.True(t, errors.Is(err, domain.ErrInvalidID))
+		_, err = repo.GetDelivery(ctx, "invalid-id")
+		assert.True(t, errors.Is(err, domain.ErrInvalidID))
+		assert.True(t, errors.Is(repo.UpdateSubscription(ctx, missingID, newSubscription(base)), domain.ErrNotFound))
+		assert.True(t, errors.Is(repo.UpdateDelivery(ctx, missingID, newDelivery(missingID, base)), domain.ErrNotFound))
+		_, err = repo.GetDelivery(ctx, missingID)
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+	})
+}
+
+func TestMemoryWebhookRepository_Contract(t *testing.T) {
+	runWebhookRepositoryContract(t, func(t *testing.T) repository2.WebhookRepository {
+		return repository2.NewMemoryWebhookRepository()
+	})
+}
+
+func TestSQLiteWebhookRepository_Contract(t *testing.T) {
+	runWebhookRepositoryContract(t, func(t *testing.T) repository2.WebhookRepository {
+		sqliteDB, err := repository2.OpenSQLite(context.Background(), ":memory:")
+		assert.Nil(t, err)
+		t.Cleanup(func() { sqliteDB.Close() })
+		return repository2.NewSQLiteWebhookRepository(sqliteDB)
+	})
+}
+
+func TestPostgresWebhookRepository_Contract(t *testing.T) {
+	pg := openTestPostgres(t)
+
+	runWebhookRepositoryContract(t, func(t *testing.T) repository2.WebhookRepository {
+		_, err := pg.Exec("TRUNCATE webhook_subscriptions, webhook_deliveries")
+		assert.Nil(t, err)
+		return repository2.NewPostgresWebhookRepository(pg)
+	})
+}
+
+func TestMongoWebhookRepository_Contract(t *testing.T) {
+	if db == nil {
+		t.Skip("MongoDB indisponível")
+	}
+	assert.Nil(t, repository2.MigrateMongo(context.Background(), db))
+	runWebhookRepositoryContract(t, func(t *testing.T) repository2.WebhookRepository {
+		_, _ = db.Collection("webhook_subscriptions").DeleteMany(context.Background(), bson.M{})
+		_, _ = db.Collection("webhook_deliveries").DeleteMany(context.Background(), bson.M{})
+		return repository2.NewWebhookRepository(db)
+	})
+}
@@ -1,63 +1,122 @@
 package routes
 
 import (
+	"bytes"
+	"context"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"payments/delivery"
 	"payments/domain"
+	"payments/middleware"
+	"payments/pubsub"
 	routes2 "payments/routes"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type MockPaymentUseCase struct {
 	mock.Mock
 }
 
-func (m *MockPaymentUseCase) GetAllPayments() ([]domain.Payment, error) {
+func (m *MockPaymentUseCase) GetAllPayments(ctx context.Context) ([]domain.Payment, error) {
 	args := m.Called()
 	return args.Get(0).([]domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentUseCase) GetPaymentByID(id string) (domain.Payment, error) {
+func (m *MockPaymentUseCase) GetPaymentByID(ctx context.Context, id string) (domain.Payment, error) {
 	args := m.Called(id)
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentUseCase) CreatePayment(payment *domain.Payment) (string, error) {
+func (m *MockPaymentUseCase) GetPaymentsByOrderID(ctx context.Context, orderID string) (domain.OrderPayments, error) {
+	args := m.Called(orderID)
+	return args.Get(0).(domain.OrderPayments), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) CreatePayment(ctx context.Context, payment *domain.Payment) (string, error) {
 	args := m.Called(payment)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockPaymentUseCase) UpdatePayment(id string, payment *domain.Payment) error {
-	args := m.Called(id, payment)
-	return args.Error(0)
+func (m *MockPaymentUseCase) UpdatePayment(ctx context.Context, id string, update domain.PaymentUpdate) (domain.Payment, error) {
+	args := m.Called(id, update)
+	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentUseCase) DeletePayment(id string) error {
+func (m *MockPaymentUseCase) DeletePayment(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockPaymentUseCase) ProcessPaymentCallback(callback *domain.PaymentCallback) error {
+func (m *MockPaymentUseCase) CancelPayment(ctx context.Context, id string) (domain.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) CapturePayment(ctx context.Context, id string, amount float64) (domain.Payment, error) {
+	args := m.Called(id, amount)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) VoidPayment(ctx context.Context, id string) (domain.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) RefundPayment(ctx context.Context, id string, amount float64) (domain.Payment, error) {
+	args := m.Called(id, amount)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) ExpireOverdueBoletos(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) VoidExpiredAuthorizations(ctx context.Context, cutoff time.Time) (int, error) {
+	args := m.Called(cutoff)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPaymentUseCase) ProcessPaymentCallback(ctx context.Context, callback *domain.PaymentCallback) error {
 	args := m.Called(callback)
 	return args.Error(0)
 }
 
+const gatewaySecret = "gateway-callback-secret"
+
+// signGatewayCallback assina o corpo da requisição como o gateway faria
+func signGatewayCallback(req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	timestamp := time.Now().Unix()
+	req.Header.Set(middleware.GatewayTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(middleware.GatewaySignatureHeader, middleware.SignGatewayCallback(gatewaySecret, timestamp, body))
+}
+
 func TestRegisterPaymentRoutes(t *testing.T) {
 	mockUseCase := new(MockPaymentUseCase)
 
 	mockUseCase.On("GetAllPayments").Return([]domain.Payment{}, nil)
 	mockUseCase.On("GetPaymentByID", "1").Return(domain.Payment{}, nil)
 	mockUseCase.On("CreatePayment", mock.Anything).Return("123", nil)
-	mockUseCase.On("UpdatePayment", "1", mock.Anything).Return(nil)
+	mockUseCase.On("UpdatePayment", "1", domain.PaymentUpdate{Method: "in_store"}).Return(domain.Payment{Method: "in_store"}, nil)
 	mockUseCase.On("DeletePayment", "1").Return(nil)
+	mockUseCase.On("CancelPayment", "1").Return(domain.Payment{Status: domain.StatusCancelled}, nil)
+	mockUseCase.On("CapturePayment", "1", 0.0).Return(domain.Payment{Status: domain.StatusCaptured}, nil)
+	mockUseCase.On("VoidPayment", "1").Return(domain.Payment{Status: domain.StatusVoided}, nil)
 	mockUseCase.On("ProcessPaymentCallback", mock.Anything).Return(nil)
+	mockUseCase.On("GetPaymentsByOrderID", "123456").Return(domain.NewOrderPayments("123456", nil), nil)
 
-	app := fiber.New()
+	app := newAuthenticatedApp(domain.ScopeAdmin)
 
-	routes2.RegisterPaymentRoutes(app, mockUseCase)
+	routes2.RegisterPaymentRoutes(app, mockUseCase, middleware.GatewaySignature(gatewaySecret))
 
 	t.Run("Test GetAllPayments Route", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/payments", nil)
@@ -75,9 +134,8 @@ func TestRegisterPaymentRoutes(t *testing.T) {
 		reqBody := strings.NewReader(`{
 			  "order_id": "123456",
 			  "amount": 100.50,
-			  "method": "credit_card",
-			  "status": "pending",
-			  "payment_type": "online"
+			  "method": "online",
+			  "payment_type": "PIX"
 			}
 		`)
 		req := httptest.NewRequest("POST", "/payments", reqBody)
@@ -87,19 +145,13 @@ func TestRegisterPaymentRoutes(t *testing.T) {
 	})
 
 	t.Run("Test UpdatePayment Route", func(t *testing.T) {
-		paymentJSON := `{
-		"order_id": "123456",
-		"amount": 150.75,
-		"method": "credit_card",
-		"status": "approved",
-		"payment_type": "online"
-	}`
+		paymentJSON := `{"method": "in_store"}`
 
 		req := httptest.NewRequest("PUT", "/payments/1", strings.NewReader(paymentJSON))
 		req.Header.Set("Content-Type", "application/json") // Definir o Content-Type é essencial
 
 		resp, _ := app.Test(req)
-		assert.Equal(t, 204, resp.StatusCode)
+		assert.Equal(t, 200, resp.StatusCode)
 	})
 
 	t.Run("Test DeletePayment Route", func(t *testing.T) {
@@ -108,6 +160,24 @@ func TestRegisterPaymentRoutes(t *testing.T) {
 		assert.Equal(t, 204, resp.StatusCode)
 	})
 
+	t.Run("Test CancelPayment Route", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/payments/1/cancel", nil)
+		resp, _ := app.Test(req)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("Test CapturePayment Route", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/payments/1/capture", nil)
+		resp, _ := app.Test(req)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("Test VoidPayment Route", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/payments/1/void", nil)
+		resp, _ := app.Test(req)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
 	t.Run("Test Callback Route", func(t *testing.T) {
 		reqBody := strings.NewReader(`    {
 			"id": "67a8ffa093a5fa72f000452b",
@@ -120,8 +190,412 @@ func TestRegisterPaymentRoutes(t *testing.T) {
 		`)
 		req := httptest.NewRequest("POST", "/payment/callback", reqBody)
 		req.Header.Set("Content-Type", "application/json") // Definir cabeçalho correto
+		signGatewayCallback(req)
+		resp, _ := app.Test(req)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("Test GetPaymentsByOrderID Route", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/orders/123456/payments", nil)
 		resp, _ := app.Test(req)
 		assert.Equal(t, 200, resp.StatusCode)
 	})
 	mockUseCase.AssertExpectations(t)
 }
+
+func TestRegisterPaymentEventRoutes(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetPaymentByID", "1").Return(domain.Payment{Status: domain.StatusCancelled}, nil)
+
+	app := newAuthenticatedApp(domain.ScopeAdmin)
+	routes2.RegisterPaymentEventRoutes(app, mockUseCase, pubsub.NewMemoryBroker())
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/payments/1/events", nil))
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestPaymentEventRoutesAcceptStreamTokens(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetPaymentByID", "1").Return(domain.Payment{Status: domain.StatusCancelled}, nil)
+
+	// O EventSource do checkout só consegue enviar o token de stream na URL
+	app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+	app.Use(middleware.Authenticate(scopedAuthenticator{"stream-1": {domain.PaymentEventsScope("1")}}))
+	routes2.RegisterPaymentEventRoutes(app, mockUseCase, pubsub.NewMemoryBroker())
+
+	req := httptest.NewRequest("GET", "/payments/1/events?access_token=stream-1", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, _ := app.Test(req)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// O token não dá acesso ao stream de outro pagamento
+	req = httptest.NewRequest("GET", "/payments/2/events?access_token=stream-1", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, _ = app.Test(req)
+	assert.Equal(t, 403, resp.StatusCode)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRegisterPaymentFeedRoutes(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+	app.Use(middleware.Authenticate(scopedAuthenticator{
+		"reader": {domain.ScopePaymentsRead},
+		"writer": {domain.ScopePaymentsWrite},
+	}))
+	// O mesmo escopo exigido pelo main
+	routes2.RegisterPaymentFeedRoutes(app, pubsub.NewMemoryBroker(), middleware.RequireScope(domain.ScopePaymentsRead))
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/ws/payments", nil))
+	assert.Equal(t, 401, resp.StatusCode)
+
+	req := httptest.NewRequest("GET", "/ws/payments", nil)
+	req.Header.Set(middleware.APIKeyHeader, "writer")
+	resp, _ = app.Test(req)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// Fora do handshake, a credencial na URL é ignorada
+	resp, _ = app.Test(httptest.NewRequest("GET", "/ws/payments?access_token=reader", nil))
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// Autenticado, mas sem o handshake do WebSocket
+	req = httptest.NewRequest("GET", "/ws/payments", nil)
+	req.Header.Set(middleware.APIKeyHeader, "reader")
+	resp, _ = app.Test(req)
+	assert.Equal(t, 426, resp.StatusCode)
+}
+
+type MockFeeRuleUseCase struct {
+	mock.Mock
+}
+
+func (m *MockFeeRuleUseCase) ListFeeRules(ctx context.Context) ([]domain.FeeRule, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleUseCase) GetFeeRule(ctx context.Context, id string) (domain.FeeRule, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleUseCase) CreateFeeRule(ctx context.Context, rule *domain.FeeRule) (string, error) {
+	args := m.Called(rule)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFeeRuleUseCase) UpdateFeeRule(ctx context.Context, id string, rule *domain.FeeRule) error {
+	args := m.Called(id, rule)
+	return args.Error(0)
+}
+
+func (m *MockFeeRuleUseCase) DeleteFeeRule(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestRegisterFeeRuleRoutes(t *testing.T) {
+	mockUseCase := new(MockFeeRuleUseCase)
+	mockUseCase.On("ListFeeRules").Return([]domain.FeeRule{}, nil)
+	mockUseCase.On("GetFeeRule", "1").Return(domain.FeeRule{}, nil)
+	mockUseCase.On("CreateFeeRule", mock.Anything).Return("1", nil)
+	mockUseCase.On("UpdateFeeRule", "1", mock.Anything).Return(nil)
+	mockUseCase.On("DeleteFeeRule", "1").Return(nil)
+
+	app := newAuthenticatedApp(domain.ScopeAdmin)
+	routes2.RegisterFeeRuleRoutes(app, mockUseCase)
+
+	rule := `{"payment_type": "PIX", "percentage": 1, "effective_from": "2030-01-01T00:00:00Z"}`
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/admin/fee-rules", "", 200},
+		{"POST", "/admin/fee-rules", rule, 201},
+		{"GET", "/admin/fee-rules/1", "", 200},
+		{"PUT", "/admin/fee-rules/1", rule, 200},
+		{"DELETE", "/admin/fee-rules/1", "", 204},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			resp, _ := app.Test(httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
+
+type MockDisputeUseCase struct {
+	mock.Mock
+}
+
+func (m *MockDisputeUseCase) OpenDispute(ctx context.Context, paymentID string, input domain.Dispute) (domain.Dispute, error) {
+	args := m.Called(paymentID, input)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) GetDispute(ctx context.Context, id string) (domain.Dispute, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) ListPaymentDisputes(ctx context.Context, paymentID string) ([]domain.Dispute, error) {
+	args := m.Called(paymentID)
+	return args.Get(0).([]domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) UpdateDispute(ctx context.Context, id string, update domain.DisputeUpdate) (domain.Dispute, error) {
+	args := m.Called(id, update)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeUseCase) ResolveDispute(ctx context.Context, id string, outcome string) (domain.Dispute, error) {
+	args := m.Called(id, outcome)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func TestRegisterDisputeRoutes(t *testing.T) {
+	mockUseCase := new(MockDisputeUseCase)
+	mockUseCase.On("OpenDispute", "1", mock.Anything).Return(domain.Dispute{}, nil)
+	mockUseCase.On("ListPaymentDisputes", "1").Return([]domain.Dispute{}, nil)
+	mockUseCase.On("GetDispute", "2").Return(domain.Dispute{}, nil)
+	mockUseCase.On("UpdateDispute", "2", mock.Anything).Return(domain.Dispute{}, nil)
+	mockUseCase.On("ResolveDispute", "2", "won").Return(domain.Dispute{}, nil)
+
+	app := newAuthenticatedApp(domain.ScopeAdmin)
+	routes2.RegisterDisputeRoutes(app, mockUseCase)
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/payments/1/disputes", `{"reason_code": "4837"}`, 201},
+		{"GET", "/payments/1/disputes", "", 200},
+		{"GET", "/disputes/2", "", 200},
+		{"PATCH", "/disputes/2", `{"reason_code": "4853"}`, 200},
+		{"POST", "/disputes/2/resolve", `{"outcome": "won"}`, 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			resp, _ := app.Test(httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+	mockUseCase.AssertExpectations(t)
+}
+
+type MockWebhookUseCase struct {
+	mock.Mock
+}
+
+func (m *MockWebhookUseCase) NotifyPayment(ctx context.Context, eventType string, payment domain.Payment) {
+	m.Called(eventType, payment)
+}
+
+func (m *MockWebhookUseCase) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) GetSubscription(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	args := m.Called(subscription)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	args := m.Called(id, subscription)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DeleteSubscription(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookUseCase) ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) SendTestEvent(ctx context.Context, subscriptionID string) (domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Redeliver(ctx context.Context, subscriptionID string, deliveryID string) (domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID, deliveryID)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestRegisterWebhookRoutes(t *testing.T) {
+	mockUseCase := new(MockWebhookUseCase)
+	mockUseCase.On("ListSubscriptions").Return([]domain.WebhookSubscription{}, nil)
+	mockUseCase.On("CreateSubscription", mock.Anything).Return(domain.WebhookSubscription{}, nil)
+	mockUseCase.On("GetSubscription", "1").Return(domain.WebhookSubscription{}, nil)
+	mockUseCase.On("UpdateSubscription", "1", mock.Anything).Return(domain.WebhookSubscription{}, nil)
+	mockUseCase.On("DeleteSubscription", "1").Return(nil)
+	mockUseCase.On("ListDeliveries", "1").Return([]domain.WebhookDelivery{}, nil)
+	mockUseCase.On("SendTestEvent", "1").Return(domain.WebhookDelivery{}, nil)
+	mockUseCase.On("Redeliver", "1", "2").Return(domain.WebhookDelivery{}, nil)
+
+	app := newAuthenticatedApp(domain.ScopeAdmin)
+	routes2.RegisterWebhookRoutes(app, mockUseCase)
+
+	subscription := `{"url": "https://shop.example.com/hooks", "events": ["*"]}`
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/webhooks", "", 200},
+		{"POST", "/webhooks", subscription, 201},
+		{"GET", "/webhooks/1", "", 200},
+		{"PUT", "/webhooks/1", subscription, 200},
+		{"DELETE", "/webhooks/1", "", 204},
+		{"GET", "/webhooks/1/deliveries", "", 200},
+		{"POST", "/webhooks/1/test", "", 200},
+		{"POST", "/webhooks/1/deliveries/2/redeliver", "", 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			resp, _ := app.Test(httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+	mockUseCase.AssertExpectations(t)
+}
+
+// scopedAuthenticator aceita as chaves do mapa, cada uma com os seus escopos
+type scopedAuthenticator map[string][]string
+
+func (a scopedAuthenticator) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	scopes, ok := a[key]
+	if !ok {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	return domain.Principal{Subject: "api_key:" + key, Scopes: scopes}, nil
+}
+
+// newAuthenticatedApp cria um app em que toda requisição chega autenticada com os escopos
+func newAuthenticatedApp(scopes ...string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Request().Header.Set(middleware.APIKeyHeader, "test-key")
+		return c.Next()
+	}, middleware.Authenticate(scopedAuthenticator{"test-key": scopes}))
+	return app
+}
+
+func TestRoutesRequireScopes(t *testing.T) {
+	mockUseCase := new(MockPaymentUseCase)
+	mockUseCase.On("GetAllPayments").Return([]domain.Payment{}, nil)
+	mockUseCase.On("RefundPayment", "1", 0.0).Return(domain.Payment{Status: domain.StatusRefunded}, nil)
+	mockUseCase.On("ProcessPaymentCallback", mock.Anything).Return(nil)
+
+	app := fiber.New(fiber.Config{ErrorHandler: delivery.ErrorHandler})
+	app.Use(middleware.Authenticate(scopedAuthenticator{
+		"reader":   {domain.ScopePaymentsRead},
+		"refunder": {domain.ScopeRefundsWrite},
+	}))
+	routes2.RegisterPaymentRoutes(app, mockUseCase, middleware.GatewaySignature(gatewaySecret))
+	routes2.RegisterFeeRuleRoutes(app, new(MockFeeRuleUseCase))
+
+	cases := []struct {
+		name, method, path, key string
+		status                  int
+	}{
+		{"anonymous", "GET", "/payments", "", 401},
+		{"unknown key", "GET", "/payments", "stolen", 401},
+		{"read scope", "GET", "/payments", "reader", 200},
+		{"write without scope", "DELETE", "/payments/1", "reader", 403},
+		{"refund scope", "POST", "/payments/1/refund", "refunder", 200},
+		{"refund scope does not read", "GET", "/payments", "refunder", 403},
+		{"admin routes", "GET", "/admin/fee-rules", "reader", 403},
+		{"unsigned gateway callback", "POST", "/payment/callback", "", 401},
+		{"signed gateway callback needs no key", "POST", "/payment/callback", "", 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"id": "67a8ffa093a5fa72f000452b", "status": "teste"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if strings.HasPrefix(tc.name, "signed") {
+				signGatewayCallback(req)
+			}
+			if tc.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.key)
+			}
+			resp, _ := app.Test(req)
+			assert.Equal(t, tc.status, resp.StatusCode)
+			// O callback não aceita chave de API, só a assinatura do gateway
+			if tc.status == 401 && tc.path != "/payment/callback" {
+				assert.Equal(t, `Bearer realm="payments"`, resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+type MockAPIKeyUseCase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUseCase) IssueAPIKey(ctx context.Context, name string, scopes []string) (domain.IssuedAPIKey, error) {
+	args := m.Called(name, scopes)
+	return args.Get(0).(domain.IssuedAPIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) GetAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) RevokeAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	args := m.Called(key)
+	return args.Get(0).(domain.Principal), args.Error(1)
+}
+
+func TestRegisterAPIKeyRoutes(t *testing.T) {
+	mockUseCase := new(MockAPIKeyUseCase)
+	mockUseCase.On("ListAPIKeys").Return([]domain.APIKey{}, nil)
+	mockUseCase.On("IssueAPIKey", "checkout", []string{domain.ScopePaymentsWrite}).Return(domain.IssuedAPIKey{Key: "pk_1_secret"}, nil)
+	mockUseCase.On("GetAPIKey", "1").Return(domain.APIKey{}, nil)
+	mockUseCase.On("RevokeAPIKey", "1").Return(domain.APIKey{}, nil)
+
+	app := newAuthenticatedApp(domain.ScopeAdmin)
+	routes2.RegisterAPIKeyRoutes(app, mockUseCase)
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/admin/api-keys", "", 200},
+		{"POST", "/admin/api-keys", `{"name": "checkout", "scopes": ["payments:write"]}`, 201},
+		{"GET", "/admin/api-keys/1", "", 200},
+		{"POST", "/admin/api-keys/1/revoke", "", 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			resp, _ := app.Test(httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+	mockUseCase.AssertExpectations(t)
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"payments/domain"
+	"payments/repository"
+	usecase2 "payments/usecase"
+)
+
+var apiKeyNow = time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func newAPIKeyUseCase(opts ...usecase2.APIKeyOption) (usecase2.APIKeyUseCase, repository.APIKeyRepository) {
+	repo := repository.NewMemoryAPIKeyRepository()
+	opts = append([]usecase2.APIKeyOption{usecase2.WithAPIKeyClock(func() time.Time { return apiKeyNow })}, opts...)
+	return usecase2.NewAPIKeyUseCase(repo, opts...), repo
+}
+
+func TestAPIKeyUseCase_IssueStoresOnlyTheHash(t *testing.T) {
+	uc, repo := newAPIKeyUseCase()
+
+	issued, err := uc.IssueAPIKey(context.Background(), " checkout ", []string{domain.ScopePaymentsWrite})
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Key, issued.Prefix+"_"))
+	assert.True(t, strings.HasPrefix(issued.Prefix, domain.APIKeyTag))
+	assert.Equal(t, "checkout", issued.Name)
+	assert.Equal(t, apiKeyNow, issued.CreatedAt)
+
+	stored, err := repo.GetByID(context.Background(), issued.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.HashAPIKey(issued.Key), stored.Hash)
+	assert.NotContains(t, stored.Hash, issued.Key)
+
+	// Cada emissão gera uma chave diferente
+	other, err := uc.IssueAPIKey(context.Background(), "checkout", []string{domain.ScopePaymentsWrite})
+	require.NoError(t, err)
+	assert.NotEqual(t, issued.Key, other.Key)
+	assert.NotEqual(t, issued.Prefix, other.Prefix)
+}
+
+func TestAPIKeyUseCase_IssueValidation(t *testing.T) {
+	uc, _ := newAPIKeyUseCase()
+
+	_, err := uc.IssueAPIKey(context.Background(), "", []string{"payments:delete"})
+
+	var validationErr *domain.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []domain.ErrorResponse{
+		{Field: "name", Message: "is required and must have at most 100 characters"},
+		{Field: "scopes", Message: "unknown scope: payments:delete"},
+	}, validationErr.Errors)
+}
+
+func TestAPIKeyUseCase_Authenticate(t *testing.T) {
+	uc, _ := newAPIKeyUseCase()
+	issued, err := uc.IssueAPIKey(context.Background(), "dashboard", []string{domain.ScopePaymentsRead})
+	require.NoError(t, err)
+
+	principal, err := uc.Authenticate(context.Background(), issued.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "api_key:"+issued.ID.Hex(), principal.Subject)
+	assert.True(t, principal.HasScope(domain.ScopePaymentsRead))
+	assert.False(t, principal.HasScope(domain.ScopePaymentsWrite))
+
+	for name, key := range map[string]string{
+		"malformed":      "not-a-key",
+		"unknown prefix": "pk_ffffffffffffffff_secret",
+		"wrong secret":   issued.Prefix + "_guessed",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := uc.Authenticate(context.Background(), key)
+			assert.True(t, errors.Is(err, domain.ErrUnauthorized))
+		})
+	}
+}
+
+func TestAPIKeyUseCase_RevokedKeyIsRejected(t *testing.T) {
+	uc, _ := newAPIKeyUseCase()
+	issued, err := uc.IssueAPIKey(context.Background(), "dashboard", []string{domain.ScopePaymentsRead})
+	require.NoError(t, err)
+
+	revoked, err := uc.RevokeAPIKey(context.Background(), issued.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, apiKeyNow, *revoked.RevokedAt)
+
+	_, err = uc.Authenticate(context.Background(), issued.Key)
+	assert.True(t, errors.Is(err, domain.ErrUnauthorized))
+
+	_, err = uc.RevokeAPIKey(context.Background(), "67a8ffa093a5fa72f000452b")
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+}
+
+func TestAPIKeyUseCase_AdminKey(t *testing.T) {
+	uc, _ := newAPIKeyUseCase(usecase2.WithAdminKey("bootstrap-secret"))
+
+	principal, err := uc.Authenticate(context.Background(), "bootstrap-secret")
+	require.NoError(t, err)
+	assert.True(t, principal.HasScope(domain.ScopeRefundsWrite))
+
+	// Sem a opção, nenhuma chave fora do repositório é aceita
+	uc, _ = newAPIKeyUseCase(usecase2.WithAdminKey(""))
+	_, err = uc.Authenticate(context.Background(), "")
+	assert.True(t, errors.Is(err, domain.ErrUnauthorized))
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	usecase2 "payments/usecase"
+)
+
+type MockDisputeRepository struct {
+	mock.Mock
+}
+
+func (m *MockDisputeRepository) GetByID(ctx context.Context, id string) (domain.Dispute, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) FindByPaymentID(ctx context.Context, paymentID string) ([]domain.Dispute, error) {
+	args := m.Called(paymentID)
+	return args.Get(0).([]domain.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) Create(ctx context.Context, dispute *domain.Dispute) (string, error) {
+	args := m.Called(dispute)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockDisputeRepository) Update(ctx context.Context, id string, dispute *domain.Dispute) error {
+	args := m.Called(id, dispute)
+	return args.Error(0)
+}
+
+var disputeNow = time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+func newDisputeUseCase(disputes *MockDisputeRepository, payments *MockPaymentRepository, orderClient *MockOrderClient) usecase2.DisputeUseCase {
+	return usecase2.NewDisputeUseCase(disputes, payments, usecase2.WithDisputeOrderClient(orderClient),
+		usecase2.WithDisputeClock(func() time.Time { return disputeNow }))
+}
+
+func TestOpenDispute(t *testing.T) {
+	paymentID := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(paymentID)
+
+	t.Run("Defaults amount and evidence window", func(t *testing.T) {
+		disputes, payments := new(MockDisputeRepository), new(MockPaymentRepository)
+		useCase := newDisputeUseCase(disputes, payments, new(MockOrderClient))
+
+		payments.On("GetByID", paymentID).Return(domain.Payment{ID: objectID, Amount: 100, RefundedAmount: 30, Status: domain.StatusCaptured}, nil)
+		disputes.On("Create", mock.MatchedBy(func(d *domain.Dispute) bool {
+			return d.PaymentID == paymentID && d.Amount == 70 && d.Status == domain.DisputeOpen &&
+				d.EvidenceDueBy.Equal(disputeNow.Add(domain.DefaultDisputeEvidenceWindow))
+		})).Return(primitive.NewObjectID().Hex(), nil)
+
+		dispute, err := useCase.OpenDispute(context.Background(), paymentID, domain.Dispute{ReasonCode: "4837"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "4837", dispute.ReasonCode)
+		disputes.AssertExpectations(t)
+	})
+
+	t.Run("Pending payment cannot be disputed", func(t *testing.T) {
+		disputes, payments := new(MockDisputeRepository), new(MockPaymentRepository)
+		useCase := newDisputeUseCase(disputes, payments, new(MockOrderClient))
+
+		payments.On("GetByID", paymentID).Return(domain.Payment{ID: objectID, Amount: 100, Status: "pending"}, nil)
+
+		_, err := useCase.OpenDispute(context.Background(), paymentID, domain.Dispute{ReasonCode: "4837"})
+
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		disputes.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestResolveDispute(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	paymentID := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(paymentID)
+	open := domain.Dispute{PaymentID: paymentID, ReasonCode: "4837", Amount: 100, Status: domain.DisputeOpen,
+		EvidenceDueBy: disputeNow.Add(time.Hour)}
+
+	t.Run("Lost dispute charges the payment back and notifies the order", func(t *testing.T) {
+		disputes, payments, orderClient := new(MockDisputeRepository), new(MockPaymentRepository), new(MockOrderClient)
+		useCase := newDisputeUseCase(disputes, payments, orderClient)
+
+		disputes.On("GetByID", id).Return(open, nil)
+		payments.On("GetByID", paymentID).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 100, Status: domain.StatusCaptured}, nil)
+		payments.On("Update", paymentID, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Status == domain.StatusChargedBack
+		})).Return(nil)
+		disputes.On("Update", id, mock.MatchedBy(func(d *domain.Dispute) bool {
+			return d.Status == domain.DisputeLost && d.ResolvedAt != nil && d.ResolvedAt.Equal(disputeNow)
+		})).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", domain.StatusChargedBack).Return(nil)
+
+		dispute, err := useCase.ResolveDispute(context.Background(), id, domain.DisputeLost)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DisputeLost, dispute.Status)
+		payments.AssertExpectations(t)
+		disputes.AssertExpectations(t)
+		orderClient.AssertExpectations(t)
+	})
+
+	t.Run("Order service failure keeps the resolution", func(t *testing.T) {
+		disputes, payments, orderClient := new(MockDisputeRepository), new(MockPaymentRepository), new(MockOrderClient)
+		useCase := newDisputeUseCase(disputes, payments, orderClient)
+
+		disputes.On("GetByID", id).Return(open, nil)
+		payments.On("GetByID", paymentID).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 100, Status: domain.StatusCaptured}, nil)
+		payments.On("Update", paymentID, mock.Anything).Return(nil)
+		disputes.On("Update", id, mock.Anything).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", domain.StatusChargedBack).Return(errors.New("order service unavailable"))
+
+		dispute, err := useCase.ResolveDispute(context.Background(), id, domain.DisputeLost)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DisputeLost, dispute.Status)
+		disputes.AssertExpectations(t)
+		orderClient.AssertExpectations(t)
+	})
+
+	t.Run("Won dispute keeps the payment", func(t *testing.T) {
+		disputes, payments, orderClient := new(MockDisputeRepository), new(MockPaymentRepository), new(MockOrderClient)
+		useCase := newDisputeUseCase(disputes, payments, orderClient)
+
+		disputes.On("GetByID", id).Return(open, nil)
+		disputes.On("Update", id, mock.Anything).Return(nil)
+
+		dispute, err := useCase.ResolveDispute(context.Background(), id, domain.DisputeWon)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DisputeWon, dispute.Status)
+		payments.AssertNotCalled(t, "GetByID", mock.Anything)
+		orderClient.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Resolved dispute is a conflict", func(t *testing.T) {
+		disputes, payments := new(MockDisputeRepository), new(MockPaymentRepository)
+		useCase := newDisputeUseCase(disputes, payments, new(MockOrderClient))
+
+		resolved := open
+		resolved.Status = domain.DisputeWon
+		disputes.On("GetByID", id).Return(resolved, nil)
+
+		_, err := useCase.ResolveDispute(context.Background(), id, domain.DisputeLost)
+
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		disputes.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdateDispute_AppendsEvidence(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	disputes := new(MockDisputeRepository)
+	useCase := newDisputeUseCase(disputes, new(MockPaymentRepository), new(MockOrderClient))
+
+	disputes.On("GetByID", id).Return(domain.Dispute{Status: domain.DisputeOpen, ReasonCode: "4837", EvidenceDueBy: disputeNow.Add(time.Hour),
+		Evidence: []domain.EvidenceAttachment{{FileName: "receipt.pdf", ContentType: "application/pdf", Size: 10, URL: "https://files.example.com/receipt.pdf"}}}, nil)
+	disputes.On("Update", id, mock.MatchedBy(func(d *domain.Dispute) bool {
+		return len(d.Evidence) == 2 && d.Evidence[1].UploadedAt.Equal(disputeNow)
+	})).Return(nil)
+
+	dispute, err := useCase.UpdateDispute(context.Background(), id, domain.DisputeUpdate{Evidence: []domain.EvidenceAttachment{
+		{FileName: "tracking.png", ContentType: "image/png", Size: 20, URL: "https://files.example.com/tracking.png"}}})
+
+	assert.Nil(t, err)
+	assert.Len(t, dispute.Evidence, 2)
+	disputes.AssertExpectations(t)
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	usecase2 "payments/usecase"
+)
+
+type MockFeeRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockFeeRuleRepository) List(ctx context.Context) ([]domain.FeeRule, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleRepository) GetByID(ctx context.Context, id string) (domain.FeeRule, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleRepository) FindEffective(ctx context.Context, paymentType domain.PaymentType, at time.Time) (domain.FeeRule, error) {
+	args := m.Called(paymentType)
+	return args.Get(0).(domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeRuleRepository) Create(ctx context.Context, rule *domain.FeeRule) (string, error) {
+	args := m.Called(rule)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFeeRuleRepository) Update(ctx context.Context, id string, rule *domain.FeeRule) error {
+	args := m.Called(id, rule)
+	return args.Error(0)
+}
+
+func (m *MockFeeRuleRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestProcessPaymentCallback_AppliesFee(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	rule := domain.FeeRule{ID: primitive.NewObjectID(), PaymentType: domain.Pix, Percentage: 0.99}
+
+	t.Run("Approval records gross, fee and net", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		feeRules := new(MockFeeRuleRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient), usecase2.WithFeeRules(feeRules))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 200, PaymentType: domain.Pix}, nil)
+		feeRules.On("FindEffective", domain.Pix).Return(rule, nil)
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.GrossAmount == 200 && p.FeeAmount == 1.98 && p.NetAmount == 198.02 && p.FeeRuleID == rule.ID.Hex()
+		})).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", "approved").Return(nil)
+
+		err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: "approved"})
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+		feeRules.AssertExpectations(t)
+	})
+
+	t.Run("Missing rule keeps the approval without fee", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		feeRules := new(MockFeeRuleRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient), usecase2.WithFeeRules(feeRules))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 200, PaymentType: domain.Pix}, nil)
+		feeRules.On("FindEffective", domain.Pix).Return(domain.FeeRule{}, fmt.Errorf("no rule: %w", domain.ErrNotFound))
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Status == "approved" && p.FeeRuleID == "" && p.NetAmount == 0
+		})).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", "approved").Return(nil)
+
+		err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: "approved"})
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejection does not look up fees", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		feeRules := new(MockFeeRuleRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient), usecase2.WithFeeRules(feeRules))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 200, PaymentType: domain.Pix}, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", "Recusado").Return(nil)
+
+		err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: "Recusado"})
+
+		assert.Nil(t, err)
+		feeRules.AssertNotCalled(t, "FindEffective", mock.Anything)
+	})
+}
+
+func TestCapturePayment_AppliesFeeOnCapturedAmount(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	authorized := domain.Payment{ID: objectID, OrderId: "order123", Amount: 100, PaymentType: domain.CreditCard,
+		Status: domain.StatusAuthorized, CaptureMethod: domain.CaptureManual}
+
+	mockRepo := new(MockPaymentRepository)
+	feeRules := new(MockFeeRuleRepository)
+	orderClient := new(MockOrderClient)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil), usecase2.WithOrderClient(orderClient),
+		usecase2.WithFeeRules(feeRules))
+
+	mockRepo.On("GetByID", id).Return(authorized, nil)
+	feeRules.On("FindEffective", domain.CreditCard).Return(domain.FeeRule{FixedAmount: 0.39, Percentage: 3.49}, nil)
+	mockRepo.On("Update", id, mock.Anything).Return(nil)
+	orderClient.On("UpdateOrderStatus", "order123", domain.StatusCaptured).Return(nil)
+
+	payment, err := useCase.CapturePayment(context.Background(), id, 40)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 40.0, payment.GrossAmount)
+	assert.Equal(t, 1.79, payment.FeeAmount)
+	assert.Equal(t, 38.21, payment.NetAmount)
+}
+
+func TestFeeRuleUseCase(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	clock := usecase2.WithFeeRuleClock(func() time.Time { return now })
+	id := primitive.NewObjectID().Hex()
+	pending := domain.FeeRule{PaymentType: domain.Pix, Percentage: 1, EffectiveFrom: now.AddDate(0, 1, 0)}
+	inEffect := domain.FeeRule{PaymentType: domain.Pix, Percentage: 1, EffectiveFrom: now.AddDate(0, -1, 0)}
+
+	t.Run("Create validates the rule", func(t *testing.T) {
+		repo := new(MockFeeRuleRepository)
+		useCase := usecase2.NewFeeRuleUseCase(repo, clock)
+
+		_, err := useCase.CreateFeeRule(context.Background(), &domain.FeeRule{PaymentType: domain.Pix, Percentage: 150, EffectiveFrom: now})
+		assert.True(t, errors.Is(err, domain.ErrValidation))
+		repo.AssertNotCalled(t, "Create", mock.Anything)
+
+		rule := inEffect
+		repo.On("Create", &rule).Return(id, nil)
+		created, err := useCase.CreateFeeRule(context.Background(), &rule)
+		assert.Nil(t, err)
+		assert.Equal(t, id, created)
+	})
+
+	t.Run("Pending versions can be updated and deleted", func(t *testing.T) {
+		repo := new(MockFeeRuleRepository)
+		useCase := usecase2.NewFeeRuleUseCase(repo, clock)
+
+		update := pending
+		update.Percentage = 0.9
+		repo.On("GetByID", id).Return(pending, nil)
+		repo.On("Update", id, &update).Return(nil)
+		repo.On("Delete", id).Return(nil)
+
+		assert.Nil(t, useCase.UpdateFeeRule(context.Background(), id, &update))
+		assert.Nil(t, useCase.DeleteFeeRule(context.Background(), id))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Versions in effect are immutable", func(t *testing.T) {
+		repo := new(MockFeeRuleRepository)
+		useCase := usecase2.NewFeeRuleUseCase(repo, clock)
+
+		repo.On("GetByID", id).Return(inEffect, nil)
+
+		err := useCase.UpdateFeeRule(context.Background(), id, &pending)
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		err = useCase.DeleteFeeRule(context.Background(), id)
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("Update cannot move a version into the past", func(t *testing.T) {
+		repo := new(MockFeeRuleRepository)
+		useCase := usecase2.NewFeeRuleUseCase(repo, clock)
+
+		repo.On("GetByID", id).Return(pending, nil)
+
+		err := useCase.UpdateFeeRule(context.Background(), id, &inEffect)
+		assert.True(t, errors.Is(err, domain.ErrValidation))
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
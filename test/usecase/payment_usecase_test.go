@@ -1,50 +1,100 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"payments/client"
 	"payments/domain"
 	usecase2 "payments/usecase"
 	"testing"
+	"time"
 )
 
 type MockPaymentRepository struct {
 	mock.Mock
 }
 
-func (m *MockPaymentRepository) GetAll() ([]domain.Payment, error) {
+func (m *MockPaymentRepository) GetAll(ctx context.Context) ([]domain.Payment, error) {
 	args := m.Called()
 	return args.Get(0).([]domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentRepository) GetByID(id string) (domain.Payment, error) {
+func (m *MockPaymentRepository) GetByID(ctx context.Context, id string) (domain.Payment, error) {
 	args := m.Called(id)
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentRepository) Create(payment *domain.Payment) (string, error) {
+func (m *MockPaymentRepository) FindByOrderID(ctx context.Context, orderID string) ([]domain.Payment, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindByStatus(ctx context.Context, status string, createdBefore time.Time) ([]domain.Payment, error) {
+	args := m.Called(status, createdBefore)
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) Create(ctx context.Context, payment *domain.Payment) (string, error) {
 	args := m.Called(payment)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockPaymentRepository) Update(id string, payment *domain.Payment) error {
+func (m *MockPaymentRepository) Update(ctx context.Context, id string, payment *domain.Payment) error {
 	args := m.Called(id, payment)
 	return args.Error(0)
 }
 
-func (m *MockPaymentRepository) Delete(id string) error {
+func (m *MockPaymentRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockPaymentRepository) ProcessPaymentCallback(callbackData *domain.PaymentCallback) error {
+func (m *MockPaymentRepository) ProcessPaymentCallback(ctx context.Context, callbackData *domain.PaymentCallback) error {
 	args := m.Called(callbackData)
 	return args.Error(0)
 }
 
+type MockPaymentGateway struct {
+	mock.Mock
+}
+
+func (m *MockPaymentGateway) CancelPayment(ctx context.Context, paymentID string) error {
+	args := m.Called(paymentID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) CapturePayment(ctx context.Context, paymentID string, amount float64) error {
+	args := m.Called(paymentID, amount)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) VoidPayment(ctx context.Context, paymentID string) error {
+	args := m.Called(paymentID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) RefundPayment(ctx context.Context, paymentID string, amount float64, splits []domain.SplitReversal) error {
+	args := m.Called(paymentID, amount, splits)
+	return args.Error(0)
+}
+
+type MockOrderClient struct {
+	mock.Mock
+}
+
+func (m *MockOrderClient) UpdateOrderStatus(ctx context.Context, orderID string, status string) error {
+	args := m.Called(orderID, status)
+	return args.Error(0)
+}
+
 func TestGetAllPayments(t *testing.T) {
 	mockRepo := new(MockPaymentRepository)
 	useCase := usecase2.NewPaymentUseCase(mockRepo)
@@ -59,7 +109,7 @@ func TestGetAllPayments(t *testing.T) {
 
 	mockRepo.On("GetAll").Return(mockPayments, nil)
 
-	payments, err := useCase.GetAllPayments()
+	payments, err := useCase.GetAllPayments(context.Background())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(payments), 2)
@@ -69,6 +119,24 @@ func TestGetAllPayments(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetPaymentsByOrderID(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo)
+
+	active := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order123", Status: domain.StatusProcessing}
+	failed := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order123", Status: "Recusado"}
+	mockRepo.On("FindByOrderID", "order123").Return([]domain.Payment{active, failed}, nil)
+
+	summary, err := useCase.GetPaymentsByOrderID(context.Background(), "order123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "order123", summary.OrderID)
+	assert.Equal(t, 2, summary.Attempts)
+	assert.Equal(t, domain.StatusProcessing, summary.Status)
+	assert.Equal(t, active.ID.Hex(), summary.ActivePaymentID)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetPaymentByID(t *testing.T) {
 	mockRepo := new(MockPaymentRepository)
 	useCase := usecase2.NewPaymentUseCase(mockRepo)
@@ -83,7 +151,7 @@ func TestGetPaymentByID(t *testing.T) {
 
 	mockRepo.On("GetByID", id).Return(mockPayment, nil)
 
-	payment, err := useCase.GetPaymentByID(id)
+	payment, err := useCase.GetPaymentByID(context.Background(), id)
 
 	assert.Nil(t, err)
 	assert.Equal(t, mockPayment.ID, payment.ID)
@@ -105,7 +173,7 @@ func TestCreatePayment(t *testing.T) {
 	mockRepo.On("Create", payment).Return("generated-id", nil)
 
 	// Chama o método de criação de pagamento
-	id, err := useCase.CreatePayment(payment)
+	id, err := useCase.CreatePayment(context.Background(), payment)
 
 	// Verificações
 	assert.Nil(t, err)
@@ -113,34 +181,120 @@ func TestCreatePayment(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreatePayment_CreditCardWithoutToken(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo)
+
+	_, err := useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 100, PaymentType: domain.CreditCard})
+
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreatePayment_InstallmentPlan(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	policies := domain.InstallmentPolicies{
+		domain.CreditCard: {MaxInstallments: 10, MinInstallmentAmount: 10, InterestFreeInstallments: 3, MonthlyInterestRate: 2},
+	}
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithInstallmentPolicies(policies))
+	card := &domain.Card{Token: "tok_123", Brand: domain.BrandVisa, Last4: "1111"}
+
+	mockRepo.On("Create", mock.Anything).Return("generated-id", nil)
+
+	payment := &domain.Payment{Amount: 1000, PaymentType: domain.CreditCard, Card: card, Installments: 10}
+	_, err := useCase.CreatePayment(context.Background(), payment)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, payment.InstallmentPlan)
+	assert.Len(t, payment.InstallmentPlan.Schedule, 10)
+	assert.Equal(t, 1113.27, payment.InstallmentPlan.TotalAmount)
+
+	t.Run("Single payment has no plan", func(t *testing.T) {
+		single := &domain.Payment{Amount: 1000, PaymentType: domain.CreditCard, Card: card, Installments: 1}
+		_, err := useCase.CreatePayment(context.Background(), single)
+
+		assert.Nil(t, err)
+		assert.Nil(t, single.InstallmentPlan)
+	})
+}
+
+func TestCreatePayment_InstallmentLimits(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo)
+	card := &domain.Card{Token: "tok_123", Brand: domain.BrandVisa, Last4: "1111"}
+
+	var validationErr *domain.ValidationError
+	_, err := useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 20, PaymentType: domain.CreditCard, Card: card, Installments: 6})
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []domain.ErrorResponse{{Field: "installments", Message: "must be at most 4 for this amount"}}, validationErr.Errors)
+
+	_, err = useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 100, PaymentType: domain.Pix, Installments: 2})
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreatePayment_Boleto(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	issuer := domain.BoletoIssuer{BankCode: "237", Covenant: "1234567", DueDays: 5, FinePercent: 2}
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithBoletoIssuer(issuer))
+
+	mockRepo.On("Create", mock.MatchedBy(func(p *domain.Payment) bool {
+		return !p.ID.IsZero() && p.Boleto != nil && domain.ValidBoletoBarcode(p.Boleto.Barcode)
+	})).Return("generated-id", nil)
+
+	payment := &domain.Payment{Amount: 80, PaymentType: domain.Boleto}
+	_, err := useCase.CreatePayment(context.Background(), payment)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "237", payment.Boleto.Barcode[:3])
+	assert.Equal(t, time.Now().AddDate(0, 0, 5).Format("2006-01-02"), payment.Boleto.DueDate.Format("2006-01-02"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePayment_BoletoRequestedDueDate(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo)
+	dueDate := time.Date(2030, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.On("Create", mock.Anything).Return("generated-id", nil)
+
+	payment := &domain.Payment{Amount: 80, PaymentType: domain.Boleto, Boleto: &domain.BoletoDetails{DueDate: dueDate}}
+	_, err := useCase.CreatePayment(context.Background(), payment)
+
+	assert.Nil(t, err)
+	assert.Equal(t, dueDate, payment.Boleto.DueDate)
+	assert.Len(t, payment.Boleto.DigitableLine, 47)
+}
+
 func TestUpdatePayment(t *testing.T) {
 	mockRepo := new(MockPaymentRepository)
 	useCase := usecase2.NewPaymentUseCase(mockRepo)
 
 	id := "507f191e810c19729de860ea"
 	existingPayment := domain.Payment{
-		ID:     primitive.NewObjectID(),
-		Amount: 200.0,
-	}
-
-	updatedPayment := &domain.Payment{
-		ID:     existingPayment.ID,
-		Amount: 250.0,
+		ID:             primitive.NewObjectID(),
+		Amount:         200.0,
+		Method:         "online",
+		Status:         domain.StatusCaptured,
+		CapturedAmount: 200.0,
+		FeeAmount:      5.0,
 	}
 
-	// Mocka a busca pelo pagamento existente
+	// Só o canal muda; o restante vem do pagamento salvo
+	expected := existingPayment
+	expected.Method = "in_store"
 	mockRepo.On("GetByID", id).Return(existingPayment, nil)
-
-	// Mocka a atualização do pagamento
-	mockRepo.On("Update", id, updatedPayment).Return(nil)
+	mockRepo.On("Update", id, &expected).Return(nil)
 
 	// Executa o método
-	err := useCase.UpdatePayment(id, updatedPayment)
+	payment, err := useCase.UpdatePayment(context.Background(), id, domain.PaymentUpdate{Method: "in_store"})
 
 	// Verificações
 	assert.Nil(t, err)
+	assert.Equal(t, expected, payment)
 	mockRepo.AssertExpectations(t)
 }
+
 func TestDeletePayment(t *testing.T) {
 	mockRepo := new(MockPaymentRepository)
 	useCase := usecase2.NewPaymentUseCase(mockRepo)
@@ -155,13 +309,13 @@ func TestDeletePayment(t *testing.T) {
 	mockRepo.On("GetByID", paymentID).Return(existingPayment, nil)
 	mockRepo.On("Delete", paymentID).Return(nil)
 
-	err := useCase.DeletePayment(paymentID)
+	err := useCase.DeletePayment(context.Background(), paymentID)
 	assert.Nil(t, err)
 
 	// Cenário de erro: pagamento não encontrado
 	mockRepo.On("GetByID", "invalid_id").Return(domain.Payment{}, fmt.Errorf("payment not found"))
 
-	err = useCase.DeletePayment("invalid_id")
+	err = useCase.DeletePayment(context.Background(), "invalid_id")
 	assert.NotNil(t, err)
 	assert.Equal(t, "payment not found: payment not found", err.Error())
 
@@ -189,6 +343,10 @@ func TestProcessPaymentCallback(t *testing.T) {
 
 	// Mock para o repositório, retornando o pagamento simulado
 	mockRepo.On("GetByID", callbackData.PaymentID).Return(payment, nil)
+	// O status recebido no callback é persistido
+	mockRepo.On("Update", callbackData.PaymentID, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Status == "completed"
+	})).Return(nil)
 
 	// Agora simula a chamada HTTP para o serviço de pedidos
 	httpmock.Activate()
@@ -199,7 +357,7 @@ func TestProcessPaymentCallback(t *testing.T) {
 		httpmock.NewStringResponder(204, `{"status":"success"}`))
 
 	// Executando a função de callback
-	err := useCase.ProcessPaymentCallback(callbackData)
+	err := useCase.ProcessPaymentCallback(context.Background(), callbackData)
 
 	// Verificando se não houve erro
 	print(err)
@@ -208,6 +366,24 @@ func TestProcessPaymentCallback(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestProcessPaymentCallback_OrderServiceFailure(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	orderClient := new(MockOrderClient)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient))
+	callbackData := &domain.PaymentCallback{PaymentID: "507f191e810c19729de860ea", Status: "approved"}
+
+	mockRepo.On("GetByID", callbackData.PaymentID).Return(domain.Payment{OrderId: "order123", Status: domain.StatusProcessing}, nil)
+	mockRepo.On("Update", callbackData.PaymentID, mock.Anything).Return(nil)
+	orderClient.On("UpdateOrderStatus", "order123", "approved").Return(errors.New("order service unavailable"))
+
+	// O callback já foi aplicado: um erro faria o gateway reenviá-lo
+	err := useCase.ProcessPaymentCallback(context.Background(), callbackData)
+
+	assert.Nil(t, err)
+	mockRepo.AssertExpectations(t)
+	orderClient.AssertExpectations(t)
+}
+
 func TestProcessPaymentCallback_ErrorFetchingPayment(t *testing.T) {
 	// Mocking the payment repository
 	mockRepo := new(MockPaymentRepository)
@@ -223,10 +399,528 @@ func TestProcessPaymentCallback_ErrorFetchingPayment(t *testing.T) {
 	mockRepo.On("GetByID", callbackData.PaymentID).Return(domain.Payment{}, fmt.Errorf("payment not found"))
 
 	// Testando o processamento do callback do pagamento
-	err := useCase.ProcessPaymentCallback(callbackData)
+	err := useCase.ProcessPaymentCallback(context.Background(), callbackData)
 
 	// Verificações
 	assert.NotNil(t, err)                                                // Espera-se que ocorra um erro
 	assert.Equal(t, "payment not found: payment not found", err.Error()) // Verifica a mensagem de erro
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCancelPayment(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+
+	t.Run("Cancels a processing payment on the gateway", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		gateway := new(MockPaymentGateway)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(gateway))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Status: domain.StatusProcessing}, nil)
+		gateway.On("CancelPayment", id).Return(nil)
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Status == domain.StatusCancelled
+		})).Return(nil)
+
+		payment, err := useCase.CancelPayment(context.Background(), id)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.StatusCancelled, payment.Status)
+		mockRepo.AssertExpectations(t)
+		gateway.AssertExpectations(t)
+	})
+
+	t.Run("Cancelling twice is a no-op", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Status: domain.StatusCancelled}, nil)
+
+		payment, err := useCase.CancelPayment(context.Background(), id)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.StatusCancelled, payment.Status)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Settled payments conflict", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Status: "completed"}, nil)
+
+		_, err := useCase.CancelPayment(context.Background(), id)
+
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Gateway failure keeps the payment active", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		gateway := new(MockPaymentGateway)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(gateway))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Status: domain.StatusProcessing}, nil)
+		gateway.On("CancelPayment", id).Return(errors.New("timeout"))
+
+		_, err := useCase.CancelPayment(context.Background(), id)
+
+		assert.True(t, errors.Is(err, domain.ErrUpstream))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProcessPaymentCallback_AfterCancel(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	cancelled := domain.Payment{ID: objectID, OrderId: "order123", Status: domain.StatusCancelled}
+
+	t.Run("Approval is flagged for refund", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient))
+
+		mockRepo.On("GetByID", id).Return(cancelled, nil)
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Status == domain.StatusCancelled && p.RefundRequired
+		})).Return(nil)
+
+		err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: "approved"})
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+		// O pedido foi abandonado e não deve ser finalizado
+		orderClient.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Other statuses are ignored", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient))
+
+		mockRepo.On("GetByID", id).Return(cancelled, nil)
+
+		err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: "failed"})
+
+		assert.Nil(t, err)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		orderClient.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProcessPaymentCallback_ClosedPayments(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+
+	cases := []struct {
+		name, status, callback string
+		refundRequired         bool
+	}{
+		{"Approval of a voided authorization is flagged for refund", domain.StatusVoided, "approved", true},
+		{"Approval of a captured payment is a duplicate", domain.StatusCaptured, "approved", false},
+		{"Failure does not reopen a captured payment", domain.StatusCaptured, "failed", false},
+		{"Approval does not undo a refund", domain.StatusRefunded, "approved", false},
+		{"Approval does not undo a chargeback", domain.StatusChargedBack, "success", false},
+		{"Failure does not overwrite an approval", "approved", "failed", false},
+		{"Repeated approval is ignored", "approved", "approved", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockPaymentRepository)
+			orderClient := new(MockOrderClient)
+			feeRules := new(MockFeeRuleRepository)
+			notifier := new(MockPaymentNotifier)
+			useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient),
+				usecase2.WithFeeRules(feeRules), usecase2.WithNotifier(notifier))
+
+			mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 100, Status: tc.status}, nil)
+			if tc.refundRequired {
+				mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+					return p.Status == tc.status && p.RefundRequired
+				})).Return(nil)
+			}
+
+			err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: tc.callback})
+
+			assert.Nil(t, err)
+			mockRepo.AssertExpectations(t)
+			if !tc.refundRequired {
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			}
+			feeRules.AssertNotCalled(t, "FindEffective", mock.Anything)
+			notifier.AssertNotCalled(t, "NotifyPayment", mock.Anything, mock.Anything)
+			orderClient.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCapturePayment(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	authorized := domain.Payment{ID: objectID, OrderId: "order123", Amount: 100, Status: domain.StatusAuthorized, CaptureMethod: domain.CaptureManual}
+
+	t.Run("Partial capture", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		gateway := new(MockPaymentGateway)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(gateway), usecase2.WithOrderClient(orderClient))
+
+		mockRepo.On("GetByID", id).Return(authorized, nil)
+		gateway.On("CapturePayment", id, 60.0).Return(nil)
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Status == domain.StatusCaptured && p.CapturedAmount == 60
+		})).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", domain.StatusCaptured).Return(nil)
+
+		payment, err := useCase.CapturePayment(context.Background(), id, 60)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 60.0, payment.CapturedAmount)
+		mockRepo.AssertExpectations(t)
+		gateway.AssertExpectations(t)
+		orderClient.AssertExpectations(t)
+	})
+
+	t.Run("Zero captures the full amount", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil), usecase2.WithOrderClient(orderClient))
+
+		mockRepo.On("GetByID", id).Return(authorized, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", domain.StatusCaptured).Return(nil)
+
+		payment, err := useCase.CapturePayment(context.Background(), id, 0)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 100.0, payment.CapturedAmount)
+	})
+
+	t.Run("Order service failure keeps the capture", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		orderClient := new(MockOrderClient)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil), usecase2.WithOrderClient(orderClient))
+
+		mockRepo.On("GetByID", id).Return(authorized, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", domain.StatusCaptured).Return(errors.New("order service unavailable"))
+
+		// A captura já foi feita e gravada: o cliente não deve repeti-la
+		payment, err := useCase.CapturePayment(context.Background(), id, 0)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.StatusCaptured, payment.Status)
+		mockRepo.AssertExpectations(t)
+		orderClient.AssertExpectations(t)
+	})
+
+	t.Run("Amount above the authorization", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil))
+
+		mockRepo.On("GetByID", id).Return(authorized, nil)
+
+		_, err := useCase.CapturePayment(context.Background(), id, 150)
+
+		assert.True(t, errors.Is(err, domain.ErrValidation))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestVoidPayment(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+
+	t.Run("Releases the authorization", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		gateway := new(MockPaymentGateway)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(gateway))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Status: domain.StatusAuthorized}, nil)
+		gateway.On("VoidPayment", id).Return(nil)
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Status == domain.StatusVoided
+		})).Return(nil)
+
+		payment, err := useCase.VoidPayment(context.Background(), id)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.StatusVoided, payment.Status)
+		mockRepo.AssertExpectations(t)
+		gateway.AssertExpectations(t)
+	})
+
+	t.Run("Captured payments conflict", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Status: domain.StatusCaptured}, nil)
+
+		_, err := useCase.VoidPayment(context.Background(), id)
+
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+	})
+}
+
+func TestVoidExpiredAuthorizations(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil))
+	cutoff := time.Now().Add(-time.Hour)
+
+	expired := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusAuthorized}
+	// Capturado entre a busca e a liberação
+	captured := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusAuthorized}
+	mockRepo.On("FindByStatus", domain.StatusAuthorized, cutoff).Return([]domain.Payment{expired, captured}, nil)
+	mockRepo.On("GetByID", expired.ID.Hex()).Return(expired, nil)
+	mockRepo.On("GetByID", captured.ID.Hex()).Return(domain.Payment{ID: captured.ID, Status: domain.StatusCaptured}, nil)
+	mockRepo.On("Update", expired.ID.Hex(), mock.Anything).Return(nil)
+
+	voided, err := useCase.VoidExpiredAuthorizations(context.Background(), cutoff)
+
+	assert.Equal(t, 1, voided)
+	assert.True(t, errors.Is(err, domain.ErrConflict))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExpireOverdueBoletos(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	orderClient := new(MockOrderClient)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient))
+	now := time.Date(2030, time.January, 20, 12, 0, 0, 0, time.UTC)
+
+	overdue := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order1", Status: domain.StatusProcessing,
+		Boleto: &domain.BoletoDetails{ExpiresAt: now.Add(-time.Hour)}}
+	notDue := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusProcessing,
+		Boleto: &domain.BoletoDetails{ExpiresAt: now.Add(time.Hour)}}
+	pix := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusProcessing}
+	// Aprovado entre a busca e o vencimento
+	paid := domain.Payment{ID: primitive.NewObjectID(), Status: domain.StatusProcessing,
+		Boleto: &domain.BoletoDetails{ExpiresAt: now.Add(-time.Hour)}}
+
+	mockRepo.On("FindByStatus", domain.StatusProcessing, now).Return([]domain.Payment{overdue, notDue, pix, paid}, nil)
+	mockRepo.On("GetByID", overdue.ID.Hex()).Return(overdue, nil)
+	mockRepo.On("GetByID", paid.ID.Hex()).Return(domain.Payment{ID: paid.ID, Status: "success", Boleto: paid.Boleto}, nil)
+	mockRepo.On("Update", overdue.ID.Hex(), mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Status == domain.StatusExpired
+	})).Return(nil)
+	orderClient.On("UpdateOrderStatus", "order1", domain.StatusExpired).Return(nil)
+
+	expired, err := useCase.ExpireOverdueBoletos(context.Background(), now)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, expired)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "Update", 1)
+	orderClient.AssertExpectations(t)
+}
+
+func TestExpireOverdueBoletos_OrderServiceFailure(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	orderClient := new(MockOrderClient)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient))
+	now := time.Date(2030, time.January, 20, 12, 0, 0, 0, time.UTC)
+	overdue := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order1", Status: domain.StatusProcessing,
+		Boleto: &domain.BoletoDetails{ExpiresAt: now.Add(-time.Hour)}}
+	mockRepo.On("FindByStatus", domain.StatusProcessing, now).Return([]domain.Payment{overdue}, nil)
+	mockRepo.On("GetByID", overdue.ID.Hex()).Return(overdue, nil)
+	mockRepo.On("Update", overdue.ID.Hex(), mock.Anything).Return(nil)
+	orderClient.On("UpdateOrderStatus", "order1", domain.StatusExpired).Return(errors.New("order service unavailable"))
+
+	expired, err := useCase.ExpireOverdueBoletos(context.Background(), now)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, expired)
+	orderClient.AssertExpectations(t)
+}
+
+func TestExpireOverdueBoletos_CancelsOrder(t *testing.T) {
+	// Com o cliente real, o corpo do PATCH precisa cancelar o pedido, e não finalizá-lo
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(client.NewOrderClient(server.URL, server.Client())))
+	now := time.Date(2030, time.January, 20, 12, 0, 0, 0, time.UTC)
+	overdue := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order1", Status: domain.StatusProcessing,
+		Boleto: &domain.BoletoDetails{ExpiresAt: now.Add(-time.Hour)}}
+	mockRepo.On("FindByStatus", domain.StatusProcessing, now).Return([]domain.Payment{overdue}, nil)
+	mockRepo.On("GetByID", overdue.ID.Hex()).Return(overdue, nil)
+	mockRepo.On("Update", overdue.ID.Hex(), mock.Anything).Return(nil)
+
+	expired, err := useCase.ExpireOverdueBoletos(context.Background(), now)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, map[string]string{"status": client.OrderStatusCancelled}, body)
+}
+
+func TestProcessPaymentCallback_AfterExpiry(t *testing.T) {
+	id := primitive.NewObjectID()
+	mockRepo := new(MockPaymentRepository)
+	orderClient := new(MockOrderClient)
+	useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient))
+
+	mockRepo.On("GetByID", id.Hex()).Return(domain.Payment{ID: id, Status: domain.StatusExpired}, nil)
+	mockRepo.On("Update", id.Hex(), mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Status == domain.StatusExpired && p.RefundRequired
+	})).Return(nil)
+
+	err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id.Hex(), Status: "approved"})
+
+	assert.Nil(t, err)
+	mockRepo.AssertExpectations(t)
+	orderClient.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+}
+
+func TestRefundPayment(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	split := func() domain.Payment {
+		payment := domain.Payment{ID: objectID, Amount: 100, Status: "success", Splits: []domain.Split{
+			{RecipientID: "seller", Type: domain.SplitPercentage, Value: 80, Liable: true},
+			{RecipientID: "platform", Type: domain.SplitPercentage, Value: 20, ChargeProcessingFee: true},
+		}}
+		_ = payment.ResolveSplits()
+		return payment
+	}
+
+	t.Run("Partial refund reverses each share", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		gateway := new(MockPaymentGateway)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(gateway))
+
+		mockRepo.On("GetByID", id).Return(split(), nil)
+		gateway.On("RefundPayment", id, 25.0, []domain.SplitReversal{
+			{RecipientID: "seller", Amount: 20},
+			{RecipientID: "platform", Amount: 5},
+		}).Return(nil)
+		mockRepo.On("Update", id, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.RefundedAmount == 25 && p.Splits[0].RefundedAmount == 20
+		})).Return(nil)
+
+		payment, err := useCase.RefundPayment(context.Background(), id, 25)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "success", payment.Status)
+		mockRepo.AssertExpectations(t)
+		gateway.AssertExpectations(t)
+	})
+
+	t.Run("Gateway failure keeps the payment", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		gateway := new(MockPaymentGateway)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(gateway))
+
+		mockRepo.On("GetByID", id).Return(split(), nil)
+		gateway.On("RefundPayment", id, 100.0, mock.Anything).Return(errors.New("timeout"))
+
+		_, err := useCase.RefundPayment(context.Background(), id, 0)
+
+		assert.True(t, errors.Is(err, domain.ErrUpstream))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unsettled payments conflict", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithPaymentGateway(nil))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Amount: 100, Status: domain.StatusProcessing}, nil)
+
+		_, err := useCase.RefundPayment(context.Background(), id, 0)
+
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+	})
+}
+
+func TestCreatePayment_Splits(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	useCase := usecase2.NewPaymentUseCase(mockRepo)
+
+	mockRepo.On("Create", mock.Anything).Return("generated-id", nil)
+
+	payment := &domain.Payment{Amount: 99.99, PaymentType: domain.Pix, Splits: []domain.Split{
+		{RecipientID: "platform", Type: domain.SplitFixed, Value: 9.99, ChargeProcessingFee: true},
+		{RecipientID: "seller", Type: domain.SplitFixed, Value: 90, Liable: true},
+	}}
+	_, err := useCase.CreatePayment(context.Background(), payment)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 9.99, payment.Splits[0].Amount)
+	assert.Equal(t, 90.0, payment.Splits[1].Amount)
+
+	_, err = useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 50, PaymentType: domain.Pix, Splits: payment.Splits})
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+}
+
+type MockRateProvider struct {
+	mock.Mock
+}
+
+func (m *MockRateProvider) Rate(ctx context.Context, from string, to string) (domain.FXRate, error) {
+	args := m.Called(from, to)
+	return args.Get(0).(domain.FXRate), args.Error(1)
+}
+
+func TestCreatePayment_Currency(t *testing.T) {
+	card := &domain.Card{Token: "tok_123", Brand: "visa", Last4: "4242"}
+
+	t.Run("Cross-currency payment stores the FX snapshot", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		rates := new(MockRateProvider)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithRateProvider(rates))
+		rate := domain.FXRate{From: "USD", To: "BRL", Rate: 6.1721, Source: "PTAX", Timestamp: time.Now().UTC()}
+
+		rates.On("Rate", "USD", "BRL").Return(rate, nil)
+		mockRepo.On("Create", mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.FXRate != nil && p.FXRate.Source == "PTAX" && p.SettlementAmount == 617.21
+		})).Return("generated-id", nil)
+
+		payment := &domain.Payment{Amount: 100, Currency: "USD", PaymentType: domain.CreditCard, Card: card}
+		_, err := useCase.CreatePayment(context.Background(), payment)
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Settlement currency needs no rate", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		rates := new(MockRateProvider)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithRateProvider(rates))
+
+		mockRepo.On("Create", mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.Currency == "BRL" && p.FXRate == nil
+		})).Return("generated-id", nil)
+
+		_, err := useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 100, PaymentType: domain.Pix})
+
+		assert.Nil(t, err)
+		rates.AssertNotCalled(t, "Rate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Without a rate source only BRL is accepted", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithRateProvider(nil))
+
+		_, err := useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 100, Currency: "EUR", PaymentType: domain.CreditCard, Card: card})
+
+		assert.True(t, errors.Is(err, domain.ErrValidation))
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Rate source failure", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		rates := new(MockRateProvider)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithRateProvider(rates))
+
+		rates.On("Rate", "EUR", "BRL").Return(domain.FXRate{}, errors.New("no FX rate for EUR"))
+
+		_, err := useCase.CreatePayment(context.Background(), &domain.Payment{Amount: 100, Currency: "EUR", PaymentType: domain.CreditCard, Card: card})
+
+		assert.True(t, errors.Is(err, domain.ErrUpstream))
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
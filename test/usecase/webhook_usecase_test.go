@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"payments/domain"
+	usecase2 "payments/usecase"
+)
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetSubscription(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (string, error) {
+	args := m.Called(subscription)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) error {
+	args := m.Called(id, subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetDelivery(ctx context.Context, id string) (domain.WebhookDelivery, error) {
+	args := m.Called(id)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error) {
+	args := m.Called(subscriptionID, limit)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (string, error) {
+	args := m.Called(delivery)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, id string, delivery *domain.WebhookDelivery) error {
+	args := m.Called(id, delivery)
+	return args.Error(0)
+}
+
+type MockWebhookSender struct {
+	mock.Mock
+}
+
+func (m *MockWebhookSender) Send(ctx context.Context, url string, secret string, delivery domain.WebhookDelivery) domain.WebhookAttempt {
+	args := m.Called(url, secret, delivery.ID)
+	return args.Get(0).(domain.WebhookAttempt)
+}
+
+type MockPaymentNotifier struct {
+	mock.Mock
+}
+
+func (m *MockPaymentNotifier) NotifyPayment(ctx context.Context, eventType string, payment domain.Payment) {
+	m.Called(eventType, payment.ID.Hex())
+}
+
+var webhookNow = time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+func newWebhookUseCase(repo *MockWebhookRepository, sender *MockWebhookSender) usecase2.WebhookUseCase {
+	return usecase2.NewWebhookUseCase(repo, usecase2.WithWebhookSender(sender),
+		usecase2.WithWebhookClock(func() time.Time { return webhookNow }),
+		usecase2.WithWebhookRetryPolicy(domain.WebhookRetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}))
+}
+
+func TestCreateSubscription_GeneratesSecret(t *testing.T) {
+	repo := new(MockWebhookRepository)
+	useCase := newWebhookUseCase(repo, new(MockWebhookSender))
+	repo.On("CreateSubscription", mock.MatchedBy(func(s *domain.WebhookSubscription) bool {
+		return strings.HasPrefix(s.Secret, "whsec_") && s.CreatedAt.Equal(webhookNow)
+	})).Return(primitive.NewObjectID().Hex(), nil)
+
+	created, err := useCase.CreateSubscription(context.Background(), &domain.WebhookSubscription{
+		URL: "https://shop.example.com/hooks", Events: []string{domain.EventPaymentApproved}, Active: true})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, created.Secret)
+
+	// URL inválida não chega ao repositório
+	_, err = useCase.CreateSubscription(context.Background(), &domain.WebhookSubscription{URL: "ftp://x", Events: []string{domain.EventAll}})
+	assert.True(t, errors.Is(err, domain.ErrValidation))
+	repo.AssertNumberOfCalls(t, "CreateSubscription", 1)
+}
+
+func TestGetSubscription_RedactsSecret(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	repo := new(MockWebhookRepository)
+	useCase := newWebhookUseCase(repo, new(MockWebhookSender))
+	repo.On("GetSubscription", id).Return(domain.WebhookSubscription{URL: "https://shop.example.com/hooks", Secret: "whsec_test"}, nil)
+
+	subscription, err := useCase.GetSubscription(context.Background(), id)
+
+	assert.Nil(t, err)
+	assert.Empty(t, subscription.Secret)
+}
+
+func TestNotifyPayment_QueuesMatchingSubscriptions(t *testing.T) {
+	repo := new(MockWebhookRepository)
+	useCase := newWebhookUseCase(repo, new(MockWebhookSender))
+	approved := domain.WebhookSubscription{ID: primitive.NewObjectID(), Active: true, Events: []string{domain.EventPaymentApproved}}
+	all := domain.WebhookSubscription{ID: primitive.NewObjectID(), Active: true, Events: []string{domain.EventAll}}
+	inactive := domain.WebhookSubscription{ID: primitive.NewObjectID(), Active: false, Events: []string{domain.EventAll}}
+	refunds := domain.WebhookSubscription{ID: primitive.NewObjectID(), Active: true, Events: []string{domain.EventPaymentRefunded}}
+	repo.On("ListSubscriptions").Return([]domain.WebhookSubscription{approved, all, inactive, refunds}, nil)
+
+	var queued []domain.WebhookDelivery
+	repo.On("CreateDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		queued = append(queued, *args.Get(0).(*domain.WebhookDelivery))
+	}).Return(primitive.NewObjectID().Hex(), nil)
+
+	payment := domain.Payment{ID: primitive.NewObjectID(), OrderId: "order123", Amount: 100, Status: "approved",
+		Card: &domain.Card{Token: "tok_secret", Brand: "visa", Last4: "4242"}, FeeAmount: 2.5}
+	useCase.NotifyPayment(context.Background(), domain.EventPaymentApproved, payment)
+
+	if assert.Len(t, queued, 2) {
+		assert.Equal(t, approved.ID.Hex(), queued[0].SubscriptionID)
+		assert.Equal(t, all.ID.Hex(), queued[1].SubscriptionID)
+		// As inscrições recebem o mesmo evento
+		assert.Equal(t, queued[0].EventID, queued[1].EventID)
+		assert.Equal(t, domain.DeliveryPending, queued[0].Status)
+		assert.True(t, webhookNow.Equal(*queued[0].NextAttemptAt))
+
+		var event struct {
+			Type    string                    `json:"type"`
+			Version string                    `json:"version"`
+			Data    domain.WebhookPaymentData `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(queued[0].Payload), &event))
+		assert.Equal(t, domain.EventPaymentApproved, event.Type)
+		assert.Equal(t, domain.WebhookDataVersion, event.Version)
+		assert.Equal(t, domain.NewWebhookPaymentData(payment), event.Data)
+		// Dados internos do pagamento não saem para o lojista
+		assert.NotContains(t, queued[0].Payload, "tok_secret")
+		assert.NotContains(t, queued[0].Payload, "fee_amount")
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	repo := new(MockWebhookRepository)
+	sender := new(MockWebhookSender)
+	useCase := newWebhookUseCase(repo, sender)
+
+	active := domain.WebhookSubscription{ID: primitive.NewObjectID(), URL: "https://shop.example.com/hooks", Secret: "whsec_test", Active: true}
+	inactive := domain.WebhookSubscription{ID: primitive.NewObjectID(), URL: "https://old.example.com/hooks", Active: false}
+	deletedID := primitive.NewObjectID().Hex()
+	event := domain.WebhookEvent{ID: "evt", Type: domain.EventPaymentCreated}
+	ok := domain.NewWebhookDelivery(active.ID.Hex(), event, []byte(`{}`), webhookNow)
+	ok.ID = primitive.NewObjectID()
+	failing := domain.NewWebhookDelivery(active.ID.Hex(), event, []byte(`{}`), webhookNow)
+	failing.ID = primitive.NewObjectID()
+	paused := domain.NewWebhookDelivery(inactive.ID.Hex(), event, []byte(`{}`), webhookNow)
+	paused.ID = primitive.NewObjectID()
+	orphan := domain.NewWebhookDelivery(deletedID, event, []byte(`{}`), webhookNow)
+	orphan.ID = primitive.NewObjectID()
+
+	repo.On("FindDueDeliveries", webhookNow, mock.Anything).Return([]domain.WebhookDelivery{ok, failing, paused, orphan}, nil)
+	repo.On("GetSubscription", active.ID.Hex()).Return(active, nil).Once()
+	repo.On("GetSubscription", inactive.ID.Hex()).Return(inactive, nil).Once()
+	repo.On("GetSubscription", deletedID).Return(domain.WebhookSubscription{}, fmt.Errorf("webhook subscription: %w", domain.ErrNotFound)).Once()
+	sender.On("Send", active.URL, "whsec_test", ok.ID).Return(domain.WebhookAttempt{AttemptedAt: webhookNow, StatusCode: 200})
+	sender.On("Send", active.URL, "whsec_test", failing.ID).Return(domain.WebhookAttempt{AttemptedAt: webhookNow, StatusCode: 500})
+
+	statuses := make(map[primitive.ObjectID]*domain.WebhookDelivery)
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		delivery := *args.Get(1).(*domain.WebhookDelivery)
+		statuses[delivery.ID] = &delivery
+	}).Return(nil)
+
+	attempted, err := useCase.DeliverDue(context.Background(), webhookNow)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempted)
+	assert.Equal(t, domain.DeliverySucceeded, statuses[ok.ID].Status)
+	assert.Equal(t, domain.DeliveryPending, statuses[failing.ID].Status)
+	assert.True(t, webhookNow.Add(time.Minute).Equal(*statuses[failing.ID].NextAttemptAt))
+	assert.Equal(t, domain.DeliveryFailed, statuses[paused.ID].Status)
+	assert.Equal(t, domain.DeliveryFailed, statuses[orphan.ID].Status)
+	sender.AssertNumberOfCalls(t, "Send", 2)
+	repo.AssertExpectations(t)
+}
+
+func TestSendTestEvent_DoesNotRetry(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	repo := new(MockWebhookRepository)
+	sender := new(MockWebhookSender)
+	useCase := newWebhookUseCase(repo, sender)
+
+	repo.On("GetSubscription", id).Return(domain.WebhookSubscription{URL: "https://shop.example.com/hooks", Secret: "whsec_test", Active: true}, nil)
+	sender.On("Send", "https://shop.example.com/hooks", "whsec_test", mock.Anything).Return(domain.WebhookAttempt{AttemptedAt: webhookNow, StatusCode: 404})
+	repo.On("CreateDelivery", mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return !d.ID.IsZero() && d.EventType == domain.EventWebhookTest && d.Status == domain.DeliveryFailed && len(d.Attempts) == 1
+	})).Return(primitive.NewObjectID().Hex(), nil)
+
+	delivery, err := useCase.SendTestEvent(context.Background(), id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, delivery.Attempts[0].StatusCode)
+	repo.AssertExpectations(t)
+}
+
+func TestRedeliver(t *testing.T) {
+	subscriptionID := primitive.NewObjectID().Hex()
+	deliveryID := primitive.NewObjectID()
+	repo := new(MockWebhookRepository)
+	sender := new(MockWebhookSender)
+	useCase := newWebhookUseCase(repo, sender)
+
+	failed := domain.WebhookDelivery{ID: deliveryID, SubscriptionID: subscriptionID, Status: domain.DeliveryFailed,
+		Attempts: []domain.WebhookAttempt{{Number: 1}, {Number: 2}, {Number: 3}}}
+	repo.On("GetDelivery", deliveryID.Hex()).Return(failed, nil)
+	repo.On("GetSubscription", subscriptionID).Return(domain.WebhookSubscription{URL: "https://shop.example.com/hooks", Secret: "whsec_test"}, nil)
+	sender.On("Send", "https://shop.example.com/hooks", "whsec_test", deliveryID).Return(domain.WebhookAttempt{AttemptedAt: webhookNow, StatusCode: 200})
+	repo.On("UpdateDelivery", deliveryID.Hex(), mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliverySucceeded && len(d.Attempts) == 4
+	})).Return(nil)
+
+	delivery, err := useCase.Redeliver(context.Background(), subscriptionID, deliveryID.Hex())
+	assert.Nil(t, err)
+	assert.Equal(t, domain.DeliverySucceeded, delivery.Status)
+
+	// A entrega precisa pertencer à inscrição da URL
+	_, err = useCase.Redeliver(context.Background(), primitive.NewObjectID().Hex(), deliveryID.Hex())
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+	sender.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestPaymentUseCase_NotifiesLifecycleEvents(t *testing.T) {
+	id := "507f191e810c19729de860ea"
+	objectID, _ := primitive.ObjectIDFromHex(id)
+
+	t.Run("Callback failure", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		orderClient := new(MockOrderClient)
+		notifier := new(MockPaymentNotifier)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(orderClient), usecase2.WithNotifier(notifier))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, OrderId: "order123", Amount: 100, PaymentType: domain.Pix}, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(nil)
+		orderClient.On("UpdateOrderStatus", "order123", "failed").Return(nil)
+		notifier.On("NotifyPayment", domain.EventPaymentFailed, id).Return()
+
+		err := useCase.ProcessPaymentCallback(context.Background(), &domain.PaymentCallback{PaymentID: id, Status: "failed"})
+
+		assert.Nil(t, err)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		notifier := new(MockPaymentNotifier)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(new(MockOrderClient)),
+			usecase2.WithPaymentGateway(nil), usecase2.WithNotifier(notifier))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Amount: 100, Status: "approved"}, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(nil)
+		notifier.On("NotifyPayment", domain.EventPaymentRefunded, id).Return()
+
+		_, err := useCase.RefundPayment(context.Background(), id, 40)
+
+		assert.Nil(t, err)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Every notifier receives the event", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		webhooks, stream := new(MockPaymentNotifier), new(MockPaymentNotifier)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(new(MockOrderClient)),
+			usecase2.WithPaymentGateway(nil), usecase2.WithNotifier(webhooks), usecase2.WithNotifier(stream))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Amount: 100, Status: domain.StatusProcessing}, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(nil)
+		webhooks.On("NotifyPayment", domain.EventPaymentCancelled, id).Return()
+		stream.On("NotifyPayment", domain.EventPaymentCancelled, id).Return()
+
+		_, err := useCase.CancelPayment(context.Background(), id)
+
+		assert.Nil(t, err)
+		webhooks.AssertExpectations(t)
+		stream.AssertExpectations(t)
+	})
+
+	t.Run("Failed update emits nothing", func(t *testing.T) {
+		mockRepo := new(MockPaymentRepository)
+		notifier := new(MockPaymentNotifier)
+		useCase := usecase2.NewPaymentUseCase(mockRepo, usecase2.WithOrderClient(new(MockOrderClient)),
+			usecase2.WithPaymentGateway(nil), usecase2.WithNotifier(notifier))
+
+		mockRepo.On("GetByID", id).Return(domain.Payment{ID: objectID, Amount: 100, Status: domain.StatusProcessing}, nil)
+		mockRepo.On("Update", id, mock.Anything).Return(errors.New("db down"))
+
+		_, err := useCase.CancelPayment(context.Background(), id)
+
+		assert.NotNil(t, err)
+		notifier.AssertNotCalled(t, "NotifyPayment", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/repository"
+	"payments/telemetry"
+)
+
+// APIKeyUseCase emite, lista e revoga as chaves de API e autentica as requisições
+type APIKeyUseCase interface {
+	// IssueAPIKey cria uma chave; a chave completa só é devolvida aqui
+	IssueAPIKey(ctx context.Context, name string, scopes []string) (domain.IssuedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	GetAPIKey(ctx context.Context, id string) (domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (domain.APIKey, error)
+	// Authenticate devolve o cliente dono da chave, ou ErrUnauthorized
+	Authenticate(ctx context.Context, key string) (domain.Principal, error)
+}
+
+type apiKeyUseCase struct {
+	repo      repository.APIKeyRepository
+	adminHash string
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+// APIKeyOption permite customizar as dependências do APIKeyUseCase
+type APIKeyOption func(*apiKeyUseCase)
+
+// WithAPIKeyLogger define o logger estruturado usado pelo caso de uso
+func WithAPIKeyLogger(logger *slog.Logger) APIKeyOption {
+	return func(uc *apiKeyUseCase) {
+		uc.logger = logger
+	}
+}
+
+// WithAPIKeyClock substitui o relógio usado nas datas de emissão e revogação
+func WithAPIKeyClock(now func() time.Time) APIKeyOption {
+	return func(uc *apiKeyUseCase) {
+		uc.now = now
+	}
+}
+
+// WithAdminKey aceita key, fora do repositório, como uma chave admin. Serve
+// para emitir as primeiras chaves de um ambiente novo.
+func WithAdminKey(key string) APIKeyOption {
+	return func(uc *apiKeyUseCase) {
+		if key != "" {
+			uc.adminHash = domain.HashAPIKey(key)
+		}
+	}
+}
+
+// NewAPIKeyUseCase cria uma nova instância do APIKeyUseCase
+func NewAPIKeyUseCase(repo repository.APIKeyRepository, opts ...APIKeyOption) APIKeyUseCase {
+	uc := &apiKeyUseCase{repo: repo, now: time.Now, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func startAPIKeySpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "APIKeyUseCase."+operation, trace.WithAttributes(attrs...))
+}
+
+// newAPIKey gera uma chave pk_<identificador>_<segredo>
+func newAPIKey() (string, error) {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("error generating api key: %v", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("error generating api key: %v", err)
+	}
+	// O identificador é hexadecimal: o primeiro "_" depois dele separa o
+	// segredo, que em base64url também pode conter "_"
+	return domain.APIKeyTag + hex.EncodeToString(id) + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func (uc *apiKeyUseCase) IssueAPIKey(ctx context.Context, name string, scopes []string) (_ domain.IssuedAPIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "IssueAPIKey")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	errs := domain.NewValidationError()
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		errs.Add("name", "is required and must have at most 100 characters")
+	}
+	var scopeErrs *domain.ValidationError
+	if errors.As(domain.ValidateScopes(scopes), &scopeErrs) {
+		errs.Errors = append(errs.Errors, scopeErrs.Errors...)
+	}
+	if errs.HasErrors() {
+		return domain.IssuedAPIKey{}, errs
+	}
+
+	key, err := newAPIKey()
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+	prefix, _ := domain.APIKeyPrefix(key)
+	apiKey := domain.APIKey{Name: name, Prefix: prefix, Hash: domain.HashAPIKey(key), Scopes: scopes, CreatedAt: uc.now()}
+	id, err := uc.repo.Create(ctx, &apiKey)
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+	uc.logger.InfoContext(ctx, "Chave de API emitida", slog.String("api_key_id", id),
+		slog.String("prefix", prefix), slog.Any("scopes", scopes))
+	return domain.IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (uc *apiKeyUseCase) ListAPIKeys(ctx context.Context) (_ []domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "ListAPIKeys")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return uc.repo.List(ctx)
+}
+
+func (uc *apiKeyUseCase) GetAPIKey(ctx context.Context, id string) (_ domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "GetAPIKey", attribute.String("api_key.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *apiKeyUseCase) RevokeAPIKey(ctx context.Context, id string) (_ domain.APIKey, err error) {
+	ctx, span := startAPIKeySpan(ctx, "RevokeAPIKey", attribute.String("api_key.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if err := uc.repo.Revoke(ctx, id, uc.now()); err != nil {
+		return domain.APIKey{}, err
+	}
+	uc.logger.InfoContext(ctx, "Chave de API revogada", slog.String("api_key_id", id))
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *apiKeyUseCase) Authenticate(ctx context.Context, key string) (_ domain.Principal, err error) {
+	ctx, span := startAPIKeySpan(ctx, "Authenticate")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	hash := domain.HashAPIKey(key)
+	if uc.adminHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(uc.adminHash)) == 1 {
+		return domain.Principal{Subject: "api_key:admin", Name: "admin", Scopes: []string{domain.ScopeAdmin}}, nil
+	}
+	prefix, ok := domain.APIKeyPrefix(key)
+	if !ok {
+		return domain.Principal{}, fmt.Errorf("malformed api key: %w", domain.ErrUnauthorized)
+	}
+	apiKey, err := uc.repo.FindByPrefix(ctx, prefix)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Principal{}, fmt.Errorf("unknown api key %s: %w", prefix, domain.ErrUnauthorized)
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	span.SetAttributes(attribute.String("api_key.id", apiKey.ID.Hex()))
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(apiKey.Hash)) != 1 {
+		return domain.Principal{}, fmt.Errorf("invalid api key %s: %w", prefix, domain.ErrUnauthorized)
+	}
+	if apiKey.Revoked() {
+		return domain.Principal{}, fmt.Errorf("revoked api key %s: %w", prefix, domain.ErrUnauthorized)
+	}
+	return apiKey.Principal(), nil
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/client"
+	"payments/domain"
+	"payments/repository"
+	"payments/telemetry"
+)
+
+// DisputeUseCase registra as contestações (chargebacks) dos pagamentos
+type DisputeUseCase interface {
+	// OpenDispute abre uma disputa com o motivo, o valor e as evidências de input
+	OpenDispute(ctx context.Context, paymentID string, input domain.Dispute) (domain.Dispute, error)
+	GetDispute(ctx context.Context, id string) (domain.Dispute, error)
+	ListPaymentDisputes(ctx context.Context, paymentID string) ([]domain.Dispute, error)
+	UpdateDispute(ctx context.Context, id string, update domain.DisputeUpdate) (domain.Dispute, error)
+	// ResolveDispute encerra a disputa; perdida, o pagamento vira StatusChargedBack e o pedido é notificado
+	ResolveDispute(ctx context.Context, id string, outcome string) (domain.Dispute, error)
+}
+
+type disputeUseCase struct {
+	disputes    repository.DisputeRepository
+	payments    repository.PaymentRepository
+	orderClient client.OrderClient
+	notifiers   Notifiers // Opcional: sem notificadores, o chargeback não gera evento
+	now         func() time.Time
+	logger      *slog.Logger
+}
+
+// DisputeOption permite customizar as dependências do DisputeUseCase
+type DisputeOption func(*disputeUseCase)
+
+// WithDisputeOrderClient substitui o cliente usado para notificar o serviço de pedidos
+func WithDisputeOrderClient(orderClient client.OrderClient) DisputeOption {
+	return func(uc *disputeUseCase) {
+		uc.orderClient = orderClient
+	}
+}
+
+// WithDisputeNotifier acrescenta um destinatário do evento de chargeback do pagamento
+func WithDisputeNotifier(notifier PaymentNotifier) DisputeOption {
+	return func(uc *disputeUseCase) {
+		uc.notifiers = append(uc.notifiers, notifier)
+	}
+}
+
+// WithDisputeClock substitui o relógio usado nos prazos das disputas
+func WithDisputeClock(now func() time.Time) DisputeOption {
+	return func(uc *disputeUseCase) {
+		uc.now = now
+	}
+}
+
+// WithDisputeLogger define o logger estruturado usado pelo caso de uso
+func WithDisputeLogger(logger *slog.Logger) DisputeOption {
+	return func(uc *disputeUseCase) {
+		uc.logger = logger
+	}
+}
+
+// NewDisputeUseCase cria uma nova instância do DisputeUseCase
+func NewDisputeUseCase(disputes repository.DisputeRepository, payments repository.PaymentRepository, opts ...DisputeOption) DisputeUseCase {
+	uc := &disputeUseCase{
+		disputes:    disputes,
+		payments:    payments,
+		orderClient: client.NewOrderClientFromEnv(),
+		now:         time.Now,
+		logger:      slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func startDisputeSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "DisputeUseCase."+operation, trace.WithAttributes(attrs...))
+}
+
+func (uc *disputeUseCase) OpenDispute(ctx context.Context, paymentID string, input domain.Dispute) (_ domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "OpenDispute", telemetry.PaymentIDAttr(paymentID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment, err := uc.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return domain.Dispute{}, fmt.Errorf("payment not found: %w", err)
+	}
+	dispute, err := domain.NewDispute(payment, input, uc.now())
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	// O índice de disputa aberta por pagamento devolve ErrConflict numa segunda abertura
+	if _, err := uc.disputes.Create(ctx, &dispute); err != nil {
+		return domain.Dispute{}, err
+	}
+	uc.logger.WarnContext(ctx, "Disputa aberta", slog.String("dispute_id", dispute.ID.Hex()),
+		slog.String("payment_id", paymentID), slog.String("reason_code", dispute.ReasonCode),
+		slog.Float64("amount", dispute.Amount))
+	return dispute, nil
+}
+
+func (uc *disputeUseCase) GetDispute(ctx context.Context, id string) (_ domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "GetDispute", attribute.String("dispute.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return uc.disputes.GetByID(ctx, id)
+}
+
+func (uc *disputeUseCase) ListPaymentDisputes(ctx context.Context, paymentID string) (_ []domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "ListPaymentDisputes", telemetry.PaymentIDAttr(paymentID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Distingue pagamento inexistente de pagamento sem disputas
+	if _, err := uc.payments.GetByID(ctx, paymentID); err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+	return uc.disputes.FindByPaymentID(ctx, paymentID)
+}
+
+func (uc *disputeUseCase) UpdateDispute(ctx context.Context, id string, update domain.DisputeUpdate) (_ domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "UpdateDispute", attribute.String("dispute.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	dispute, err := uc.disputes.GetByID(ctx, id)
+	if err != nil {
+		return dispute, err
+	}
+	if err := dispute.Apply(update, uc.now()); err != nil {
+		return dispute, err
+	}
+	if err := uc.disputes.Update(ctx, id, &dispute); err != nil {
+		return dispute, err
+	}
+	uc.logger.InfoContext(ctx, "Disputa atualizada", slog.String("dispute_id", id),
+		slog.Int("evidence", len(dispute.Evidence)))
+	return dispute, nil
+}
+
+func (uc *disputeUseCase) ResolveDispute(ctx context.Context, id string, outcome string) (_ domain.Dispute, err error) {
+	ctx, span := startDisputeSpan(ctx, "ResolveDispute", attribute.String("dispute.id", id), attribute.String("dispute.outcome", outcome))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	dispute, err := uc.disputes.GetByID(ctx, id)
+	if err != nil {
+		return dispute, err
+	}
+	if err := dispute.Resolve(outcome, uc.now()); err != nil {
+		return dispute, err
+	}
+
+	var payment domain.Payment
+	if dispute.Status == domain.DisputeLost {
+		// O pagamento é atualizado antes da disputa: se a gravação da disputa
+		// falhar, uma nova resolução encontra o pagamento já em chargeback
+		payment, err = uc.payments.GetByID(ctx, dispute.PaymentID)
+		if err != nil {
+			return dispute, fmt.Errorf("payment not found: %w", err)
+		}
+		if payment.Status != domain.StatusChargedBack {
+			payment.ChargeBack()
+			if err := uc.payments.Update(ctx, dispute.PaymentID, &payment); err != nil {
+				return dispute, err
+			}
+			uc.notifiers.NotifyPayment(ctx, domain.EventPaymentChargedBack, payment)
+		}
+	}
+	if err := uc.disputes.Update(ctx, id, &dispute); err != nil {
+		return dispute, err
+	}
+	uc.logger.WarnContext(ctx, "Disputa resolvida", slog.String("dispute_id", id),
+		slog.String("payment_id", dispute.PaymentID), slog.String("outcome", dispute.Status))
+
+	// A disputa já está resolvida: a falha ao avisar o pedido só é registrada
+	if dispute.Status == domain.DisputeLost {
+		if err := uc.orderClient.UpdateOrderStatus(ctx, payment.OrderId, payment.Status); err != nil {
+			uc.logger.ErrorContext(ctx, "Erro ao atualizar o status do pedido", slog.String("order_id", payment.OrderId),
+				slog.String("dispute_id", id), slog.Any("error", err))
+		}
+	}
+	return dispute, nil
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/domain"
+	"payments/repository"
+	"payments/telemetry"
+)
+
+// FeeRuleUseCase administra as versões das regras de tarifa
+type FeeRuleUseCase interface {
+	ListFeeRules(ctx context.Context) ([]domain.FeeRule, error)
+	GetFeeRule(ctx context.Context, id string) (domain.FeeRule, error)
+	CreateFeeRule(ctx context.Context, rule *domain.FeeRule) (string, error)
+	// UpdateFeeRule e DeleteFeeRule só alteram versões que ainda não entraram em vigor
+	UpdateFeeRule(ctx context.Context, id string, rule *domain.FeeRule) error
+	DeleteFeeRule(ctx context.Context, id string) error
+}
+
+type feeRuleUseCase struct {
+	repo   repository.FeeRuleRepository
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// FeeRuleOption permite customizar as dependências do FeeRuleUseCase
+type FeeRuleOption func(*feeRuleUseCase)
+
+// WithFeeRuleLogger define o logger estruturado usado pelo caso de uso
+func WithFeeRuleLogger(logger *slog.Logger) FeeRuleOption {
+	return func(uc *feeRuleUseCase) {
+		uc.logger = logger
+	}
+}
+
+// WithFeeRuleClock substitui o relógio usado para decidir se uma versão já está em vigor
+func WithFeeRuleClock(now func() time.Time) FeeRuleOption {
+	return func(uc *feeRuleUseCase) {
+		uc.now = now
+	}
+}
+
+// NewFeeRuleUseCase cria uma nova instância do FeeRuleUseCase
+func NewFeeRuleUseCase(repo repository.FeeRuleRepository, opts ...FeeRuleOption) FeeRuleUseCase {
+	uc := &feeRuleUseCase{repo: repo, now: time.Now, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func startFeeRuleSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "FeeRuleUseCase."+operation, trace.WithAttributes(attrs...))
+}
+
+func (uc *feeRuleUseCase) ListFeeRules(ctx context.Context) (_ []domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "ListFeeRules")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return uc.repo.List(ctx)
+}
+
+func (uc *feeRuleUseCase) GetFeeRule(ctx context.Context, id string) (_ domain.FeeRule, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "GetFeeRule", attribute.String("fee_rule.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *feeRuleUseCase) CreateFeeRule(ctx context.Context, rule *domain.FeeRule) (_ string, err error) {
+	ctx, span := startFeeRuleSpan(ctx, "CreateFeeRule", attribute.String("payment.type", string(rule.PaymentType)))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if err := rule.Validate(); err != nil {
+		return "", err
+	}
+	id, err := uc.repo.Create(ctx, rule)
+	if err != nil {
+		return "", err
+	}
+	uc.logger.InfoContext(ctx, "Regra de tarifa criada", slog.Any("fee_rule", *rule))
+	return id, nil
+}
+
+func (uc *feeRuleUseCase) UpdateFeeRule(ctx context.Context, id string, rule *domain.FeeRule) (err error) {
+	ctx, span := startFeeRuleSpan(ctx, "UpdateFeeRule", attribute.String("fee_rule.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if err := uc.ensurePending(ctx, id); err != nil {
+		return err
+	}
+	// A versão editada também não pode passar a valer retroativamente
+	if rule.InEffect(uc.now()) {
+		return domain.NewValidationError(domain.ErrorResponse{Field: "effective_from", Message: "must be in the future when updating a fee rule"})
+	}
+	if err := uc.repo.Update(ctx, id, rule); err != nil {
+		return err
+	}
+	uc.logger.InfoContext(ctx, "Regra de tarifa atualizada", slog.String("fee_rule_id", id))
+	return nil
+}
+
+func (uc *feeRuleUseCase) DeleteFeeRule(ctx context.Context, id string) (err error) {
+	ctx, span := startFeeRuleSpan(ctx, "DeleteFeeRule", attribute.String("fee_rule.id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if err := uc.ensurePending(ctx, id); err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.logger.InfoContext(ctx, "Regra de tarifa removida", slog.String("fee_rule_id", id))
+	return nil
+}
+
+// ensurePending recusa alterações em versões já em vigor: pagamentos aprovados
+// registram o ID da regra, e o histórico precisa continuar reproduzível
+func (uc *feeRuleUseCase) ensurePending(ctx context.Context, id string) error {
+	current, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.InEffect(uc.now()) {
+		return fmt.Errorf("%w: fee rule %s is already in effect; create a new version instead", domain.ErrConflict, id)
+	}
+	return nil
+}
@@ -1,117 +1,547 @@
 package usecase
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/client"
 	"payments/domain"
 	"payments/repository"
+	"payments/telemetry"
 )
 
 type PaymentUseCase interface {
-	GetAllPayments() ([]domain.Payment, error)
-	GetPaymentByID(id string) (domain.Payment, error)
-	CreatePayment(payment *domain.Payment) (string, error) // Atualizado para retornar UUID (string) e erro
-	UpdatePayment(id string, payment *domain.Payment) error
-	DeletePayment(id string) error
-	ProcessPaymentCallback(paymentCallback *domain.PaymentCallback) error
+	GetAllPayments(ctx context.Context) ([]domain.Payment, error)
+	GetPaymentByID(ctx context.Context, id string) (domain.Payment, error)
+	GetPaymentsByOrderID(ctx context.Context, orderID string) (domain.OrderPayments, error)
+	CreatePayment(ctx context.Context, payment *domain.Payment) (string, error) // Atualizado para retornar UUID (string) e erro
+	// UpdatePayment aplica as alterações permitidas e devolve o pagamento atualizado
+	UpdatePayment(ctx context.Context, id string, update domain.PaymentUpdate) (domain.Payment, error)
+	DeletePayment(ctx context.Context, id string) error
+	CancelPayment(ctx context.Context, id string) (domain.Payment, error)
+	// CapturePayment captura amount de um pagamento autorizado; zero captura o valor total
+	CapturePayment(ctx context.Context, id string, amount float64) (domain.Payment, error)
+	VoidPayment(ctx context.Context, id string) (domain.Payment, error)
+	// RefundPayment estorna, total ou parcialmente, um pagamento liquidado
+	RefundPayment(ctx context.Context, id string, amount float64) (domain.Payment, error)
+	// ExpireOverdueBoletos encerra os boletos em aberto cujo prazo acabou antes de now
+	ExpireOverdueBoletos(ctx context.Context, now time.Time) (int, error)
+	// VoidExpiredAuthorizations libera as autorizações criadas antes de cutoff e devolve quantas foram liberadas
+	VoidExpiredAuthorizations(ctx context.Context, cutoff time.Time) (int, error)
+	ProcessPaymentCallback(ctx context.Context, paymentCallback *domain.PaymentCallback) error
 }
 
 type paymentUseCase struct {
-	paymentRepo repository.PaymentRepository
+	paymentRepo  repository.PaymentRepository
+	orderClient  client.OrderClient
+	gateway      client.PaymentGateway // Opcional: nil quando nenhum gateway está configurado
+	rates        client.RateProvider   // Opcional: sem provedor, só a moeda de liquidação é aceita
+	boleto       domain.BoletoIssuer
+	installments domain.InstallmentPolicies
+	feeRules     repository.FeeRuleRepository // Opcional: sem regras, nenhuma tarifa é registrada
+	notifiers    Notifiers                    // Opcional: sem notificadores, nenhum evento é emitido
+	logger       *slog.Logger
+}
+
+// Option permite customizar as dependências do PaymentUseCase
+type Option func(*paymentUseCase)
+
+// WithOrderClient substitui o cliente usado para notificar o serviço de pedidos
+func WithOrderClient(orderClient client.OrderClient) Option {
+	return func(uc *paymentUseCase) {
+		uc.orderClient = orderClient
+	}
+}
+
+// WithPaymentGateway define o gateway notificado nos cancelamentos
+func WithPaymentGateway(gateway client.PaymentGateway) Option {
+	return func(uc *paymentUseCase) {
+		uc.gateway = gateway
+	}
+}
+
+// WithRateProvider define a fonte das cotações dos pagamentos em moeda estrangeira
+func WithRateProvider(rates client.RateProvider) Option {
+	return func(uc *paymentUseCase) {
+		uc.rates = rates
+	}
+}
+
+// WithBoletoIssuer define os dados do beneficiário usados na emissão de boletos
+func WithBoletoIssuer(issuer domain.BoletoIssuer) Option {
+	return func(uc *paymentUseCase) {
+		uc.boleto = issuer
+	}
+}
+
+// WithInstallmentPolicies define os limites e juros de parcelamento por tipo de pagamento
+func WithInstallmentPolicies(policies domain.InstallmentPolicies) Option {
+	return func(uc *paymentUseCase) {
+		uc.installments = policies
+	}
+}
+
+// WithFeeRules define o repositório das regras de tarifa aplicadas na aprovação
+func WithFeeRules(feeRules repository.FeeRuleRepository) Option {
+	return func(uc *paymentUseCase) {
+		uc.feeRules = feeRules
+	}
+}
+
+// WithNotifier acrescenta um destinatário dos eventos do ciclo de vida dos pagamentos
+func WithNotifier(notifier PaymentNotifier) Option {
+	return func(uc *paymentUseCase) {
+		uc.notifiers = append(uc.notifiers, notifier)
+	}
+}
+
+// WithLogger define o logger estruturado usado pelo caso de uso
+func WithLogger(logger *slog.Logger) Option {
+	return func(uc *paymentUseCase) {
+		uc.logger = logger
+	}
 }
 
 // NewPaymentUseCase cria uma nova instância do PaymentUseCase
-func NewPaymentUseCase(repo repository.PaymentRepository) PaymentUseCase {
-	return &paymentUseCase{repo}
+func NewPaymentUseCase(repo repository.PaymentRepository, opts ...Option) PaymentUseCase {
+	uc := &paymentUseCase{
+		paymentRepo:  repo,
+		orderClient:  client.NewOrderClientFromEnv(),
+		gateway:      client.NewPaymentGatewayFromEnv(),
+		rates:        client.NewRateProviderFromEnv(),
+		boleto:       domain.DefaultBoletoIssuer,
+		installments: domain.DefaultInstallmentPolicies(),
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "PaymentUseCase."+operation, trace.WithAttributes(attrs...))
+}
+
+func (uc *paymentUseCase) GetAllPayments(ctx context.Context) ([]domain.Payment, error) {
+	ctx, span := startSpan(ctx, "GetAllPayments")
+	defer span.End()
+
+	payments, err := uc.paymentRepo.GetAll(ctx)
+	telemetry.RecordError(span, err)
+	return payments, err
 }
 
-func (uc *paymentUseCase) GetAllPayments() ([]domain.Payment, error) {
-	return uc.paymentRepo.GetAll()
+func (uc *paymentUseCase) GetPaymentByID(ctx context.Context, id string) (domain.Payment, error) {
+	ctx, span := startSpan(ctx, "GetPaymentByID", telemetry.PaymentIDAttr(id))
+	defer span.End()
+
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
+	telemetry.RecordError(span, err)
+	return payment, err
 }
 
-func (uc *paymentUseCase) GetPaymentByID(id string) (domain.Payment, error) {
-	return uc.paymentRepo.GetByID(id)
+func (uc *paymentUseCase) GetPaymentsByOrderID(ctx context.Context, orderID string) (domain.OrderPayments, error) {
+	ctx, span := startSpan(ctx, "GetPaymentsByOrderID", telemetry.OrderIDAttr(orderID))
+	defer span.End()
+
+	payments, err := uc.paymentRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return domain.OrderPayments{}, err
+	}
+	return domain.NewOrderPayments(orderID, payments), nil
 }
 
-func (uc *paymentUseCase) CreatePayment(payment *domain.Payment) (string, error) {
+func (uc *paymentUseCase) CreatePayment(ctx context.Context, payment *domain.Payment) (_ string, err error) {
+	ctx, span := startSpan(ctx, "CreatePayment", telemetry.OrderIDAttr(payment.OrderId))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
 	// A validação de tipo de pagamento será feita no domínio
 	if err := payment.PaymentType.IsValid(); err != nil {
 		return "", err // Erro retornado pela camada de domínio
 	}
+	if err := payment.ValidateDetails(); err != nil {
+		return "", err
+	}
+	if err := payment.ValidateInstallments(uc.installments); err != nil {
+		return "", err
+	}
+	if err := payment.ResolveSplits(); err != nil {
+		return "", err
+	}
+	payment.Currency = payment.CurrencyCode()
+	if payment.IsCrossCurrency() {
+		if err := uc.snapshotFXRate(ctx, payment); err != nil {
+			return "", err
+		}
+	}
+	if payment.Installments > 1 {
+		plan := domain.NewInstallmentPlan(payment.Amount, payment.Installments, uc.installments[payment.PaymentType], time.Now())
+		payment.InstallmentPlan = &plan
+	}
+	if payment.PaymentType == domain.Boleto {
+		if err := uc.issueBoleto(payment); err != nil {
+			return "", err
+		}
+	}
 	// Chama o repositório para criar o pagamento e obter o ID gerado
-	return uc.paymentRepo.Create(payment)
+	id, err := uc.paymentRepo.Create(ctx, payment)
+	if err != nil {
+		return "", err
+	}
+	uc.logger.InfoContext(ctx, "Pagamento criado", slog.String("payment_id", id), slog.Any("payment", *payment))
+	uc.notify(ctx, domain.EventPaymentCreated, *payment)
+	return id, nil
 }
 
-func (uc *paymentUseCase) UpdatePayment(id string, payment *domain.Payment) error {
-	// Chama o use case para verificar se o pagamento existe
-	_, err := uc.GetPaymentByID(id)
+// snapshotFXRate registra no pagamento a cotação vigente na criação, para que o
+// valor liquidado possa ser reproduzido mesmo depois que a cotação mudar
+func (uc *paymentUseCase) snapshotFXRate(ctx context.Context, payment *domain.Payment) error {
+	if uc.rates == nil {
+		return domain.NewValidationError(domain.ErrorResponse{Field: "currency",
+			Message: "only " + domain.SettlementCurrency + " is accepted: no FX rate source is configured"})
+	}
+	rate, err := uc.rates.Rate(ctx, payment.Currency, domain.SettlementCurrency)
 	if err != nil {
-		// Retorna erro se o pagamento não for encontrado
-		return fmt.Errorf("payment not found: %v", err)
+		return fmt.Errorf("%w: error fetching FX rate: %v", domain.ErrUpstream, err)
+	}
+	if err := payment.ApplyFXRate(rate); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUpstream, err)
 	}
-	// Se o pagamento existe, chama o método de atualização no repositório
-	return uc.paymentRepo.Update(id, payment)
+	return nil
+}
+
+// issueBoleto gera o boleto com o vencimento pedido ou o padrão do emissor. O ID
+// é definido antes da inserção porque compõe o nosso número.
+func (uc *paymentUseCase) issueBoleto(payment *domain.Payment) error {
+	dueDate := time.Now().AddDate(0, 0, uc.boleto.DueDays)
+	if payment.Boleto != nil && !payment.Boleto.DueDate.IsZero() {
+		dueDate = payment.Boleto.DueDate
+	}
+	if payment.ID.IsZero() {
+		payment.ID = primitive.NewObjectID()
+	}
+	boleto, err := domain.NewBoleto(uc.boleto, *payment, dueDate)
+	if err != nil {
+		return err
+	}
+	payment.Boleto = &boleto
+	return nil
 }
 
-func (uc *paymentUseCase) DeletePayment(id string) error {
+func (uc *paymentUseCase) UpdatePayment(ctx context.Context, id string, update domain.PaymentUpdate) (_ domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "UpdatePayment", telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Parte do pagamento salvo: o cliente não sobrescreve valores, status nem tarifas
+	payment, err := uc.GetPaymentByID(ctx, id)
+	if err != nil {
+		return payment, fmt.Errorf("payment not found: %w", err)
+	}
+	payment.Method = update.Method
+	if err := uc.paymentRepo.Update(ctx, id, &payment); err != nil {
+		return payment, err
+	}
+	return payment, nil
+}
+
+func (uc *paymentUseCase) DeletePayment(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "DeletePayment", telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
 	// Chama o use case para verificar se o pagamento existe
-	_, err := uc.GetPaymentByID(id)
+	_, err = uc.GetPaymentByID(ctx, id)
 	if err != nil {
 		// Retorna erro se o pagamento não for encontrado
-		return fmt.Errorf("payment not found: %v", err)
+		return fmt.Errorf("payment not found: %w", err)
 	}
 	// Se o pagamento existe, chama o método de deleção
-	return uc.paymentRepo.Delete(id)
+	return uc.paymentRepo.Delete(ctx, id)
 }
 
-func (uc *paymentUseCase) ProcessPaymentCallback(callbackData *domain.PaymentCallback) error {
-	// Primeiro, processe o pagamento como já estava fazendo
-	payment, err := uc.paymentRepo.GetByID(callbackData.PaymentID)
+// CancelPayment cancela um pagamento ainda em andamento. Cancelar de novo um
+// pagamento cancelado não tem efeito; pagamentos liquidados resultam em ErrConflict.
+func (uc *paymentUseCase) CancelPayment(ctx context.Context, id string) (_ domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "CancelPayment", telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("payment not found: %v", err)
+		return payment, fmt.Errorf("payment not found: %w", err)
+	}
+	if payment.Status == domain.StatusCancelled {
+		return payment, nil
+	}
+	if !payment.IsCancellable() {
+		return payment, fmt.Errorf("payment %s is %q and can no longer be cancelled: %w", id, payment.Status, domain.ErrConflict)
 	}
-	payment.Status = callbackData.Status
 
-	fmt.Println("Pagamento processado:", payment)
+	if uc.gateway != nil {
+		if err := uc.gateway.CancelPayment(ctx, id); err != nil {
+			return payment, fmt.Errorf("%w: error cancelling payment on the gateway: %v", domain.ErrUpstream, err)
+		}
+	}
+	payment.Status = domain.StatusCancelled
+	if err := uc.paymentRepo.Update(ctx, id, &payment); err != nil {
+		return payment, err
+	}
+	uc.logger.InfoContext(ctx, "Pagamento cancelado", slog.Any("payment", payment))
+	uc.notify(ctx, domain.EventPaymentCancelled, payment)
+	return payment, nil
+}
 
-	// Após processar o pagamento, faça um POST para o microserviço de pedidos para atualizar o status do pedido
-	err = uc.updateOrderStatus(payment.OrderId, callbackData.Status)
+func (uc *paymentUseCase) CapturePayment(ctx context.Context, id string, amount float64) (_ domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "CapturePayment", telemetry.PaymentIDAttr(id), attribute.Float64("payment.capture_amount", amount))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("error updating order status: %v", err)
+		return payment, fmt.Errorf("payment not found: %w", err)
+	}
+	if err := payment.Capture(amount); err != nil {
+		return payment, err
 	}
+	if uc.gateway != nil {
+		if err := uc.gateway.CapturePayment(ctx, id, payment.CapturedAmount); err != nil {
+			return payment, fmt.Errorf("%w: error capturing payment on the gateway: %v", domain.ErrUpstream, err)
+		}
+	}
+	uc.applyFee(ctx, &payment, time.Now())
+	if err := uc.paymentRepo.Update(ctx, id, &payment); err != nil {
+		return payment, err
+	}
+	uc.logger.InfoContext(ctx, "Pagamento capturado", slog.Any("payment", payment),
+		slog.Float64("captured_amount", payment.CapturedAmount))
+	uc.notify(ctx, domain.EventPaymentCaptured, payment)
 
-	return nil
+	// A captura conclui o pagamento, como a aprovação no fluxo de captura imediata
+	uc.syncOrder(ctx, payment, payment.Status)
+	return payment, nil
 }
 
-func (uc *paymentUseCase) updateOrderStatus(orderID string, status string) error {
-	orderUpdate := map[string]interface{}{
-		"status": "Finalizado",
+// VoidPayment libera uma autorização. Liberar de novo não tem efeito.
+func (uc *paymentUseCase) VoidPayment(ctx context.Context, id string) (_ domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "VoidPayment", telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return payment, fmt.Errorf("payment not found: %w", err)
+	}
+	if payment.Status == domain.StatusVoided {
+		return payment, nil
+	}
+	if err := payment.Void(); err != nil {
+		return payment, err
+	}
+	if uc.gateway != nil {
+		if err := uc.gateway.VoidPayment(ctx, id); err != nil {
+			return payment, fmt.Errorf("%w: error voiding payment on the gateway: %v", domain.ErrUpstream, err)
+		}
 	}
-	jsonData, err := json.Marshal(orderUpdate)
+	if err := uc.paymentRepo.Update(ctx, id, &payment); err != nil {
+		return payment, err
+	}
+	uc.logger.InfoContext(ctx, "Autorização liberada", slog.Any("payment", payment))
+	uc.notify(ctx, domain.EventPaymentVoided, payment)
+	return payment, nil
+}
+
+func (uc *paymentUseCase) VoidExpiredAuthorizations(ctx context.Context, cutoff time.Time) (voided int, err error) {
+	ctx, span := startSpan(ctx, "VoidExpiredAuthorizations")
+	defer func() {
+		span.SetAttributes(attribute.Int("payment.voided_count", voided))
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	expired, err := uc.paymentRepo.FindByStatus(ctx, domain.StatusAuthorized, cutoff)
 	if err != nil {
-		return fmt.Errorf("error marshalling order data: %v", err)
+		return 0, err
 	}
+	// Uma falha não impede a liberação das demais autorizações
+	var errs []error
+	for _, payment := range expired {
+		if _, err := uc.VoidPayment(ctx, payment.ID.Hex()); err != nil {
+			errs = append(errs, fmt.Errorf("payment %s: %w", payment.ID.Hex(), err))
+			continue
+		}
+		voided++
+	}
+	return voided, errors.Join(errs...)
+}
 
-	url := "https://api-ms-order-6ec42f917adf.herokuapp.com/orders/" + orderID
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+func (uc *paymentUseCase) RefundPayment(ctx context.Context, id string, amount float64) (_ domain.Payment, err error) {
+	ctx, span := startSpan(ctx, "RefundPayment", telemetry.PaymentIDAttr(id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return payment, fmt.Errorf("payment not found: %w", err)
+	}
+	refunded, reversals, err := payment.Refund(amount)
 	if err != nil {
-		return fmt.Errorf("error creating HTTP request: %v", err)
+		return payment, err
+	}
+
+	if uc.gateway != nil {
+		if err := uc.gateway.RefundPayment(ctx, id, refunded, reversals); err != nil {
+			return payment, fmt.Errorf("%w: error refunding payment on the gateway: %v", domain.ErrUpstream, err)
+		}
+	}
+	if err := uc.paymentRepo.Update(ctx, id, &payment); err != nil {
+		return payment, err
 	}
+	uc.logger.InfoContext(ctx, "Pagamento estornado", slog.Any("payment", payment),
+		slog.Float64("refunded_amount", refunded), slog.Int("split_reversals", len(reversals)))
+	uc.notify(ctx, domain.EventPaymentRefunded, payment)
+	return payment, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (uc *paymentUseCase) ExpireOverdueBoletos(ctx context.Context, now time.Time) (expired int, err error) {
+	ctx, span := startSpan(ctx, "ExpireOverdueBoletos")
+	defer func() {
+		span.SetAttributes(attribute.Int("payment.expired_count", expired))
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Percorre os pagamentos em aberto; só os boletos têm prazo de pagamento
+	open, err := uc.paymentRepo.FindByStatus(ctx, domain.StatusProcessing, now)
 	if err != nil {
-		return fmt.Errorf("error sending HTTP request: %v", err)
+		return 0, err
+	}
+	var errs []error
+	for _, payment := range open {
+		if payment.Boleto == nil || !payment.Boleto.IsExpired(now) {
+			continue
+		}
+		ok, err := uc.expireBoleto(ctx, payment.ID.Hex())
+		if ok {
+			expired++
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("payment %s: %w", payment.ID.Hex(), err))
+		}
 	}
-	defer resp.Body.Close()
+	return expired, errors.Join(errs...)
+}
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to update order status: received status code %d", resp.StatusCode)
+// expireBoleto relê o pagamento antes de encerrá-lo: um callback de aprovação pode
+// ter chegado depois da busca. Devolve false quando o boleto já não está em aberto.
+func (uc *paymentUseCase) expireBoleto(ctx context.Context, id string) (bool, error) {
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !payment.IsActive() {
+		return false, nil
+	}
+	if err := payment.Expire(); err != nil {
+		return false, err
+	}
+	if err := uc.paymentRepo.Update(ctx, id, &payment); err != nil {
+		return false, err
+	}
+	uc.logger.InfoContext(ctx, "Boleto vencido", slog.Any("payment", payment), slog.Time("expires_at", payment.Boleto.ExpiresAt))
+	uc.notify(ctx, domain.EventPaymentExpired, payment)
+
+	// O pedido deixa de aguardar o pagamento
+	uc.syncOrder(ctx, payment, payment.Status)
+	return true, nil
+}
+
+func (uc *paymentUseCase) ProcessPaymentCallback(ctx context.Context, callbackData *domain.PaymentCallback) (err error) {
+	ctx, span := startSpan(ctx, "ProcessPaymentCallback",
+		telemetry.PaymentIDAttr(callbackData.PaymentID),
+		attribute.String("payment.status", callbackData.Status),
+	)
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Primeiro, processe o pagamento como já estava fazendo
+	payment, err := uc.paymentRepo.GetByID(ctx, callbackData.PaymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+
+	// O callback não reabre um pagamento encerrado (o endpoint é público): no
+	// máximo marca para estorno uma aprovação que chegou depois do encerramento
+	if payment.IsClosed() {
+		return uc.handleCallbackAfterClose(ctx, &payment, callbackData)
+	}
+
+	payment.Status = callbackData.Status
+	if callbackData.IsApproval() {
+		uc.applyFee(ctx, &payment, time.Now())
+	}
+	if err := uc.paymentRepo.Update(ctx, callbackData.PaymentID, &payment); err != nil {
+		return err
 	}
 
+	uc.logger.InfoContext(ctx, "Pagamento processado", slog.Any("payment", payment))
+	uc.notify(ctx, domain.CallbackEvent(callbackData.Status), payment)
+
+	// Após processar o pagamento, faça um PATCH para o microserviço de pedidos para atualizar o status do pedido
+	uc.syncOrder(ctx, payment, callbackData.Status)
+	return nil
+}
+
+// notify repassa o evento aos notificadores configurados
+func (uc *paymentUseCase) notify(ctx context.Context, eventType string, payment domain.Payment) {
+	uc.notifiers.NotifyPayment(ctx, eventType, payment)
+}
+
+// syncOrder repassa ao serviço de pedidos o status de um pagamento já gravado.
+// A falha só é registrada: a operação do pagamento está concluída e devolver
+// erro faria o cliente (ou o gateway) repetir uma captura ou um callback já aplicados.
+func (uc *paymentUseCase) syncOrder(ctx context.Context, payment domain.Payment, status string) {
+	if err := uc.orderClient.UpdateOrderStatus(ctx, payment.OrderId, status); err != nil {
+		uc.logger.ErrorContext(ctx, "Erro ao atualizar o status do pedido", slog.String("order_id", payment.OrderId),
+			slog.String("payment_id", payment.ID.Hex()), slog.String("status", status), slog.Any("error", err))
+	}
+}
+
+// applyFee registra bruto, tarifa e líquido segundo a regra em vigor em at. A
+// falta de regra não impede a aprovação: o pagamento segue sem tarifa registrada.
+func (uc *paymentUseCase) applyFee(ctx context.Context, payment *domain.Payment, at time.Time) {
+	if uc.feeRules == nil {
+		return
+	}
+	rule, err := uc.feeRules.FindEffective(ctx, payment.PaymentType, at)
+	if err != nil {
+		level := slog.LevelError
+		if errors.Is(err, domain.ErrNotFound) {
+			level = slog.LevelWarn
+		}
+		uc.logger.Log(ctx, level, "Tarifa não aplicada ao pagamento",
+			slog.String("payment_id", payment.ID.Hex()), slog.String("payment_type", string(payment.PaymentType)),
+			slog.Any("error", err))
+		return
+	}
+	payment.ApplyFee(rule)
+}
+
+// handleCallbackAfterClose mantém o status do pagamento encerrado. Se o gateway
+// aprovou um pagamento que não chegou a ser cobrado (cancelado, anulado ou
+// vencido), marca-o para estorno. O pedido não é notificado.
+func (uc *paymentUseCase) handleCallbackAfterClose(ctx context.Context, payment *domain.Payment, callbackData *domain.PaymentCallback) error {
+	if !callbackData.IsApproval() || payment.WasCharged() {
+		uc.logger.InfoContext(ctx, "Callback ignorado para pagamento encerrado",
+			slog.String("payment_id", callbackData.PaymentID), slog.String("payment_status", payment.Status),
+			slog.String("callback_status", callbackData.Status))
+		return nil
+	}
+	payment.RefundRequired = true
+	if err := uc.paymentRepo.Update(ctx, callbackData.PaymentID, payment); err != nil {
+		return err
+	}
+	uc.logger.WarnContext(ctx, "Pagamento encerrado foi aprovado pelo gateway e precisa de estorno", slog.Any("payment", *payment))
 	return nil
 }
@@ -0,0 +1,373 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"payments/client"
+	"payments/domain"
+	"payments/repository"
+	"payments/telemetry"
+)
+
+const (
+	// maxListedDeliveries limita o log de entregas devolvido por inscrição
+	maxListedDeliveries = 100
+	// dueDeliveriesBatch limita as entregas tentadas a cada execução do job
+	dueDeliveriesBatch = 100
+)
+
+// PaymentNotifier recebe os eventos do ciclo de vida dos pagamentos. Uma falha
+// ao notificar é apenas registrada: nunca interrompe o fluxo do pagamento.
+type PaymentNotifier interface {
+	NotifyPayment(ctx context.Context, eventType string, payment domain.Payment)
+}
+
+// Notifiers repassa cada evento a todos os notificadores, na ordem em que foram configurados
+type Notifiers []PaymentNotifier
+
+func (n Notifiers) NotifyPayment(ctx context.Context, eventType string, payment domain.Payment) {
+	for _, notifier := range n {
+		notifier.NotifyPayment(ctx, eventType, payment)
+	}
+}
+
+// WebhookUseCase administra as inscrições de webhook e entrega os eventos aos lojistas
+type WebhookUseCase interface {
+	PaymentNotifier
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (domain.WebhookSubscription, error)
+	// CreateSubscription gera o segredo da inscrição; é a única resposta que o exibe
+	CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListDeliveries devolve o log das entregas mais recentes da inscrição
+	ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.WebhookDelivery, error)
+	// SendTestEvent envia na hora um evento webhook.test, sem novas tentativas
+	SendTestEvent(ctx context.Context, subscriptionID string) (domain.WebhookDelivery, error)
+	// Redeliver reenvia na hora uma entrega, com o mesmo corpo, qualquer que seja a sua situação
+	Redeliver(ctx context.Context, subscriptionID string, deliveryID string) (domain.WebhookDelivery, error)
+	// DeliverDue tenta as entregas pendentes vencidas até now e devolve quantas foram tentadas
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type webhookUseCase struct {
+	repo   repository.WebhookRepository
+	sender client.WebhookSender
+	policy domain.WebhookRetryPolicy
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// WebhookOption permite customizar as dependências do WebhookUseCase
+type WebhookOption func(*webhookUseCase)
+
+// WithWebhookSender substitui o remetente HTTP das entregas
+func WithWebhookSender(sender client.WebhookSender) WebhookOption {
+	return func(uc *webhookUseCase) {
+		uc.sender = sender
+	}
+}
+
+// WithWebhookRetryPolicy substitui o número de tentativas e o backoff entre elas
+func WithWebhookRetryPolicy(policy domain.WebhookRetryPolicy) WebhookOption {
+	return func(uc *webhookUseCase) {
+		uc.policy = policy
+	}
+}
+
+// WithWebhookClock substitui o relógio usado nos eventos e no agendamento das tentativas
+func WithWebhookClock(now func() time.Time) WebhookOption {
+	return func(uc *webhookUseCase) {
+		uc.now = now
+	}
+}
+
+// WithWebhookLogger define o logger estruturado usado pelo caso de uso
+func WithWebhookLogger(logger *slog.Logger) WebhookOption {
+	return func(uc *webhookUseCase) {
+		uc.logger = logger
+	}
+}
+
+// NewWebhookUseCase cria uma nova instância do WebhookUseCase
+func NewWebhookUseCase(repo repository.WebhookRepository, opts ...WebhookOption) WebhookUseCase {
+	uc := &webhookUseCase{
+		repo:   repo,
+		sender: client.NewWebhookSender(nil),
+		policy: domain.DefaultWebhookRetryPolicy,
+		now:    time.Now,
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func startWebhookSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "WebhookUseCase."+operation, trace.WithAttributes(attrs...))
+}
+
+// newWebhookSecret gera o segredo HMAC de uma nova inscrição
+func newWebhookSecret() (string, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %v", err)
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+func (uc *webhookUseCase) ListSubscriptions(ctx context.Context) (_ []domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, "ListSubscriptions")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	subscriptions, err := uc.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i] = subscriptions[i].Redacted()
+	}
+	return subscriptions, nil
+}
+
+func (uc *webhookUseCase) GetSubscription(ctx context.Context, id string) (_ domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, "GetSubscription", attribute.String("webhook.subscription_id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	subscription, err := uc.repo.GetSubscription(ctx, id)
+	return subscription.Redacted(), err
+}
+
+func (uc *webhookUseCase) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) (_ domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, "CreateSubscription")
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if err := subscription.Validate(); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	if subscription.Secret, err = newWebhookSecret(); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	subscription.CreatedAt = uc.now()
+	subscription.UpdatedAt = subscription.CreatedAt
+	id, err := uc.repo.CreateSubscription(ctx, subscription)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	uc.logger.InfoContext(ctx, "Inscrição de webhook criada", slog.String("subscription_id", id),
+		slog.String("url", subscription.URL), slog.Any("events", subscription.Events))
+	return *subscription, nil
+}
+
+func (uc *webhookUseCase) UpdateSubscription(ctx context.Context, id string, subscription *domain.WebhookSubscription) (_ domain.WebhookSubscription, err error) {
+	ctx, span := startWebhookSpan(ctx, "UpdateSubscription", attribute.String("webhook.subscription_id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	existing, err := uc.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	if err := subscription.Validate(); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	existing.URL = subscription.URL
+	existing.Events = subscription.Events
+	existing.Description = subscription.Description
+	existing.Active = subscription.Active
+	existing.UpdatedAt = uc.now()
+	if err := uc.repo.UpdateSubscription(ctx, id, &existing); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	uc.logger.InfoContext(ctx, "Inscrição de webhook atualizada", slog.String("subscription_id", id),
+		slog.Bool("active", existing.Active), slog.Any("events", existing.Events))
+	return existing.Redacted(), nil
+}
+
+func (uc *webhookUseCase) DeleteSubscription(ctx context.Context, id string) (err error) {
+	ctx, span := startWebhookSpan(ctx, "DeleteSubscription", attribute.String("webhook.subscription_id", id))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	if err := uc.repo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	uc.logger.InfoContext(ctx, "Inscrição de webhook removida", slog.String("subscription_id", id))
+	return nil
+}
+
+func (uc *webhookUseCase) ListDeliveries(ctx context.Context, subscriptionID string) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, "ListDeliveries", attribute.String("webhook.subscription_id", subscriptionID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	// Distingue inscrição inexistente de inscrição sem entregas
+	if _, err := uc.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListDeliveries(ctx, subscriptionID, maxListedDeliveries)
+}
+
+// NotifyPayment registra uma entrega pendente para cada inscrição ativa no
+// evento; o envio fica com o job, que também cuida das novas tentativas
+func (uc *webhookUseCase) NotifyPayment(ctx context.Context, eventType string, payment domain.Payment) {
+	var err error
+	ctx, span := startWebhookSpan(ctx, "NotifyPayment", attribute.String("webhook.event", eventType), telemetry.PaymentIDAttr(payment.ID.Hex()))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	subscriptions, err := uc.repo.ListSubscriptions(ctx)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "Erro ao buscar as inscrições de webhook",
+			slog.String("event", eventType), slog.String("payment_id", payment.ID.Hex()), slog.Any("error", err))
+		return
+	}
+	var matching []domain.WebhookSubscription
+	for _, subscription := range subscriptions {
+		if subscription.Matches(eventType) {
+			matching = append(matching, subscription)
+		}
+	}
+	if len(matching) == 0 {
+		return
+	}
+
+	event := domain.WebhookEvent{ID: primitive.NewObjectID().Hex(), Type: eventType, Version: domain.WebhookDataVersion,
+		CreatedAt: uc.now().UTC(), Data: domain.NewWebhookPaymentData(payment)}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "Erro ao serializar o evento de webhook", slog.String("event", eventType), slog.Any("error", err))
+		return
+	}
+	var errs []error
+	for _, subscription := range matching {
+		delivery := domain.NewWebhookDelivery(subscription.ID.Hex(), event, payload, uc.now())
+		if _, err := uc.repo.CreateDelivery(ctx, &delivery); err != nil {
+			errs = append(errs, err)
+			uc.logger.ErrorContext(ctx, "Erro ao registrar a entrega de webhook", slog.String("event", eventType),
+				slog.String("subscription_id", subscription.ID.Hex()), slog.Any("error", err))
+		}
+	}
+	err = errors.Join(errs...)
+	uc.logger.DebugContext(ctx, "Evento de webhook enfileirado", slog.String("event", eventType),
+		slog.String("event_id", event.ID), slog.Int("subscriptions", len(matching)))
+}
+
+func (uc *webhookUseCase) SendTestEvent(ctx context.Context, subscriptionID string) (_ domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, "SendTestEvent", attribute.String("webhook.subscription_id", subscriptionID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	subscription, err := uc.repo.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	event := domain.WebhookEvent{ID: primitive.NewObjectID().Hex(), Type: domain.EventWebhookTest, Version: domain.WebhookDataVersion,
+		CreatedAt: uc.now().UTC(),
+		Data:      map[string]string{"subscription_id": subscriptionID}}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	// A entrega é gravada só depois do envio, para que o job não a tente em paralelo
+	delivery := domain.NewWebhookDelivery(subscriptionID, event, payload, uc.now())
+	delivery.ID = primitive.NewObjectID()
+	delivery.Record(uc.sender.Send(ctx, subscription.URL, subscription.Secret, delivery), domain.WebhookRetryPolicy{MaxAttempts: 1})
+	if _, err := uc.repo.CreateDelivery(ctx, &delivery); err != nil {
+		return delivery, err
+	}
+	uc.logger.InfoContext(ctx, "Evento de teste de webhook enviado", slog.String("subscription_id", subscriptionID),
+		slog.String("status", delivery.Status))
+	return delivery, nil
+}
+
+func (uc *webhookUseCase) Redeliver(ctx context.Context, subscriptionID string, deliveryID string) (_ domain.WebhookDelivery, err error) {
+	ctx, span := startWebhookSpan(ctx, "Redeliver", attribute.String("webhook.subscription_id", subscriptionID),
+		attribute.String("webhook.delivery_id", deliveryID))
+	defer func() { telemetry.RecordError(span, err); span.End() }()
+
+	delivery, err := uc.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return delivery, err
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return domain.WebhookDelivery{}, fmt.Errorf("webhook delivery %s of subscription %s: %w", deliveryID, subscriptionID, domain.ErrNotFound)
+	}
+	subscription, err := uc.repo.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return delivery, err
+	}
+	if err := uc.attempt(ctx, subscription, &delivery); err != nil {
+		return delivery, err
+	}
+	uc.logger.InfoContext(ctx, "Entrega de webhook reenviada", slog.String("delivery_id", deliveryID),
+		slog.String("status", delivery.Status), slog.Int("attempts", len(delivery.Attempts)))
+	return delivery, nil
+}
+
+func (uc *webhookUseCase) DeliverDue(ctx context.Context, now time.Time) (attempted int, err error) {
+	ctx, span := startWebhookSpan(ctx, "DeliverDue")
+	defer func() {
+		span.SetAttributes(attribute.Int("webhook.attempted_count", attempted))
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	due, err := uc.repo.FindDueDeliveries(ctx, now, dueDeliveriesBatch)
+	if err != nil {
+		return 0, err
+	}
+	// Uma falha não impede as demais entregas; com várias instâncias, uma
+	// entrega pode ser enviada em duplicidade e o lojista deduplica por X-Webhook-Id
+	subscriptions := make(map[string]*domain.WebhookSubscription)
+	var errs []error
+	for _, delivery := range due {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			found, err := uc.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.ID.Hex(), err))
+				continue
+			}
+			if err == nil {
+				subscription = &found
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		switch {
+		case subscription == nil:
+			delivery.Abandon("webhook subscription was deleted", uc.now())
+			err = uc.repo.UpdateDelivery(ctx, delivery.ID.Hex(), &delivery)
+		case !subscription.Active:
+			delivery.Abandon("webhook subscription is inactive", uc.now())
+			err = uc.repo.UpdateDelivery(ctx, delivery.ID.Hex(), &delivery)
+		default:
+			err = uc.attempt(ctx, *subscription, &delivery)
+			attempted++
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.ID.Hex(), err))
+		}
+	}
+	return attempted, errors.Join(errs...)
+}
+
+// attempt envia a entrega, registra a tentativa e grava o próximo passo
+func (uc *webhookUseCase) attempt(ctx context.Context, subscription domain.WebhookSubscription, delivery *domain.WebhookDelivery) error {
+	attempt := uc.sender.Send(ctx, subscription.URL, subscription.Secret, *delivery)
+	delivery.Record(attempt, uc.policy)
+	if !attempt.Succeeded() {
+		uc.logger.WarnContext(ctx, "Falha na entrega de webhook", slog.String("delivery_id", delivery.ID.Hex()),
+			slog.String("event", delivery.EventType), slog.Int("attempt", len(delivery.Attempts)),
+			slog.Int("status_code", attempt.StatusCode), slog.String("error", attempt.Error),
+			slog.String("status", delivery.Status))
+	}
+	return uc.repo.UpdateDelivery(ctx, delivery.ID.Hex(), delivery)
+}